@@ -186,6 +186,51 @@
 //		fmt.Printf("Primary monitor: %s\n", primary.Name)
 //	}
 //
+// Sharing One Connection:
+//
+//	import "github.com/bnema/libwldevices-go/session"
+//
+//	// Open a single connection for several managers
+//	sess, err := session.NewSession(ctx)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer sess.Close() // Closes every manager built from the session
+//
+//	pointers, err := virtual_pointer.NewVirtualPointerManagerFromSession(ctx, sess)
+//	keyboards, err := virtual_keyboard.NewVirtualKeyboardManagerFromSession(ctx, sess)
+//	outputs, err := output_management.NewOutputManagerFromSession(ctx, sess)
+//
+// Multiple Seats:
+//
+//	// Devices go to the first seat unless another one is named
+//	for _, seat := range sess.Seats() {
+//		fmt.Println(seat.Name, seat.HasPointer(), seat.HasKeyboard())
+//	}
+//	pointer, err := pointers.CreatePointer(virtual_pointer.WithSeat("seat1"))
+//
+// Multiple Monitors:
+//
+//	// Absolute motion maps onto the bound output instead of the whole layout
+//	pointer, err := pointers.CreatePointer(virtual_pointer.WithOutput("DP-2"))
+//	err = pointer.MotionAbsolute(time.Now(), 500, 500, 1000, 1000)
+//
+// Surviving Compositor Restarts:
+//
+//	// Managers rebind and recreate their devices after a reconnection
+//	sess, err := session.NewSession(ctx,
+//		session.WithReconnect(session.Backoff{MaxAttempts: 10}),
+//		session.WithStateHandler(func(state session.State, err error) {
+//			log.Println("connection", state, err)
+//		}),
+//	)
+//
+// Using the Application's Connection:
+//
+//	// Bind the managers' globals on a display the application owns;
+//	// Close leaves the display open
+//	sess, err := session.NewSessionFromDisplay(ctx, display, session.WithExternalDispatch())
+//
 // # Architecture
 //
 // Built on **WLTurbo** (https://github.com/bnema/wlturbo) - a high-performance,
@@ -194,6 +239,7 @@
 // **Core Components:**
 // • **Protocol Layer** (internal/protocols/) - Low-level Wayland protocol bindings
 // • **Client Layer** (internal/client/) - Connection and registry management
+// • **Session Layer** (session/) - Shared connection used by several managers
 // • **High-Level APIs** - User-friendly interfaces with automatic resource management
 //
 // **Key Features:**
@@ -204,6 +250,13 @@
 // • **Performance**: Built on zero-allocation, sub-microsecond client library
 // • **Production Ready**: Complete implementations, not just stubs
 //
+// # Event Loop
+//
+// Managers on a session share one event loop goroutine. Session.Run and
+// Session.Start tie it to a context, and Session.Err reports why it stopped.
+// Applications with a poll loop of their own use session.WithExternalDispatch,
+// then call Session.DispatchPending whenever Session.FD is readable.
+//
 // # Thread Safety and Performance
 //
 // The current implementation is **not thread-safe**. All operations should be
@@ -236,6 +289,28 @@
 // • Failed resource allocation
 // • Cleanup failures (generally safe to ignore)
 //
+// Errors wrap the sentinels of the session package (ErrProtocolUnavailable,
+// ErrConnectionClosed, ErrManagerClosed, ErrSeatNotFound, ErrOutputNotFound,
+// ErrKeymapNotSet) for use with errors.Is. Errors raised by the compositor are
+// *session.ProtocolError values naming the interface, object, code and enum
+// entry:
+//
+//	var perr *session.ProtocolError
+//	if errors.As(err, &perr) && perr.Name == "already_constrained" {
+//		// same as errors.Is(err, pointer_constraints.ErrAlreadyConstrained)
+//	}
+//
+// # Logging
+//
+// session.WithLogger sends diagnostics to a *slog.Logger, and
+// session.WithWireTrace writes every request and event to an io.Writer in the
+// format of WAYLAND_DEBUG=1:
+//
+//	pointers, err := virtual_pointer.NewVirtualPointerManager(ctx,
+//		session.WithLogger(slog.Default()),
+//		session.WithWireTrace(os.Stderr),
+//	)
+//
 // # Installation
 //
 //	go get github.com/bnema/libwldevices-go
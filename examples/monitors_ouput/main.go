@@ -98,8 +98,10 @@ func main() {
 		// Print scale
 		fmt.Printf("  Scale: %.6f\n", head.Scale)
 
-		// Print adaptive sync (hardcoded to disabled for compatibility)
-		fmt.Println("  Adaptive Sync: disabled")
+		// Print adaptive sync (only reported from protocol version 4)
+		if manager.Version() >= 4 {
+			fmt.Printf("  Adaptive Sync: %s\n", enabledToString(head.AdaptiveSync))
+		}
 
 		// Add blank line between outputs (except last one)
 		if i < len(heads)-1 {
@@ -115,4 +117,9 @@ func boolToYesNo(b bool) string {
 	return "no"
 }
 
-// Note: AdaptiveSync helper function removed as the type is not available
\ No newline at end of file
+func enabledToString(b bool) string {
+	if b {
+		return "enabled"
+	}
+	return "disabled"
+}
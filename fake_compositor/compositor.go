@@ -0,0 +1,793 @@
+// Package fake_compositor runs a minimal Wayland compositor on a temporary
+// socket, for tests that must not touch the session of the developer or need
+// a compositor in CI.
+//
+// The compositor advertises wl_seat, zwlr_virtual_pointer_manager_v1,
+// zwp_virtual_keyboard_manager_v1, zwp_pointer_constraints_v1,
+// zwlr_output_manager_v1 and a wl_output for every enabled head. It
+// implements just enough of each protocol for the managers of this module:
+// objects are created and destroyed, output heads are announced and
+// configurations applied. Every request is recorded, and tests script the
+// events a real compositor would send: new heads, mode changes, pointer
+// constraints becoming active and so on. Constraints apply to a wl_surface,
+// which clients create once WithGlobal advertises wl_compositor.
+//
+//	fc := fake_compositor.NewT(t)
+//
+//	manager, err := virtual_pointer.NewVirtualPointerManager(ctx, session.WithSocketPath(fc.Path()))
+//	...
+//	motions := fc.ExpectRequests(t, 1, "zwlr_virtual_pointer_v1", "motion")
+package fake_compositor
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+
+	"github.com/bnema/libwldevices-go/internal/protocols"
+)
+
+// Seat capabilities, as in wl_seat
+const (
+	CapabilityPointer  uint32 = 1
+	CapabilityKeyboard uint32 = 2
+	CapabilityTouch    uint32 = 4
+)
+
+// firstServerID is the first object ID allocated by the compositor
+const firstServerID = 0xff000000
+
+// ErrClosed is returned once the compositor is closed
+var ErrClosed = errors.New("fake compositor closed")
+
+// Seat is a wl_seat global advertised by the compositor
+type Seat struct {
+	Name         string
+	Capabilities uint32
+}
+
+// Option configures a Compositor
+type Option func(*Compositor)
+
+// WithSeats replaces the default seat, "seat0" with a pointer and a keyboard
+func WithSeats(seats ...Seat) Option {
+	return func(c *Compositor) {
+		c.seats = seats
+	}
+}
+
+// WithHeads replaces the default output, a single 1920x1080 head named FAKE-1
+func WithHeads(heads ...Head) Option {
+	return func(c *Compositor) {
+		c.heads = nil
+		for _, h := range heads {
+			c.heads = append(c.heads, newHeadState(h))
+		}
+	}
+}
+
+// WithGlobal advertises iface at version. It changes the version of a
+// default global, or adds a global the compositor doesn't implement: its
+// objects can be created and their requests are recorded.
+func WithGlobal(iface string, version uint32) Option {
+	return func(c *Compositor) {
+		c.versions[iface] = version
+	}
+}
+
+// WithoutGlobal stops advertising iface, to test a missing protocol
+func WithoutGlobal(iface string) Option {
+	return func(c *Compositor) {
+		delete(c.versions, iface)
+	}
+}
+
+// Compositor is a fake Wayland compositor listening on a temporary socket
+type Compositor struct {
+	dir      string
+	path     string
+	listener *net.UnixListener
+	wg       sync.WaitGroup
+
+	mu         sync.Mutex
+	closed     bool
+	versions   map[string]uint32
+	seats      []Seat
+	globals    []*global
+	nextGlobal uint32
+	clients    []*client
+	requests   []Request
+	recorded   chan struct{} // Closed and replaced when a request is recorded
+	heads      []*headState
+	serial     uint32
+	configErr  bool
+}
+
+// global is an advertised global
+type global struct {
+	name    uint32
+	iface   string
+	version uint32
+	seat    *Seat
+	head    *headState // Head of a wl_output global
+}
+
+// client is the state of a client connection
+type client struct {
+	index        int
+	conn         *net.UnixConn
+	wmu          sync.Mutex
+	objects      map[uint32]*object
+	nextServerID uint32
+	fds          []int
+	gone         bool
+}
+
+// object is an object of a client
+type object struct {
+	id      uint32
+	iface   string
+	version uint32
+	data    any // Protocol state, see output.go and handleRequest
+}
+
+// keyboardState is the state of a zwp_virtual_keyboard_v1
+type keyboardState struct {
+	keymap bool
+}
+
+// constraintState is the state of a locked or confined pointer
+type constraintState struct {
+	active bool
+}
+
+// New starts a compositor on a socket in a new temporary directory
+func New(opts ...Option) (*Compositor, error) {
+	c := &Compositor{
+		versions: map[string]uint32{
+			"wl_seat":                                 7,
+			protocols.VirtualPointerManagerInterface:  2,
+			protocols.VirtualKeyboardManagerInterface: 1,
+			protocols.PointerConstraintsInterface:     1,
+			protocols.OutputManagerInterface:          4,
+			"wl_output":                               4,
+		},
+		seats:    []Seat{{Name: "seat0", Capabilities: CapabilityPointer | CapabilityKeyboard}},
+		heads:    []*headState{newHeadState(DefaultHead())},
+		recorded: make(chan struct{}),
+		serial:   1,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.createGlobals()
+
+	dir, err := os.MkdirTemp("", "fake-compositor-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	c.dir = dir
+	c.path = filepath.Join(dir, "wayland-0")
+
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: c.path, Net: "unix"})
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to listen on %s: %w", c.path, err)
+	}
+	c.listener = listener
+
+	c.wg.Add(1)
+	go c.accept()
+	return c, nil
+}
+
+// createGlobals turns the configured seats and versions into globals, in a
+// stable order
+func (c *Compositor) createGlobals() {
+	if version, ok := c.versions["wl_seat"]; ok {
+		for i := range c.seats {
+			c.addGlobal("wl_seat", version, &c.seats[i])
+		}
+	}
+	order := []string{
+		protocols.VirtualPointerManagerInterface,
+		protocols.VirtualKeyboardManagerInterface,
+		protocols.PointerConstraintsInterface,
+		protocols.OutputManagerInterface,
+	}
+	known := map[string]bool{"wl_seat": true, "wl_output": true}
+	for _, iface := range order {
+		known[iface] = true
+		if version, ok := c.versions[iface]; ok {
+			c.addGlobal(iface, version, nil)
+		}
+	}
+	for iface, version := range c.versions {
+		if !known[iface] {
+			c.addGlobal(iface, version, nil)
+		}
+	}
+	for _, h := range c.heads {
+		c.syncOutputGlobal(h)
+	}
+}
+
+func (c *Compositor) addGlobal(iface string, version uint32, seat *Seat) *global {
+	c.nextGlobal++
+	g := &global{name: c.nextGlobal, iface: iface, version: version, seat: seat}
+	c.globals = append(c.globals, g)
+	return g
+}
+
+// Path returns the path of the compositor socket, for session.WithSocketPath
+func (c *Compositor) Path() string {
+	return c.path
+}
+
+// Close disconnects every client, stops listening and removes the socket
+func (c *Compositor) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	clients := c.clients
+	close(c.recorded)
+	c.mu.Unlock()
+
+	err := c.listener.Close()
+	for _, cl := range clients {
+		_ = cl.conn.Close()
+	}
+	c.wg.Wait()
+	if rmErr := os.RemoveAll(c.dir); err == nil {
+		err = rmErr
+	}
+	return err
+}
+
+// DisconnectClients closes the connection of every client, as a compositor
+// that crashed would. The compositor keeps accepting new clients.
+func (c *Compositor) DisconnectClients() {
+	c.mu.Lock()
+	clients := c.clients
+	c.mu.Unlock()
+	for _, cl := range clients {
+		_ = cl.conn.Close()
+	}
+}
+
+// Clients returns the number of clients that connected so far
+func (c *Compositor) Clients() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.clients)
+}
+
+func (c *Compositor) accept() {
+	defer c.wg.Done()
+	for {
+		conn, err := c.listener.AcceptUnix()
+		if err != nil {
+			return
+		}
+
+		c.mu.Lock()
+		if c.closed {
+			c.mu.Unlock()
+			_ = conn.Close()
+			return
+		}
+		cl := &client{
+			index:        len(c.clients),
+			conn:         conn,
+			objects:      map[uint32]*object{1: {id: 1, iface: "wl_display", version: 1}},
+			nextServerID: firstServerID,
+		}
+		c.clients = append(c.clients, cl)
+		c.mu.Unlock()
+
+		c.wg.Add(1)
+		go c.serve(cl)
+	}
+}
+
+// serve reads the requests of a client until it disconnects
+func (c *Compositor) serve(cl *client) {
+	defer c.wg.Done()
+	defer func() {
+		_ = cl.conn.Close()
+		c.mu.Lock()
+		cl.gone = true
+		for _, fd := range cl.fds {
+			_ = syscall.Close(fd)
+		}
+		cl.fds = nil
+		c.mu.Unlock()
+	}()
+
+	var buf []byte
+	chunk := make([]byte, 4096)
+	oob := make([]byte, syscall.CmsgSpace(28*4))
+	for {
+		n, oobn, _, _, err := cl.conn.ReadMsgUnix(chunk, oob)
+		if err != nil || n <= 0 {
+			return
+		}
+		if oobn > 0 {
+			fds := receivedFDs(oob[:oobn])
+			c.mu.Lock()
+			cl.fds = append(cl.fds, fds...)
+			c.mu.Unlock()
+		}
+		buf = append(buf, chunk[:n]...)
+
+		for len(buf) >= 8 {
+			size := int(binary.LittleEndian.Uint32(buf[4:8]) >> 16)
+			if size < 8 {
+				return
+			}
+			if len(buf) < size {
+				break
+			}
+			msg := buf[:size]
+			buf = buf[size:]
+			if !c.dispatch(cl, msg) {
+				return
+			}
+		}
+	}
+}
+
+// dispatch decodes, records and handles one request. It returns false when
+// the client must be disconnected.
+func (c *Compositor) dispatch(cl *client, msg []byte) bool {
+	objectID := binary.LittleEndian.Uint32(msg[0:4])
+	opcode := uint16(binary.LittleEndian.Uint32(msg[4:8]))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	req := Request{Client: cl.index, Interface: "unknown", Object: objectID, Opcode: opcode, Name: fmt.Sprintf("opcode_%d", opcode)}
+	obj := cl.objects[objectID]
+	if obj != nil {
+		req.Interface = obj.iface
+	}
+	message, known := requestMessage(req.Interface, opcode)
+	if known {
+		req.Name = message.Name
+		args, fds, err := decodeArgs(message.Signature, msg[8:], cl.fds)
+		cl.fds = fds
+		if err != nil {
+			c.record(req)
+			c.postError(cl, objectID, 1, err.Error()) // WL_DISPLAY_ERROR_INVALID_METHOD
+			return false
+		}
+		req.Args = args
+	}
+	c.record(req)
+
+	if obj == nil {
+		c.postError(cl, objectID, 0, fmt.Sprintf("invalid object %d", objectID)) // WL_DISPLAY_ERROR_INVALID_OBJECT
+		return false
+	}
+	if !known {
+		return true
+	}
+	return c.handleRequest(cl, obj, req, message)
+}
+
+// record appends a request and wakes WaitForRequest, with c.mu held
+func (c *Compositor) record(req Request) {
+	if c.closed {
+		return
+	}
+	c.requests = append(c.requests, req)
+	close(c.recorded)
+	c.recorded = make(chan struct{})
+}
+
+// handleRequest implements the requests of the advertised protocols, with
+// c.mu held. Requests of interfaces the compositor doesn't implement only
+// create and destroy objects.
+func (c *Compositor) handleRequest(cl *client, obj *object, req Request, message protocols.Message) bool {
+	switch {
+	case req.Is("wl_display", "sync"):
+		callback := req.Uint(0)
+		c.send(cl, callback, 0, c.serial)
+		c.send(cl, 1, 1, callback) // wl_display.delete_id
+		return true
+
+	case req.Is("wl_display", "get_registry"):
+		registry := c.createObject(cl, req.Uint(0), "wl_registry", 1, nil)
+		for _, g := range c.globals {
+			c.send(cl, registry.id, 0, g.name, g.iface, g.version)
+		}
+		return true
+
+	case req.Is("wl_registry", "bind"):
+		return c.bind(cl, req)
+
+	case req.Is(protocols.VirtualKeyboardInterface, "keymap"):
+		obj.data.(*keyboardState).keymap = true
+
+	case req.Is(protocols.VirtualKeyboardInterface, "key"), req.Is(protocols.VirtualKeyboardInterface, "modifiers"):
+		if !obj.data.(*keyboardState).keymap {
+			c.postError(cl, obj.id, 0, "no keymap set") // ZWP_VIRTUAL_KEYBOARD_V1_ERROR_NO_KEYMAP
+			return false
+		}
+
+	case req.Is(protocols.VirtualPointerInterface, "axis"), req.Is(protocols.VirtualPointerInterface, "axis_stop"),
+		req.Is(protocols.VirtualPointerInterface, "axis_discrete"):
+		if req.Uint(1) > 1 {
+			c.postError(cl, obj.id, 0, "invalid axis") // ZWLR_VIRTUAL_POINTER_V1_ERROR_INVALID_AXIS
+			return false
+		}
+
+	case req.Is(protocols.VirtualPointerInterface, "axis_source"):
+		if req.Uint(0) > 3 {
+			c.postError(cl, obj.id, 1, "invalid axis source") // ZWLR_VIRTUAL_POINTER_V1_ERROR_INVALID_AXIS_SOURCE
+			return false
+		}
+
+	case req.Is(protocols.PointerConstraintsInterface, "lock_pointer"), req.Is(protocols.PointerConstraintsInterface, "confine_pointer"):
+		// Only the region may be null
+		if !cl.isObject(req.Uint(1), "wl_surface") || !cl.isObject(req.Uint(2), "wl_pointer") {
+			c.postError(cl, obj.id, 1, req.Name+" needs a wl_surface and a wl_pointer") // WL_DISPLAY_ERROR_INVALID_METHOD
+			return false
+		}
+
+	case req.Is(protocols.OutputManagerInterface, "stop"):
+		c.send(cl, obj.id, 2) // finished
+		c.destroyObject(cl, obj)
+		return true
+
+	case obj.iface == protocols.OutputManagerInterface, obj.iface == protocols.OutputConfigurationInterface,
+		obj.iface == protocols.OutputConfigurationHeadInterface:
+		if !c.handleOutputRequest(cl, obj, req) {
+			return false
+		}
+	}
+
+	// Objects created by the request
+	if message.NewInterface != "" {
+		if id, ok := req.NewObject(); ok && cl.objects[id.ID] == nil {
+			c.createObject(cl, id.ID, message.NewInterface, obj.version, nil)
+		}
+	}
+	if message.Name == "destroy" || message.Name == "release" {
+		c.destroyObject(cl, obj)
+	}
+	return true
+}
+
+// bind creates the object of a wl_registry.bind request
+func (c *Compositor) bind(cl *client, req Request) bool {
+	name, iface, version, id := req.Uint(0), req.String(1), req.Uint(2), req.Uint(3)
+	var g *global
+	for _, candidate := range c.globals {
+		if candidate.name == name {
+			g = candidate
+		}
+	}
+	if g == nil || g.iface != iface || version == 0 || version > g.version {
+		c.postError(cl, req.Object, 0, fmt.Sprintf("invalid global %s (%d) version %d", iface, name, version))
+		return false
+	}
+
+	obj := c.createObject(cl, id, iface, version, nil)
+	switch iface {
+	case "wl_seat":
+		c.send(cl, id, 0, g.seat.Capabilities)
+		if version >= 2 {
+			c.send(cl, id, 1, g.seat.Name)
+		}
+	case protocols.OutputManagerInterface:
+		c.bindOutputManager(cl, obj)
+	case "wl_output":
+		c.bindOutput(cl, obj, g.head)
+	}
+	return true
+}
+
+// createObject adds an object to a client, with c.mu held
+func (c *Compositor) createObject(cl *client, id uint32, iface string, version uint32, data any) *object {
+	if data == nil {
+		switch iface {
+		case protocols.VirtualKeyboardInterface:
+			data = &keyboardState{}
+		case protocols.LockedPointerInterface, protocols.ConfinedPointerInterface:
+			data = &constraintState{}
+		}
+	}
+	obj := &object{id: id, iface: iface, version: version, data: data}
+	cl.objects[id] = obj
+	return obj
+}
+
+// destroyObject removes an object of a client and acknowledges the ID, with
+// c.mu held
+func (c *Compositor) destroyObject(cl *client, obj *object) {
+	c.forgetOutputObject(cl, obj)
+	delete(cl.objects, obj.id)
+	if obj.id < firstServerID {
+		c.send(cl, 1, 1, obj.id) // wl_display.delete_id
+	}
+}
+
+// isObject reports whether id is a live object of iface
+func (cl *client) isObject(id uint32, iface string) bool {
+	obj := cl.objects[id]
+	return obj != nil && obj.iface == iface
+}
+
+// newServerID allocates the ID of an object created by an event
+func (cl *client) newServerID() uint32 {
+	id := cl.nextServerID
+	cl.nextServerID++
+	return id
+}
+
+// send writes an event to a client. Write errors are ignored: the client is
+// gone and serve cleans up.
+func (c *Compositor) send(cl *client, object uint32, opcode uint16, args ...any) {
+	msg, err := encodeEvent(object, opcode, args...)
+	if err != nil {
+		panic(err)
+	}
+	cl.wmu.Lock()
+	defer cl.wmu.Unlock()
+	_, _ = cl.conn.Write(msg)
+}
+
+// postError sends wl_display.error, with c.mu held
+func (c *Compositor) postError(cl *client, object uint32, code uint32, message string) {
+	c.send(cl, 1, 0, object, code, message)
+}
+
+// Requests returns the requests received so far, from every client, in order
+func (c *Compositor) Requests() []Request {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Request(nil), c.requests...)
+}
+
+// Find returns the requests matching match
+func (c *Compositor) Find(match func(Request) bool) []Request {
+	var found []Request
+	for _, req := range c.Requests() {
+		if match(req) {
+			found = append(found, req)
+		}
+	}
+	return found
+}
+
+// ClearRequests forgets the requests received so far
+func (c *Compositor) ClearRequests() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = nil
+}
+
+// Named matches the requests called name on objects of iface
+func Named(iface, name string) func(Request) bool {
+	return func(r Request) bool {
+		return r.Is(iface, name)
+	}
+}
+
+// WaitForRequest returns the first recorded request matching match, waiting
+// for it until ctx is done
+func (c *Compositor) WaitForRequest(ctx context.Context, match func(Request) bool) (Request, error) {
+	reqs, err := c.WaitForRequests(ctx, 1, match)
+	if err != nil {
+		return Request{}, err
+	}
+	return reqs[0], nil
+}
+
+// WaitForRequests waits until n recorded requests match match and returns them
+func (c *Compositor) WaitForRequests(ctx context.Context, n int, match func(Request) bool) ([]Request, error) {
+	seen := 0
+	var found []Request
+	for {
+		c.mu.Lock()
+		for _, req := range c.requests[min(seen, len(c.requests)):] {
+			if match(req) {
+				found = append(found, req)
+			}
+		}
+		seen = len(c.requests)
+		recorded, closed := c.recorded, c.closed
+		c.mu.Unlock()
+
+		if len(found) >= n {
+			return found[:n], nil
+		}
+		if closed {
+			return found, ErrClosed
+		}
+		select {
+		case <-recorded:
+		case <-ctx.Done():
+			return found, fmt.Errorf("waiting for %d requests, got %d: %w", n, len(found), ctx.Err())
+		}
+	}
+}
+
+// Objects returns the live objects of iface, across clients
+func (c *Compositor) Objects(iface string) []Object {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var objects []Object
+	for _, cl := range c.clients {
+		if cl.gone {
+			continue
+		}
+		for id, obj := range cl.objects {
+			if obj.iface == iface {
+				objects = append(objects, Object{Client: cl.index, ID: id})
+			}
+		}
+	}
+	return objects
+}
+
+// lookup returns a live object of the given interface, with c.mu held
+func (c *Compositor) lookup(ref Object, iface string) (*client, *object, error) {
+	if c.closed {
+		return nil, nil, ErrClosed
+	}
+	if ref.Client < 0 || ref.Client >= len(c.clients) || c.clients[ref.Client].gone {
+		return nil, nil, fmt.Errorf("no client %d", ref.Client)
+	}
+	cl := c.clients[ref.Client]
+	obj := cl.objects[ref.ID]
+	if obj == nil {
+		return nil, nil, fmt.Errorf("client %d has no object %d", ref.Client, ref.ID)
+	}
+	if iface != "" && obj.iface != iface {
+		return nil, nil, fmt.Errorf("object %d of client %d is a %s, not a %s", ref.ID, ref.Client, obj.iface, iface)
+	}
+	return cl, obj, nil
+}
+
+// SendEvent sends an event to an object. Arguments are uint32, int32,
+// float64 for fixed, string, []byte, ObjectID and NewID.
+func (c *Compositor) SendEvent(ref Object, opcode uint16, args ...any) error {
+	if _, err := encodeEvent(0, opcode, args...); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cl, _, err := c.lookup(ref, "")
+	if err != nil {
+		return err
+	}
+	c.send(cl, ref.ID, opcode, args...)
+	return nil
+}
+
+// PostError sends a protocol error about an object and disconnects its
+// client, as a compositor does when a client misbehaves
+func (c *Compositor) PostError(ref Object, code uint32, message string) error {
+	c.mu.Lock()
+	cl, _, err := c.lookup(ref, "")
+	if err == nil {
+		c.postError(cl, ref.ID, code, message)
+	}
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return cl.conn.Close()
+}
+
+// AddGlobal advertises a new global to every client and returns its name
+func (c *Compositor) AddGlobal(iface string, version uint32) uint32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	g := c.addGlobal(iface, version, nil)
+	c.announce(g)
+	return g.name
+}
+
+// RemoveGlobal withdraws the globals of iface from every client. Objects
+// already bound keep working, as with a real compositor.
+func (c *Compositor) RemoveGlobal(iface string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	removed := false
+	globals := c.globals[:0]
+	for _, g := range c.globals {
+		if g.iface != iface {
+			globals = append(globals, g)
+			continue
+		}
+		removed = true
+		c.eachRegistry(func(cl *client, registry uint32) {
+			c.send(cl, registry, 1, g.name)
+		})
+	}
+	c.globals = globals
+	if !removed {
+		return fmt.Errorf("no %s global", iface)
+	}
+	return nil
+}
+
+// announce sends wl_registry.global for g, with c.mu held
+func (c *Compositor) announce(g *global) {
+	c.eachRegistry(func(cl *client, registry uint32) {
+		c.send(cl, registry, 0, g.name, g.iface, g.version)
+	})
+}
+
+// eachRegistry calls fn for every live wl_registry, with c.mu held
+func (c *Compositor) eachRegistry(fn func(*client, uint32)) {
+	c.eachObject("wl_registry", func(cl *client, obj *object) {
+		fn(cl, obj.id)
+	})
+}
+
+// eachObject calls fn for every live object of iface, with c.mu held
+func (c *Compositor) eachObject(iface string, fn func(*client, *object)) {
+	for _, cl := range c.clients {
+		if cl.gone {
+			continue
+		}
+		for _, obj := range cl.objects {
+			if obj.iface == iface {
+				fn(cl, obj)
+			}
+		}
+	}
+}
+
+// Lock sends zwp_locked_pointer_v1.locked, as when the surface gets focus
+func (c *Compositor) Lock(ref Object) error {
+	return c.setConstraint(ref, protocols.LockedPointerInterface, true)
+}
+
+// Unlock sends zwp_locked_pointer_v1.unlocked
+func (c *Compositor) Unlock(ref Object) error {
+	return c.setConstraint(ref, protocols.LockedPointerInterface, false)
+}
+
+// Confine sends zwp_confined_pointer_v1.confined
+func (c *Compositor) Confine(ref Object) error {
+	return c.setConstraint(ref, protocols.ConfinedPointerInterface, true)
+}
+
+// Unconfine sends zwp_confined_pointer_v1.unconfined
+func (c *Compositor) Unconfine(ref Object) error {
+	return c.setConstraint(ref, protocols.ConfinedPointerInterface, false)
+}
+
+func (c *Compositor) setConstraint(ref Object, iface string, active bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cl, obj, err := c.lookup(ref, iface)
+	if err != nil {
+		return err
+	}
+	state := obj.data.(*constraintState)
+	if state.active == active {
+		return fmt.Errorf("constraint %d of client %d already in that state", ref.ID, ref.Client)
+	}
+	state.active = active
+	opcode := uint16(0)
+	if !active {
+		opcode = 1
+	}
+	c.send(cl, obj.id, opcode)
+	return nil
+}
@@ -0,0 +1,359 @@
+package fake_compositor
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bnema/libwldevices-go/internal/protocols"
+	"github.com/bnema/libwldevices-go/output_management"
+	"github.com/bnema/libwldevices-go/session"
+	"github.com/bnema/libwldevices-go/virtual_keyboard"
+	"github.com/bnema/libwldevices-go/virtual_pointer"
+)
+
+func testContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func TestSessionGlobals(t *testing.T) {
+	fc := NewT(t, WithSeats(Seat{Name: "seat0", Capabilities: CapabilityPointer}, Seat{Name: "kiosk", Capabilities: CapabilityTouch}))
+
+	s, err := session.NewSession(testContext(t), session.WithSocketPath(fc.Path()))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	if seats := s.Seats(); len(seats) != 2 || seats[1].Name != "kiosk" || !seats[1].HasTouch() {
+		t.Errorf("Unexpected seats: %+v", seats)
+	}
+	advertised := make(map[string]bool)
+	for _, g := range s.Globals() {
+		advertised[g.Interface] = true
+	}
+	for _, iface := range []string{
+		protocols.VirtualPointerManagerInterface,
+		protocols.VirtualKeyboardManagerInterface,
+		protocols.PointerConstraintsInterface,
+		protocols.OutputManagerInterface,
+	} {
+		if !advertised[iface] {
+			t.Errorf("%s is not advertised", iface)
+		}
+	}
+	if fc.Clients() != 1 {
+		t.Errorf("Clients() = %d, want 1", fc.Clients())
+	}
+	if len(fc.Find(Named("wl_registry", "bind"))) != 2 {
+		t.Errorf("Expected the two seats to be bound: %v", fc.Requests())
+	}
+}
+
+func TestWithoutGlobal(t *testing.T) {
+	fc := NewT(t, WithoutGlobal(protocols.VirtualPointerManagerInterface))
+
+	_, err := virtual_pointer.NewVirtualPointerManager(testContext(t), session.WithSocketPath(fc.Path()))
+	if err == nil {
+		t.Fatal("Creating a manager without its global should fail")
+	}
+}
+
+func TestRecordPointerRequests(t *testing.T) {
+	fc := NewT(t)
+	ctx := testContext(t)
+
+	manager, err := virtual_pointer.NewVirtualPointerManager(ctx, session.WithSocketPath(fc.Path()))
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	defer func() { _ = manager.Close() }()
+
+	pointer, err := manager.CreatePointer()
+	if err != nil {
+		t.Fatalf("Failed to create pointer: %v", err)
+	}
+	if err := pointer.Motion(time.Now(), 1.5, -2); err != nil {
+		t.Fatalf("Motion failed: %v", err)
+	}
+	if err := pointer.Button(time.Now(), virtual_pointer.BTN_LEFT, virtual_pointer.ButtonStatePressed); err != nil {
+		t.Fatalf("Button failed: %v", err)
+	}
+
+	motion, err := fc.WaitForRequest(ctx, Named(protocols.VirtualPointerInterface, "motion"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if motion.Fixed(1) != 1.5 || motion.Fixed(2) != -2 {
+		t.Errorf("Unexpected motion: %s", motion.Format())
+	}
+	button, err := fc.WaitForRequest(ctx, Named(protocols.VirtualPointerInterface, "button"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if button.Uint(1) != virtual_pointer.BTN_LEFT || button.Uint(2) != uint32(virtual_pointer.ButtonStatePressed) {
+		t.Errorf("Unexpected button: %s", button.Format())
+	}
+	if button.Object != motion.Object {
+		t.Errorf("Requests sent to different objects: %d and %d", motion.Object, button.Object)
+	}
+
+	create := fc.Find(Named(protocols.VirtualPointerManagerInterface, "create_virtual_pointer"))
+	if len(create) != 1 {
+		t.Fatalf("Expected one create_virtual_pointer, got %d", len(create))
+	}
+	if obj, ok := create[0].NewObject(); !ok || obj.ID != motion.Object {
+		t.Errorf("create_virtual_pointer created %+v, motion went to %d", obj, motion.Object)
+	}
+	if len(fc.Objects(protocols.VirtualPointerInterface)) != 1 {
+		t.Error("The pointer should be alive")
+	}
+
+	_ = pointer.Close()
+	if _, err := fc.WaitForRequest(ctx, Named(protocols.VirtualPointerInterface, "destroy")); err != nil {
+		t.Fatal(err)
+	}
+	if len(fc.Objects(protocols.VirtualPointerInterface)) != 0 {
+		t.Error("The pointer should be destroyed")
+	}
+}
+
+func TestRecordKeymap(t *testing.T) {
+	fc := NewT(t)
+	ctx := testContext(t)
+
+	manager, err := virtual_keyboard.NewVirtualKeyboardManager(ctx, session.WithSocketPath(fc.Path()))
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	defer func() { _ = manager.Close() }()
+
+	keyboard, err := manager.CreateKeyboard()
+	if err != nil {
+		t.Fatalf("Failed to create keyboard: %v", err)
+	}
+	defer func() { _ = keyboard.Close() }()
+	if err := keyboard.TypeKey(virtual_keyboard.KEY_A); err != nil {
+		t.Fatalf("TypeKey failed: %v", err)
+	}
+
+	keymap, err := fc.WaitForRequest(ctx, Named(protocols.VirtualKeyboardInterface, "keymap"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(keymap.File(1)), "xkb_keymap") {
+		t.Errorf("Unexpected keymap: %q", keymap.File(1))
+	}
+	keys, err := fc.WaitForRequests(ctx, 2, Named(protocols.VirtualKeyboardInterface, "key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys[0].Uint(1) != virtual_keyboard.KEY_A || keys[0].Uint(2) != 1 || keys[1].Uint(2) != 0 {
+		t.Errorf("Unexpected keys: %s, %s", keys[0].Format(), keys[1].Format())
+	}
+}
+
+// headSnapshot is a copy of a head taken on the event loop, the manager
+// keeps updating its own heads
+type headSnapshot struct {
+	output_management.OutputHead
+	Modes []output_management.OutputMode
+}
+
+// outputManager connects an output manager reporting every configuration
+func outputManager(t *testing.T, fc *Compositor) (*output_management.OutputManager, <-chan map[string]headSnapshot) {
+	t.Helper()
+	manager, err := output_management.NewOutputManager(testContext(t), session.WithSocketPath(fc.Path()))
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	t.Cleanup(func() { _ = manager.Close() })
+
+	changes := make(chan map[string]headSnapshot, 16)
+	manager.SetHandlers(output_management.OutputHandlers{
+		OnConfigurationChanged: func(heads []*output_management.OutputHead) {
+			snapshot := make(map[string]headSnapshot)
+			for _, h := range heads {
+				s := headSnapshot{OutputHead: *h}
+				for _, m := range h.GetModes() {
+					s.Modes = append(s.Modes, *m)
+				}
+				snapshot[h.Name] = s
+			}
+			changes <- snapshot
+		},
+	})
+	return manager, changes
+}
+
+func waitForChange(t *testing.T, changes <-chan map[string]headSnapshot) map[string]headSnapshot {
+	t.Helper()
+	select {
+	case heads := <-changes:
+		return heads
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the configuration to change")
+		return nil
+	}
+}
+
+func TestOutputHeads(t *testing.T) {
+	fc := NewT(t)
+	manager, changes := outputManager(t, fc)
+
+	head := manager.GetHeadByName("FAKE-1")
+	if head == nil {
+		t.Fatalf("The default head is missing: %+v", manager.GetHeads())
+	}
+	if !head.Enabled || head.Make != "Fake" || len(head.GetModes()) != 2 || head.Scale != 1 {
+		t.Errorf("Unexpected head: %+v", head)
+	}
+
+	second := DefaultHead()
+	second.Name = "FAKE-2"
+	second.X = 1920
+	if err := fc.AddHead(second); err != nil {
+		t.Fatalf("AddHead failed: %v", err)
+	}
+	heads := waitForChange(t, changes)
+	if len(heads) != 2 {
+		t.Fatalf("Expected two heads, got %d", len(heads))
+	}
+	if h := heads["FAKE-2"]; h.Position.X != 1920 || len(h.Modes) != 2 {
+		t.Errorf("Unexpected new head: %+v", h)
+	}
+
+	err := fc.UpdateHead("FAKE-2", func(h *Head) {
+		h.Modes = []Mode{{Width: 2560, Height: 1440, Refresh: 144000, Preferred: true}}
+		h.Y = 100
+		h.Scale = 1.5
+	})
+	if err != nil {
+		t.Fatalf("UpdateHead failed: %v", err)
+	}
+	h := waitForChange(t, changes)["FAKE-2"]
+	if h.Position.Y != 100 || h.Scale != 1.5 {
+		t.Errorf("Head not updated: %+v", h)
+	}
+	if last := h.Modes[len(h.Modes)-1]; last.Width != 2560 || last.Refresh != 144000 {
+		t.Errorf("New mode not announced: %+v", h.Modes)
+	}
+
+	if err := fc.RemoveHead("FAKE-2"); err != nil {
+		t.Fatalf("RemoveHead failed: %v", err)
+	}
+	if heads := waitForChange(t, changes); len(heads) != 1 {
+		t.Errorf("Expected one head left, got %d", len(heads))
+	}
+	if err := fc.RemoveHead("FAKE-2"); err == nil {
+		t.Error("Removing an unknown head should fail")
+	}
+}
+
+func TestOutputGlobals(t *testing.T) {
+	fc := NewT(t)
+	ctx := testContext(t)
+	s, err := session.NewSession(ctx, session.WithSocketPath(fc.Path()))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	second := DefaultHead()
+	second.Name = "FAKE-2"
+	second.Description = "Second fake output"
+	if err := fc.AddHead(second); err != nil {
+		t.Fatalf("AddHead failed: %v", err)
+	}
+	c := s.Client()
+	if err := c.RoundtripContext(ctx); err != nil {
+		t.Fatalf("Roundtrip failed: %v", err)
+	}
+	outputs, err := c.BindOutputs(ctx)
+	if err != nil {
+		t.Fatalf("BindOutputs failed: %v", err)
+	}
+	if len(outputs) != 2 || outputs[0].Name() != "FAKE-1" || outputs[1].Name() != "FAKE-2" {
+		t.Fatalf("Unexpected outputs: %+v", outputs)
+	}
+	if outputs[1].Description() != "Second fake output" {
+		t.Errorf("Description() = %q", outputs[1].Description())
+	}
+	for _, output := range outputs {
+		if err := output.Release(); err != nil {
+			t.Errorf("Release failed: %v", err)
+		}
+	}
+
+	// Disabled and unplugged heads have no wl_output
+	if err := fc.UpdateHead("FAKE-1", func(h *Head) { h.Enabled = false }); err != nil {
+		t.Fatalf("UpdateHead failed: %v", err)
+	}
+	if err := fc.RemoveHead("FAKE-2"); err != nil {
+		t.Fatalf("RemoveHead failed: %v", err)
+	}
+	if err := c.RoundtripContext(ctx); err != nil {
+		t.Fatalf("Roundtrip failed: %v", err)
+	}
+	for _, g := range s.Globals() {
+		if g.Interface == "wl_output" {
+			t.Errorf("wl_output global still advertised: %+v", g)
+		}
+	}
+}
+
+func TestRemoveGlobal(t *testing.T) {
+	fc := NewT(t)
+
+	manager, err := virtual_pointer.NewVirtualPointerManager(testContext(t), session.WithSocketPath(fc.Path()))
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	defer func() { _ = manager.Close() }()
+
+	unavailable := make(chan struct{})
+	manager.SetUnavailableHandler(func() { close(unavailable) })
+	if err := fc.RemoveGlobal(protocols.VirtualPointerManagerInterface); err != nil {
+		t.Fatalf("RemoveGlobal failed: %v", err)
+	}
+	select {
+	case <-unavailable:
+	case <-time.After(5 * time.Second):
+		t.Fatal("The manager was not told the global is gone")
+	}
+	if err := fc.RemoveGlobal(protocols.VirtualPointerManagerInterface); err == nil {
+		t.Error("Removing a missing global should fail")
+	}
+}
+
+func TestConstraintEvents(t *testing.T) {
+	fc := NewT(t)
+
+	if err := fc.Lock(Object{Client: 0, ID: 3}); err == nil {
+		t.Error("Lock without a client should fail")
+	}
+
+	s, err := session.NewSession(testContext(t), session.WithSocketPath(fc.Path()))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+	if err := fc.Lock(Object{Client: 0, ID: 1}); err == nil {
+		t.Error("Lock on wl_display should fail")
+	}
+}
+
+func TestRequestFormat(t *testing.T) {
+	req := Request{
+		Interface: "zwlr_virtual_pointer_manager_v1",
+		Object:    4,
+		Name:      "create_virtual_pointer",
+		Args:      []any{ObjectID(0), NewID(7)},
+	}
+	if got, want := req.Format(), "zwlr_virtual_pointer_manager_v1@4.create_virtual_pointer(nil, new id @7)"; got != want {
+		t.Errorf("Format() = %q, want %q", got, want)
+	}
+}
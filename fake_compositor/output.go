@@ -0,0 +1,502 @@
+package fake_compositor
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/bnema/libwldevices-go/internal/protocols"
+)
+
+// Mode is a mode of an output head
+type Mode struct {
+	Width     int32
+	Height    int32
+	Refresh   int32 // In mHz
+	Preferred bool
+}
+
+// Head is an output advertised through zwlr_output_manager_v1
+type Head struct {
+	Name           string
+	Description    string
+	PhysicalWidth  int32 // In millimeters
+	PhysicalHeight int32
+	Modes          []Mode
+	CurrentMode    int // Index in Modes, only sent for enabled heads
+	Enabled        bool
+	X              int32
+	Y              int32
+	Transform      int32
+	Scale          float64 // 1 when zero
+	Make           string
+	Model          string
+	SerialNumber   string
+	AdaptiveSync   bool
+}
+
+// DefaultHead returns the head advertised when WithHeads isn't used
+func DefaultHead() Head {
+	return Head{
+		Name:           "FAKE-1",
+		Description:    "Fake Compositor FAKE-1",
+		PhysicalWidth:  600,
+		PhysicalHeight: 340,
+		Modes: []Mode{
+			{Width: 1920, Height: 1080, Refresh: 60000, Preferred: true},
+			{Width: 1280, Height: 720, Refresh: 60000},
+		},
+		Enabled: true,
+		Scale:   1,
+		Make:    "Fake",
+		Model:   "Compositor",
+	}
+}
+
+// headState is a head of the compositor. Objects refer to it by pointer.
+type headState struct {
+	Head
+}
+
+func newHeadState(h Head) *headState {
+	h.Modes = slices.Clone(h.Modes)
+	if h.Scale == 0 {
+		h.Scale = 1
+	}
+	return &headState{Head: h}
+}
+
+// managerState is the state of a zwlr_output_manager_v1
+type managerState struct {
+	heads map[*headState]*headObject
+}
+
+// headObject is a zwlr_output_head_v1, head is nil once finished
+type headObject struct {
+	id      uint32
+	head    *headState
+	manager *object
+	modes   []uint32 // IDs of the zwlr_output_mode_v1 of head.Modes
+}
+
+// modeObject is a zwlr_output_mode_v1, head is nil once finished
+type modeObject struct {
+	head  *headState
+	index int
+}
+
+// configState is a zwlr_output_configuration_v1
+type configState struct {
+	serial uint32
+	heads  map[*headState]*Head // Configured state of each head
+	used   bool
+}
+
+// configHead is a zwlr_output_configuration_head_v1
+type configHead struct {
+	head *Head
+}
+
+// bindOutputManager announces every head to a new zwlr_output_manager_v1,
+// with c.mu held
+func (c *Compositor) bindOutputManager(cl *client, manager *object) {
+	manager.data = &managerState{heads: make(map[*headState]*headObject)}
+	for _, h := range c.heads {
+		c.announceHead(cl, manager, h)
+	}
+	c.send(cl, manager.id, 1, c.serial) // done
+}
+
+// announceHead sends a head and all its properties to a manager
+func (c *Compositor) announceHead(cl *client, manager *object, h *headState) {
+	ho := &headObject{id: cl.newServerID(), head: h, manager: manager}
+	manager.data.(*managerState).heads[h] = ho
+	c.createObject(cl, ho.id, protocols.OutputHeadInterface, manager.version, ho)
+	c.send(cl, manager.id, 0, NewID(ho.id)) // head
+	c.send(cl, ho.id, 0, h.Name)
+	c.sendHeadChanges(cl, ho, nil)
+}
+
+// sendHeadChanges sends the properties of a head that differ from prev, or
+// all of them if prev is nil
+func (c *Compositor) sendHeadChanges(cl *client, ho *headObject, prev *Head) {
+	h := &ho.head.Head
+	version := ho.manager.version
+	changed := func(differs bool) bool {
+		return prev == nil || differs
+	}
+
+	if changed(prev != nil && prev.Description != h.Description) {
+		c.send(cl, ho.id, 1, h.Description)
+	}
+	if prev == nil && (h.PhysicalWidth != 0 || h.PhysicalHeight != 0) ||
+		prev != nil && (prev.PhysicalWidth != h.PhysicalWidth || prev.PhysicalHeight != h.PhysicalHeight) {
+		c.send(cl, ho.id, 2, h.PhysicalWidth, h.PhysicalHeight)
+	}
+
+	modesChanged := changed(prev != nil && !slices.Equal(prev.Modes, h.Modes))
+	if modesChanged {
+		for _, id := range ho.modes {
+			c.finishMode(cl, id)
+		}
+		ho.modes = nil
+		for i, mode := range h.Modes {
+			id := cl.newServerID()
+			ho.modes = append(ho.modes, id)
+			c.createObject(cl, id, protocols.OutputModeInterface, version, &modeObject{head: ho.head, index: i})
+			c.send(cl, ho.id, 3, NewID(id)) // mode
+			c.send(cl, id, 0, mode.Width, mode.Height)
+			if mode.Refresh != 0 {
+				c.send(cl, id, 1, mode.Refresh)
+			}
+			if mode.Preferred {
+				c.send(cl, id, 2)
+			}
+		}
+	}
+
+	enabledChanged := changed(prev != nil && prev.Enabled != h.Enabled)
+	if enabledChanged {
+		c.send(cl, ho.id, 4, boolArg(h.Enabled))
+	}
+	if h.Enabled {
+		// An enabled head reports its state even if it only was enabled now
+		forced := enabledChanged
+		if (forced || modesChanged || prev.CurrentMode != h.CurrentMode) && h.CurrentMode >= 0 && h.CurrentMode < len(ho.modes) {
+			c.send(cl, ho.id, 5, ObjectID(ho.modes[h.CurrentMode]))
+		}
+		if forced || prev.X != h.X || prev.Y != h.Y {
+			c.send(cl, ho.id, 6, h.X, h.Y)
+		}
+		if forced || prev.Transform != h.Transform {
+			c.send(cl, ho.id, 7, h.Transform)
+		}
+		if forced || prev.Scale != h.Scale {
+			c.send(cl, ho.id, 8, h.Scale)
+		}
+	}
+
+	if version >= 2 {
+		props := []struct {
+			opcode      uint16
+			value, prev string
+		}{
+			{10, h.Make, ""},
+			{11, h.Model, ""},
+			{12, h.SerialNumber, ""},
+		}
+		if prev != nil {
+			props[0].prev, props[1].prev, props[2].prev = prev.Make, prev.Model, prev.SerialNumber
+		}
+		for _, s := range props {
+			if s.value != "" && (prev == nil || s.value != s.prev) {
+				c.send(cl, ho.id, s.opcode, s.value)
+			}
+		}
+	}
+	if version >= 4 && h.Enabled && (enabledChanged || prev.AdaptiveSync != h.AdaptiveSync) {
+		c.send(cl, ho.id, 13, uint32(boolArg(h.AdaptiveSync)))
+	}
+}
+
+func boolArg(v bool) int32 {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+// finishMode sends zwlr_output_mode_v1.finished. The object stays until the
+// client releases it.
+func (c *Compositor) finishMode(cl *client, id uint32) {
+	if obj := cl.objects[id]; obj != nil {
+		obj.data.(*modeObject).head = nil
+	}
+	c.send(cl, id, 3)
+}
+
+// eachHeadObject calls fn with the head object of h of every output manager
+func (c *Compositor) eachHeadObject(h *headState, fn func(*client, *headObject)) {
+	c.eachObject(protocols.OutputManagerInterface, func(cl *client, manager *object) {
+		if ho := manager.data.(*managerState).heads[h]; ho != nil {
+			fn(cl, ho)
+		}
+	})
+}
+
+// done bumps the serial and sends zwlr_output_manager_v1.done to every
+// manager, with c.mu held
+func (c *Compositor) done() {
+	c.serial++
+	c.eachObject(protocols.OutputManagerInterface, func(cl *client, manager *object) {
+		c.send(cl, manager.id, 1, c.serial)
+	})
+}
+
+// findHead returns the head called name, with c.mu held
+func (c *Compositor) findHead(name string) (*headState, error) {
+	for _, h := range c.heads {
+		if h.Name == name {
+			return h, nil
+		}
+	}
+	return nil, fmt.Errorf("no head %q", name)
+}
+
+// changeHead moves a head to a new state and reports the changes
+func (c *Compositor) changeHead(h *headState, next Head) {
+	next.Name = h.Name
+	next.Modes = slices.Clone(next.Modes)
+	if next.Scale == 0 {
+		next.Scale = 1
+	}
+	prev := h.Head
+	h.Head = next
+	c.eachHeadObject(h, func(cl *client, ho *headObject) {
+		c.sendHeadChanges(cl, ho, &prev)
+	})
+	c.syncOutputGlobal(h)
+}
+
+// Heads returns the current state of the outputs
+func (c *Compositor) Heads() []Head {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	heads := make([]Head, 0, len(c.heads))
+	for _, h := range c.heads {
+		head := h.Head
+		head.Modes = slices.Clone(h.Modes)
+		heads = append(heads, head)
+	}
+	return heads
+}
+
+// AddHead plugs in an output: it's announced to every output manager,
+// followed by a done event
+func (c *Compositor) AddHead(head Head) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return ErrClosed
+	}
+	if _, err := c.findHead(head.Name); err == nil {
+		return fmt.Errorf("head %q already exists", head.Name)
+	}
+	h := newHeadState(head)
+	c.heads = append(c.heads, h)
+	c.syncOutputGlobal(h)
+	c.eachObject(protocols.OutputManagerInterface, func(cl *client, manager *object) {
+		c.announceHead(cl, manager, h)
+	})
+	c.done()
+	return nil
+}
+
+// UpdateHead changes an output, as the user or the compositor would, and
+// sends what changed followed by a done event. A new Modes slice replaces
+// every mode object; changing CurrentMode only reports the current mode.
+func (c *Compositor) UpdateHead(name string, update func(*Head)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return ErrClosed
+	}
+	h, err := c.findHead(name)
+	if err != nil {
+		return err
+	}
+	next := h.Head
+	next.Modes = slices.Clone(h.Modes)
+	update(&next)
+	c.changeHead(h, next)
+	c.done()
+	return nil
+}
+
+// RemoveHead unplugs an output: its modes and head objects are finished,
+// followed by a done event
+func (c *Compositor) RemoveHead(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return ErrClosed
+	}
+	h, err := c.findHead(name)
+	if err != nil {
+		return err
+	}
+	c.heads = slices.DeleteFunc(c.heads, func(other *headState) bool { return other == h })
+	c.syncOutputGlobal(h)
+	c.eachHeadObject(h, func(cl *client, ho *headObject) {
+		for _, id := range ho.modes {
+			c.finishMode(cl, id)
+		}
+		c.send(cl, ho.id, 9) // finished
+		ho.head = nil
+		delete(ho.manager.data.(*managerState).heads, h)
+	})
+	c.done()
+	return nil
+}
+
+// syncOutputGlobal advertises a wl_output global for h while it's plugged in
+// and enabled, and withdraws it otherwise, with c.mu held
+func (c *Compositor) syncOutputGlobal(h *headState) {
+	version, ok := c.versions["wl_output"]
+	if !ok {
+		return
+	}
+	want := h.Enabled && slices.Contains(c.heads, h)
+	i := slices.IndexFunc(c.globals, func(g *global) bool { return g.head == h })
+	switch {
+	case want && i < 0:
+		g := c.addGlobal("wl_output", version, nil)
+		g.head = h
+		c.announce(g)
+	case !want && i >= 0:
+		g := c.globals[i]
+		c.globals = slices.Delete(c.globals, i, i+1)
+		c.eachRegistry(func(cl *client, registry uint32) {
+			c.send(cl, registry, 1, g.name) // global_remove
+		})
+	}
+}
+
+// bindOutput describes the head of a new wl_output: its geometry, current
+// mode, scale, name and description, followed by a done event
+func (c *Compositor) bindOutput(cl *client, output *object, h *headState) {
+	output.data = h
+	c.send(cl, output.id, 0, h.X, h.Y, h.PhysicalWidth, h.PhysicalHeight, int32(0), h.Make, h.Model, h.Transform) // geometry
+	if h.CurrentMode >= 0 && h.CurrentMode < len(h.Modes) {
+		m := h.Modes[h.CurrentMode]
+		flags := uint32(1) // WL_OUTPUT_MODE_CURRENT
+		if m.Preferred {
+			flags |= 2 // WL_OUTPUT_MODE_PREFERRED
+		}
+		c.send(cl, output.id, 1, flags, m.Width, m.Height, m.Refresh) // mode
+	}
+	if output.version >= 2 {
+		c.send(cl, output.id, 3, int32(math.Ceil(h.Scale))) // scale
+	}
+	if output.version >= 4 {
+		c.send(cl, output.id, 4, h.Name)        // name
+		c.send(cl, output.id, 5, h.Description) // description
+	}
+	if output.version >= 2 {
+		c.send(cl, output.id, 2) // done
+	}
+}
+
+// FailConfigurations makes applied and tested output configurations fail
+// instead of succeeding
+func (c *Compositor) FailConfigurations(fail bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.configErr = fail
+}
+
+// handleOutputRequest implements output manager, configuration and
+// configuration head requests, with c.mu held
+func (c *Compositor) handleOutputRequest(cl *client, obj *object, req Request) bool {
+	switch obj.iface {
+	case protocols.OutputManagerInterface:
+		if req.Name == "create_configuration" {
+			state := &configState{serial: req.Uint(1), heads: make(map[*headState]*Head)}
+			c.createObject(cl, req.Uint(0), protocols.OutputConfigurationInterface, obj.version, state)
+		}
+		return true
+
+	case protocols.OutputConfigurationHeadInterface:
+		head := obj.data.(*configHead).head
+		switch req.Name {
+		case "set_mode":
+			mode, ok := cl.objects[req.Uint(0)]
+			if !ok || mode.iface != protocols.OutputModeInterface || mode.data.(*modeObject).head == nil {
+				c.postError(cl, obj.id, 1, "invalid mode") // ZWLR_OUTPUT_CONFIGURATION_HEAD_V1_ERROR_INVALID_MODE
+				return false
+			}
+			head.CurrentMode = mode.data.(*modeObject).index
+		case "set_custom_mode":
+			custom := Mode{Width: req.Int(0), Height: req.Int(1), Refresh: req.Int(2)}
+			head.CurrentMode = slices.IndexFunc(head.Modes, func(m Mode) bool {
+				return m.Width == custom.Width && m.Height == custom.Height && m.Refresh == custom.Refresh
+			})
+			if head.CurrentMode < 0 {
+				head.Modes = append(head.Modes, custom)
+				head.CurrentMode = len(head.Modes) - 1
+			}
+		case "set_position":
+			head.X, head.Y = req.Int(0), req.Int(1)
+		case "set_transform":
+			head.Transform = req.Int(0)
+		case "set_scale":
+			head.Scale = req.Fixed(0)
+		case "set_adaptive_sync":
+			head.AdaptiveSync = req.Uint(0) == 1
+		}
+		return true
+	}
+
+	config := obj.data.(*configState)
+	switch req.Name {
+	case "enable_head", "disable_head":
+		headArg := req.Uint(0)
+		if req.Name == "enable_head" {
+			headArg = req.Uint(1)
+		}
+		target, ok := cl.objects[headArg]
+		if !ok || target.iface != protocols.OutputHeadInterface || target.data.(*headObject).head == nil {
+			// The head was removed meanwhile, the serial is outdated and
+			// apply cancels the configuration
+			if req.Name == "enable_head" {
+				c.createObject(cl, req.Uint(0), protocols.OutputConfigurationHeadInterface, obj.version, &configHead{head: &Head{}})
+			}
+			return true
+		}
+		h := target.data.(*headObject).head
+		if config.heads[h] != nil {
+			c.postError(cl, obj.id, 1, "head configured twice") // ZWLR_OUTPUT_CONFIGURATION_V1_ERROR_ALREADY_CONFIGURED_HEAD
+			return false
+		}
+		head := h.Head
+		head.Modes = slices.Clone(h.Modes)
+		head.Enabled = req.Name == "enable_head"
+		config.heads[h] = &head
+		if req.Name == "enable_head" {
+			c.createObject(cl, req.Uint(0), protocols.OutputConfigurationHeadInterface, obj.version, &configHead{head: &head})
+		}
+
+	case "apply", "test":
+		if config.used {
+			c.postError(cl, obj.id, 3, "configuration already used") // ZWLR_OUTPUT_CONFIGURATION_V1_ERROR_ALREADY_USED
+			return false
+		}
+		config.used = true
+		switch {
+		case config.serial != c.serial:
+			c.send(cl, obj.id, 2) // cancelled
+		case c.configErr:
+			c.send(cl, obj.id, 1) // failed
+		case len(config.heads) != len(c.heads):
+			c.postError(cl, obj.id, 2, "head left unconfigured") // ZWLR_OUTPUT_CONFIGURATION_V1_ERROR_UNCONFIGURED_HEAD
+			return false
+		case req.Name == "test":
+			c.send(cl, obj.id, 0) // succeeded
+		default:
+			c.send(cl, obj.id, 0) // succeeded
+			for _, h := range c.heads {
+				c.changeHead(h, *config.heads[h])
+			}
+			c.done()
+		}
+	}
+	return true
+}
+
+// forgetOutputObject drops the references to a destroyed object, with c.mu
+// held
+func (c *Compositor) forgetOutputObject(_ *client, obj *object) {
+	if ho, ok := obj.data.(*headObject); ok && ho.head != nil {
+		delete(ho.manager.data.(*managerState).heads, ho.head)
+	}
+}
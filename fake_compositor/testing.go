@@ -0,0 +1,35 @@
+package fake_compositor
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// waitTimeout bounds how long ExpectRequests waits for the requests
+const waitTimeout = 5 * time.Second
+
+// NewT starts a compositor like New and closes it once the test is over. It
+// fails the test if the compositor can't start.
+func NewT(tb testing.TB, opts ...Option) *Compositor {
+	tb.Helper()
+	fc, err := New(opts...)
+	if err != nil {
+		tb.Fatalf("Failed to start fake compositor: %v", err)
+	}
+	tb.Cleanup(func() { _ = fc.Close() })
+	return fc
+}
+
+// ExpectRequests waits for n requests called name on the objects of iface and
+// returns them. It fails the test if they don't arrive within five seconds.
+func (c *Compositor) ExpectRequests(tb testing.TB, n int, iface, name string) []Request {
+	tb.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), waitTimeout)
+	defer cancel()
+	reqs, err := c.WaitForRequests(ctx, n, Named(iface, name))
+	if err != nil {
+		tb.Fatalf("Compositor didn't receive %s.%s: %v", iface, name, err)
+	}
+	return reqs
+}
@@ -0,0 +1,273 @@
+package fake_compositor
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/bnema/libwldevices-go/internal/protocols"
+)
+
+// ObjectID is an object argument of a request
+type ObjectID uint32
+
+// NewID is a new_id argument of a request: the object it creates
+type NewID uint32
+
+// File is an fd argument of a request. The compositor reads the file when
+// the request arrives and closes its copy of the descriptor.
+type File struct {
+	Data []byte
+}
+
+// Request is a request received from a client, with its arguments decoded
+// following the protocol: int32 for int, uint32 for uint, float64 for fixed,
+// string, []byte for arrays, ObjectID, NewID and File.
+type Request struct {
+	Client    int    // Index of the client connection, in connection order
+	Interface string // Interface of the object, "unknown" if it doesn't exist
+	Object    uint32 // Object the request was sent to
+	Opcode    uint16
+	Name      string // Name of the request, "opcode_N" if the interface isn't known
+	Args      []any
+}
+
+// Is reports whether the request is the named request of iface
+func (r Request) Is(iface, name string) bool {
+	return r.Interface == iface && r.Name == name
+}
+
+// Uint returns argument i as a uint32, or 0
+func (r Request) Uint(i int) uint32 {
+	switch v := r.arg(i).(type) {
+	case uint32:
+		return v
+	case int32:
+		return uint32(v)
+	case ObjectID:
+		return uint32(v)
+	case NewID:
+		return uint32(v)
+	}
+	return 0
+}
+
+// Int returns argument i as an int32, or 0
+func (r Request) Int(i int) int32 {
+	switch v := r.arg(i).(type) {
+	case int32:
+		return v
+	case uint32:
+		return int32(v)
+	}
+	return 0
+}
+
+// Fixed returns fixed argument i, or 0
+func (r Request) Fixed(i int) float64 {
+	v, _ := r.arg(i).(float64)
+	return v
+}
+
+// String returns string argument i, or ""
+func (r Request) String(i int) string {
+	v, _ := r.arg(i).(string)
+	return v
+}
+
+// File returns the contents of fd argument i, or nil
+func (r Request) File(i int) []byte {
+	v, _ := r.arg(i).(File)
+	return v.Data
+}
+
+// NewObject returns the object created by the request, if any
+func (r Request) NewObject() (Object, bool) {
+	for _, arg := range r.Args {
+		if id, ok := arg.(NewID); ok {
+			return Object{Client: r.Client, ID: uint32(id)}, true
+		}
+	}
+	return Object{}, false
+}
+
+func (r Request) arg(i int) any {
+	if i < 0 || i >= len(r.Args) {
+		return nil
+	}
+	return r.Args[i]
+}
+
+// Format returns the request in the format of WAYLAND_DEBUG=1
+func (r Request) Format() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s@%d.%s(", r.Interface, r.Object, r.Name)
+	for i, arg := range r.Args {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		switch v := arg.(type) {
+		case string:
+			b.WriteString(strconv.Quote(v))
+		case float64:
+			b.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+		case []byte:
+			fmt.Fprintf(&b, "array[%d]", len(v))
+		case ObjectID:
+			if v == 0 {
+				b.WriteString("nil")
+			} else {
+				fmt.Fprintf(&b, "@%d", v)
+			}
+		case NewID:
+			fmt.Fprintf(&b, "new id @%d", v)
+		case File:
+			fmt.Fprintf(&b, "fd (%d bytes)", len(v.Data))
+		default:
+			fmt.Fprint(&b, v)
+		}
+	}
+	b.WriteString(")")
+	return b.String()
+}
+
+// Object is an object of a client connection
+type Object struct {
+	Client int
+	ID     uint32
+}
+
+// decodeArgs decodes the body of a message with the given signature,
+// taking fd arguments from fds. It returns the descriptors left.
+func decodeArgs(signature string, body []byte, fds []int) ([]any, []int, error) {
+	var args []any
+	next := func() (uint32, error) {
+		if len(body) < 4 {
+			return 0, fmt.Errorf("message too short for signature %q", signature)
+		}
+		v := binary.LittleEndian.Uint32(body)
+		body = body[4:]
+		return v, nil
+	}
+
+	for _, kind := range signature {
+		if kind == 'h' {
+			if len(fds) == 0 {
+				return nil, fds, fmt.Errorf("missing fd for signature %q", signature)
+			}
+			args = append(args, File{Data: readFD(fds[0])})
+			fds = fds[1:]
+			continue
+		}
+
+		v, err := next()
+		if err != nil {
+			return nil, fds, err
+		}
+		switch kind {
+		case 'i':
+			args = append(args, int32(v))
+		case 'u':
+			args = append(args, v)
+		case 'f':
+			args = append(args, float64(int32(v))/256)
+		case 'o':
+			args = append(args, ObjectID(v))
+		case 'n':
+			args = append(args, NewID(v))
+		case 's', 'a':
+			padded := int((v + 3) &^ 3)
+			if padded > len(body) {
+				return nil, fds, fmt.Errorf("argument longer than the message")
+			}
+			data := body[:v]
+			body = body[padded:]
+			if kind == 'a' {
+				args = append(args, append([]byte(nil), data...))
+			} else if v == 0 {
+				args = append(args, "")
+			} else {
+				args = append(args, string(data[:v-1]))
+			}
+		}
+	}
+	return args, fds, nil
+}
+
+// readFD reads the whole file behind a received descriptor and closes it
+func readFD(fd int) []byte {
+	f := os.NewFile(uintptr(fd), "request-fd")
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	if err != nil || info.Size() <= 0 {
+		return nil
+	}
+	data := make([]byte, info.Size())
+	n, _ := f.ReadAt(data, 0)
+	return data[:n]
+}
+
+// encodeEvent builds a message. Arguments are uint32, int32, float64 for
+// fixed, string, []byte, ObjectID and NewID.
+func encodeEvent(object uint32, opcode uint16, args ...any) ([]byte, error) {
+	msg := make([]byte, 8, 32)
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case uint32:
+			msg = binary.LittleEndian.AppendUint32(msg, v)
+		case int32:
+			msg = binary.LittleEndian.AppendUint32(msg, uint32(v))
+		case ObjectID:
+			msg = binary.LittleEndian.AppendUint32(msg, uint32(v))
+		case NewID:
+			msg = binary.LittleEndian.AppendUint32(msg, uint32(v))
+		case float64:
+			msg = binary.LittleEndian.AppendUint32(msg, uint32(int32(math.Round(v*256))))
+		case string:
+			msg = binary.LittleEndian.AppendUint32(msg, uint32(len(v)+1))
+			msg = append(msg, v...)
+			msg = append(msg, make([]byte, 4-len(v)%4)...)
+		case []byte:
+			msg = binary.LittleEndian.AppendUint32(msg, uint32(len(v)))
+			msg = append(msg, v...)
+			msg = append(msg, make([]byte, (4-len(v)%4)%4)...)
+		default:
+			return nil, fmt.Errorf("unsupported event argument %T", arg)
+		}
+	}
+	binary.LittleEndian.PutUint32(msg[0:4], object)
+	binary.LittleEndian.PutUint32(msg[4:8], uint32(len(msg))<<16|uint32(opcode))
+	return msg, nil
+}
+
+// requestMessage returns the description of a request
+func requestMessage(iface string, opcode uint16) (protocols.Message, bool) {
+	messages, ok := protocols.LookupMessages(iface)
+	if !ok || int(opcode) >= len(messages.Requests) {
+		return protocols.Message{}, false
+	}
+	return messages.Requests[opcode], true
+}
+
+// receivedFDs returns the descriptors passed in a control message
+func receivedFDs(oob []byte) []int {
+	if len(oob) == 0 {
+		return nil
+	}
+	messages, err := syscall.ParseSocketControlMessage(oob)
+	if err != nil {
+		return nil
+	}
+	var fds []int
+	for _, m := range messages {
+		if rights, err := syscall.ParseUnixRights(&m); err == nil {
+			fds = append(fds, rights...)
+		}
+	}
+	return fds
+}
@@ -0,0 +1,274 @@
+package fake_input
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bnema/libwldevices-go/session"
+	"github.com/bnema/libwldevices-go/virtual_keyboard"
+	"github.com/bnema/libwldevices-go/virtual_pointer"
+	"github.com/bnema/libwldevices-go/xkb"
+)
+
+// recorder is a testing.TB recording failures instead of failing the test
+type recorder struct {
+	testing.TB
+	failed bool
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) { r.failed = true }
+
+func TestPointerPosition(t *testing.T) {
+	p := NewPointer()
+
+	if err := p.MotionAbsolute(time.Now(), 100, 50, 1920, 1080); err != nil {
+		t.Fatalf("MotionAbsolute failed: %v", err)
+	}
+	if err := p.MoveRelative(10, -5); err != nil {
+		t.Fatalf("MoveRelative failed: %v", err)
+	}
+	p.AssertPosition(t, 110, 45)
+
+	if err := p.LeftClick(); err != nil {
+		t.Fatalf("LeftClick failed: %v", err)
+	}
+	p.AssertClickedAt(t, virtual_pointer.BTN_LEFT, 110, 45)
+	if p.Held(virtual_pointer.BTN_LEFT) {
+		t.Error("The button should be released after a click")
+	}
+
+	r := &recorder{TB: t}
+	p.AssertClickedAt(r, virtual_pointer.BTN_RIGHT, 110, 45)
+	if !r.failed {
+		t.Error("AssertClickedAt should fail for a button that was not clicked")
+	}
+
+	var kinds []PointerEventKind
+	for _, e := range p.Events() {
+		kinds = append(kinds, e.Kind)
+	}
+	want := []PointerEventKind{PointerMotionAbsolute, PointerMotion, PointerFrame, PointerButton, PointerButton, PointerFrame}
+	if len(kinds) != len(want) {
+		t.Fatalf("Recorded %v, want %v", kinds, want)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Errorf("Event %d is %s, want %s", i, kinds[i], want[i])
+		}
+	}
+}
+
+func TestPointerClick(t *testing.T) {
+	p := NewPointer()
+	p.SetPosition(30, 40)
+
+	if err := p.Click(virtual_pointer.BTN_SIDE); err != nil {
+		t.Fatalf("Click failed: %v", err)
+	}
+	p.AssertClickedAt(t, virtual_pointer.BTN_SIDE, 30, 40)
+	if p.Held(virtual_pointer.BTN_SIDE) {
+		t.Error("The button should be released after a click")
+	}
+	if events := p.Events(); len(events) != 3 || events[2].Kind != PointerFrame {
+		t.Errorf("Click should end with a frame: %+v", events)
+	}
+}
+
+func TestPointerScroll(t *testing.T) {
+	p := NewPointer()
+	_ = p.ScrollVertical(5)
+	_ = p.ScrollVertical(-2)
+	_ = p.ScrollHorizontal(3)
+	p.AssertScrolled(t, virtual_pointer.AxisVertical, 3)
+	p.AssertScrolled(t, virtual_pointer.AxisHorizontal, 3)
+}
+
+func TestPointerErrors(t *testing.T) {
+	p := NewPointer()
+
+	injected := errors.New("broken pipe")
+	p.SetError(injected)
+	if err := p.LeftClick(); !errors.Is(err, injected) {
+		t.Errorf("LeftClick returned %v, want %v", err, injected)
+	}
+	if len(p.Events()) != 0 {
+		t.Error("Failed calls should not be recorded")
+	}
+	p.SetError(nil)
+
+	_ = p.Close()
+	if err := p.Frame(); !errors.Is(err, session.ErrManagerClosed) {
+		t.Errorf("Frame after Close returned %v", err)
+	}
+}
+
+func TestPointerReleaseAll(t *testing.T) {
+	p := NewPointer()
+	_ = p.Button(time.Now(), virtual_pointer.BTN_RIGHT, virtual_pointer.ButtonStatePressed)
+	_ = p.ReleaseAll()
+	if p.Held(virtual_pointer.BTN_RIGHT) {
+		t.Error("ReleaseAll should release the buttons held")
+	}
+}
+
+func TestKeyboardTyped(t *testing.T) {
+	k := NewKeyboard()
+
+	_ = k.TypeString("hello")
+	_ = k.TypeStringContext(context.Background(), " world")
+	k.AssertTyped(t, "hello world")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := k.TypeStringContext(ctx, "!"); !errors.Is(err, context.Canceled) {
+		t.Errorf("TypeStringContext with a cancelled context returned %v", err)
+	}
+	k.AssertTyped(t, "hello world")
+
+	r := &recorder{TB: t}
+	k.AssertTyped(r, "hello")
+	if !r.failed {
+		t.Error("AssertTyped should fail on different text")
+	}
+}
+
+func TestKeyboardKeys(t *testing.T) {
+	k := NewKeyboard()
+
+	_ = k.PressKey(virtual_keyboard.KEY_LEFTCTRL)
+	_ = k.TypeKey(virtual_keyboard.KEY_C)
+	if held := k.Held(); len(held) != 1 || held[0] != virtual_keyboard.KEY_LEFTCTRL {
+		t.Errorf("Held() = %v, want [KEY_LEFTCTRL]", held)
+	}
+	_ = k.ReleaseKey(virtual_keyboard.KEY_LEFTCTRL)
+
+	k.AssertKeys(t, virtual_keyboard.KEY_LEFTCTRL, virtual_keyboard.KEY_C)
+	k.AssertNoKeysHeld(t)
+
+	_ = k.PressKey(virtual_keyboard.KEY_LEFTSHIFT)
+	_ = k.PressKey(virtual_keyboard.KEY_A)
+	_ = k.ReleaseAll()
+	k.AssertNoKeysHeld(t)
+
+	k.Reset()
+	k.AssertKeys(t)
+
+	_ = k.Close()
+	if err := k.TypeKey(virtual_keyboard.KEY_A); !errors.Is(err, session.ErrManagerClosed) {
+		t.Errorf("TypeKey after Close returned %v", err)
+	}
+}
+
+func TestKeyboardChords(t *testing.T) {
+	k := NewKeyboard()
+
+	_ = k.TypeChord("ctrl+shift+t")
+	_ = k.TypeChord("ctrl+k ctrl+c")
+	if err := k.TypeChord(" "); err == nil {
+		t.Error("TypeChord should reject an empty chord")
+	}
+	k.AssertChords(t, "ctrl+shift+t", "ctrl+k ctrl+c")
+	k.AssertTyped(t, "")
+
+	r := &recorder{TB: t}
+	k.AssertChords(r, "ctrl+shift+t")
+	if !r.failed {
+		t.Error("AssertChords should fail on different chords")
+	}
+}
+
+func TestKeyboardKeymap(t *testing.T) {
+	k := NewKeyboard()
+
+	if err := k.SetKeymap(nil); err == nil {
+		t.Error("SetKeymap should reject a nil keymap")
+	}
+	keymap := xkb.DefaultKeymap()
+	if err := k.SetKeymap(keymap); err != nil {
+		t.Fatalf("SetKeymap failed: %v", err)
+	}
+	if k.Keymap() != keymap {
+		t.Error("Keymap should return the keymap set")
+	}
+	if events := k.Events(); len(events) != 1 || events[0].Kind != KeyboardKeymap || events[0].Keymap != keymap {
+		t.Errorf("Unexpected events: %+v", events)
+	}
+}
+
+func TestKeyboardHolds(t *testing.T) {
+	k := NewKeyboard()
+	ctx := context.Background()
+
+	if err := k.HoldKey(ctx, virtual_keyboard.KEY_A, time.Hour); err != nil {
+		t.Fatalf("HoldKey failed: %v", err)
+	}
+	if err := k.RepeatKey(ctx, virtual_keyboard.KEY_B, time.Second, 600*time.Millisecond, 25); err != nil {
+		t.Fatalf("RepeatKey failed: %v", err)
+	}
+	if err := k.RepeatKey(ctx, virtual_keyboard.KEY_B, time.Second, 0, 0); err == nil {
+		t.Error("RepeatKey should reject a zero rate")
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if err := k.HoldKey(cancelled, virtual_keyboard.KEY_C, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("HoldKey until cancelled returned %v", err)
+	}
+
+	holds := k.Holds()
+	if len(holds) != 3 {
+		t.Fatalf("Recorded %d holds, want 3: %+v", len(holds), holds)
+	}
+	if h := holds[0]; h.Kind != KeyboardHold || h.Key != virtual_keyboard.KEY_A || h.Duration != time.Hour {
+		t.Errorf("Unexpected hold: %+v", h)
+	}
+	if r := holds[1]; r.Kind != KeyboardRepeat || r.Key != virtual_keyboard.KEY_B || r.Delay != 600*time.Millisecond || r.Rate != 25 {
+		t.Errorf("Unexpected repeat: %+v", r)
+	}
+	k.AssertNoKeysHeld(t)
+}
+
+func TestKeyboardLocks(t *testing.T) {
+	k := NewKeyboard()
+
+	_ = k.Modifiers(uint32(xkb.ModShift), 0, 0, 0)
+	if err := k.SetCapsLock(true); err != nil {
+		t.Fatalf("SetCapsLock failed: %v", err)
+	}
+	if err := k.SetNumLock(true); err != nil {
+		t.Fatalf("SetNumLock failed: %v", err)
+	}
+	k.AssertLocked(t, xkb.ModLock|xkb.Mod2)
+	if err := k.SetCapsLock(false); err != nil {
+		t.Fatalf("SetCapsLock failed: %v", err)
+	}
+	k.AssertLocked(t, xkb.Mod2)
+
+	// Held modifiers are kept
+	events := k.Events()
+	if last := events[len(events)-1]; last.Kind != KeyboardModifiers || last.Depressed != uint32(xkb.ModShift) {
+		t.Errorf("Unexpected modifiers event: %+v", last)
+	}
+
+	if err := k.SetLockedModifiers(0); err != nil {
+		t.Fatalf("SetLockedModifiers failed: %v", err)
+	}
+	k.AssertLocked(t, 0)
+
+	r := &recorder{TB: t}
+	k.AssertLocked(r, xkb.ModLock)
+	if !r.failed {
+		t.Error("AssertLocked should fail when Caps Lock is off")
+	}
+}
+
+// Code written against the interfaces accepts both the fakes and the real
+// devices
+func TestInterfaces(t *testing.T) {
+	var _ virtual_pointer.Pointer = NewPointer()
+	var _ virtual_keyboard.Keyboard = NewKeyboard()
+}
@@ -0,0 +1,419 @@
+package fake_input
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bnema/libwldevices-go/session"
+	"github.com/bnema/libwldevices-go/virtual_keyboard"
+	"github.com/bnema/libwldevices-go/xkb"
+)
+
+// KeyboardEventKind identifies the method a KeyboardEvent was recorded by
+type KeyboardEventKind int
+
+// Keyboard event kinds
+const (
+	KeyboardKey KeyboardEventKind = iota
+	KeyboardModifiers
+	KeyboardText   // A TypeString call
+	KeyboardChord  // A TypeChord call
+	KeyboardKeymap // A SetKeymap call
+	KeyboardHold   // A HoldKey call
+	KeyboardRepeat // A RepeatKey call
+)
+
+// String returns the name of the request or method the event stands for
+func (k KeyboardEventKind) String() string {
+	switch k {
+	case KeyboardKey:
+		return "key"
+	case KeyboardModifiers:
+		return "modifiers"
+	case KeyboardText:
+		return "text"
+	case KeyboardChord:
+		return "chord"
+	case KeyboardKeymap:
+		return "keymap"
+	case KeyboardHold:
+		return "hold"
+	case KeyboardRepeat:
+		return "repeat"
+	}
+	return fmt.Sprintf("KeyboardEventKind(%d)", int(k))
+}
+
+// KeyboardEvent is a call recorded by Keyboard. Only the fields of its kind
+// are set.
+type KeyboardEvent struct {
+	Kind KeyboardEventKind
+	Time time.Time
+
+	Key   uint32                    // KeyboardKey, KeyboardHold, KeyboardRepeat
+	State virtual_keyboard.KeyState // KeyboardKey
+
+	Duration time.Duration // KeyboardHold, KeyboardRepeat
+	Delay    time.Duration // KeyboardRepeat
+	Rate     float64       // KeyboardRepeat
+
+	Depressed, Latched, Locked, Group uint32 // KeyboardModifiers
+
+	Text string // KeyboardText, and the chords of KeyboardChord
+
+	Keymap *xkb.Keymap // KeyboardKeymap
+}
+
+// Keyboard is an in-memory virtual_keyboard.Keyboard. TypeString and
+// TypeChord are recorded as text rather than key presses, since those depend
+// on the keymap: tests check them with Text or AssertTyped and Chords or
+// AssertChords, and individual keys with Pressed or AssertKeys.
+type Keyboard struct {
+	mu     sync.Mutex
+	events []KeyboardEvent
+	held   []uint32
+	keymap *xkb.Keymap
+	mods   KeyboardEvent // Last KeyboardModifiers event
+	err    error
+	closed bool
+}
+
+var _ virtual_keyboard.Keyboard = (*Keyboard)(nil)
+
+// NewKeyboard returns a keyboard with no key held
+func NewKeyboard() *Keyboard {
+	return &Keyboard{}
+}
+
+// record applies an event, or returns the error set with SetError
+func (k *Keyboard) record(e KeyboardEvent) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.closed {
+		return fmt.Errorf("fake keyboard: %w", session.ErrManagerClosed)
+	}
+	if k.err != nil {
+		return k.err
+	}
+
+	switch e.Kind {
+	case KeyboardKey:
+		k.held = slices.DeleteFunc(k.held, func(key uint32) bool { return key == e.Key })
+		if e.State == virtual_keyboard.KeyStatePressed {
+			k.held = append(k.held, e.Key)
+		}
+	case KeyboardModifiers:
+		k.mods = e
+	case KeyboardKeymap:
+		k.keymap = e.Keymap
+	}
+	k.events = append(k.events, e)
+	return nil
+}
+
+// SetKeymap implements virtual_keyboard.Keyboard. The keymap is recorded, not
+// compiled.
+func (k *Keyboard) SetKeymap(keymap *xkb.Keymap) error {
+	if keymap == nil {
+		return errors.New("nil keymap")
+	}
+	return k.record(KeyboardEvent{Kind: KeyboardKeymap, Time: time.Now(), Keymap: keymap})
+}
+
+// Key implements virtual_keyboard.Keyboard
+func (k *Keyboard) Key(timestamp time.Time, key uint32, state virtual_keyboard.KeyState) error {
+	return k.record(KeyboardEvent{Kind: KeyboardKey, Time: timestamp, Key: key, State: state})
+}
+
+// Modifiers implements virtual_keyboard.Keyboard
+func (k *Keyboard) Modifiers(modsDepressed, modsLatched, modsLocked, group uint32) error {
+	return k.record(KeyboardEvent{
+		Kind:      KeyboardModifiers,
+		Time:      time.Now(),
+		Depressed: modsDepressed,
+		Latched:   modsLatched,
+		Locked:    modsLocked,
+		Group:     group,
+	})
+}
+
+// SetLockedModifiers implements virtual_keyboard.Keyboard: a modifiers event
+// is recorded with the modifiers held and latched by the last one
+func (k *Keyboard) SetLockedModifiers(locked xkb.ModMask) error {
+	k.mu.Lock()
+	mods := k.mods
+	k.mu.Unlock()
+	return k.Modifiers(mods.Depressed, mods.Latched, uint32(locked), mods.Group)
+}
+
+// SetCapsLock implements virtual_keyboard.Keyboard
+func (k *Keyboard) SetCapsLock(on bool) error {
+	return k.setLock("Lock", on)
+}
+
+// SetNumLock implements virtual_keyboard.Keyboard. The modifier is looked up
+// in the keymap set with SetKeymap, or in xkb.DefaultKeymap.
+func (k *Keyboard) SetNumLock(on bool) error {
+	return k.setLock("NumLock", on)
+}
+
+// setLock locks or unlocks the modifier called name, leaving the other
+// locks as they are
+func (k *Keyboard) setLock(name string, on bool) error {
+	keymap := k.Keymap()
+	if keymap == nil {
+		keymap = xkb.DefaultKeymap()
+	}
+	mods, err := keymap.ModifierMask(name)
+	if err != nil {
+		return err
+	}
+	locked := k.Locked()
+	if on {
+		locked |= mods
+	} else {
+		locked &^= mods
+	}
+	return k.SetLockedModifiers(locked)
+}
+
+// PressKey implements virtual_keyboard.Keyboard
+func (k *Keyboard) PressKey(key uint32) error {
+	return k.Key(time.Now(), key, virtual_keyboard.KeyStatePressed)
+}
+
+// ReleaseKey implements virtual_keyboard.Keyboard
+func (k *Keyboard) ReleaseKey(key uint32) error {
+	return k.Key(time.Now(), key, virtual_keyboard.KeyStateReleased)
+}
+
+// TypeKey implements virtual_keyboard.Keyboard, without the delay between
+// press and release
+func (k *Keyboard) TypeKey(key uint32) error {
+	if err := k.PressKey(key); err != nil {
+		return err
+	}
+	return k.ReleaseKey(key)
+}
+
+// HoldKey implements virtual_keyboard.Keyboard. The hold is recorded as one
+// event without waiting for d; with d <= 0 it waits for ctx like
+// VirtualKeyboard. The key isn't held afterwards.
+func (k *Keyboard) HoldKey(ctx context.Context, key uint32, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if d <= 0 {
+		<-ctx.Done()
+	}
+	err := k.record(KeyboardEvent{Kind: KeyboardHold, Time: time.Now(), Key: key, Duration: d})
+	return errors.Join(err, ctx.Err())
+}
+
+// RepeatKey implements virtual_keyboard.Keyboard. The arguments are checked
+// like VirtualKeyboard does and recorded as one event, without waiting for
+// d; with d <= 0 it waits for ctx.
+func (k *Keyboard) RepeatKey(ctx context.Context, key uint32, d, delay time.Duration, rate float64) error {
+	if rate <= 0 || math.IsNaN(rate) || math.IsInf(rate, 0) {
+		return fmt.Errorf("repeat rate must be positive, got %v", rate)
+	}
+	if delay < 0 {
+		return fmt.Errorf("repeat delay must not be negative, got %v", delay)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if d <= 0 {
+		<-ctx.Done()
+	}
+	err := k.record(KeyboardEvent{Kind: KeyboardRepeat, Time: time.Now(), Key: key, Duration: d, Delay: delay, Rate: rate})
+	return errors.Join(err, ctx.Err())
+}
+
+// TypeString implements virtual_keyboard.Keyboard
+func (k *Keyboard) TypeString(text string) error {
+	return k.record(KeyboardEvent{Kind: KeyboardText, Time: time.Now(), Text: text})
+}
+
+// TypeStringContext implements virtual_keyboard.Keyboard: the text is
+// recorded unless ctx is done
+func (k *Keyboard) TypeStringContext(ctx context.Context, text string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return k.TypeString(text)
+}
+
+// TypeChord implements virtual_keyboard.Keyboard. The chords are recorded as
+// given; only an empty string is rejected, since parsing them needs a keymap.
+func (k *Keyboard) TypeChord(chords string) error {
+	if len(strings.Fields(chords)) == 0 {
+		return fmt.Errorf("empty chord %q", chords)
+	}
+	return k.record(KeyboardEvent{Kind: KeyboardChord, Time: time.Now(), Text: chords})
+}
+
+// ReleaseAll implements virtual_keyboard.Keyboard: the keys held are
+// released, last pressed first
+func (k *Keyboard) ReleaseAll() error {
+	held := k.Held()
+	for i := len(held) - 1; i >= 0; i-- {
+		if err := k.ReleaseKey(held[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close implements virtual_keyboard.Keyboard. Later calls fail with
+// session.ErrManagerClosed.
+func (k *Keyboard) Close() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.closed = true
+	return nil
+}
+
+// Closed reports whether Close was called
+func (k *Keyboard) Closed() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.closed
+}
+
+// SetError makes every following call fail with err, to test error paths.
+// A nil err restores the keyboard.
+func (k *Keyboard) SetError(err error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.err = err
+}
+
+// Events returns the recorded calls, in order
+func (k *Keyboard) Events() []KeyboardEvent {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return append([]KeyboardEvent(nil), k.events...)
+}
+
+// Text returns the text passed to TypeString, concatenated
+func (k *Keyboard) Text() string {
+	var b strings.Builder
+	for _, e := range k.Events() {
+		if e.Kind == KeyboardText {
+			b.WriteString(e.Text)
+		}
+	}
+	return b.String()
+}
+
+// Chords returns the strings passed to TypeChord, in order
+func (k *Keyboard) Chords() []string {
+	var chords []string
+	for _, e := range k.Events() {
+		if e.Kind == KeyboardChord {
+			chords = append(chords, e.Text)
+		}
+	}
+	return chords
+}
+
+// Holds returns the calls to HoldKey and RepeatKey, in order
+func (k *Keyboard) Holds() []KeyboardEvent {
+	var holds []KeyboardEvent
+	for _, e := range k.Events() {
+		if e.Kind == KeyboardHold || e.Kind == KeyboardRepeat {
+			holds = append(holds, e)
+		}
+	}
+	return holds
+}
+
+// Pressed returns the keys pressed with Key, PressKey and TypeKey, in order
+func (k *Keyboard) Pressed() []uint32 {
+	var keys []uint32
+	for _, e := range k.Events() {
+		if e.Kind == KeyboardKey && e.State == virtual_keyboard.KeyStatePressed {
+			keys = append(keys, e.Key)
+		}
+	}
+	return keys
+}
+
+// Keymap returns the keymap last passed to SetKeymap, nil if none was
+func (k *Keyboard) Keymap() *xkb.Keymap {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.keymap
+}
+
+// Locked returns the modifiers locked by the last modifiers event
+func (k *Keyboard) Locked() xkb.ModMask {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return xkb.ModMask(k.mods.Locked)
+}
+
+// Held returns the keys currently pressed, in the order they were pressed
+func (k *Keyboard) Held() []uint32 {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return slices.Clone(k.held)
+}
+
+// Reset forgets the recorded events. Held keys, locked modifiers, the keymap
+// and the closed state are kept.
+func (k *Keyboard) Reset() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.events = nil
+}
+
+// AssertTyped fails the test unless the text typed with TypeString is want
+func (k *Keyboard) AssertTyped(t testing.TB, want string) {
+	t.Helper()
+	if got := k.Text(); got != want {
+		t.Errorf("typed %q, want %q", got, want)
+	}
+}
+
+// AssertChords fails the test unless exactly chords were passed to TypeChord,
+// in that order
+func (k *Keyboard) AssertChords(t testing.TB, chords ...string) {
+	t.Helper()
+	if got := k.Chords(); !slices.Equal(got, chords) {
+		t.Errorf("typed chords %q, want %q", got, chords)
+	}
+}
+
+// AssertKeys fails the test unless exactly keys were pressed, in that order
+func (k *Keyboard) AssertKeys(t testing.TB, keys ...uint32) {
+	t.Helper()
+	if got := k.Pressed(); !slices.Equal(got, keys) {
+		t.Errorf("pressed keys %v, want %v", got, keys)
+	}
+}
+
+// AssertLocked fails the test unless exactly the modifiers of want are
+// locked, such as xkb.ModLock for Caps Lock alone
+func (k *Keyboard) AssertLocked(t testing.TB, want xkb.ModMask) {
+	t.Helper()
+	if got := k.Locked(); got != want {
+		t.Errorf("locked modifiers %s, want %s", got, want)
+	}
+}
+
+// AssertNoKeysHeld fails the test if a key is still pressed
+func (k *Keyboard) AssertNoKeysHeld(t testing.TB) {
+	t.Helper()
+	if held := k.Held(); len(held) > 0 {
+		t.Errorf("keys still held: %v", held)
+	}
+}
@@ -0,0 +1,355 @@
+// Package fake_input provides in-memory virtual devices for testing code that
+// drives a virtual_pointer.Pointer or a virtual_keyboard.Keyboard, without a
+// compositor. The fakes record every call and keep the state a compositor
+// would derive from them: pointer position, clicks, held keys and typed text.
+//
+//	func TestSubmit(t *testing.T) {
+//		keyboard := fake_input.NewKeyboard()
+//		pointer := fake_input.NewPointer()
+//
+//		submitForm(keyboard, pointer) // code under test
+//
+//		keyboard.AssertTyped(t, "hello")
+//		pointer.AssertClickedAt(t, virtual_pointer.BTN_LEFT, 200, 100)
+//	}
+//
+// To check the requests actually sent on the wire, use fake_compositor.
+package fake_input
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bnema/libwldevices-go/session"
+	"github.com/bnema/libwldevices-go/virtual_pointer"
+)
+
+// PointerEventKind identifies the method a PointerEvent was recorded by
+type PointerEventKind int
+
+// Pointer event kinds
+const (
+	PointerMotion PointerEventKind = iota
+	PointerMotionAbsolute
+	PointerButton
+	PointerAxis
+	PointerFrame
+	PointerAxisSource
+	PointerAxisStop
+	PointerAxisDiscrete
+)
+
+// String returns the name of the request the event stands for
+func (k PointerEventKind) String() string {
+	switch k {
+	case PointerMotion:
+		return "motion"
+	case PointerMotionAbsolute:
+		return "motion_absolute"
+	case PointerButton:
+		return "button"
+	case PointerAxis:
+		return "axis"
+	case PointerFrame:
+		return "frame"
+	case PointerAxisSource:
+		return "axis_source"
+	case PointerAxisStop:
+		return "axis_stop"
+	case PointerAxisDiscrete:
+		return "axis_discrete"
+	}
+	return fmt.Sprintf("PointerEventKind(%d)", int(k))
+}
+
+// PointerEvent is a call recorded by Pointer. Only the fields of its kind are set.
+type PointerEvent struct {
+	Kind PointerEventKind
+	Time time.Time
+
+	DX, DY           float64 // PointerMotion
+	X, Y             uint32  // PointerMotionAbsolute
+	XExtent, YExtent uint32  // PointerMotionAbsolute
+
+	Button uint32                      // PointerButton
+	State  virtual_pointer.ButtonState // PointerButton
+
+	Axis     virtual_pointer.Axis       // PointerAxis, PointerAxisStop, PointerAxisDiscrete
+	Value    float64                    // PointerAxis, PointerAxisDiscrete
+	Discrete int32                      // PointerAxisDiscrete
+	Source   virtual_pointer.AxisSource // PointerAxisSource
+}
+
+// Click is a button press and the pointer position at that time
+type Click struct {
+	Button uint32
+	X, Y   float64
+}
+
+// Pointer is an in-memory virtual_pointer.Pointer. Relative motion moves it
+// from its position, absolute motion places it at the given coordinates
+// within the extents, so positions are in the units of the extents.
+type Pointer struct {
+	mu     sync.Mutex
+	events []PointerEvent
+	x, y   float64
+	clicks []Click
+	held   map[uint32]bool
+	err    error
+	closed bool
+}
+
+var _ virtual_pointer.Pointer = (*Pointer)(nil)
+
+// NewPointer returns a pointer at position (0, 0)
+func NewPointer() *Pointer {
+	return &Pointer{held: make(map[uint32]bool)}
+}
+
+// record applies an event, or returns the error set with SetError
+func (p *Pointer) record(e PointerEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return fmt.Errorf("fake pointer: %w", session.ErrManagerClosed)
+	}
+	if p.err != nil {
+		return p.err
+	}
+
+	switch e.Kind {
+	case PointerMotion:
+		p.x += e.DX
+		p.y += e.DY
+	case PointerMotionAbsolute:
+		p.x, p.y = float64(e.X), float64(e.Y)
+	case PointerButton:
+		if e.State == virtual_pointer.ButtonStatePressed {
+			p.clicks = append(p.clicks, Click{Button: e.Button, X: p.x, Y: p.y})
+			p.held[e.Button] = true
+		} else {
+			delete(p.held, e.Button)
+		}
+	}
+	p.events = append(p.events, e)
+	return nil
+}
+
+// Motion implements virtual_pointer.Pointer
+func (p *Pointer) Motion(timestamp time.Time, dx, dy float64) error {
+	return p.record(PointerEvent{Kind: PointerMotion, Time: timestamp, DX: dx, DY: dy})
+}
+
+// MotionAbsolute implements virtual_pointer.Pointer
+func (p *Pointer) MotionAbsolute(timestamp time.Time, x, y uint32, xExtent, yExtent uint32) error {
+	return p.record(PointerEvent{Kind: PointerMotionAbsolute, Time: timestamp, X: x, Y: y, XExtent: xExtent, YExtent: yExtent})
+}
+
+// Button implements virtual_pointer.Pointer
+func (p *Pointer) Button(timestamp time.Time, button uint32, state virtual_pointer.ButtonState) error {
+	return p.record(PointerEvent{Kind: PointerButton, Time: timestamp, Button: button, State: state})
+}
+
+// Axis implements virtual_pointer.Pointer
+func (p *Pointer) Axis(timestamp time.Time, axis virtual_pointer.Axis, value float64) error {
+	return p.record(PointerEvent{Kind: PointerAxis, Time: timestamp, Axis: axis, Value: value})
+}
+
+// Frame implements virtual_pointer.Pointer
+func (p *Pointer) Frame() error {
+	return p.record(PointerEvent{Kind: PointerFrame})
+}
+
+// AxisSource implements virtual_pointer.Pointer
+func (p *Pointer) AxisSource(source virtual_pointer.AxisSource) error {
+	return p.record(PointerEvent{Kind: PointerAxisSource, Source: source})
+}
+
+// AxisStop implements virtual_pointer.Pointer
+func (p *Pointer) AxisStop(timestamp time.Time, axis virtual_pointer.Axis) error {
+	return p.record(PointerEvent{Kind: PointerAxisStop, Time: timestamp, Axis: axis})
+}
+
+// AxisDiscrete implements virtual_pointer.Pointer
+func (p *Pointer) AxisDiscrete(timestamp time.Time, axis virtual_pointer.Axis, value float64, discrete int32) error {
+	return p.record(PointerEvent{Kind: PointerAxisDiscrete, Time: timestamp, Axis: axis, Value: value, Discrete: discrete})
+}
+
+// MoveRelative implements virtual_pointer.Pointer, as a motion and a frame
+func (p *Pointer) MoveRelative(dx, dy float64) error {
+	if err := p.Motion(time.Now(), dx, dy); err != nil {
+		return err
+	}
+	return p.Frame()
+}
+
+// LeftClick implements virtual_pointer.Pointer
+func (p *Pointer) LeftClick() error {
+	return p.Click(virtual_pointer.BTN_LEFT)
+}
+
+// RightClick implements virtual_pointer.Pointer
+func (p *Pointer) RightClick() error {
+	return p.Click(virtual_pointer.BTN_RIGHT)
+}
+
+// MiddleClick implements virtual_pointer.Pointer
+func (p *Pointer) MiddleClick() error {
+	return p.Click(virtual_pointer.BTN_MIDDLE)
+}
+
+// Click implements virtual_pointer.Pointer: the button is pressed and
+// released followed by a frame, like VirtualPointer. Any button code is
+// accepted.
+func (p *Pointer) Click(button uint32) error {
+	now := time.Now()
+	if err := p.Button(now, button, virtual_pointer.ButtonStatePressed); err != nil {
+		return err
+	}
+	if err := p.Button(now, button, virtual_pointer.ButtonStateReleased); err != nil {
+		return err
+	}
+	return p.Frame()
+}
+
+// ScrollVertical implements virtual_pointer.Pointer
+func (p *Pointer) ScrollVertical(amount float64) error {
+	return p.scroll(virtual_pointer.AxisVertical, amount)
+}
+
+// ScrollHorizontal implements virtual_pointer.Pointer
+func (p *Pointer) ScrollHorizontal(amount float64) error {
+	return p.scroll(virtual_pointer.AxisHorizontal, amount)
+}
+
+func (p *Pointer) scroll(axis virtual_pointer.Axis, amount float64) error {
+	if err := p.Axis(time.Now(), axis, amount); err != nil {
+		return err
+	}
+	return p.Frame()
+}
+
+// ReleaseAll implements virtual_pointer.Pointer: the buttons held are
+// released in the order of their codes, followed by a frame
+func (p *Pointer) ReleaseAll() error {
+	p.mu.Lock()
+	buttons := slices.Sorted(maps.Keys(p.held))
+	p.mu.Unlock()
+	if len(buttons) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	for _, button := range buttons {
+		if err := p.Button(now, button, virtual_pointer.ButtonStateReleased); err != nil {
+			return err
+		}
+	}
+	return p.Frame()
+}
+
+// Close implements virtual_pointer.Pointer. Later calls fail with
+// session.ErrManagerClosed.
+func (p *Pointer) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	return nil
+}
+
+// Closed reports whether Close was called
+func (p *Pointer) Closed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.closed
+}
+
+// SetError makes every following call fail with err, to test error paths.
+// A nil err restores the pointer.
+func (p *Pointer) SetError(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+}
+
+// SetPosition moves the pointer without recording an event
+func (p *Pointer) SetPosition(x, y float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.x, p.y = x, y
+}
+
+// Position returns the position of the pointer
+func (p *Pointer) Position() (x, y float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.x, p.y
+}
+
+// Events returns the recorded calls, in order
+func (p *Pointer) Events() []PointerEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]PointerEvent(nil), p.events...)
+}
+
+// Clicks returns every button press with the position it happened at
+func (p *Pointer) Clicks() []Click {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Click(nil), p.clicks...)
+}
+
+// Held reports whether a button is pressed
+func (p *Pointer) Held(button uint32) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.held[button]
+}
+
+// Reset forgets the recorded events and clicks. The position, held buttons
+// and closed state are kept.
+func (p *Pointer) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = nil
+	p.clicks = nil
+}
+
+// AssertClickedAt fails the test unless button was pressed at (x, y)
+func (p *Pointer) AssertClickedAt(t testing.TB, button uint32, x, y float64) {
+	t.Helper()
+	clicks := p.Clicks()
+	for _, c := range clicks {
+		if c.Button == button && c.X == x && c.Y == y {
+			return
+		}
+	}
+	t.Errorf("button %#x was not clicked at (%g, %g), clicks: %+v", button, x, y, clicks)
+}
+
+// AssertPosition fails the test unless the pointer is at (x, y)
+func (p *Pointer) AssertPosition(t testing.TB, x, y float64) {
+	t.Helper()
+	if px, py := p.Position(); px != x || py != y {
+		t.Errorf("pointer at (%g, %g), want (%g, %g)", px, py, x, y)
+	}
+}
+
+// AssertScrolled fails the test unless the axis events of axis add up to total
+func (p *Pointer) AssertScrolled(t testing.TB, axis virtual_pointer.Axis, total float64) {
+	t.Helper()
+	sum := 0.0
+	for _, e := range p.Events() {
+		if (e.Kind == PointerAxis || e.Kind == PointerAxisDiscrete) && e.Axis == axis {
+			sum += e.Value
+		}
+	}
+	if sum != total {
+		t.Errorf("scrolled %g on axis %d, want %g", sum, axis, total)
+	}
+}
@@ -2,6 +2,7 @@ module github.com/bnema/libwldevices-go
 
 go 1.24
 
-require github.com/bnema/wlturbo v0.1.0
-
-require golang.org/x/sys v0.33.0 // indirect
+require (
+	github.com/bnema/wlturbo v0.1.0
+	golang.org/x/sys v0.33.0
+)
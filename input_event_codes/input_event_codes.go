@@ -0,0 +1,35 @@
+// Package input_event_codes lists the Linux input event codes of keys and
+// buttons, the KEY_* and BTN_* defines of linux/input-event-codes.h, as sent
+// by virtual_keyboard and virtual_pointer:
+//
+//	keyboard.TypeKey(input_event_codes.KEY_VOLUMEUP)
+//	pointer.Click(input_event_codes.BTN_SIDE)
+//
+// Code and Name convert between the codes and their names, for codes read
+// from configuration files or shown to users.
+package input_event_codes
+
+import "strings"
+
+//go:generate go run ../tools/input_event_codes -header=/usr/include/linux/input-event-codes.h -output=codes.go
+
+// Code returns the code called name, such as KEY_VOLUMEUP or BTN_LEFT. Old
+// names and range markers are accepted too: KEY_SCREENLOCK, BTN_MOUSE.
+func Code(name string) (uint32, bool) {
+	code, ok := codesByName[name]
+	return code, ok
+}
+
+// Name returns the name of code, such as KEY_VOLUMEUP for 115. Codes with
+// several names get the current one of the header: BTN_LEFT rather than
+// BTN_MOUSE, KEY_COFFEE rather than KEY_SCREENLOCK.
+func Name(code uint32) (string, bool) {
+	name, ok := namesByCode[code]
+	return name, ok
+}
+
+// IsButton reports whether code is a button (BTN_*) rather than a key
+func IsButton(code uint32) bool {
+	name, ok := namesByCode[code]
+	return ok && strings.HasPrefix(name, "BTN_")
+}
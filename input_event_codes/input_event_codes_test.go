@@ -0,0 +1,59 @@
+package input_event_codes
+
+import "testing"
+
+func TestCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		code uint32
+	}{
+		{"KEY_ESC", 1},
+		{"KEY_VOLUMEUP", 115},
+		{"KEY_RIGHTCTRL", 97},
+		{"KEY_KP7", 71},
+		{"KEY_F24", 194},
+		{"KEY_OK", 0x160},
+		{"BTN_LEFT", 0x110},
+		{"BTN_SOUTH", 0x130},
+		{"BTN_TRIGGER_HAPPY40", 0x2e7},
+	}
+	for _, tt := range tests {
+		if code, ok := Code(tt.name); !ok || code != tt.code {
+			t.Errorf("Code(%q) = %d, %v, want %d", tt.name, code, ok, tt.code)
+		}
+		if name, ok := Name(tt.code); !ok || name != tt.name {
+			t.Errorf("Name(%d) = %q, %v, want %q", tt.code, name, ok, tt.name)
+		}
+	}
+
+	// Old names and range markers have codes but aren't names
+	for name, want := range map[string]string{
+		"KEY_SCREENLOCK":      "KEY_COFFEE",
+		"KEY_MIN_INTERESTING": "KEY_MUTE",
+		"BTN_A":               "BTN_SOUTH",
+		"BTN_MOUSE":           "BTN_LEFT",
+		"BTN_MISC":            "BTN_0",
+	} {
+		code, ok := Code(name)
+		if !ok {
+			t.Errorf("Code(%q) failed", name)
+			continue
+		}
+		if got, _ := Name(code); got != want {
+			t.Errorf("Name(Code(%q)) = %q, want %q", name, got, want)
+		}
+	}
+	if code, ok := Code("KEY_CNT"); !ok || code != KEY_MAX+1 {
+		t.Errorf("Code(KEY_CNT) = %d, %v", code, ok)
+	}
+
+	if _, ok := Code("KEY_BOGUS"); ok {
+		t.Error("Code should fail on unknown names")
+	}
+	if _, ok := Name(0x2fe); ok {
+		t.Error("Name should fail on unused codes")
+	}
+	if !IsButton(BTN_RIGHT) || IsButton(KEY_A) || IsButton(0x2fe) {
+		t.Error("IsButton mixes keys and buttons up")
+	}
+}
@@ -0,0 +1,188 @@
+package client
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/bnema/libwldevices-go/internal/protocols"
+	"github.com/bnema/wlturbo/wl"
+)
+
+// AdoptConfig describes a connection owned by the application, see
+// NewClientFromDisplay and NewClientFromContext
+type AdoptConfig struct {
+	// Registry binds the globals; nil uses the registry of the display, or
+	// the client's own registry on a context
+	Registry *wl.Registry
+
+	// Seat is the default seat for new devices; nil uses the first seat
+	// announced by the compositor
+	Seat *wl.Seat
+
+	// ExternalDispatch tells the client that the application dispatches
+	// the display's events on a goroutine of its own. Roundtrips then wait
+	// for that goroutine to deliver the sync callback instead of reading
+	// the connection themselves.
+	ExternalDispatch bool
+
+	// Logger receives diagnostics; nil discards them
+	Logger *slog.Logger
+}
+
+// NewClientFromDisplay creates a client on a display owned by the
+// application. The client never closes the display and never starts a
+// dispatch goroutine of its own: events are delivered when the application
+// dispatches them.
+//
+// Globals are learned through a second wl_registry object so the handlers of
+// the application's registry are left alone. Unless cfg.ExternalDispatch is
+// set, the call reads from the connection to wait for them, so it must not
+// run while another goroutine dispatches the display.
+func NewClientFromDisplay(display *wl.Display, cfg AdoptConfig) (*Client, error) {
+	if display == nil {
+		return nil, errors.New("display is nil")
+	}
+	if cfg.Registry == nil {
+		cfg.Registry = display.GetRegistry()
+	}
+	client, err := adopt(display.Context(), cfg)
+	if err != nil {
+		return nil, err
+	}
+	client.display = display
+	return client, nil
+}
+
+// NewClientFromContext is NewClientFromDisplay for applications that hold
+// the wlturbo context of their connection rather than its display. Without
+// cfg.Registry, globals are bound through the client's own registry, and
+// GetDisplay and GetRegistry return nil.
+func NewClientFromContext(ctx *wl.Context, cfg AdoptConfig) (*Client, error) {
+	if ctx == nil {
+		return nil, errors.New("context is nil")
+	}
+	return adopt(ctx, cfg)
+}
+
+// adopt creates a client on the connection of ctx, which only goes through
+// the context: wlturbo has no way back from a context to its display.
+func adopt(ctx *wl.Context, cfg AdoptConfig) (*Client, error) {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+
+	client := &Client{
+		registry:    cfg.Registry,
+		context:     ctx,
+		logger:      logger,
+		adopted:     true,
+		defaultSeat: cfg.Seat,
+		globals:     make(map[uint32]Global),
+	}
+	if err := client.initLoop(cfg.ExternalDispatch); err != nil {
+		return nil, err
+	}
+
+	id, err := client.allocateID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get registry: %w", err)
+	}
+	client.privateRegistry = &privateRegistry{client: client}
+	client.privateRegistry.SetContext(ctx)
+	client.privateRegistry.SetID(id)
+	if cfg.Registry != nil {
+		protocols.TrackObject(ctx, cfg.Registry.ID(), "wl_registry")
+	}
+	protocols.TrackObject(ctx, id, "wl_registry")
+	ctx.Register(client.privateRegistry)
+	// wl_display.get_registry is opcode 1
+	if err := ctx.SendRequest(displayObject, 1, id); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("failed to get registry: %w", err)
+	}
+
+	if err := client.Roundtrip(); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("failed to get initial globals: %w", err)
+	}
+	// Roundtrip again so that the seats bound above report their name and capabilities
+	if err := client.Roundtrip(); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("failed to get seat information: %w", err)
+	}
+
+	logger.Debug("adopted wayland connection", "globals", len(client.Globals()), "seats", len(client.Seats()))
+	return client, nil
+}
+
+// displayObject stands for wl_display in requests sent through a context
+var displayObject = func() *wl.BaseProxy {
+	p := &wl.BaseProxy{}
+	p.SetID(1)
+	return p
+}()
+
+// privateRegistry is the wl_registry an adopted client learns globals from
+type privateRegistry struct {
+	wl.BaseProxy
+	client *Client
+}
+
+// Dispatch handles the wl_registry events
+func (r *privateRegistry) Dispatch(event *wl.Event) {
+	switch event.Opcode {
+	case 0:
+		r.client.handleGlobal(event.Data())
+	case 1:
+		r.client.handleGlobalRemove(event.Data())
+	}
+}
+
+// Adopted reports whether the client runs on a display owned by the application
+func (c *Client) Adopted() bool {
+	return c.adopted
+}
+
+// handleGlobal is the raw listener for wl_registry.global on the private
+// registry of an adopted display
+func (c *Client) handleGlobal(data []byte) {
+	if len(data) < 8 || c.Closed() {
+		return
+	}
+	name := binary.LittleEndian.Uint32(data[0:4])
+	size := binary.LittleEndian.Uint32(data[4:8])
+	padded := int((size + 3) &^ 3)
+	if size == 0 || len(data) < 8+padded+4 {
+		return
+	}
+	c.HandleRegistryGlobal(wl.RegistryGlobalEvent{
+		Registry:  c.registry,
+		Name:      name,
+		Interface: string(data[8 : 8+size-1]),
+		Version:   binary.LittleEndian.Uint32(data[8+padded:]),
+	})
+}
+
+// release drops what an adopted client created on the display, which stays
+// open for the application
+func (c *Client) release() {
+	c.mu.Lock()
+	seats := c.seats
+	c.seats = nil
+	c.mu.Unlock()
+
+	for _, seat := range seats {
+		// wl_seat.release is opcode 3, since version 5
+		if seat.version >= 5 {
+			_ = c.context.SendRequest(seat, 3)
+		}
+		c.context.Unregister(seat)
+	}
+	// wl_registry has no destructor, its events are dropped from now on
+	if c.privateRegistry != nil {
+		c.context.Unregister(c.privateRegistry)
+	}
+}
@@ -2,9 +2,16 @@
 package client
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
 	"sync"
+	"sync/atomic"
 
+	"github.com/bnema/libwldevices-go/internal/protocols"
 	"github.com/bnema/wlturbo/wl"
 )
 
@@ -12,8 +19,20 @@ import (
 type Client struct {
 	display    *wl.Display
 	registry   *wl.Registry
-	seat       *wl.Seat
 	context    *wl.Context
+	link       *link // Compositor socket of a connection the client owns, see relay.go
+	fromFD     bool  // Connected over an inherited socket, which can't be dialled again
+	logger     *slog.Logger
+
+	// Set when the display belongs to the application, see NewClientFromDisplay
+	adopted         bool
+	privateRegistry *privateRegistry // wl_registry the globals are learned from
+	defaultSeat     *wl.Seat         // Seat given by the application, if any
+
+	// Bound seats in announcement order; the first one is the default
+	seats       []*Seat
+	seatAdded   func(*Seat)
+	seatRemoved func(*Seat)
 	
 	// Protocol globals
 	pointerManager     uint32
@@ -21,70 +40,173 @@ type Client struct {
 	constraintsManager uint32
 	outputManager      uint32
 
-	mu      sync.Mutex
-	globals map[uint32]string
+	mu        sync.Mutex
+	globals   map[uint32]Global
+	listeners []*GlobalListener
+
+	// Event loop state, shared by every user of the connection, see loop.go
+	external     bool          // The application drives the event loop
+	socketFD     int           // Socket polled for events
+	wakeMu       sync.RWMutex
+	wakeFD       int // eventfd interrupting poll, -1 once closed
+	pumpSem      chan struct{} // Held by whoever reads the connection
+	handling     atomic.Bool   // Set while the holder of pumpSem runs event handlers
+	dispatching  bool
+	dispatchDone chan struct{}
+	dispatchErr  error
+	stopDispatch context.CancelCauseFunc
+	closing      chan struct{} // Closed by Close
+	lost         chan struct{} // Closed once the connection is gone
+	lostErr      error
+}
+
+// Global is a global object advertised by the compositor
+type Global struct {
+	Name      uint32
+	Interface string
+	Version   uint32
 }
 
-// NewClient creates a new Wayland client
+// Config selects the compositor socket a Client connects to
+type Config struct {
+	// Display is a socket name relative to XDG_RUNTIME_DIR or an absolute
+	// socket path. Empty means WAYLAND_SOCKET, then WAYLAND_DISPLAY, then
+	// "wayland-0", like libwayland.
+	Display string
+
+	// SocketFD is an already connected socket, used when UseSocketFD is set.
+	// The client takes ownership of the descriptor.
+	SocketFD    int
+	UseSocketFD bool
+
+	// Logger receives diagnostics; nil discards them
+	Logger *slog.Logger
+
+	// Trace, if set, receives every request and event on the connection
+	// in the format of WAYLAND_DEBUG=1
+	Trace io.Writer
+
+	// Capture, if set, receives every request and event on the connection
+	// in the format of wire_capture, to be replayed in tests
+	Capture io.Writer
+
+	// ExternalDispatch leaves reading the connection to the application,
+	// which polls FD and calls DispatchPending; StartDispatch does nothing
+	ExternalDispatch bool
+}
+
+// NewClient creates a new Wayland client using the environment to find the compositor
 func NewClient() (*Client, error) {
-	// fmt.Println("[DEBUG] Connecting to Wayland display...")
-	display, err := wl.Connect("")
+	return NewClientWithConfig(Config{})
+}
+
+// NewClientWithConfig creates a new Wayland client connected as described by cfg
+func NewClientWithConfig(cfg Config) (*Client, error) {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+
+	logger.Debug("connecting to wayland display", "display", cfg.Display, "fd", cfg.UseSocketFD)
+	display, link, fromFD, err := connect(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Wayland: %w", err)
 	}
-	// fmt.Println("[DEBUG] Connected to Wayland display successfully")
 	
 	client := &Client{
 		display: display,
 		context: display.Context(),
-		globals: make(map[uint32]string),
+		link:    link,
+		fromFD:  fromFD,
+		logger:  logger,
+		globals: make(map[uint32]Global),
+	}
+	if err := client.initLoop(cfg.ExternalDispatch); err != nil {
+		_ = display.Close()
+		link.close()
+		return nil, err
 	}
 	
 	// Get registry
 	registry := display.GetRegistry()
 	client.registry = registry
+	protocols.TrackObject(client.context, registry.ID(), "wl_registry")
 	
 	// Set up registry listener BEFORE doing any roundtrips
 	registry.AddGlobalHandler(client)
 	registry.AddGlobalRemoveHandler(client)
+	// AddGlobalRemoveHandler is a no-op in wlturbo, listen to global_remove directly
+	display.AddListener(registry.ID(), 1, client.handleGlobalRemove)
 	
 	// Now do a roundtrip to get all globals announced
-	// fmt.Println("[DEBUG] Performing roundtrip to get globals...")
-	if err := display.Roundtrip(); err != nil {
+	if err := client.initialRoundtrip(); err != nil {
+		_ = client.Close()
 		return nil, fmt.Errorf("failed to get initial globals: %w", err)
 	}
-	// fmt.Println("[DEBUG] Roundtrip completed, globals should be announced")
-	
-	// Debug: print all globals we received
-	// client.mu.Lock()
-	// fmt.Printf("[DEBUG] Received %d globals:\n", len(client.globals))
-	// for name, iface := range client.globals {
-	// 	fmt.Printf("[DEBUG]   - %s (name=%d)\n", iface, name)
-	// }
-	// client.mu.Unlock()
+
+	// Roundtrip again so that the seats bound above report their name and capabilities
+	if err := client.Roundtrip(); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("failed to get seat information: %w", err)
+	}
 	
+	client.mu.Lock()
+	globals, seats := len(client.globals), len(client.seats)
+	client.mu.Unlock()
+	logger.Debug("connected to wayland display", "globals", globals, "seats", seats)
+
 	return client, nil
 }
 
+// connect opens the display selected by cfg and reports whether it came from an inherited socket
+func connect(cfg Config) (*wl.Display, *link, bool, error) {
+	t := newTracer(cfg.Trace, cfg.Capture)
+	if cfg.UseSocketFD {
+		display, link, err := connectFD(cfg.SocketFD, t)
+		return display, link, true, err
+	}
+
+	if cfg.Display == "" {
+		// A socket inherited from the parent process wins over WAYLAND_DISPLAY.
+		// Unset it so that our own children don't try to reuse it.
+		if value, ok := os.LookupEnv("WAYLAND_SOCKET"); ok {
+			_ = os.Unsetenv("WAYLAND_SOCKET")
+			fd, err := strconv.Atoi(value)
+			if err != nil || fd < 0 {
+				return nil, nil, true, fmt.Errorf("invalid WAYLAND_SOCKET value %q", value)
+			}
+			display, link, err := connectFD(fd, t)
+			return display, link, true, err
+		}
+	}
+
+	display, link, err := connectName(cfg.Display, t)
+	return display, link, false, err
+}
+
+// FromSocketFD reports whether the client was connected over an inherited
+// socket rather than by dialling a socket path
+func (c *Client) FromSocketFD() bool {
+	return c.fromFD
+}
+
 // HandleRegistryGlobal implements wl.RegistryGlobalHandler
 func (c *Client) HandleRegistryGlobal(event wl.RegistryGlobalEvent) {
-	// fmt.Printf("[DEBUG] Global announced: %s v%d (name=%d)\n", event.Interface, event.Version, event.Name)
-	
+	c.logger.Debug("global announced", "interface", event.Interface, "version", event.Version, "name", event.Name)
+
+	global := Global{
+		Name:      event.Name,
+		Interface: event.Interface,
+		Version:   event.Version,
+	}
+
 	c.mu.Lock()
-	defer c.mu.Unlock()
-	
-	c.globals[event.Name] = event.Interface
+	c.globals[event.Name] = global
 	
 	switch event.Interface {
 	case "wl_seat":
-		// Bind to seat for virtual input
-		seatID, err := c.registry.BindID(event.Name, event.Interface, event.Version)
-		if err == nil {
-			seat := wl.NewSeat(c.context)
-			seat.SetID(seatID)
-			c.context.Register(seat)
-			c.seat = seat
-		}
+		// Bind every seat, devices can be created on any of them
+		c.bindSeat(event.Name, event.Version)
 		
 	case "zwlr_virtual_pointer_manager_v1":
 		c.pointerManager = event.Name
@@ -96,17 +218,61 @@ func (c *Client) HandleRegistryGlobal(event wl.RegistryGlobalEvent) {
 		c.constraintsManager = event.Name
 		
 	case "zwlr_output_manager_v1":
-		// fmt.Printf("[DEBUG] Setting outputManager to %d\n", event.Name)
 		c.outputManager = event.Name
 	}
+	listeners := c.globalListeners()
+	c.mu.Unlock()
+
+	for _, l := range listeners {
+		if l.Added != nil {
+			l.Added(global)
+		}
+	}
 }
 
 // HandleRegistryGlobalRemove implements wl.RegistryGlobalRemoveHandler
 func (c *Client) HandleRegistryGlobalRemove(event wl.RegistryGlobalRemoveEvent) {
 	c.mu.Lock()
-	defer c.mu.Unlock()
-	
+	global, known := c.globals[event.Name]
 	delete(c.globals, event.Name)
+
+	// Forget protocol globals so managers created later don't bind a stale name
+	switch event.Name {
+	case c.pointerManager:
+		c.pointerManager = 0
+	case c.keyboardManager:
+		c.keyboardManager = 0
+	case c.constraintsManager:
+		c.constraintsManager = 0
+	case c.outputManager:
+		c.outputManager = 0
+	}
+
+	seat := c.removeSeat(event.Name)
+	handler := c.seatRemoved
+	listeners := c.globalListeners()
+	c.mu.Unlock()
+
+	if seat != nil && handler != nil {
+		handler(seat)
+	}
+	if !known {
+		return
+	}
+	c.logger.Debug("global removed", "interface", global.Interface, "name", global.Name)
+	for _, l := range listeners {
+		if l.Removed != nil {
+			l.Removed(global)
+		}
+	}
+}
+
+// GlobalVersion returns the version the compositor advertised for a global,
+// or 0 if the global is unknown
+func (c *Client) GlobalVersion(name uint32) uint32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.globals[name].Version
 }
 
 // HasVirtualPointer returns true if virtual pointer protocol is available
@@ -123,12 +289,43 @@ func (c *Client) HasVirtualKeyboard() bool {
 	return c.keyboardManager != 0
 }
 
-// GetRegistry returns the Wayland registry
+// Logger returns the logger diagnostics are written to
+func (c *Client) Logger() *slog.Logger {
+	return c.logger
+}
+
+// GetRegistry returns the Wayland registry, or nil for a client adopted from
+// a context without one
 func (c *Client) GetRegistry() *wl.Registry {
 	return c.registry
 }
 
-// GetDisplay returns the Wayland display
+// Bind binds the global name to proxy, whose context must be set. It goes
+// through GetRegistry, or the client's own registry when there is none.
+func (c *Client) Bind(name uint32, iface string, version uint32, proxy wl.Proxy) error {
+	if proxy.ID() == 0 {
+		id, err := c.allocateID()
+		if err != nil {
+			return err
+		}
+		proxy.SetID(id)
+	}
+	if c.registry != nil {
+		return protocols.Bind(c.registry, name, iface, version, proxy)
+	}
+
+	protocols.TrackObject(c.context, proxy.ID(), iface)
+	c.context.Register(proxy)
+	// wl_registry.bind is opcode 0
+	if err := c.context.SendRequest(c.privateRegistry, 0, name, iface, version, proxy.ID()); err != nil {
+		c.context.Unregister(proxy)
+		return err
+	}
+	return nil
+}
+
+// GetDisplay returns the Wayland display, or nil for a client adopted from a
+// context
 func (c *Client) GetDisplay() *wl.Display {
 	return c.display
 }
@@ -138,9 +335,18 @@ func (c *Client) GetContext() *wl.Context {
 	return c.context
 }
 
-// GetSeat returns the Wayland seat
+// GetSeat returns the default Wayland seat, which is the one given by the
+// application for an adopted display and the first one announced otherwise
 func (c *Client) GetSeat() *wl.Seat {
-	return c.seat
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.defaultSeat != nil {
+		return c.defaultSeat
+	}
+	if len(c.seats) == 0 {
+		return nil
+	}
+	return c.seats[0].Proxy()
 }
 
 // GetPointerManagerName returns the name ID for the virtual pointer manager
@@ -185,10 +391,29 @@ func (c *Client) GetOutputManagerName() uint32 {
 	return c.outputManager
 }
 
-// Close closes the Wayland connection
+// Close closes the Wayland connection and stops the event loop. On an
+// adopted display it only releases the seats bound by the client and leaves
+// the connection open.
 func (c *Client) Close() error {
-	if c.context != nil {
-		return c.context.Close()
+	c.mu.Lock()
+	if c.isClosed() {
+		c.mu.Unlock()
+		return nil
 	}
-	return nil
-}
\ No newline at end of file
+	close(c.closing)
+	c.mu.Unlock()
+	c.markLost(protocols.ErrConnectionClosed)
+	c.wake()
+
+	// A handler calling Close runs on the goroutine holding pumpSem
+	go c.closeWake()
+
+	if c.adopted {
+		c.release()
+		return nil
+	}
+	protocols.ForgetObjects(c.context)
+	err := c.context.Close()
+	c.link.close()
+	return err
+}
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/bnema/wlturbo/wl"
+)
+
+// shutdownConn shuts a socket down in both directions
+func shutdownConn(conn *net.UnixConn) error {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var shutdownErr error
+	if err := raw.Control(func(fd uintptr) {
+		shutdownErr = syscall.Shutdown(int(fd), syscall.SHUT_RDWR)
+	}); err != nil {
+		return err
+	}
+	return shutdownErr
+}
+
+// connectFD creates a display on top of an already connected socket, such as
+// the one a parent process passes through WAYLAND_SOCKET. The fd is owned by
+// the returned link once the call succeeds. t, if set, traces the
+// connection.
+func connectFD(fd int, t *tracer) (*wl.Display, *link, error) {
+	file := os.NewFile(uintptr(fd), "wayland-socket")
+	if file == nil {
+		return nil, nil, fmt.Errorf("invalid socket fd %d", fd)
+	}
+	conn, err := net.FileConn(file)
+	// FileConn dups the descriptor, the original is no longer needed
+	_ = file.Close()
+	if err != nil {
+		return nil, nil, fmt.Errorf("fd %d is not a socket: %w", fd, err)
+	}
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		_ = conn.Close()
+		return nil, nil, fmt.Errorf("fd %d is not a unix socket", fd)
+	}
+	return relayDisplay(unixConn, t)
+}
+
+// connectName dials the display named like wl.Connect expects it. t, if set,
+// traces the connection.
+func connectName(name string, t *tracer) (*wl.Display, *link, error) {
+	if name == "" {
+		name = os.Getenv("WAYLAND_DISPLAY")
+		if name == "" {
+			name = "wayland-0"
+		}
+	}
+	if !filepath.IsAbs(name) {
+		runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+		if runtimeDir == "" {
+			return nil, nil, errors.New("XDG_RUNTIME_DIR not set")
+		}
+		name = filepath.Join(runtimeDir, name)
+	}
+	conn, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: name, Net: "unix"})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to Wayland: %w", err)
+	}
+	return relayDisplay(conn, t)
+}
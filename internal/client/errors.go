@@ -0,0 +1,51 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bnema/libwldevices-go/internal/protocols"
+	"github.com/bnema/wlturbo/wl"
+)
+
+// dispatchError converts an error returned by Display.Dispatch: a
+// wl_display.error event becomes a *protocols.ProtocolError naming the object
+// it was raised on, and a dropped connection wraps ErrConnectionClosed.
+func dispatchError(ctx *wl.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if perr := protocolError(ctx, err); perr != nil {
+		return perr
+	}
+	if errors.Is(err, protocols.ErrConnectionClosed) {
+		return err
+	}
+	// Any read failure leaves the stream unusable
+	return fmt.Errorf("%w: %w", protocols.ErrConnectionClosed, err)
+}
+
+// protocolError returns the wl_display.error err reports, or nil. wlturbo
+// returns the event's arguments as "protocol error: object %d, code %d: %s",
+// and the interface of the object comes from the objects recorded for ctx.
+func protocolError(ctx *wl.Context, err error) *protocols.ProtocolError {
+	perr := &protocols.ProtocolError{}
+	if _, serr := fmt.Sscanf(err.Error(), "protocol error: object %d, code %d:", &perr.ObjectID, &perr.Code); serr != nil {
+		return nil
+	}
+	prefix := fmt.Sprintf("protocol error: object %d, code %d: ", perr.ObjectID, perr.Code)
+	message, ok := strings.CutPrefix(err.Error(), prefix)
+	if !ok {
+		return nil
+	}
+	perr.Message = message
+
+	if perr.ObjectID == 1 {
+		perr.Interface = protocols.InterfaceOf((*wl.Display)(nil))
+	} else {
+		perr.Interface = protocols.ObjectInterface(ctx, perr.ObjectID)
+	}
+	perr.Name = protocols.ErrorName(perr.Interface, perr.Code)
+	return perr
+}
@@ -0,0 +1,477 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bnema/libwldevices-go/internal/protocols"
+	"github.com/bnema/wlturbo/wl"
+	"golang.org/x/sys/unix"
+)
+
+// ErrDispatchRunning is returned by DispatchPending while the event loop
+// goroutine reads the connection
+var ErrDispatchRunning = errors.New("event loop is running")
+
+// ErrDisplayAdopted is returned by FD and DispatchPending on a display owned
+// by the application, which reads the connection itself
+var ErrDisplayAdopted = errors.New("display is read by the application")
+
+// ErrRoundtripInHandler is returned by a roundtrip made while an event handler
+// runs. The handler runs on the goroutine reading the connection, which can't
+// read the reply before the handler returns. The client can't tell which
+// goroutine a roundtrip comes from, so one made by another goroutine while a
+// handler runs gets the error too and can be retried.
+var ErrRoundtripInHandler = errors.New("roundtrip called from an event handler")
+
+// Every read from the connection goes through pump, whether it is done by the
+// event loop goroutine, by a roundtrip or by DispatchPending. Only the holder
+// of pumpSem reads, and it polls the compositor socket together with an
+// eventfd so it can be interrupted without closing the connection. The events
+// read are forwarded to the display and dispatched right away, see relay.go.
+// An adopted display has no socket the client can poll: roundtrips made on it
+// let wlturbo read the connection directly, see runTill. A roundtrip waiting
+// for its callback while another goroutine reads just waits for that
+// goroutine to deliver it. The reader sets handling while it dispatches, so
+// that a roundtrip made by an event handler fails instead of waiting for
+// itself.
+
+// initLoop sets up the event loop state. external selects an event loop run
+// by the application.
+func (c *Client) initLoop(external bool) error {
+	c.socketFD = -1
+	if c.link != nil {
+		raw, err := c.link.upstream.SyscallConn()
+		if err != nil {
+			return err
+		}
+		if err := raw.Control(func(fd uintptr) { c.socketFD = int(fd) }); err != nil {
+			return err
+		}
+	}
+
+	wakeFD, err := unix.Eventfd(0, unix.EFD_CLOEXEC|unix.EFD_NONBLOCK)
+	if err != nil {
+		return fmt.Errorf("eventfd failed: %w", err)
+	}
+	c.wakeFD = wakeFD
+	c.external = external
+	c.pumpSem = make(chan struct{}, 1)
+	c.closing = make(chan struct{})
+	c.lost = make(chan struct{})
+	return nil
+}
+
+// StartDispatch starts a goroutine that reads and dispatches events until the
+// connection fails or is closed. It is safe to call more than once; only the
+// first call starts the goroutine. It does nothing when the application runs
+// the event loop (Config.ExternalDispatch) or owns the display.
+func (c *Client) StartDispatch() {
+	c.StartDispatchContext(context.Background())
+}
+
+// StartDispatchContext is StartDispatch with a goroutine that also stops when
+// ctx is done. If the goroutine already runs, ctx is added to the conditions
+// that stop it.
+func (c *Client) StartDispatchContext(ctx context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.adopted || c.external || c.isClosed() {
+		return
+	}
+	if c.dispatching {
+		stop := c.stopDispatch
+		context.AfterFunc(ctx, func() { stop(context.Cause(ctx)) })
+		return
+	}
+
+	loopCtx, cancel := context.WithCancelCause(context.Background())
+	unlink := context.AfterFunc(ctx, func() { cancel(context.Cause(ctx)) })
+	c.dispatching = true
+	c.dispatchDone = make(chan struct{})
+	c.dispatchErr = nil
+	c.stopDispatch = func(cause error) {
+		cancel(cause)
+		c.wake()
+	}
+	done := c.dispatchDone
+
+	go func() {
+		var err error
+		select {
+		case c.pumpSem <- struct{}{}:
+			err = c.pump(loopCtx, nil)
+			<-c.pumpSem
+		case <-loopCtx.Done():
+			err = context.Cause(loopCtx)
+		case <-c.closing:
+			err = protocols.ErrConnectionClosed
+		}
+		unlink()
+		cancel(nil)
+		c.logger.Debug("dispatch stopped", "error", err)
+
+		c.mu.Lock()
+		c.dispatching = false
+		c.dispatchErr = err
+		c.stopDispatch = nil
+		close(done)
+		c.mu.Unlock()
+	}()
+}
+
+// StopDispatch stops the event loop goroutine, if any, and waits for it.
+// The connection stays open.
+func (c *Client) StopDispatch() {
+	c.mu.Lock()
+	stop, done := c.stopDispatch, c.dispatchDone
+	c.mu.Unlock()
+	if stop == nil {
+		return
+	}
+	stop(context.Canceled)
+	<-done
+}
+
+// Dispatching reports whether the event loop goroutine runs
+func (c *Client) Dispatching() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.dispatching
+}
+
+// Done returns a channel that is closed when the dispatch goroutine stops,
+// or nil if StartDispatch was never called or did nothing
+func (c *Client) Done() <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.dispatchDone
+}
+
+// Err returns the error that stopped the dispatch goroutine: the cause of the
+// context it was stopped with, or an error wrapping
+// protocols.ErrConnectionClosed if the connection ended, which is a
+// *protocols.ProtocolError if the compositor raised one.
+func (c *Client) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.dispatchErr
+}
+
+// Lost returns a channel that is closed once the connection is gone, because
+// it failed or was closed. Failures are only noticed while someone reads the
+// connection.
+func (c *Client) Lost() <-chan struct{} {
+	return c.lost
+}
+
+// LostErr returns why the connection is gone, or nil while it works
+func (c *Client) LostErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lostErr
+}
+
+// Closed reports whether Close has been called
+func (c *Client) Closed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.isClosed()
+}
+
+// isClosed is Closed with c.mu held
+func (c *Client) isClosed() bool {
+	select {
+	case <-c.closing:
+		return true
+	default:
+		return false
+	}
+}
+
+// markLost records the first reason the connection is gone
+func (c *Client) markLost(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.lostErr != nil {
+		return
+	}
+	c.lostErr = err
+	close(c.lost)
+}
+
+// fail turns an error of Display.Dispatch into the error of the connection
+func (c *Client) fail(err error) error {
+	err = dispatchError(c.context, err)
+	var perr *protocols.ProtocolError
+	if errors.As(err, &perr) {
+		c.logger.Error("compositor raised a protocol error", "interface", perr.Interface,
+			"object", perr.ObjectID, "code", perr.Code, "name", perr.Name, "message", perr.Message)
+	}
+	c.markLost(err)
+	return c.LostErr()
+}
+
+// FD returns the socket of the connection, for applications that run the
+// event loop themselves: when it is readable, call DispatchPending. The
+// descriptor belongs to the client and must not be closed. It returns
+// ErrDisplayAdopted on an adopted display.
+func (c *Client) FD() (int, error) {
+	if err := c.LostErr(); err != nil {
+		return -1, err
+	}
+	if c.link == nil {
+		return -1, ErrDisplayAdopted
+	}
+	return c.socketFD, nil
+}
+
+// DispatchPending dispatches every event that can be read without blocking.
+// It returns ErrDispatchRunning while the event loop goroutine runs, and
+// nothing if another goroutine is reading the connection for a roundtrip. It
+// returns ErrDisplayAdopted on an adopted display.
+func (c *Client) DispatchPending() error {
+	if err := c.LostErr(); err != nil {
+		return err
+	}
+	if c.link == nil {
+		return ErrDisplayAdopted
+	}
+	if c.Dispatching() {
+		return ErrDispatchRunning
+	}
+	select {
+	case c.pumpSem <- struct{}{}:
+	default:
+		return nil
+	}
+	defer func() { <-c.pumpSem }()
+
+	for {
+		readable, err := c.poll(0)
+		if err != nil || !readable {
+			return err
+		}
+		if err := c.dispatchForwarded(); err != nil {
+			return err
+		}
+	}
+}
+
+// pump reads and dispatches events until until is closed, ctx is done or the
+// connection is closed or fails. The caller holds pumpSem.
+func (c *Client) pump(ctx context.Context, until <-chan struct{}) error {
+	stop := context.AfterFunc(ctx, c.wake)
+	defer stop()
+
+	for {
+		select {
+		case <-until:
+			return nil
+		case <-c.lost:
+			return c.LostErr()
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		default:
+		}
+
+		readable, err := c.poll(-1)
+		if err != nil {
+			return err
+		}
+		if !readable {
+			// Woken up, check why
+			continue
+		}
+		if err := c.dispatchForwarded(); err != nil {
+			return err
+		}
+	}
+}
+
+// dispatchForwarded forwards the events waiting on the compositor socket to
+// the display and dispatches them. The socket must be readable.
+func (c *Client) dispatchForwarded() error {
+	for range c.link.forward() {
+		if err := c.handle(c.display.Dispatch); err != nil {
+			return c.fail(err)
+		}
+	}
+	return nil
+}
+
+// handle runs dispatch, which calls event handlers, with handling set. The
+// caller holds pumpSem.
+func (c *Client) handle(dispatch func() error) error {
+	c.handling.Store(true)
+	defer c.handling.Store(false)
+	return dispatch()
+}
+
+// poll waits up to timeout milliseconds, or forever if negative, for the
+// socket to be readable or for a wake up. It reports whether the socket is
+// readable, which includes hangups so that Dispatch reports them.
+func (c *Client) poll(timeout int) (bool, error) {
+	fds := []unix.PollFd{
+		{Fd: int32(c.socketFD), Events: unix.POLLIN},
+		{Fd: int32(c.wakeFD), Events: unix.POLLIN},
+	}
+	for {
+		_, err := unix.Poll(fds, timeout)
+		if err == unix.EINTR {
+			continue
+		}
+		if err != nil {
+			return false, fmt.Errorf("poll failed: %w", err)
+		}
+		break
+	}
+	if fds[1].Revents != 0 {
+		var buf [8]byte
+		_, _ = unix.Read(c.wakeFD, buf[:])
+	}
+	return fds[0].Revents != 0, nil
+}
+
+// wake interrupts the goroutine blocked in poll, if any
+func (c *Client) wake() {
+	c.wakeMu.RLock()
+	defer c.wakeMu.RUnlock()
+	if c.wakeFD < 0 {
+		return
+	}
+	// eventfd counters are written as native 64-bit integers
+	one := [8]byte{1}
+	_, _ = unix.Write(c.wakeFD, one[:])
+}
+
+// closeWake releases the eventfd once nobody polls it anymore. pumpSem is
+// never given back, so no one reads the connection after this point.
+func (c *Client) closeWake() {
+	c.pumpSem <- struct{}{}
+	c.wakeMu.Lock()
+	defer c.wakeMu.Unlock()
+	_ = unix.Close(c.wakeFD)
+	c.wakeFD = -1
+}
+
+// Roundtrip blocks until the compositor has processed every request sent so
+// far. It is safe to call from any goroutine: the sync callback is delivered
+// by whoever reads the connection, the event loop goroutine included.
+//
+// Event handlers run on the goroutine reading the connection, so a roundtrip
+// made from one could never see its reply: it returns ErrRoundtripInHandler
+// instead of blocking, as does a roundtrip made by any goroutine while a
+// handler runs. On an adopted display dispatched by the application,
+// the client can't tell the application's goroutine apart and a handler
+// calling Roundtrip blocks the application's event loop.
+func (c *Client) Roundtrip() error {
+	return c.RoundtripContext(context.Background())
+}
+
+// RoundtripContext is Roundtrip with support for cancellation. Like
+// Roundtrip, it returns ErrRoundtripInHandler when called from an event
+// handler. On an adopted display the client reads itself, ctx is only checked
+// before reading: wlturbo reads until the callback is delivered, and other
+// roundtrips made meanwhile return ErrRoundtripInHandler.
+func (c *Client) RoundtripContext(ctx context.Context) error {
+	if err := c.LostErr(); err != nil {
+		return err
+	}
+	if c.handling.Load() {
+		return ErrRoundtripInHandler
+	}
+	cb, err := c.sync()
+	if err != nil {
+		return err
+	}
+
+	if c.adopted && c.external {
+		// The application reads its display on a goroutine of its own
+		select {
+		case <-cb.done:
+			return nil
+		case <-c.closing:
+			c.context.Unregister(cb)
+			return protocols.ErrConnectionClosed
+		case <-ctx.Done():
+			c.context.Unregister(cb)
+			return ctx.Err()
+		}
+	}
+
+	select {
+	case <-cb.done:
+		return nil
+	case <-ctx.Done():
+		c.context.Unregister(cb)
+		return ctx.Err()
+	case c.pumpSem <- struct{}{}:
+	}
+	defer func() { <-c.pumpSem }()
+	if c.adopted {
+		return c.runTill(cb)
+	}
+	if err := c.pump(ctx, cb.done); err != nil {
+		c.context.Unregister(cb)
+		return err
+	}
+	return nil
+}
+
+// runTill lets wlturbo read an adopted display until cb is delivered.
+// handling stays set throughout, as wlturbo gives no hook around handlers.
+// The caller holds pumpSem.
+func (c *Client) runTill(cb *syncCallback) error {
+	select {
+	case <-cb.done:
+		// Delivered by the previous holder
+		return nil
+	default:
+	}
+	err := c.handle(func() error { return c.context.RunTill(cb) })
+	if err != nil {
+		c.context.Unregister(cb)
+		return c.fail(err)
+	}
+	return nil
+}
+
+// sync sends wl_display.sync and returns its callback, which closes done
+// once delivered. Unregistering it forgets the callback.
+func (c *Client) sync() (*syncCallback, error) {
+	id, err := c.allocateID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to send sync request: %w", err)
+	}
+
+	// Register the callback before sending the request so the done event
+	// can never be dispatched ahead of us
+	cb := &syncCallback{done: make(chan struct{})}
+	cb.SetContext(c.context)
+	cb.SetID(id)
+	protocols.TrackObject(c.context, id, "wl_callback")
+	c.context.Register(cb)
+
+	// wl_display.sync is opcode 0
+	if err := c.context.SendRequest(displayObject, 0, id); err != nil {
+		c.context.Unregister(cb)
+		return nil, fmt.Errorf("failed to send sync request: %w", protocols.ConnectionError(err))
+	}
+	return cb, nil
+}
+
+// syncCallback is the wl_callback of a roundtrip
+type syncCallback struct {
+	wl.BaseProxy
+	done chan struct{}
+}
+
+// Dispatch handles the wl_callback.done event
+func (cb *syncCallback) Dispatch(event *wl.Event) {
+	if event.Opcode == 0 {
+		cb.Context().Unregister(cb)
+		close(cb.done)
+	}
+}
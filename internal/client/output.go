@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bnema/libwldevices-go/internal/protocols"
+	"github.com/bnema/wlturbo/wl"
+)
+
+// outputVersion is the highest wl_output version bound, the first one
+// sending the name of the output
+const outputVersion = 4
+
+// Output is a wl_output global bound by BindOutputs. It embeds the wlturbo
+// output so it can be passed wherever a *wl.Output is expected, and keeps
+// track of the name and description the compositor reports for it.
+type Output struct {
+	wl.Output
+	client     *Client
+	globalName uint32
+	version    uint32
+
+	// Guarded by client.mu
+	name        string
+	description string
+}
+
+// Name returns the output name, such as DP-1, empty for outputs bound below
+// version 4
+func (o *Output) Name() string {
+	o.client.mu.Lock()
+	defer o.client.mu.Unlock()
+	return o.name
+}
+
+// Description returns the description of the output, such as the make and
+// model of the monitor
+func (o *Output) Description() string {
+	o.client.mu.Lock()
+	defer o.client.mu.Unlock()
+	return o.description
+}
+
+// GlobalName returns the registry name of the output global
+func (o *Output) GlobalName() uint32 {
+	return o.globalName
+}
+
+// Proxy returns the output as a *wl.Output for use in protocol requests
+func (o *Output) Proxy() *wl.Output {
+	return &o.Output
+}
+
+// Dispatch handles wl_output events
+func (o *Output) Dispatch(event *wl.Event) {
+	switch event.Opcode {
+	case 4: // name
+		name := event.String()
+		o.client.mu.Lock()
+		o.name = name
+		o.client.mu.Unlock()
+	case 5: // description
+		description := event.String()
+		o.client.mu.Lock()
+		o.description = description
+		o.client.mu.Unlock()
+	}
+}
+
+// Release releases the output. Outputs bound below version 3 can't be
+// released and are only forgotten.
+func (o *Output) Release() error {
+	var err error
+	// wl_output.release is opcode 0, since version 3
+	if o.version >= 3 {
+		if serr := o.client.context.SendRequest(o, 0); serr != nil {
+			err = fmt.Errorf("failed to release wl_output: %w", protocols.ConnectionError(serr))
+		}
+	}
+	o.client.context.Unregister(o)
+	return err
+}
+
+// BindOutputs binds every wl_output global and waits for the compositor to
+// describe them. Outputs aren't bound when connecting as few users need
+// them; the caller releases those it doesn't keep.
+func (c *Client) BindOutputs(ctx context.Context) ([]*Output, error) {
+	var outputs []*Output
+	for _, g := range c.Globals() {
+		if g.Interface != "wl_output" {
+			continue
+		}
+		version := min(g.Version, outputVersion)
+		output := &Output{client: c, globalName: g.Name, version: version}
+		output.SetContext(c.context)
+		if err := c.Bind(g.Name, "wl_output", version, output); err != nil {
+			releaseOutputs(outputs)
+			return nil, fmt.Errorf("failed to bind wl_output: %w", err)
+		}
+		outputs = append(outputs, output)
+	}
+	if len(outputs) == 0 {
+		return nil, nil
+	}
+	if err := c.RoundtripContext(ctx); err != nil {
+		releaseOutputs(outputs)
+		return nil, fmt.Errorf("failed to get output information: %w", err)
+	}
+	return outputs, nil
+}
+
+// releaseOutputs releases outputs, ignoring errors
+func releaseOutputs(outputs []*Output) {
+	for _, output := range outputs {
+		_ = output.Release()
+	}
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"sort"
+)
+
+// GlobalListener is notified when globals are announced or removed after the
+// client was created. Either function may be nil. Listeners run on the
+// goroutine dispatching events, without any client lock held.
+type GlobalListener struct {
+	Added   func(Global)
+	Removed func(Global)
+}
+
+// AddGlobalListener registers l for registry changes
+func (c *Client) AddGlobalListener(l *GlobalListener) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.listeners = append(c.listeners, l)
+}
+
+// RemoveGlobalListener unregisters a listener added with AddGlobalListener
+func (c *Client) RemoveGlobalListener(l *GlobalListener) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, listener := range c.listeners {
+		if listener == l {
+			c.listeners = append(c.listeners[:i], c.listeners[i+1:]...)
+			return
+		}
+	}
+}
+
+// WatchGlobal calls removed once the global with the given registry name goes
+// away. The returned function cancels the watch.
+func (c *Client) WatchGlobal(name uint32, removed func(Global)) func() {
+	l := &GlobalListener{
+		Removed: func(g Global) {
+			if g.Name == name {
+				removed(g)
+			}
+		},
+	}
+	c.AddGlobalListener(l)
+	return func() { c.RemoveGlobalListener(l) }
+}
+
+// Globals returns every global currently advertised, ordered by registry name
+func (c *Client) Globals() []Global {
+	c.mu.Lock()
+	globals := make([]Global, 0, len(c.globals))
+	for _, g := range c.globals {
+		globals = append(globals, g)
+	}
+	c.mu.Unlock()
+
+	sort.Slice(globals, func(i, j int) bool { return globals[i].Name < globals[j].Name })
+	return globals
+}
+
+// globalListeners returns a copy of the listeners. Called with c.mu held.
+func (c *Client) globalListeners() []*GlobalListener {
+	listeners := make([]*GlobalListener, len(c.listeners))
+	copy(listeners, c.listeners)
+	return listeners
+}
@@ -0,0 +1,220 @@
+package client
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/bnema/wlturbo/wl"
+)
+
+// wlturbo dials socket paths and keeps the socket to itself. The client
+// holds the compositor socket instead: every display it creates dials a
+// listener in a private directory, and a link passes messages between the
+// accepted connection and the compositor. Requests are copied by a goroutine
+// as wlturbo writes them. Events are forwarded by whoever reads the
+// connection, see pump, which then knows how many of them Display.Dispatch
+// can read without blocking.
+
+// closeTimeout bounds how long close waits for the compositor to take the
+// last requests
+const closeTimeout = time.Second
+
+// link carries the messages of a display to and from the compositor
+type link struct {
+	upstream *net.UnixConn // Socket to the compositor, polled for events
+	local    *net.UnixConn // Accepted end of the connection wlturbo dialed
+	events   func([]byte, []int)
+	stop     func()
+	copied   chan struct{} // Closed once every request has been passed on
+
+	buf     []byte
+	oob     []byte
+	header  []byte // Start of the header of the event being forwarded
+	missing int    // Body bytes of that event not forwarded yet
+}
+
+// relayDisplay creates a display talking to the compositor through upstream,
+// which is owned by the returned link once the call succeeds. The link stops
+// when either side hangs up. t, if set, traces every message passed on.
+func relayDisplay(upstream *net.UnixConn, t *tracer) (*wl.Display, *link, error) {
+	dir, err := os.MkdirTemp("", "libwldevices-")
+	if err != nil {
+		_ = upstream.Close()
+		return nil, nil, err
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: filepath.Join(dir, "wayland-relay"), Net: "unix"})
+	if err != nil {
+		_ = upstream.Close()
+		return nil, nil, err
+	}
+	defer func() { _ = listener.Close() }()
+
+	// Connecting to a unix socket doesn't wait for the accept, so the
+	// get_registry request sent by Connect waits in the accepted connection
+	display, err := wl.Connect(listener.Addr().String())
+	if err != nil {
+		_ = upstream.Close()
+		return nil, nil, err
+	}
+	local, err := listener.AcceptUnix()
+	if err != nil {
+		_ = display.Close()
+		_ = upstream.Close()
+		return nil, nil, fmt.Errorf("failed to accept relay connection: %w", err)
+	}
+
+	l := &link{
+		upstream: upstream,
+		local:    local,
+		buf:      make([]byte, 4096),
+		oob:      make([]byte, syscall.CmsgSpace(28*4)),
+		copied:   make(chan struct{}),
+	}
+	// Shutting the sockets down wakes up whoever reads them. They are only
+	// closed by close, so that the descriptor polled by the event loop stays
+	// valid until the client is done with it.
+	var once sync.Once
+	l.stop = func() {
+		once.Do(func() {
+			_ = shutdownConn(local)
+			_ = shutdownConn(upstream)
+		})
+	}
+	var requests func([]byte, []int)
+	if t != nil {
+		requests, l.events = t.observer(true), t.observer(false)
+	}
+	go func() {
+		defer close(l.copied)
+		relay(local, upstream, requests, l.stop)
+	}()
+	return display, l, nil
+}
+
+// forward passes the events waiting on the compositor socket on to the
+// display and returns how many of them are now complete. It blocks until
+// something can be read, so callers poll the socket first. Once the
+// compositor socket fails, the display is shut off too and one more event is
+// counted, so that Display.Dispatch reports the failure.
+func (l *link) forward() int {
+	n, oobn, _, _, err := l.upstream.ReadMsgUnix(l.buf, l.oob)
+	if n <= 0 && (err != nil || oobn == 0) {
+		// ReadMsgUnix reports the end of a stream without io.EOF
+		l.stop()
+		return 1
+	}
+	n, oobn = max(n, 0), max(oobn, 0)
+
+	received := receivedFDs(l.oob[:oobn])
+	if l.events != nil {
+		l.events(l.buf[:n], received)
+	}
+	_, _, werr := l.local.WriteMsgUnix(l.buf[:n], l.oob[:oobn], nil)
+	// The descriptors were duplicated into this process on receipt
+	for _, fd := range received {
+		_ = syscall.Close(fd)
+	}
+	complete := l.count(l.buf[:n])
+	if werr != nil || err != nil {
+		l.stop()
+		complete++
+	}
+	return complete
+}
+
+// count returns how many events end in data, the next bytes of the stream
+func (l *link) count(data []byte) int {
+	complete := 0
+	for len(data) > 0 {
+		if l.missing == 0 {
+			take := min(8-len(l.header), len(data))
+			l.header = append(l.header, data[:take]...)
+			data = data[take:]
+			if len(l.header) < 8 {
+				break
+			}
+			// The upper 16 bits of the second word are the size, header included
+			size := int(binary.LittleEndian.Uint32(l.header[4:8]) >> 16)
+			l.header = l.header[:0]
+			l.missing = max(size-8, 0)
+			if l.missing == 0 {
+				complete++
+				continue
+			}
+		}
+		take := min(l.missing, len(data))
+		l.missing -= take
+		data = data[take:]
+		if l.missing == 0 {
+			complete++
+		}
+	}
+	return complete
+}
+
+// close closes both sockets once the display is closed. The requests the
+// display sent before are still passed on, unless the compositor doesn't
+// take them within closeTimeout.
+func (l *link) close() {
+	_ = l.upstream.SetWriteDeadline(time.Now().Add(closeTimeout))
+	<-l.copied
+	_ = l.local.Close()
+	_ = l.upstream.Close()
+}
+
+// relay copies bytes and their file descriptors from src to dst until either
+// side fails. observe, if set, sees every chunk and the descriptors received
+// with it before they are passed on.
+func relay(src, dst *net.UnixConn, observe func([]byte, []int), stop func()) {
+	defer stop()
+
+	buf := make([]byte, 4096)
+	oob := make([]byte, syscall.CmsgSpace(28*4))
+	for {
+		n, oobn, _, _, err := src.ReadMsgUnix(buf, oob)
+		if n <= 0 && (err != nil || oobn == 0) {
+			// ReadMsgUnix reports the end of a stream without io.EOF
+			return
+		}
+		n, oobn = max(n, 0), max(oobn, 0)
+
+		received := receivedFDs(oob[:oobn])
+		if observe != nil {
+			observe(buf[:n], received)
+		}
+		_, _, werr := dst.WriteMsgUnix(buf[:n], oob[:oobn], nil)
+		// The descriptors were duplicated into this process on receipt
+		for _, fd := range received {
+			_ = syscall.Close(fd)
+		}
+		if werr != nil || err != nil {
+			return
+		}
+	}
+}
+
+// receivedFDs returns the descriptors passed in a control message
+func receivedFDs(oob []byte) []int {
+	if len(oob) == 0 {
+		return nil
+	}
+	messages, err := syscall.ParseSocketControlMessage(oob)
+	if err != nil {
+		return nil
+	}
+	var fds []int
+	for _, m := range messages {
+		if rights, err := syscall.ParseUnixRights(&m); err == nil {
+			fds = append(fds, rights...)
+		}
+	}
+	return fds
+}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestLinkCount(t *testing.T) {
+	message := func(size int) []byte {
+		msg := binary.LittleEndian.AppendUint32(nil, 3)
+		msg = binary.LittleEndian.AppendUint32(msg, uint32(size)<<16)
+		return append(msg, make([]byte, size-8)...)
+	}
+	stream := append(append(message(8), message(20)...), message(12)...)
+
+	// Every split of the stream in two chunks
+	for split := range len(stream) + 1 {
+		l := &link{}
+		first, second := l.count(stream[:split]), l.count(stream[split:])
+		if first+second != 3 {
+			t.Fatalf("Split at %d counted %d and %d events, want 3 in total", split, first, second)
+		}
+		if l.missing != 0 || len(l.header) != 0 {
+			t.Fatalf("Split at %d left %d header and %d body bytes pending", split, len(l.header), l.missing)
+		}
+	}
+
+	// One byte at a time, each event counted with its last byte
+	l := &link{}
+	complete := 0
+	for i := range stream {
+		complete += l.count(stream[i : i+1])
+		want := 0
+		for _, end := range []int{8, 28, 40} {
+			if i+1 >= end {
+				want++
+			}
+		}
+		if complete != want {
+			t.Fatalf("Counted %d events after %d bytes, want %d", complete, i+1, want)
+		}
+	}
+}
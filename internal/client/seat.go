@@ -0,0 +1,147 @@
+package client
+
+import (
+	"encoding/binary"
+
+	"github.com/bnema/wlturbo/wl"
+)
+
+// seatVersion is the highest wl_seat version bound. Seat only listens to the
+// capabilities and name events, which every later version still sends.
+const seatVersion = 9
+
+// Seat is a wl_seat global bound by the client. It embeds the wlturbo seat
+// so it can be passed wherever a *wl.Seat is expected, and keeps track of the
+// name and capabilities the compositor reports for it.
+type Seat struct {
+	wl.Seat
+	client     *Client
+	globalName uint32
+	version    uint32
+
+	// Guarded by client.mu
+	name         string
+	capabilities uint32
+	announced    bool
+}
+
+// Name returns the seat name, empty until the compositor has sent it
+func (s *Seat) Name() string {
+	s.client.mu.Lock()
+	defer s.client.mu.Unlock()
+	return s.name
+}
+
+// Capabilities returns the wl_seat capability bitmask
+func (s *Seat) Capabilities() uint32 {
+	s.client.mu.Lock()
+	defer s.client.mu.Unlock()
+	return s.capabilities
+}
+
+// GlobalName returns the registry name of the seat global
+func (s *Seat) GlobalName() uint32 {
+	return s.globalName
+}
+
+// Proxy returns the seat as a *wl.Seat for use in protocol requests
+func (s *Seat) Proxy() *wl.Seat {
+	return &s.Seat
+}
+
+// Dispatch handles wl_seat events
+func (s *Seat) Dispatch(event *wl.Event) {
+	c := s.client
+
+	c.mu.Lock()
+	s.Seat.Dispatch(event)
+	s.name = s.Seat.Name()
+	s.capabilities = s.Seat.Capabilities()
+
+	// A seat is announced once its name is known. Version 1 seats have
+	// no name event, their capabilities are the last thing we get.
+	added := false
+	if !s.announced && (event.Opcode == 1 || s.version < 2) {
+		s.announced = true
+		added = true
+	}
+	handler := c.seatAdded
+	c.mu.Unlock()
+
+	if added && handler != nil {
+		handler(s)
+	}
+}
+
+// bindSeat binds a wl_seat global. Called with c.mu held.
+func (c *Client) bindSeat(name, version uint32) {
+	if version > seatVersion {
+		version = seatVersion
+	}
+	seat := &Seat{
+		client:     c,
+		globalName: name,
+		version:    version,
+	}
+	seat.SetContext(c.context)
+	if err := c.Bind(name, "wl_seat", version, seat); err != nil {
+		return
+	}
+	c.seats = append(c.seats, seat)
+}
+
+// removeSeat forgets a seat whose global went away. Called with c.mu held.
+func (c *Client) removeSeat(name uint32) *Seat {
+	for i, seat := range c.seats {
+		if seat.globalName == name {
+			c.seats = append(c.seats[:i], c.seats[i+1:]...)
+			c.context.Unregister(seat)
+			return seat
+		}
+	}
+	return nil
+}
+
+// Seats returns every seat currently advertised by the compositor, in announcement order
+func (c *Client) Seats() []*Seat {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	seats := make([]*Seat, len(c.seats))
+	copy(seats, c.seats)
+	return seats
+}
+
+// FindSeat returns the seat with the given name
+func (c *Client) FindSeat(name string) (*Seat, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, seat := range c.seats {
+		if seat.name == name {
+			return seat, true
+		}
+	}
+	return nil, false
+}
+
+// SetSeatHandlers sets callbacks for seats appearing and disappearing.
+// Events are only delivered while the dispatch goroutine runs.
+func (c *Client) SetSeatHandlers(added, removed func(*Seat)) {
+	c.mu.Lock()
+	c.seatAdded = added
+	c.seatRemoved = removed
+	c.mu.Unlock()
+}
+
+// handleGlobalRemove is registered as a raw listener for wl_registry.global_remove,
+// because wlturbo never calls the handler registered with AddGlobalRemoveHandler.
+func (c *Client) handleGlobalRemove(data []byte) {
+	if len(data) < 4 || c.Closed() {
+		return
+	}
+	c.HandleRegistryGlobalRemove(wl.RegistryGlobalRemoveEvent{
+		Registry: c.registry,
+		Name:     binary.LittleEndian.Uint32(data[0:4]),
+	})
+}
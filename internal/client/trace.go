@@ -0,0 +1,220 @@
+package client
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bnema/libwldevices-go/internal/protocols"
+	"github.com/bnema/libwldevices-go/wire_capture"
+)
+
+// tracer writes every request and event relayed to a display in the format
+// of WAYLAND_DEBUG=1 to w, and to capture as a wire_capture file. wlturbo has
+// no hooks for this, so a traced display is always created on a relay, the
+// only place that sees every message, including the ones wlturbo sends on its
+// own.
+type tracer struct {
+	w       io.Writer // nil when only capturing
+	capture *wire_capture.Recorder
+	start   time.Time
+
+	mu      sync.Mutex
+	objects map[uint32]string
+}
+
+// newTracer returns a tracer writing to w and capture, or nil if both are nil
+func newTracer(w, capture io.Writer) *tracer {
+	if w == nil && capture == nil {
+		return nil
+	}
+	t := &tracer{
+		w:       w,
+		start:   time.Now(),
+		objects: map[uint32]string{1: "wl_display"},
+	}
+	if capture != nil {
+		t.capture = wire_capture.NewRecorder(capture)
+	}
+	return t
+}
+
+// observer returns the function the relay calls with the requests, or the
+// events, it passes on. Messages are traced before they are passed on, so an
+// object is always known by the time the other side refers to it.
+func (t *tracer) observer(requests bool) func([]byte, []int) {
+	var pending []byte
+	var fds []int
+	return func(data []byte, received []int) {
+		pending = append(pending, data...)
+		fds = append(fds, received...)
+		pending, fds = t.traceMessages(pending, fds, requests)
+	}
+}
+
+// traceMessages writes every complete message of buf and returns what is
+// left, along with the descriptors not consumed yet
+func (t *tracer) traceMessages(buf []byte, fds []int, requests bool) ([]byte, []int) {
+	for len(buf) >= 8 {
+		objectID := binary.LittleEndian.Uint32(buf[0:4])
+		sizeOpcode := binary.LittleEndian.Uint32(buf[4:8])
+		size := int(sizeOpcode >> 16)
+		if size < 8 {
+			// Not a valid stream; give up on tracing it
+			return nil, nil
+		}
+		if len(buf) < size {
+			break
+		}
+		if t.capture != nil {
+			direction := wire_capture.Event
+			if requests {
+				direction = wire_capture.Request
+			}
+			// A failed capture must not break the connection
+			_ = t.capture.Record(direction, objectID, uint16(sizeOpcode), buf[8:size])
+		}
+		if t.w != nil {
+			fds = t.traceMessage(objectID, uint16(sizeOpcode), buf[8:size], fds, requests)
+		} else {
+			fds = nil
+		}
+		buf = buf[size:]
+	}
+	return append([]byte(nil), buf...), fds
+}
+
+// traceMessage writes one message and returns the unused descriptors
+func (t *tracer) traceMessage(objectID uint32, opcode uint16, body []byte, fds []int, requests bool) []int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	iface, known := t.objects[objectID]
+	if !known {
+		iface = "unknown"
+	}
+
+	var line strings.Builder
+	elapsed := float64(time.Since(t.start).Microseconds()) / 1000
+	fmt.Fprintf(&line, "[%10.3f] ", elapsed)
+	if requests {
+		line.WriteString(" -> ")
+	}
+
+	messages, _ := protocols.LookupMessages(iface)
+	list := messages.Events
+	if requests {
+		list = messages.Requests
+	}
+	if int(opcode) >= len(list) {
+		fmt.Fprintf(&line, "%s@%d.opcode_%d(%d bytes)\n", iface, objectID, opcode, len(body))
+		_, _ = io.WriteString(t.w, line.String())
+		return fds
+	}
+	message := list[opcode]
+
+	fmt.Fprintf(&line, "%s@%d.%s(", iface, objectID, message.Name)
+	var lastString string
+	args := argReader{data: body}
+	for i, kind := range message.Signature {
+		if i > 0 {
+			line.WriteString(", ")
+		}
+		switch kind {
+		case 'i':
+			line.WriteString(strconv.Itoa(int(int32(args.uint32()))))
+		case 'u':
+			line.WriteString(strconv.FormatUint(uint64(args.uint32()), 10))
+		case 'f':
+			line.WriteString(strconv.FormatFloat(float64(int32(args.uint32()))/256, 'f', -1, 64))
+		case 's':
+			s, ok := args.string()
+			lastString = s
+			if !ok {
+				line.WriteString("nil")
+			} else {
+				line.WriteString(strconv.Quote(s))
+			}
+		case 'o':
+			id := args.uint32()
+			if id == 0 {
+				line.WriteString("nil")
+			} else {
+				fmt.Fprintf(&line, "%s@%d", t.interfaceOf(id), id)
+			}
+		case 'n':
+			id := args.uint32()
+			newIface := message.NewInterface
+			if newIface == "" {
+				newIface = lastString
+			}
+			t.objects[id] = newIface
+			fmt.Fprintf(&line, "new id %s@%d", newIface, id)
+		case 'a':
+			fmt.Fprintf(&line, "array[%d]", args.array())
+		case 'h':
+			if len(fds) > 0 {
+				fmt.Fprintf(&line, "fd %d", fds[0])
+				fds = fds[1:]
+			} else {
+				line.WriteString("fd ?")
+			}
+		}
+	}
+	line.WriteString(")\n")
+
+	// wl_display.delete_id releases an object ID for reuse
+	if !requests && objectID == 1 && opcode == 1 && len(body) >= 4 {
+		delete(t.objects, binary.LittleEndian.Uint32(body))
+	}
+
+	_, _ = io.WriteString(t.w, line.String())
+	return fds
+}
+
+// interfaceOf returns the interface of a known object
+func (t *tracer) interfaceOf(id uint32) string {
+	if iface, ok := t.objects[id]; ok {
+		return iface
+	}
+	return "unknown"
+}
+
+// argReader reads wire arguments, returning zero values past the end
+type argReader struct {
+	data []byte
+}
+
+func (r *argReader) uint32() uint32 {
+	if len(r.data) < 4 {
+		r.data = nil
+		return 0
+	}
+	v := binary.LittleEndian.Uint32(r.data)
+	r.data = r.data[4:]
+	return v
+}
+
+// string reads a string argument; ok is false for a null string
+func (r *argReader) string() (string, bool) {
+	size := r.uint32()
+	if size == 0 {
+		return "", false
+	}
+	padded := min(int((size+3)&^3), len(r.data))
+	s := r.data[:min(int(size)-1, padded)]
+	r.data = r.data[padded:]
+	return string(s), true
+}
+
+// array skips an array argument and returns its length
+func (r *argReader) array() int {
+	size := r.uint32()
+	padded := min(int((size+3)&^3), len(r.data))
+	r.data = r.data[padded:]
+	return int(size)
+}
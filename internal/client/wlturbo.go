@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bnema/libwldevices-go/internal/protocols"
+)
+
+// wlturbo v0.1.0 Display.handleServerObject takes any object with ID 5 for a
+// zwlr_output_manager_v1. TestWlturboObjectFive fails once wlturbo stops doing
+// so and initialRoundtrip can go back to Roundtrip.
+
+// wlturboOutputManagerID is the object ID wlturbo assumes belongs to
+// zwlr_output_manager_v1: every opcode 0 event sent to it is turned into a
+// head object registered under the ID found in the event, replacing whatever
+// object used that ID. A seat with that ID would have its capabilities event
+// replace the registry or another early object.
+const wlturboOutputManagerID = 5
+
+// initialRoundtrip waits for the initial globals like Roundtrip, but uses
+// every ID up to wlturboOutputManagerID for sync callbacks that are only
+// known to the listener table, so no real object ever gets that ID.
+func (c *Client) initialRoundtrip() error {
+	done := make(chan struct{})
+	for {
+		id := c.display.AllocateID()
+		// wl_display.sync is opcode 0 on object 1
+		if err := c.display.SendRequest(1, 0, id); err != nil {
+			return fmt.Errorf("failed to send sync request: %w", err)
+		}
+		if id >= wlturboOutputManagerID {
+			// Nothing is read before pump, the listener can't miss the event
+			c.display.AddListener(id, 0, func([]byte) { close(done) })
+			break
+		}
+	}
+
+	c.pumpSem <- struct{}{}
+	defer func() { <-c.pumpSem }()
+	return c.pump(context.Background(), done)
+}
+
+// allocateID allocates an object ID for the client, never
+// wlturboOutputManagerID. An adopted connection may not be past it yet: the
+// ID is then spent on a sync callback nobody listens to, as IDs can't be
+// skipped.
+func (c *Client) allocateID() (uint32, error) {
+	id := c.context.AllocateID()
+	if id != wlturboOutputManagerID {
+		return id, nil
+	}
+	// wl_display.sync is opcode 0
+	if err := c.context.SendRequest(displayObject, 0, id); err != nil {
+		return 0, protocols.ConnectionError(err)
+	}
+	return c.context.AllocateID(), nil
+}
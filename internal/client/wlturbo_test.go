@@ -0,0 +1,78 @@
+package client
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/bnema/wlturbo/wl"
+)
+
+// recordingProxy records the opcodes of the events it receives
+type recordingProxy struct {
+	wl.BaseProxy
+	opcodes []uint16
+}
+
+func (p *recordingProxy) Dispatch(event *wl.Event) {
+	p.opcodes = append(p.opcodes, event.Opcode)
+}
+
+// TestWlturboObjectFive fails once wlturbo stops turning the opcode 0 events
+// of object 5 into output heads, see initialRoundtrip
+func TestWlturboObjectFive(t *testing.T) {
+	listener, err := net.Listen("unix", filepath.Join(t.TempDir(), "wayland-0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = listener.Close() }()
+	display, err := wl.Connect(listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = display.Close() }()
+	server, err := listener.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = server.Close() }()
+	// wl_display.get_registry sent by Connect
+	if _, err := io.ReadFull(server, make([]byte, 12)); err != nil {
+		t.Fatal(err)
+	}
+
+	five, other := &recordingProxy{}, &recordingProxy{}
+	for _, p := range []*recordingProxy{five, other} {
+		p.SetContext(display.Context())
+	}
+	five.SetID(wlturboOutputManagerID)
+	other.SetID(9)
+	display.Context().Register(five)
+	display.Context().Register(other)
+
+	// An opcode 0 event on object 5 carrying the ID of the other object,
+	// then an event on the other object
+	event := func(object uint32, opcode uint16, body []byte) []byte {
+		msg := binary.LittleEndian.AppendUint32(nil, object)
+		msg = binary.LittleEndian.AppendUint32(msg, uint32(8+len(body))<<16|uint32(opcode))
+		return append(msg, body...)
+	}
+	msgs := append(event(5, 0, binary.LittleEndian.AppendUint32(nil, 9)), event(9, 1, nil)...)
+	if _, err := server.Write(msgs); err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if err := display.Dispatch(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(five.opcodes) != 1 {
+		t.Fatalf("Object 5 received %v, want one event", five.opcodes)
+	}
+	if len(other.opcodes) != 0 {
+		t.Fatal("wlturbo no longer replaces objects from the events of object 5, initialRoundtrip can use Display.Roundtrip")
+	}
+}
@@ -0,0 +1,116 @@
+// Package held tracks the keys and buttons a virtual device holds down, so
+// that they can be released when the code driving the device goes away: on
+// Close, when a context is done, after a panic, or when a watchdog expires.
+package held
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Tracker is the set of codes a device holds, in the order they were pressed
+type Tracker struct {
+	release func(codes []uint32) error // Sends releases, newest press first
+
+	mu       sync.Mutex
+	codes    []uint32
+	stopCtx  func() bool
+	watchdog *time.Timer
+	timeout  time.Duration
+}
+
+// NewTracker returns a tracker releasing codes with release
+func NewTracker(release func(codes []uint32) error) *Tracker {
+	return &Tracker{release: release}
+}
+
+// Update records a press or release sent by the device
+func (t *Tracker) Update(code uint32, pressed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.codes = slices.DeleteFunc(t.codes, func(c uint32) bool { return c == code })
+	if pressed {
+		t.codes = append(t.codes, code)
+	}
+}
+
+// Held returns the codes held, in the order they were pressed
+func (t *Tracker) Held() []uint32 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return slices.Clone(t.codes)
+}
+
+// ReleaseAll releases the codes held, last pressed first. They are
+// forgotten even when sending the releases fails.
+func (t *Tracker) ReleaseAll() error {
+	t.mu.Lock()
+	codes := t.codes
+	t.codes = nil
+	t.mu.Unlock()
+
+	if len(codes) == 0 {
+		return nil
+	}
+	slices.Reverse(codes)
+	return t.release(codes)
+}
+
+// Forget drops the codes held without releasing them, for a device the
+// compositor recreated with nothing held
+func (t *Tracker) Forget() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.codes = nil
+}
+
+// ReleaseWhenDone releases the codes held once ctx is done, replacing the
+// context given before
+func (t *Tracker) ReleaseWhenDone(ctx context.Context) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stopCtx != nil {
+		t.stopCtx()
+	}
+	t.stopCtx = context.AfterFunc(ctx, func() { _ = t.ReleaseAll() })
+}
+
+// StartWatchdog releases the codes held whenever Heartbeat isn't called for
+// timeout. A timeout of 0 stops the watchdog.
+func (t *Tracker) StartWatchdog(timeout time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.watchdog != nil {
+		t.watchdog.Stop()
+		t.watchdog = nil
+	}
+	t.timeout = timeout
+	if timeout > 0 {
+		t.watchdog = time.AfterFunc(timeout, func() { _ = t.ReleaseAll() })
+	}
+}
+
+// Heartbeat restarts the watchdog, and rearms it after it expired
+func (t *Tracker) Heartbeat() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.watchdog != nil {
+		t.watchdog.Reset(t.timeout)
+	}
+}
+
+// Stop stops the context watch and the watchdog
+func (t *Tracker) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stopCtx != nil {
+		t.stopCtx()
+		t.stopCtx = nil
+	}
+	if t.watchdog != nil {
+		t.watchdog.Stop()
+		t.watchdog = nil
+	}
+}
@@ -0,0 +1,199 @@
+package protocols
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+
+	"github.com/bnema/wlturbo/wl"
+)
+
+// Sentinel errors shared by every package of the module. They are re-exported
+// by the session package.
+var (
+	// ErrProtocolUnavailable is returned when the compositor does not
+	// advertise a global, or no longer does
+	ErrProtocolUnavailable = errors.New("protocol not available")
+	// ErrConnectionClosed is returned once the connection to the compositor
+	// is gone, whether it was closed, dropped or killed by a protocol error
+	ErrConnectionClosed = errors.New("wayland connection closed")
+	// ErrManagerClosed is returned by the methods of a closed manager
+	ErrManagerClosed = errors.New("manager is closed")
+	// ErrSeatNotFound is returned when no seat has the requested name
+	ErrSeatNotFound = errors.New("seat not found")
+	// ErrOutputNotFound is returned when no output has the requested name
+	ErrOutputNotFound = errors.New("output not found")
+	// ErrKeymapNotSet is returned when keys are sent before a keymap
+	ErrKeymapNotSet = errors.New("keymap not set")
+)
+
+// displayInterface is the interface of object 1
+const displayInterface = "wl_display"
+
+// errorNames are the entries of the error enum of each interface
+var errorNames = map[string]map[uint32]string{
+	displayInterface: {
+		0: "invalid_object",
+		1: "invalid_method",
+		2: "no_memory",
+		3: "implementation",
+	},
+	"wl_seat": {
+		0: "missing_capability",
+	},
+	VirtualPointerInterface: {
+		0: "invalid_axis",
+		1: "invalid_axis_source",
+	},
+	VirtualKeyboardManagerInterface: {
+		0: "unauthorized",
+	},
+	VirtualKeyboardInterface: {
+		0: "no_keymap",
+	},
+	PointerConstraintsInterface: {
+		1: "already_constrained",
+	},
+	OutputConfigurationInterface: {
+		1: "already_configured_head",
+		2: "unconfigured_head",
+		3: "already_used",
+	},
+	OutputConfigurationHeadInterface: {
+		1: "already_set",
+		2: "invalid_mode",
+		3: "invalid_custom_mode",
+		4: "invalid_transform",
+		5: "invalid_scale",
+		6: "invalid_adaptive_sync_state",
+	},
+}
+
+// ErrorName decodes a protocol error code sent for an object of the given
+// interface. Codes the interface doesn't define are looked up in the
+// wl_display enum, which libwayland uses for errors such as invalid_method on
+// any object. It returns "" for unknown codes.
+func ErrorName(iface string, code uint32) string {
+	if name, ok := errorNames[iface][code]; ok {
+		return name
+	}
+	return errorNames[displayInterface][code]
+}
+
+// ProtocolError is a wl_display.error event: the compositor found a fatal
+// error in a request and closed the connection.
+type ProtocolError struct {
+	Interface string // Interface of the object, "" if it isn't known to the client
+	ObjectID  uint32 // Object the error was raised on
+	Code      uint32 // Entry of the error enum of Interface
+	Name      string // Decoded Code, such as "already_constrained", "" if unknown
+	Message   string // Description sent by the compositor
+}
+
+func (e *ProtocolError) Error() string {
+	iface := e.Interface
+	if iface == "" {
+		iface = "object"
+	}
+	name := e.Name
+	if name == "" {
+		name = "unknown"
+	}
+	return fmt.Sprintf("protocol error on %s@%d: %s (%d): %s", iface, e.ObjectID, name, e.Code, e.Message)
+}
+
+// Is reports ErrConnectionClosed, since the connection does not survive a
+// protocol error, and matches a ProtocolError target with the same interface
+// and code. A zero ObjectID in target matches any object, so packages can
+// declare errors such as
+//
+//	var ErrAlreadyConstrained = &ProtocolError{Interface: "zwp_pointer_constraints_v1", Code: 1}
+func (e *ProtocolError) Is(target error) bool {
+	if target == ErrConnectionClosed {
+		return true
+	}
+	t, ok := target.(*ProtocolError)
+	if !ok {
+		return false
+	}
+	return t.Interface == e.Interface && t.Code == e.Code && (t.ObjectID == 0 || t.ObjectID == e.ObjectID)
+}
+
+// InterfaceOf returns the interface name of a proxy created by this module
+// or by wlturbo, or "" if it isn't known
+func InterfaceOf(proxy any) string {
+	switch proxy.(type) {
+	case *wl.Display:
+		return displayInterface
+	case *wl.Registry:
+		return "wl_registry"
+	case *wl.Seat:
+		return "wl_seat"
+	case *wl.Surface:
+		return "wl_surface"
+	case *wl.Pointer:
+		return "wl_pointer"
+	case *wl.Keyboard:
+		return "wl_keyboard"
+	case *wl.Touch:
+		return "wl_touch"
+	case *wl.Output:
+		return "wl_output"
+	case *wl.Region:
+		return "wl_region"
+	case *wl.Compositor:
+		return "wl_compositor"
+	case *VirtualPointerManager:
+		return VirtualPointerManagerInterface
+	case *VirtualPointer:
+		return VirtualPointerInterface
+	case *VirtualKeyboardManager:
+		return VirtualKeyboardManagerInterface
+	case *VirtualKeyboard:
+		return VirtualKeyboardInterface
+	case *PointerConstraintsManager:
+		return PointerConstraintsInterface
+	case *LockedPointer:
+		return LockedPointerInterface
+	case *ConfinedPointer:
+		return ConfinedPointerInterface
+	case *OutputManager:
+		return OutputManagerInterface
+	case *OutputHead:
+		return OutputHeadInterface
+	case *OutputMode:
+		return OutputModeInterface
+	case *OutputConfiguration:
+		return OutputConfigurationInterface
+	case *OutputConfigurationHead:
+		return OutputConfigurationHeadInterface
+	default:
+		return ""
+	}
+}
+
+// ConnectionError wraps errors caused by a connection that is gone with
+// ErrConnectionClosed, and returns other errors unchanged
+func ConnectionError(err error) error {
+	if err == nil || errors.Is(err, ErrConnectionClosed) {
+		return err
+	}
+	if errors.Is(err, net.ErrClosed) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EBADF) {
+		return fmt.Errorf("%w: %w", ErrConnectionClosed, err)
+	}
+	return err
+}
+
+// sendRequest sends a request on the connection of ctx
+func sendRequest(ctx *wl.Context, proxy wl.Proxy, opcode uint32, args ...interface{}) error {
+	return ConnectionError(ctx.SendRequest(proxy, opcode, args...))
+}
+
+// sendRequestWithFDs sends a request carrying file descriptors on the
+// connection of ctx
+func sendRequestWithFDs(ctx *wl.Context, proxy wl.Proxy, opcode uint32, fds []int, args ...interface{}) error {
+	return ConnectionError(ctx.SendRequestWithFDs(proxy, opcode, fds, args...))
+}
@@ -0,0 +1,262 @@
+package protocols
+
+// Message describes a request or event on the wire, for tracing. Signature
+// holds one character per argument, as in libwayland:
+//
+//	i int, u uint, f fixed, s string, o object, n new_id, a array, h fd
+//
+// A new_id without a fixed interface (wl_registry.bind) is preceded by its
+// interface name and version, "sun".
+type Message struct {
+	Name      string
+	Signature string
+	// NewInterface is the interface of the object created by the new_id
+	// argument, if any and known in advance
+	NewInterface string
+}
+
+// Messages lists the requests and events of an interface, indexed by opcode
+type Messages struct {
+	Requests []Message
+	Events   []Message
+}
+
+// wireMessages holds the interfaces used by this module
+var wireMessages = map[string]Messages{
+	"wl_display": {
+		Requests: []Message{
+			{Name: "sync", Signature: "n", NewInterface: "wl_callback"},
+			{Name: "get_registry", Signature: "n", NewInterface: "wl_registry"},
+		},
+		Events: []Message{
+			{Name: "error", Signature: "ous"},
+			{Name: "delete_id", Signature: "u"},
+		},
+	},
+	"wl_registry": {
+		Requests: []Message{
+			{Name: "bind", Signature: "usun"},
+		},
+		Events: []Message{
+			{Name: "global", Signature: "usu"},
+			{Name: "global_remove", Signature: "u"},
+		},
+	},
+	"wl_callback": {
+		Events: []Message{
+			{Name: "done", Signature: "u"},
+		},
+	},
+	"wl_seat": {
+		Requests: []Message{
+			{Name: "get_pointer", Signature: "n", NewInterface: "wl_pointer"},
+			{Name: "get_keyboard", Signature: "n", NewInterface: "wl_keyboard"},
+			{Name: "get_touch", Signature: "n", NewInterface: "wl_touch"},
+			{Name: "release"},
+		},
+		Events: []Message{
+			{Name: "capabilities", Signature: "u"},
+			{Name: "name", Signature: "s"},
+		},
+	},
+	"wl_compositor": {
+		Requests: []Message{
+			{Name: "create_surface", Signature: "n", NewInterface: "wl_surface"},
+			{Name: "create_region", Signature: "n", NewInterface: "wl_region"},
+		},
+	},
+	"wl_surface": {
+		Requests: []Message{
+			{Name: "destroy"},
+			{Name: "attach", Signature: "oii"},
+			{Name: "damage", Signature: "iiii"},
+			{Name: "frame", Signature: "n", NewInterface: "wl_callback"},
+			{Name: "set_opaque_region", Signature: "o"},
+			{Name: "set_input_region", Signature: "o"},
+			{Name: "commit"},
+			{Name: "set_buffer_transform", Signature: "i"},
+			{Name: "set_buffer_scale", Signature: "i"},
+			{Name: "damage_buffer", Signature: "iiii"},
+			{Name: "offset", Signature: "ii"},
+		},
+		Events: []Message{
+			{Name: "enter", Signature: "o"},
+			{Name: "leave", Signature: "o"},
+			{Name: "preferred_buffer_scale", Signature: "i"},
+			{Name: "preferred_buffer_transform", Signature: "u"},
+		},
+	},
+	"wl_region": {
+		Requests: []Message{
+			{Name: "destroy"},
+			{Name: "add", Signature: "iiii"},
+			{Name: "subtract", Signature: "iiii"},
+		},
+	},
+	"wl_pointer": {
+		Requests: []Message{
+			{Name: "set_cursor", Signature: "uoii"},
+			{Name: "release"},
+		},
+		Events: []Message{
+			{Name: "enter", Signature: "uoff"},
+			{Name: "leave", Signature: "uo"},
+			{Name: "motion", Signature: "uff"},
+			{Name: "button", Signature: "uuuu"},
+			{Name: "axis", Signature: "uuf"},
+			{Name: "frame"},
+			{Name: "axis_source", Signature: "u"},
+			{Name: "axis_stop", Signature: "uu"},
+			{Name: "axis_discrete", Signature: "ui"},
+			{Name: "axis_value120", Signature: "ui"},
+			{Name: "axis_relative_direction", Signature: "uu"},
+		},
+	},
+	"wl_output": {
+		Requests: []Message{
+			{Name: "release"},
+		},
+		Events: []Message{
+			{Name: "geometry", Signature: "iiiiissi"},
+			{Name: "mode", Signature: "uiii"},
+			{Name: "done"},
+			{Name: "scale", Signature: "i"},
+			{Name: "name", Signature: "s"},
+			{Name: "description", Signature: "s"},
+		},
+	},
+	VirtualPointerManagerInterface: {
+		Requests: []Message{
+			{Name: "create_virtual_pointer", Signature: "on", NewInterface: VirtualPointerInterface},
+			{Name: "destroy"},
+			{Name: "create_virtual_pointer_with_output", Signature: "oon", NewInterface: VirtualPointerInterface},
+		},
+	},
+	VirtualPointerInterface: {
+		Requests: []Message{
+			{Name: "motion", Signature: "uff"},
+			{Name: "motion_absolute", Signature: "uuuuu"},
+			{Name: "button", Signature: "uuu"},
+			{Name: "axis", Signature: "uuf"},
+			{Name: "frame"},
+			{Name: "axis_source", Signature: "u"},
+			{Name: "axis_stop", Signature: "uu"},
+			{Name: "axis_discrete", Signature: "uufi"},
+			{Name: "destroy"},
+		},
+	},
+	VirtualKeyboardManagerInterface: {
+		Requests: []Message{
+			{Name: "create_virtual_keyboard", Signature: "on", NewInterface: VirtualKeyboardInterface},
+		},
+	},
+	VirtualKeyboardInterface: {
+		Requests: []Message{
+			{Name: "keymap", Signature: "uhu"},
+			{Name: "key", Signature: "uuu"},
+			{Name: "modifiers", Signature: "uuuu"},
+			{Name: "destroy"},
+		},
+	},
+	PointerConstraintsInterface: {
+		Requests: []Message{
+			{Name: "destroy"},
+			{Name: "lock_pointer", Signature: "nooou", NewInterface: LockedPointerInterface},
+			{Name: "confine_pointer", Signature: "nooou", NewInterface: ConfinedPointerInterface},
+		},
+	},
+	LockedPointerInterface: {
+		Requests: []Message{
+			{Name: "destroy"},
+			{Name: "set_cursor_position_hint", Signature: "ff"},
+			{Name: "set_region", Signature: "o"},
+		},
+		Events: []Message{
+			{Name: "locked"},
+			{Name: "unlocked"},
+		},
+	},
+	ConfinedPointerInterface: {
+		Requests: []Message{
+			{Name: "destroy"},
+			{Name: "set_region", Signature: "o"},
+		},
+		Events: []Message{
+			{Name: "confined"},
+			{Name: "unconfined"},
+		},
+	},
+	OutputManagerInterface: {
+		Requests: []Message{
+			{Name: "create_configuration", Signature: "nu", NewInterface: OutputConfigurationInterface},
+			{Name: "stop"},
+		},
+		Events: []Message{
+			{Name: "head", Signature: "n", NewInterface: OutputHeadInterface},
+			{Name: "done", Signature: "u"},
+			{Name: "finished"},
+		},
+	},
+	OutputHeadInterface: {
+		Requests: []Message{
+			{Name: "release"},
+		},
+		Events: []Message{
+			{Name: "name", Signature: "s"},
+			{Name: "description", Signature: "s"},
+			{Name: "physical_size", Signature: "ii"},
+			{Name: "mode", Signature: "n", NewInterface: OutputModeInterface},
+			{Name: "enabled", Signature: "i"},
+			{Name: "current_mode", Signature: "o"},
+			{Name: "position", Signature: "ii"},
+			{Name: "transform", Signature: "i"},
+			{Name: "scale", Signature: "f"},
+			{Name: "finished"},
+			{Name: "make", Signature: "s"},
+			{Name: "model", Signature: "s"},
+			{Name: "serial_number", Signature: "s"},
+			{Name: "adaptive_sync", Signature: "u"},
+		},
+	},
+	OutputModeInterface: {
+		Requests: []Message{
+			{Name: "release"},
+		},
+		Events: []Message{
+			{Name: "size", Signature: "ii"},
+			{Name: "refresh", Signature: "i"},
+			{Name: "preferred"},
+			{Name: "finished"},
+		},
+	},
+	OutputConfigurationInterface: {
+		Requests: []Message{
+			{Name: "enable_head", Signature: "no", NewInterface: OutputConfigurationHeadInterface},
+			{Name: "disable_head", Signature: "o"},
+			{Name: "apply"},
+			{Name: "test"},
+			{Name: "destroy"},
+		},
+		Events: []Message{
+			{Name: "succeeded"},
+			{Name: "failed"},
+			{Name: "cancelled"},
+		},
+	},
+	OutputConfigurationHeadInterface: {
+		Requests: []Message{
+			{Name: "set_mode", Signature: "o"},
+			{Name: "set_custom_mode", Signature: "iii"},
+			{Name: "set_position", Signature: "ii"},
+			{Name: "set_transform", Signature: "i"},
+			{Name: "set_scale", Signature: "f"},
+			{Name: "set_adaptive_sync", Signature: "u"},
+		},
+	},
+}
+
+// LookupMessages returns the requests and events of an interface
+func LookupMessages(iface string) (Messages, bool) {
+	messages, ok := wireMessages[iface]
+	return messages, ok
+}
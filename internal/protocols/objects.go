@@ -0,0 +1,70 @@
+package protocols
+
+import (
+	"sync"
+
+	"github.com/bnema/wlturbo/wl"
+)
+
+// wlturbo can't look an object up by ID, while a wl_display.error event only
+// carries the ID of the object it was raised on. The objects of this module
+// are recorded here as they are created, so that the error can name their
+// interface. Client-allocated IDs are never reused by wlturbo and the server
+// reuses its own for objects of the same interface, so entries are kept
+// until the connection is forgotten.
+
+// objectTables holds the *objectTable of each *wl.Context
+var objectTables sync.Map
+
+// objectTable maps the object IDs of a connection to their interface
+type objectTable struct {
+	mu         sync.Mutex
+	interfaces map[uint32]string
+}
+
+// TrackObject records the interface of the object id of ctx
+func TrackObject(ctx *wl.Context, id uint32, iface string) {
+	if ctx == nil || id == 0 || iface == "" {
+		return
+	}
+	value, _ := objectTables.LoadOrStore(ctx, &objectTable{interfaces: make(map[uint32]string)})
+	table := value.(*objectTable)
+	table.mu.Lock()
+	defer table.mu.Unlock()
+	table.interfaces[id] = iface
+}
+
+// ObjectInterface returns the interface recorded for the object id of ctx,
+// or "" if it isn't known
+func ObjectInterface(ctx *wl.Context, id uint32) string {
+	value, ok := objectTables.Load(ctx)
+	if !ok {
+		return ""
+	}
+	table := value.(*objectTable)
+	table.mu.Lock()
+	defer table.mu.Unlock()
+	return table.interfaces[id]
+}
+
+// ForgetObjects drops the objects recorded for ctx, once its connection is
+// closed
+func ForgetObjects(ctx *wl.Context) {
+	objectTables.Delete(ctx)
+}
+
+// Register registers proxy with ctx and records its interface
+func Register(ctx *wl.Context, proxy wl.Proxy) {
+	TrackObject(ctx, proxy.ID(), InterfaceOf(proxy))
+	ctx.Register(proxy)
+}
+
+// Bind binds the global name to proxy, whose context must be set, and
+// records its interface before the request is sent
+func Bind(registry *wl.Registry, name uint32, iface string, version uint32, proxy wl.Proxy) error {
+	if proxy.ID() == 0 {
+		proxy.SetID(proxy.Context().AllocateID())
+	}
+	TrackObject(proxy.Context(), proxy.ID(), iface)
+	return registry.Bind(name, iface, version, proxy)
+}
@@ -16,6 +16,7 @@ const (
 // OutputManager manages output configuration
 type OutputManager struct {
 	wl.BaseProxy
+	version         uint32
 	headHandler     func(*OutputHead)
 	doneHandler     func(uint32)
 	finishedHandler func()
@@ -28,6 +29,16 @@ func NewOutputManager(ctx *wl.Context) *OutputManager {
 	return manager
 }
 
+// SetVersion records the version the OutputManager was bound at
+func (m *OutputManager) SetVersion(version uint32) {
+	m.version = version
+}
+
+// Version returns the version the OutputManager was bound at
+func (m *OutputManager) Version() uint32 {
+	return m.version
+}
+
 // SetHeadHandler sets the handler for new head events
 func (m *OutputManager) SetHeadHandler(handler func(*OutputHead)) {
 	m.headHandler = handler
@@ -46,11 +57,12 @@ func (m *OutputManager) SetFinishedHandler(handler func()) {
 // CreateConfiguration creates a new output configuration
 func (m *OutputManager) CreateConfiguration(serial uint32) (*OutputConfiguration, error) {
 	config := NewOutputConfiguration(m.Context())
+	config.version = m.version
 
 	// Opcode 0: create_configuration
 	const opcode = 0
 
-	err := m.Context().SendRequest(m, opcode, config, serial)
+	err := sendRequest(m.Context(), m, opcode, config, serial)
 	if err != nil {
 		m.Context().Unregister(config)
 		return nil, err
@@ -63,7 +75,7 @@ func (m *OutputManager) CreateConfiguration(serial uint32) (*OutputConfiguration
 func (m *OutputManager) Stop() error {
 	// Opcode 1: stop
 	const opcode = 1
-	return m.Context().SendRequest(m, opcode)
+	return sendRequest(m.Context(), m, opcode)
 }
 
 // Destroy destroys the output manager
@@ -74,35 +86,16 @@ func (m *OutputManager) Destroy() error {
 
 // Dispatch handles incoming events
 func (m *OutputManager) Dispatch(event *wl.Event) {
-	// fmt.Printf("[DEBUG] OutputManager.Dispatch called with opcode %d\n", event.Opcode)
 	switch event.Opcode {
 	case 0: // head
-		// For new_id in events, the ID is sent by the server
-		// Let me check if the bytes might be in a different order
-		// if len(event.Data()) >= 4 {
-		// 	fmt.Printf("[DEBUG] Head event raw bytes: [")
-		// 	for i := 0; i < len(event.Data()); i++ {
-		// 		fmt.Printf("%02x ", event.Data()[i])
-		// 	}
-		// 	fmt.Printf("]\n")
-		//
-		// 	// What if we read it as big-endian?
-		// 	bigEndianID := binary.BigEndian.Uint32(event.Data()[0:4])
-		// 	littleEndianID := binary.LittleEndian.Uint32(event.Data()[0:4])
-		// 	fmt.Printf("[DEBUG] Big-endian read: %d (0x%x), Little-endian read: %d (0x%x)\n",
-		// 		bigEndianID, bigEndianID, littleEndianID, littleEndianID)
-		// }
+		// For new_id in events, the ID is sent by the server, in the
+		// 0xff000000 and up range
 		headID := event.Uint32()
-		// fmt.Printf("[DEBUG] Creating new head with ID %d (0x%x)\n", headID, headID)
-
-		// Accept the ID as-is - the server might be using a special range
-		// if headID > 1000000 {
-		// 	fmt.Printf("[DEBUG] Note: Head ID %d (0x%x) is in a special range\n", headID, headID)
-		// }
 		head := NewOutputHead(m.Context())
+		head.version = m.version
 		head.SetID(headID)
 		head.SetContext(m.Context())
-		m.Context().Register(head)
+		Register(m.Context(), head)
 		if m.headHandler != nil {
 			m.headHandler(head)
 		}
@@ -122,6 +115,7 @@ func (m *OutputManager) Dispatch(event *wl.Event) {
 // OutputHead represents an output device
 type OutputHead struct {
 	wl.BaseProxy
+	version             uint32
 	nameHandler         func(string)
 	descriptionHandler  func(string)
 	physicalSizeHandler func(int32, int32)
@@ -136,6 +130,10 @@ type OutputHead struct {
 	serialNumberHandler func(string)
 	adaptiveSyncHandler func(uint32)
 	finishedHandler     func()
+
+	// modes holds the modes announced by the head, current_mode refers to
+	// them by ID and the context has no lookup
+	modes map[uint32]*OutputMode
 }
 
 // NewOutputHead creates a new output head
@@ -190,24 +188,45 @@ func (h *OutputHead) SetScaleHandler(handler func(wl.Fixed)) {
 	h.scaleHandler = handler
 }
 
-// SetMakeHandler sets the handler for make events
-func (h *OutputHead) SetMakeHandler(handler func(string)) {
+// SetMakeHandler sets the handler for make events (since version 2)
+func (h *OutputHead) SetMakeHandler(handler func(string)) error {
+	if err := requireVersion(OutputHeadInterface, "make", h.version, 2); err != nil {
+		return err
+	}
 	h.makeHandler = handler
+	return nil
 }
 
-// SetModelHandler sets the handler for model events
-func (h *OutputHead) SetModelHandler(handler func(string)) {
+// SetModelHandler sets the handler for model events (since version 2)
+func (h *OutputHead) SetModelHandler(handler func(string)) error {
+	if err := requireVersion(OutputHeadInterface, "model", h.version, 2); err != nil {
+		return err
+	}
 	h.modelHandler = handler
+	return nil
 }
 
-// SetSerialNumberHandler sets the handler for serial number events
-func (h *OutputHead) SetSerialNumberHandler(handler func(string)) {
+// SetSerialNumberHandler sets the handler for serial number events (since version 2)
+func (h *OutputHead) SetSerialNumberHandler(handler func(string)) error {
+	if err := requireVersion(OutputHeadInterface, "serial_number", h.version, 2); err != nil {
+		return err
+	}
 	h.serialNumberHandler = handler
+	return nil
 }
 
-// SetAdaptiveSyncHandler sets the handler for adaptive sync events
-func (h *OutputHead) SetAdaptiveSyncHandler(handler func(uint32)) {
+// SetAdaptiveSyncHandler sets the handler for adaptive sync events (since version 4)
+func (h *OutputHead) SetAdaptiveSyncHandler(handler func(uint32)) error {
+	if err := requireVersion(OutputHeadInterface, "adaptive_sync", h.version, 4); err != nil {
+		return err
+	}
 	h.adaptiveSyncHandler = handler
+	return nil
+}
+
+// Version returns the version the head's output manager was bound at
+func (h *OutputHead) Version() uint32 {
+	return h.version
 }
 
 // SetFinishedHandler sets the handler for finished events
@@ -217,9 +236,13 @@ func (h *OutputHead) SetFinishedHandler(handler func()) {
 
 // Release releases the output head
 func (h *OutputHead) Release() error {
+	if err := requireVersion(OutputHeadInterface, "release", h.version, 3); err != nil {
+		return err
+	}
+
 	// Opcode 0: release (since version 3)
 	const opcode = 0
-	err := h.Context().SendRequest(h, opcode)
+	err := sendRequest(h.Context(), h, opcode)
 	h.Context().Unregister(h)
 	return err
 }
@@ -252,9 +275,14 @@ func (h *OutputHead) Dispatch(event *wl.Event) {
 	case 3: // mode
 		proxy := event.NewID()
 		mode := NewOutputMode(h.Context())
+		mode.version = h.version
 		mode.SetID(proxy.ID())
 		mode.SetContext(h.Context())
-		h.Context().Register(mode)
+		Register(h.Context(), mode)
+		if h.modes == nil {
+			h.modes = make(map[uint32]*OutputMode)
+		}
+		h.modes[mode.ID()] = mode
 		if h.modeHandler != nil {
 			h.modeHandler(mode)
 		}
@@ -265,10 +293,9 @@ func (h *OutputHead) Dispatch(event *wl.Event) {
 		}
 	case 5: // current_mode
 		if h.currentModeHandler != nil {
-			// Get the proxy ID and look it up in the context
-			_ = event.Uint32() // proxyId
-			// TODO: Need a way to lookup proxies by ID from context
-			// For now, we'll skip this handler
+			if mode, ok := h.modes[event.Uint32()]; ok {
+				h.currentModeHandler(mode)
+			}
 		}
 	case 6: // position
 		if h.positionHandler != nil {
@@ -295,6 +322,7 @@ func (h *OutputHead) Dispatch(event *wl.Event) {
 		if h.finishedHandler != nil {
 			h.finishedHandler()
 		}
+		h.modes = nil
 		h.Context().Unregister(h)
 	case 10: // make (since version 2)
 		if h.makeHandler != nil {
@@ -322,6 +350,7 @@ func (h *OutputHead) Dispatch(event *wl.Event) {
 // OutputMode represents an output mode
 type OutputMode struct {
 	wl.BaseProxy
+	version          uint32
 	sizeHandler      func(int32, int32)
 	refreshHandler   func(int32)
 	preferredHandler func()
@@ -357,9 +386,13 @@ func (m *OutputMode) SetFinishedHandler(handler func()) {
 
 // Release releases the output mode
 func (m *OutputMode) Release() error {
+	if err := requireVersion(OutputModeInterface, "release", m.version, 3); err != nil {
+		return err
+	}
+
 	// Opcode 0: release (since version 3)
 	const opcode = 0
-	err := m.Context().SendRequest(m, opcode)
+	err := sendRequest(m.Context(), m, opcode)
 	m.Context().Unregister(m)
 	return err
 }
@@ -399,6 +432,7 @@ func (m *OutputMode) Dispatch(event *wl.Event) {
 // OutputConfiguration represents an output configuration
 type OutputConfiguration struct {
 	wl.BaseProxy
+	version          uint32
 	succeededHandler func()
 	failedHandler    func()
 	cancelledHandler func()
@@ -429,11 +463,12 @@ func (c *OutputConfiguration) SetCancelledHandler(handler func()) {
 // EnableHead enables a head
 func (c *OutputConfiguration) EnableHead(head *OutputHead) (*OutputConfigurationHead, error) {
 	configHead := NewOutputConfigurationHead(c.Context())
+	configHead.version = c.version
 
 	// Opcode 0: enable_head
 	const opcode = 0
 
-	err := c.Context().SendRequest(c, opcode, configHead, head)
+	err := sendRequest(c.Context(), c, opcode, configHead, head)
 	if err != nil {
 		c.Context().Unregister(configHead)
 		return nil, err
@@ -446,28 +481,28 @@ func (c *OutputConfiguration) EnableHead(head *OutputHead) (*OutputConfiguration
 func (c *OutputConfiguration) DisableHead(head *OutputHead) error {
 	// Opcode 1: disable_head
 	const opcode = 1
-	return c.Context().SendRequest(c, opcode, head)
+	return sendRequest(c.Context(), c, opcode, head)
 }
 
 // Apply applies the configuration
 func (c *OutputConfiguration) Apply() error {
 	// Opcode 2: apply
 	const opcode = 2
-	return c.Context().SendRequest(c, opcode)
+	return sendRequest(c.Context(), c, opcode)
 }
 
 // Test tests the configuration
 func (c *OutputConfiguration) Test() error {
 	// Opcode 3: test
 	const opcode = 3
-	return c.Context().SendRequest(c, opcode)
+	return sendRequest(c.Context(), c, opcode)
 }
 
 // Destroy destroys the output configuration
 func (c *OutputConfiguration) Destroy() error {
 	// Opcode 4: destroy
 	const opcode = 4
-	err := c.Context().SendRequest(c, opcode)
+	err := sendRequest(c.Context(), c, opcode)
 	c.Context().Unregister(c)
 	return err
 }
@@ -493,6 +528,7 @@ func (c *OutputConfiguration) Dispatch(event *wl.Event) {
 // OutputConfigurationHead represents a head configuration
 type OutputConfigurationHead struct {
 	wl.BaseProxy
+	version uint32
 }
 
 // NewOutputConfigurationHead creates a new output configuration head
@@ -506,42 +542,46 @@ func NewOutputConfigurationHead(ctx *wl.Context) *OutputConfigurationHead {
 func (h *OutputConfigurationHead) SetMode(mode *OutputMode) error {
 	// Opcode 0: set_mode
 	const opcode = 0
-	return h.Context().SendRequest(h, opcode, mode)
+	return sendRequest(h.Context(), h, opcode, mode)
 }
 
 // SetCustomMode sets a custom mode
 func (h *OutputConfigurationHead) SetCustomMode(width, height, refresh int32) error {
 	// Opcode 1: set_custom_mode
 	const opcode = 1
-	return h.Context().SendRequest(h, opcode, width, height, refresh)
+	return sendRequest(h.Context(), h, opcode, width, height, refresh)
 }
 
 // SetPosition sets the position
 func (h *OutputConfigurationHead) SetPosition(x, y int32) error {
 	// Opcode 2: set_position
 	const opcode = 2
-	return h.Context().SendRequest(h, opcode, x, y)
+	return sendRequest(h.Context(), h, opcode, x, y)
 }
 
 // SetTransform sets the transform
 func (h *OutputConfigurationHead) SetTransform(transform int32) error {
 	// Opcode 3: set_transform
 	const opcode = 3
-	return h.Context().SendRequest(h, opcode, transform)
+	return sendRequest(h.Context(), h, opcode, transform)
 }
 
 // SetScale sets the scale
 func (h *OutputConfigurationHead) SetScale(scale wl.Fixed) error {
 	// Opcode 4: set_scale
 	const opcode = 4
-	return h.Context().SendRequest(h, opcode, scale)
+	return sendRequest(h.Context(), h, opcode, scale)
 }
 
 // SetAdaptiveSync sets adaptive sync state (since version 4)
 func (h *OutputConfigurationHead) SetAdaptiveSync(state uint32) error {
+	if err := requireVersion(OutputConfigurationHeadInterface, "set_adaptive_sync", h.version, 4); err != nil {
+		return err
+	}
+
 	// Opcode 5: set_adaptive_sync
 	const opcode = 5
-	return h.Context().SendRequest(h, opcode, state)
+	return sendRequest(h.Context(), h, opcode, state)
 }
 
 // Destroy destroys the output configuration head
@@ -26,18 +26,31 @@ const (
 // PointerConstraintsManager manages pointer constraints
 type PointerConstraintsManager struct {
 	wl.BaseProxy
+	version uint32
 }
 
 // NewPointerConstraintsManager creates a new pointer constraints manager
 func NewPointerConstraintsManager(ctx *wl.Context) *PointerConstraintsManager {
 	manager := &PointerConstraintsManager{}
-	ctx.Register(manager)
+	manager.SetContext(ctx)
+	Register(ctx, manager)
 	return manager
 }
 
-// LockPointer creates a locked pointer
-func (m *PointerConstraintsManager) LockPointer(surface *wl.Surface, pointer *wl.Pointer, region *wl.Region, lifetime uint32) (*LockedPointer, error) {
-	locked := NewLockedPointer(m.Context())
+// SetVersion records the version the PointerConstraintsManager was bound at
+func (m *PointerConstraintsManager) SetVersion(version uint32) {
+	m.version = version
+}
+
+// Version returns the version the PointerConstraintsManager was bound at
+func (m *PointerConstraintsManager) Version() uint32 {
+	return m.version
+}
+
+// LockPointer creates a locked pointer whose events go to handler, which may
+// be nil
+func (m *PointerConstraintsManager) LockPointer(surface *wl.Surface, pointer *wl.Pointer, region *wl.Region, lifetime uint32, handler LockedPointerHandler) (*LockedPointer, error) {
+	locked := NewLockedPointer(m.Context(), handler)
 
 	// Opcode 1: lock_pointer
 	const opcode = 1
@@ -58,7 +71,7 @@ func (m *PointerConstraintsManager) LockPointer(surface *wl.Surface, pointer *wl
 		regionProxy = region
 	}
 
-	err := m.Context().SendRequest(m, opcode, locked, surfaceProxy, pointerProxy, regionProxy, lifetime)
+	err := sendRequest(m.Context(), m, opcode, locked, surfaceProxy, pointerProxy, regionProxy, lifetime)
 	if err != nil {
 		m.Context().Unregister(locked)
 		return nil, err
@@ -67,9 +80,10 @@ func (m *PointerConstraintsManager) LockPointer(surface *wl.Surface, pointer *wl
 	return locked, nil
 }
 
-// ConfinePointer creates a confined pointer
-func (m *PointerConstraintsManager) ConfinePointer(surface *wl.Surface, pointer *wl.Pointer, region *wl.Region, lifetime uint32) (*ConfinedPointer, error) {
-	confined := NewConfinedPointer(m.Context())
+// ConfinePointer creates a confined pointer whose events go to handler, which
+// may be nil
+func (m *PointerConstraintsManager) ConfinePointer(surface *wl.Surface, pointer *wl.Pointer, region *wl.Region, lifetime uint32, handler ConfinedPointerHandler) (*ConfinedPointer, error) {
+	confined := NewConfinedPointer(m.Context(), handler)
 
 	// Opcode 2: confine_pointer
 	const opcode = 2
@@ -90,7 +104,7 @@ func (m *PointerConstraintsManager) ConfinePointer(surface *wl.Surface, pointer
 		regionProxy = region
 	}
 
-	err := m.Context().SendRequest(m, opcode, confined, surfaceProxy, pointerProxy, regionProxy, lifetime)
+	err := sendRequest(m.Context(), m, opcode, confined, surfaceProxy, pointerProxy, regionProxy, lifetime)
 	if err != nil {
 		m.Context().Unregister(confined)
 		return nil, err
@@ -103,7 +117,7 @@ func (m *PointerConstraintsManager) ConfinePointer(surface *wl.Surface, pointer
 func (m *PointerConstraintsManager) Destroy() error {
 	// Opcode 0: destroy
 	const opcode = 0
-	err := m.Context().SendRequest(m, opcode)
+	err := sendRequest(m.Context(), m, opcode)
 	m.Context().Unregister(m)
 	return err
 }
@@ -125,10 +139,15 @@ type LockedPointerHandler interface {
 	HandleUnlocked(*LockedPointer)
 }
 
-// NewLockedPointer creates a new locked pointer
-func NewLockedPointer(ctx *wl.Context) *LockedPointer {
-	locked := &LockedPointer{}
-	ctx.Register(locked)
+// NewLockedPointer creates a new locked pointer. The handler is set before the
+// pointer is registered, so it sees every event.
+func NewLockedPointer(ctx *wl.Context, handler LockedPointerHandler) *LockedPointer {
+	locked := &LockedPointer{handler: handler}
+	locked.SetContext(ctx)
+	// The ID must be set before registering, lock_pointer and
+	// confine_pointer send it as new_id
+	locked.SetID(ctx.AllocateID())
+	Register(ctx, locked)
 	return locked
 }
 
@@ -141,7 +160,7 @@ func (l *LockedPointer) SetHandler(handler LockedPointerHandler) {
 func (l *LockedPointer) SetCursorPositionHint(surfaceX, surfaceY float64) error {
 	// Opcode 1: set_cursor_position_hint
 	const opcode = 1
-	return l.Context().SendRequest(l, opcode, wl.Fixed(surfaceX*256.0), wl.Fixed(surfaceY*256.0))
+	return sendRequest(l.Context(), l, opcode, wl.Fixed(surfaceX*256.0), wl.Fixed(surfaceY*256.0))
 }
 
 // SetRegion updates the lock region
@@ -155,14 +174,14 @@ func (l *LockedPointer) SetRegion(region *wl.Region) error {
 		regionProxy = region
 	}
 
-	return l.Context().SendRequest(l, opcode, regionProxy)
+	return sendRequest(l.Context(), l, opcode, regionProxy)
 }
 
 // Destroy destroys the locked pointer
 func (l *LockedPointer) Destroy() error {
 	// Opcode 0: destroy
 	const opcode = 0
-	err := l.Context().SendRequest(l, opcode)
+	err := sendRequest(l.Context(), l, opcode)
 	l.Context().Unregister(l)
 	return err
 }
@@ -193,10 +212,15 @@ type ConfinedPointerHandler interface {
 	HandleUnconfined(*ConfinedPointer)
 }
 
-// NewConfinedPointer creates a new confined pointer
-func NewConfinedPointer(ctx *wl.Context) *ConfinedPointer {
-	confined := &ConfinedPointer{}
-	ctx.Register(confined)
+// NewConfinedPointer creates a new confined pointer. The handler is set before
+// the pointer is registered, so it sees every event.
+func NewConfinedPointer(ctx *wl.Context, handler ConfinedPointerHandler) *ConfinedPointer {
+	confined := &ConfinedPointer{handler: handler}
+	confined.SetContext(ctx)
+	// The ID must be set before registering, lock_pointer and
+	// confine_pointer send it as new_id
+	confined.SetID(ctx.AllocateID())
+	Register(ctx, confined)
 	return confined
 }
 
@@ -216,14 +240,14 @@ func (c *ConfinedPointer) SetRegion(region *wl.Region) error {
 		regionProxy = region
 	}
 
-	return c.Context().SendRequest(c, opcode, regionProxy)
+	return sendRequest(c.Context(), c, opcode, regionProxy)
 }
 
 // Destroy destroys the confined pointer
 func (c *ConfinedPointer) Destroy() error {
 	// Opcode 0: destroy
 	const opcode = 0
-	err := c.Context().SendRequest(c, opcode)
+	err := sendRequest(c.Context(), c, opcode)
 	c.Context().Unregister(c)
 	return err
 }
@@ -0,0 +1,41 @@
+package protocols
+
+import "fmt"
+
+// Highest version of each global implemented by this package. Globals are
+// bound at the lower of this and the version advertised by the compositor.
+const (
+	VirtualPointerManagerVersion  = 2
+	VirtualKeyboardManagerVersion = 1
+	PointerConstraintsVersion     = 1
+	OutputManagerVersion          = 4
+)
+
+// VersionError is returned when a request or event needs a newer protocol
+// version than the one the object was bound at
+type VersionError struct {
+	Interface string // Interface the request or event belongs to
+	Feature   string // Request or event name
+	Since     uint32 // First version providing the feature
+	Version   uint32 // Version the object was bound at
+}
+
+func (e *VersionError) Error() string {
+	return fmt.Sprintf("%s.%s requires version %d, bound version is %d", e.Interface, e.Feature, e.Since, e.Version)
+}
+
+// NegotiateVersion returns the version to bind a global at
+func NegotiateVersion(advertised, supported uint32) uint32 {
+	if advertised < supported {
+		return advertised
+	}
+	return supported
+}
+
+// requireVersion checks that an object bound at version provides a feature added in since
+func requireVersion(iface, feature string, version, since uint32) error {
+	if version < since {
+		return &VersionError{Interface: iface, Feature: feature, Since: since, Version: version}
+	}
+	return nil
+}
@@ -16,6 +16,7 @@ const (
 // VirtualKeyboardManager manages virtual keyboard objects
 type VirtualKeyboardManager struct {
 	wl.BaseProxy
+	version uint32
 }
 
 // NewVirtualKeyboardManager creates a new virtual keyboard manager
@@ -27,6 +28,16 @@ func NewVirtualKeyboardManager(ctx *wl.Context) *VirtualKeyboardManager {
 	return manager
 }
 
+// SetVersion records the version the VirtualKeyboardManager was bound at
+func (m *VirtualKeyboardManager) SetVersion(version uint32) {
+	m.version = version
+}
+
+// Version returns the version the VirtualKeyboardManager was bound at
+func (m *VirtualKeyboardManager) Version() uint32 {
+	return m.version
+}
+
 // CreateVirtualKeyboard creates a new virtual keyboard
 func (m *VirtualKeyboardManager) CreateVirtualKeyboard(seat *wl.Seat) (*VirtualKeyboard, error) {
 	keyboard := NewVirtualKeyboard(m.Context())
@@ -34,7 +45,7 @@ func (m *VirtualKeyboardManager) CreateVirtualKeyboard(seat *wl.Seat) (*VirtualK
 	// Opcode 0: create_virtual_keyboard
 	const opcode = 0
 
-	err := m.Context().SendRequest(m, opcode, seat, keyboard)
+	err := sendRequest(m.Context(), m, opcode, seat, keyboard)
 	if err != nil {
 		m.Context().Unregister(keyboard)
 		return nil, err
@@ -67,7 +78,7 @@ func NewVirtualKeyboard(ctx *wl.Context) *VirtualKeyboard {
 	// Allocate and set ID before registering
 	id := ctx.AllocateID()
 	keyboard.SetID(id)
-	ctx.Register(keyboard)
+	Register(ctx, keyboard)
 	return keyboard
 }
 
@@ -83,7 +94,7 @@ func (k *VirtualKeyboard) Keymap(format uint32, fd int, size uint32) error {
 
 	// File descriptors must be sent via SendRequestWithFDs
 	// The fd argument is passed as uintptr for neurlang/wayland compatibility
-	return k.Context().SendRequestWithFDs(k, opcode, []int{fd}, format, uintptr(fd), size)
+	return sendRequestWithFDs(k.Context(), k, opcode, []int{fd}, format, uintptr(fd), size)
 }
 
 // Key sends a key press/release event
@@ -93,29 +104,27 @@ func (k *VirtualKeyboard) Key(time, key, state uint32) error {
 
 	// The virtual keyboard protocol expects raw evdev key codes, NOT XKB key codes
 	// Do NOT add 8 - that's only for XKB keysyms, not for virtual keyboard input
-	return k.Context().SendRequest(k, opcode, time, key, state)
+	return sendRequest(k.Context(), k, opcode, time, key, state)
 }
 
 // Modifiers updates modifier state
 func (k *VirtualKeyboard) Modifiers(modsDepressed, modsLatched, modsLocked, group uint32) error {
 	// Opcode 2: modifiers
 	const opcode = 2
-	return k.Context().SendRequest(k, opcode, modsDepressed, modsLatched, modsLocked, group)
+	return sendRequest(k.Context(), k, opcode, modsDepressed, modsLatched, modsLocked, group)
 }
 
 // Destroy destroys the virtual keyboard
 func (k *VirtualKeyboard) Destroy() error {
 	// Opcode 3: destroy
 	const opcode = 3
-	err := k.Context().SendRequest(k, opcode)
+	err := sendRequest(k.Context(), k, opcode)
 	k.Context().Unregister(k)
 	return err
 }
 
-// CreateDefaultKeymap creates a minimal XKB keymap file descriptor
-func CreateDefaultKeymap() (int, uint32, error) {
-	// Minimal XKB keymap
-	keymap := `xkb_keymap {
+// DefaultKeymap is a minimal XKB keymap for a US layout
+const DefaultKeymap = `xkb_keymap {
 	xkb_keycodes  { include "evdev+aliases(qwerty)"	};
 	xkb_types     { include "complete"	};
 	xkb_compat    { include "complete"	};
@@ -123,6 +132,14 @@ func CreateDefaultKeymap() (int, uint32, error) {
 	xkb_geometry  { include "pc(pc105)"	};
 };`
 
+// CreateDefaultKeymap creates a minimal XKB keymap file descriptor
+func CreateDefaultKeymap() (int, uint32, error) {
+	return CreateKeymap(DefaultKeymap)
+}
+
+// CreateKeymap writes an XKB keymap to a shared memory file descriptor and
+// returns it with the size to send, including the null terminator
+func CreateKeymap(keymap string) (int, uint32, error) {
 	// Create anonymous shared memory file
 	size := len(keymap) + 1 // +1 for null terminator
 	fd, err := wl.CreateAnonymousFile(int64(size))
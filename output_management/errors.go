@@ -0,0 +1,31 @@
+package output_management
+
+import (
+	"github.com/bnema/libwldevices-go/internal/protocols"
+	"github.com/bnema/libwldevices-go/session"
+)
+
+// Protocol errors of zwlr_output_configuration_v1, for use with errors.Is
+var (
+	ErrAlreadyConfiguredHead = configurationError(ERROR_ALREADY_CONFIGURED_HEAD, "already_configured_head")
+	ErrUnconfiguredHead      = configurationError(ERROR_UNCONFIGURED_HEAD, "unconfigured_head")
+	ErrAlreadyUsed           = configurationError(ERROR_ALREADY_USED, "already_used")
+)
+
+// Protocol errors of zwlr_output_configuration_head_v1, for use with errors.Is
+var (
+	ErrAlreadySet               = configurationHeadError(ERROR_ALREADY_SET, "already_set")
+	ErrInvalidMode              = configurationHeadError(ERROR_INVALID_MODE, "invalid_mode")
+	ErrInvalidCustomMode        = configurationHeadError(ERROR_INVALID_CUSTOM_MODE, "invalid_custom_mode")
+	ErrInvalidTransform         = configurationHeadError(ERROR_INVALID_TRANSFORM, "invalid_transform")
+	ErrInvalidScale             = configurationHeadError(ERROR_INVALID_SCALE, "invalid_scale")
+	ErrInvalidAdaptiveSyncState = configurationHeadError(ERROR_INVALID_ADAPTIVE_SYNC_STATE, "invalid_adaptive_sync_state")
+)
+
+func configurationError(code uint32, name string) *session.ProtocolError {
+	return &session.ProtocolError{Interface: protocols.OutputConfigurationInterface, Code: code, Name: name}
+}
+
+func configurationHeadError(code uint32, name string) *session.ProtocolError {
+	return &session.ProtocolError{Interface: protocols.OutputConfigurationHeadInterface, Code: code, Name: name}
+}
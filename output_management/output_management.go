@@ -7,24 +7,33 @@ package output_management
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"sync"
 	"time"
 
 	"github.com/bnema/libwldevices-go/internal/client"
 	"github.com/bnema/libwldevices-go/internal/protocols"
+	"github.com/bnema/libwldevices-go/session"
 	"github.com/bnema/wlturbo/wl"
 )
 
 // OutputManager manages output configuration and monitoring
 type OutputManager struct {
-	client    *client.Client
-	manager   *protocols.OutputManager
-	heads     map[uint32]*OutputHead
-	mu        sync.RWMutex
-	serial    uint32
-	handlers  OutputHandlers
-	hasSerial bool
-	serialCh  chan struct{}
+	session     *session.Session
+	client      *client.Client
+	manager     *protocols.OutputManager
+	ownsSession bool
+	heads       map[uint32]*OutputHead
+	mu          sync.RWMutex
+	serial      uint32
+	handlers    OutputHandlers
+	hasSerial   bool
+	serialCh    chan struct{}
+	version     uint32
+	unavailable bool
+	unwatch     func()
+	removeHook  func()
+	closed      bool
 }
 
 // OutputHandlers contains callback functions for output events
@@ -35,6 +44,9 @@ type OutputHandlers struct {
 	OnHeadRemoved func(head *OutputHead)
 	// OnConfigurationChanged is called when output configuration changes
 	OnConfigurationChanged func(heads []*OutputHead)
+	// OnUnavailable is called once when the compositor removes the
+	// zwlr_output_manager_v1 global, for example when it reloads
+	OnUnavailable func()
 }
 
 // OutputHead represents a physical output device (monitor)
@@ -53,8 +65,10 @@ type OutputHead struct {
 	CurrentMode  *OutputMode
 	Scale        float64
 	Transform    Transform
+	AdaptiveSync bool // Always false before protocol version 4
 	head         *protocols.OutputHead
 	modes        []*OutputMode
+	reported     bool // Complete, and passed to OnHeadAdded
 }
 
 // Position represents the position of an output in the global compositor space
@@ -93,113 +107,109 @@ const (
 	TransformFlipped270                      // Horizontal flip + 270 degree rotation
 )
 
-// NewOutputManager creates a new output manager
-func NewOutputManager(ctx context.Context) (*OutputManager, error) {
-	// fmt.Println("[DEBUG] Creating output manager...")
-	
-	// Check if context is already cancelled
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
+// NewOutputManager creates a new output manager on its own Wayland connection.
+// The options select the compositor to connect to, see session.NewSession.
+func NewOutputManager(ctx context.Context, opts ...session.Option) (*OutputManager, error) {
+	s, err := session.NewSession(ctx, opts...)
+	if err != nil {
+		return nil, err
 	}
-	
-	// Create Wayland client with timeout
-	type clientResult struct {
-		client *client.Client
-		err    error
-	}
-	
-	clientCh := make(chan clientResult, 1)
-	go func() {
-		c, err := client.NewClient()
-		clientCh <- clientResult{client: c, err: err}
-	}()
-	
-	// Wait for client creation or context cancellation
-	var c *client.Client
-	select {
-	case result := <-clientCh:
-		if result.err != nil {
-			return nil, fmt.Errorf("failed to create client: %w", result.err)
-		}
-		c = result.client
-	case <-ctx.Done():
-		return nil, fmt.Errorf("context cancelled during client creation: %w", ctx.Err())
+
+	om, err := newOutputManager(ctx, s, true)
+	if err != nil {
+		_ = s.Close()
+		return nil, err
 	}
-	// fmt.Println("[DEBUG] Client created successfully")
+	return om, nil
+}
+
+// NewOutputManagerFromSession creates an output manager on a shared session.
+// The manager is closed automatically when the session is closed.
+func NewOutputManagerFromSession(ctx context.Context, s *session.Session) (*OutputManager, error) {
+	return newOutputManager(ctx, s, false)
+}
+
+func newOutputManager(ctx context.Context, s *session.Session, ownsSession bool) (*OutputManager, error) {
+	c := s.Client()
 
 	// Check if output manager protocol is available using the client's detection
-	// fmt.Printf("[DEBUG] Checking HasOutputManager: %v\n", c.HasOutputManager())
-	// fmt.Printf("[DEBUG] OutputManagerName: %d\n", c.GetOutputManagerName())
 	if !c.HasOutputManager() {
-		c.Close()
-		return nil, fmt.Errorf("zwlr_output_manager_v1 not available - compositor may not support wlr-output-management protocol")
+		return nil, fmt.Errorf("zwlr_output_manager_v1: %w - compositor may not support wlr-output-management protocol", session.ErrProtocolUnavailable)
 	}
-	// fmt.Println("[DEBUG] Output manager protocol is available")
 
 	om := &OutputManager{
-		client:   c,
-		heads:    make(map[uint32]*OutputHead),
-		serialCh: make(chan struct{}, 1),
+		session:     s,
+		client:      c,
+		ownsSession: ownsSession,
+		heads:       make(map[uint32]*OutputHead),
+		serialCh:    make(chan struct{}, 1),
 	}
 
-	// Use the output manager name from the client
-	managerName := c.GetOutputManagerName()
-	registry := c.GetRegistry()
-	context := c.GetContext()
-	// fmt.Printf("[DEBUG] Output manager name: %d\n", managerName)
-
-	// Create and bind output manager
-	om.manager = protocols.NewOutputManager(context)
-	// fmt.Printf("[DEBUG] Created output manager proxy with ID: %d\n", om.manager.ID())
-
-	err := registry.Bind(managerName, protocols.OutputManagerInterface, 4, om.manager)
+	manager, managerName, err := om.bind(c)
 	if err != nil {
-		return nil, fmt.Errorf("failed to bind output manager: %w", err)
-	}
-	// fmt.Printf("[DEBUG] Bound to output manager successfully, ID: %d\n", om.manager.ID())
-
-	// Set up event handlers
-	om.manager.SetHeadHandler(om.handleHead)
-	om.manager.SetDoneHandler(om.handleDone)
-	om.manager.SetFinishedHandler(om.handleFinished)
-	// fmt.Println("[DEBUG] Event handlers set up")
-
-	// Start event processing in background
-	go func() {
-		// fmt.Println("[DEBUG] Starting event dispatch loop...")
-		for {
-			if err := c.GetDisplay().Dispatch(); err != nil {
-				// Connection closed or error occurred
-				// fmt.Printf("[DEBUG] Dispatch error: %v\n", err)
-				return
-			}
+		return nil, err
+	}
+	om.manager = manager
+	om.version = manager.Version()
+
+	if !ownsSession {
+		if err := s.Attach(om); err != nil {
+			_ = om.manager.Destroy()
+			return nil, err
 		}
-	}()
+	}
+	om.unwatch = c.WatchGlobal(managerName, om.handleGlobalRemoved)
+	om.removeHook = s.AddReconnectHook(om.rebind)
+
+	// Start event processing in background. The goroutine is shared by
+	// every manager on the connection.
+	c.StartDispatch()
 
 	// Force a roundtrip to get initial events
-	// fmt.Println("[DEBUG] Performing roundtrip...")
-	_ = c.GetDisplay().Roundtrip() // Ignore roundtrip errors during initialization
+	_ = c.RoundtripContext(ctx) // Ignore roundtrip errors during initialization
 
 	// Wait for initial configuration to be received with context support
-	// fmt.Println("[DEBUG] Waiting for initial configuration...")
+	c.Logger().Debug("waiting for initial output configuration")
 	select {
 	case <-om.serialCh:
 		// Initial configuration received
-		// fmt.Println("[DEBUG] Initial configuration received")
 	case <-time.After(5 * time.Second):
-		// fmt.Println("[DEBUG] Timeout waiting for initial configuration")
-		_ = om.Close()
+		c.Logger().Warn("timeout waiting for initial output configuration")
+		_ = om.closeManager()
 		return nil, fmt.Errorf("timeout waiting for initial output configuration")
 	case <-ctx.Done():
-		_ = om.Close()
+		_ = om.closeManager()
 		return nil, ctx.Err()
 	}
 
 	return om, nil
 }
 
+// bind binds the output manager global of c with the event handlers of om
+// and returns it with its registry name
+func (om *OutputManager) bind(c *client.Client) (*protocols.OutputManager, uint32, error) {
+	// Use the output manager name from the client
+	managerName := c.GetOutputManagerName()
+	context := c.GetContext()
+
+	// Create and bind output manager
+	manager := protocols.NewOutputManager(context)
+
+	// Set up event handlers before binding so no event can be missed
+	manager.SetHeadHandler(om.handleHead)
+	manager.SetDoneHandler(om.handleDone)
+	manager.SetFinishedHandler(om.handleFinished)
+
+	version := protocols.NegotiateVersion(c.GlobalVersion(managerName), protocols.OutputManagerVersion)
+	manager.SetVersion(version)
+	err := c.Bind(managerName, protocols.OutputManagerInterface, version, manager)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to bind output manager: %w", err)
+	}
+	c.Logger().Debug("bound output manager", "name", managerName, "version", version, "id", manager.ID())
+	return manager, managerName, nil
+}
+
 // GetHeads returns all currently detected output heads
 func (om *OutputManager) GetHeads() []*OutputHead {
 	if om == nil {
@@ -304,19 +314,70 @@ func (om *OutputManager) SetHandlers(handlers OutputHandlers) {
 	om.mu.Unlock()
 }
 
-// Close cleans up the output manager
+// Available reports whether the compositor still advertises zwlr_output_manager_v1.
+// Once it is gone the heads are no longer updated; build a new manager when the
+// protocol is announced again.
+func (om *OutputManager) Available() bool {
+	if om == nil {
+		return false
+	}
+
+	om.mu.RLock()
+	defer om.mu.RUnlock()
+	return !om.unavailable
+}
+
+// Version returns the protocol version the manager was bound at: the lower of
+// the version advertised by the compositor and the one this package implements.
+// Heads only report make, model and serial number from version 2 and adaptive
+// sync from version 4.
+func (om *OutputManager) Version() uint32 {
+	if om == nil {
+		return 0
+	}
+
+	om.mu.RLock()
+	defer om.mu.RUnlock()
+	return om.version
+}
+
+// Close cleans up the output manager. The underlying connection is only
+// closed if the manager was not created from a shared session.
 func (om *OutputManager) Close() error {
 	if om == nil {
 		return nil
 	}
 
-	if om.manager != nil {
-		_ = om.manager.Stop()
-		_ = om.manager.Destroy()
+	if om.session != nil && om.ownsSession {
+		return om.session.Close()
+	}
+	return om.closeManager()
+}
+
+// closeManager stops the manager and detaches it from a shared session
+func (om *OutputManager) closeManager() error {
+	om.mu.Lock()
+	manager := om.manager
+	om.manager = nil
+	om.closed = true
+	unwatch, removeHook := om.unwatch, om.removeHook
+	om.unwatch, om.removeHook = nil, nil
+	om.mu.Unlock()
+
+	if removeHook != nil {
+		removeHook()
+	}
+	if unwatch != nil {
+		unwatch()
+	}
+
+	if manager != nil {
+		_ = manager.Stop()
+		_ = manager.Destroy()
 	}
 
-	if om.client != nil {
-		return om.client.Close()
+	if om.session != nil && !om.ownsSession {
+		om.session.Detach(om)
 	}
 
 	return nil
@@ -324,10 +385,10 @@ func (om *OutputManager) Close() error {
 
 // Event handlers
 func (om *OutputManager) handleHead(head *protocols.OutputHead) {
-	// fmt.Printf("[DEBUG] handleHead called with head ID: %d\n", head.ID())
 	if head == nil {
 		return
 	}
+	om.logger().Debug("output head announced", "id", head.ID())
 
 	om.mu.Lock()
 	defer om.mu.Unlock()
@@ -342,94 +403,115 @@ func (om *OutputManager) handleHead(head *protocols.OutputHead) {
 		Scale: 1.0, // Default scale
 	}
 
-	// Set up head event handlers
-	head.SetNameHandler(func(name string) {
+	// Set up head event handlers. They run on the event loop while the
+	// application reads the heads, so every change is made under om.mu.
+	locked := func(fn func()) {
 		om.mu.Lock()
-		outputHead.Name = name
+		fn()
 		om.mu.Unlock()
+	}
+
+	head.SetNameHandler(func(name string) {
+		locked(func() { outputHead.Name = name })
 	})
 
 	head.SetDescriptionHandler(func(description string) {
-		outputHead.Description = description
+		locked(func() { outputHead.Description = description })
 	})
 
 	head.SetPhysicalSizeHandler(func(width, height int32) {
-		outputHead.PhysicalSize = Size{Width: width, Height: height}
+		locked(func() { outputHead.PhysicalSize = Size{Width: width, Height: height} })
 	})
 
 	head.SetEnabledHandler(func(enabled int32) {
-		outputHead.Enabled = enabled != 0
+		locked(func() { outputHead.Enabled = enabled != 0 })
 	})
 
 	head.SetPositionHandler(func(x, y int32) {
-		outputHead.Position = Position{X: x, Y: y}
+		locked(func() { outputHead.Position = Position{X: x, Y: y} })
 	})
 
 	head.SetModeHandler(func(mode *protocols.OutputMode) {
-		om := &OutputMode{
+		m := &OutputMode{
 			mode: mode,
 		}
 
 		mode.SetSizeHandler(func(width, height int32) {
-			om.Width = width
-			om.Height = height
+			locked(func() {
+				m.Width = width
+				m.Height = height
+			})
 		})
 
 		mode.SetRefreshHandler(func(refresh int32) {
-			om.Refresh = refresh
+			locked(func() { m.Refresh = refresh })
 		})
 
+		// preferred follows the mode event, the head falls back to the
+		// preferred mode until it reports a current one
 		mode.SetPreferredHandler(func() {
-			om.Preferred = true
+			locked(func() {
+				m.Preferred = true
+				if outputHead.Mode == nil {
+					outputHead.Mode = m
+				}
+			})
 		})
 
-		outputHead.modes = append(outputHead.modes, om)
-
-		// Set as default mode if preferred
-		if om.Preferred && outputHead.Mode == nil {
-			outputHead.Mode = om
-		}
+		locked(func() { outputHead.modes = append(outputHead.modes, m) })
 	})
 
 	head.SetCurrentModeHandler(func(mode *protocols.OutputMode) {
-		// Find the mode in our list
-		for _, m := range outputHead.modes {
-			if m.mode == mode {
-				outputHead.CurrentMode = m
-				outputHead.Mode = m
-				break
+		locked(func() {
+			// Find the mode in our list
+			for _, m := range outputHead.modes {
+				if m.mode == mode {
+					outputHead.CurrentMode = m
+					outputHead.Mode = m
+					break
+				}
 			}
-		}
+		})
 	})
 
 	head.SetScaleHandler(func(scale wl.Fixed) {
-		outputHead.Scale = float64(scale) / 256.0
-		if outputHead.Scale == 0 {
-			outputHead.Scale = 1.0 // Default to 1.0 if not set
-		}
+		locked(func() {
+			outputHead.Scale = float64(scale) / 256.0
+			if outputHead.Scale == 0 {
+				outputHead.Scale = 1.0 // Default to 1.0 if not set
+			}
+		})
 	})
 
 	head.SetTransformHandler(func(transform int32) {
-		outputHead.Transform = Transform(transform)
+		locked(func() { outputHead.Transform = Transform(transform) })
 	})
 
-	head.SetMakeHandler(func(makeStr string) {
-		outputHead.Make = makeStr
+	// Only available from version 2 and 4, older heads leave these fields empty
+	_ = head.SetMakeHandler(func(makeStr string) {
+		locked(func() { outputHead.Make = makeStr })
 	})
 
-	head.SetModelHandler(func(model string) {
-		outputHead.Model = model
+	_ = head.SetModelHandler(func(model string) {
+		locked(func() { outputHead.Model = model })
 	})
 
-	head.SetSerialNumberHandler(func(serial string) {
-		outputHead.SerialNumber = serial
+	_ = head.SetSerialNumberHandler(func(serial string) {
+		locked(func() { outputHead.SerialNumber = serial })
+	})
+
+	_ = head.SetAdaptiveSyncHandler(func(state uint32) {
+		locked(func() { outputHead.AdaptiveSync = state == ADAPTIVE_SYNC_STATE_ENABLED })
 	})
 
 	head.SetFinishedHandler(func() {
 		// Head is being removed
+		om.mu.Lock()
 		delete(om.heads, outputHead.ID)
-		if om.handlers.OnHeadRemoved != nil {
-			om.handlers.OnHeadRemoved(outputHead)
+		handler := om.handlers.OnHeadRemoved
+		om.mu.Unlock()
+		if handler != nil {
+			handler(outputHead)
 		}
 	})
 
@@ -437,12 +519,21 @@ func (om *OutputManager) handleHead(head *protocols.OutputHead) {
 }
 
 func (om *OutputManager) handleDone(serial uint32) {
-	// fmt.Printf("[DEBUG] handleDone called with serial: %d\n", serial)
+	om.logger().Debug("output configuration done", "serial", serial)
 	om.mu.Lock()
 	isFirst := !om.hasSerial
 	om.serial = serial
 	om.hasSerial = true
 	handlers := om.handlers
+	// Heads are complete once done arrives. Some compositors announce them
+	// only after the first done.
+	var added []*OutputHead
+	for _, head := range om.heads {
+		if !head.reported {
+			head.reported = true
+			added = append(added, head)
+		}
+	}
 	om.mu.Unlock()
 
 	// Signal that we have received the initial configuration
@@ -459,18 +550,35 @@ func (om *OutputManager) handleDone(serial uint32) {
 		handlers.OnConfigurationChanged(heads)
 	}
 
-	// Notify about new heads (only on first done after binding)
-	if handlers.OnHeadAdded != nil && isFirst {
-		om.mu.RLock()
-		for _, head := range om.heads {
+	if handlers.OnHeadAdded != nil {
+		for _, head := range added {
 			handlers.OnHeadAdded(head)
 		}
-		om.mu.RUnlock()
+	}
+}
+
+// logger returns the logger of the session the manager was built on
+func (om *OutputManager) logger() *slog.Logger {
+	if om.session == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+	return om.session.Logger()
+}
+
+func (om *OutputManager) handleGlobalRemoved(client.Global) {
+	om.mu.Lock()
+	om.unavailable = true
+	handler := om.handlers.OnUnavailable
+	om.logger().Warn("zwlr_output_manager_v1 is not available")
+	om.mu.Unlock()
+
+	if handler != nil {
+		handler()
 	}
 }
 
 func (om *OutputManager) handleFinished() {
-	// fmt.Println("[DEBUG] handleFinished called")
+	om.logger().Debug("output manager finished")
 	// Manager is being destroyed
 	om.mu.Lock()
 	defer om.mu.Unlock()
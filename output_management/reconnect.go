@@ -0,0 +1,65 @@
+package output_management
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bnema/libwldevices-go/internal/client"
+	"github.com/bnema/libwldevices-go/session"
+)
+
+// rebind is the session reconnect hook. The heads of the old connection are
+// reported as removed, then the manager is bound on the new connection, which
+// announces the current heads again through OnHeadAdded.
+func (om *OutputManager) rebind(ctx context.Context) error {
+	c := om.session.Client()
+
+	om.mu.Lock()
+	if om.closed {
+		om.mu.Unlock()
+		return nil
+	}
+	old := make([]*OutputHead, 0, len(om.heads))
+	for _, head := range om.heads {
+		old = append(old, head)
+	}
+	om.heads = make(map[uint32]*OutputHead)
+	om.hasSerial = false
+	om.client = c
+	if om.unwatch != nil {
+		om.unwatch()
+		om.unwatch = nil
+	}
+	handlers := om.handlers
+	om.mu.Unlock()
+
+	if handlers.OnHeadRemoved != nil {
+		for _, head := range old {
+			handlers.OnHeadRemoved(head)
+		}
+	}
+
+	if !c.HasOutputManager() {
+		om.handleGlobalRemoved(client.Global{})
+		return fmt.Errorf("zwlr_output_manager_v1 after reconnecting: %w", session.ErrProtocolUnavailable)
+	}
+
+	manager, name, err := om.bind(c)
+	if err != nil {
+		om.handleGlobalRemoved(client.Global{})
+		return err
+	}
+
+	om.mu.Lock()
+	if om.closed {
+		om.mu.Unlock()
+		return manager.Destroy()
+	}
+	om.manager = manager
+	om.version = manager.Version()
+	om.unavailable = false
+	om.unwatch = c.WatchGlobal(name, om.handleGlobalRemoved)
+	om.mu.Unlock()
+
+	return c.RoundtripContext(ctx)
+}
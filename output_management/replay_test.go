@@ -0,0 +1,213 @@
+package output_management
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bnema/libwldevices-go/session"
+	"github.com/bnema/libwldevices-go/wire_capture"
+)
+
+// Tests replaying the captures of testdata. Record new ones with
+// session.WithWireCapture; the test must make the same requests as the
+// client that was recorded, roundtrips included.
+
+// replayManager connects an output manager to a replay of testdata/name
+func replayManager(t *testing.T, name string) (*OutputManager, *wire_capture.Replay) {
+	t.Helper()
+	capture, err := wire_capture.Load("testdata/" + name)
+	if err != nil {
+		t.Fatalf("Failed to load capture: %v", err)
+	}
+	replay, err := wire_capture.NewReplay(capture)
+	if err != nil {
+		t.Fatalf("Failed to start replay: %v", err)
+	}
+	t.Cleanup(func() { _ = replay.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s, err := session.NewSession(ctx, session.WithSocketPath(replay.Path()))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v (replay: %v)", err, replay.Err())
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	manager, err := NewOutputManagerFromSession(ctx, s)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v (replay: %v)", err, replay.Err())
+	}
+	return manager, replay
+}
+
+// finishReplay closes the session of the manager, which stops it, and checks
+// the client went through the whole capture
+func finishReplay(t *testing.T, manager *OutputManager, replay *wire_capture.Replay) {
+	t.Helper()
+	_ = manager.session.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := replay.Wait(ctx); err != nil {
+		t.Errorf("Replay failed: %v", err)
+	}
+}
+
+// roundtrip lets the replay send the events recorded after the next sync
+func roundtrip(t *testing.T, manager *OutputManager) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := manager.client.RoundtripContext(ctx); err != nil {
+		t.Fatalf("Roundtrip failed: %v", err)
+	}
+}
+
+// headSummary is what the replay tests check of a head. It's taken on the
+// event loop, which keeps updating the heads.
+type headSummary struct {
+	Enabled      bool
+	AdaptiveSync bool
+	Scale        float64
+	X            int32
+	Make         string
+	Current      string
+	Mode         string
+	Modes        int
+}
+
+func modeString(m *OutputMode) string {
+	if m == nil {
+		return "none"
+	}
+	return fmt.Sprintf("%dx%d@%d", m.Width, m.Height, m.Refresh)
+}
+
+func summarize(heads []*OutputHead) map[string]headSummary {
+	summary := make(map[string]headSummary)
+	for _, h := range heads {
+		summary[h.Name] = headSummary{
+			Enabled:      h.Enabled,
+			AdaptiveSync: h.AdaptiveSync,
+			Scale:        h.Scale,
+			X:            h.Position.X,
+			Make:         h.Make,
+			Current:      modeString(h.CurrentMode),
+			Mode:         modeString(h.Mode),
+			Modes:        len(h.GetModes()),
+		}
+	}
+	return summary
+}
+
+// watchChanges reports the heads on every done event
+func watchChanges(manager *OutputManager) <-chan map[string]headSummary {
+	changes := make(chan map[string]headSummary, 16)
+	manager.SetHandlers(OutputHandlers{
+		OnConfigurationChanged: func(heads []*OutputHead) {
+			changes <- summarize(heads)
+		},
+	})
+	return changes
+}
+
+func nextChange(t *testing.T, changes <-chan map[string]headSummary) map[string]headSummary {
+	t.Helper()
+	select {
+	case heads := <-changes:
+		return heads
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for a done event")
+		return nil
+	}
+}
+
+func TestReplayHotplug(t *testing.T) {
+	manager, replay := replayManager(t, "two_heads_v4.wire")
+
+	if manager.Version() != 4 {
+		t.Errorf("Version() = %d, want 4", manager.Version())
+	}
+	heads := summarize(manager.GetHeads())
+	want := map[string]headSummary{
+		"DP-1": {
+			Enabled: true, AdaptiveSync: true, Scale: 1.5, Make: "Dell Inc.",
+			Current: "3840x2160@59997", Mode: "3840x2160@59997", Modes: 3,
+		},
+		// Disabled heads have no current mode, Mode falls back to the
+		// preferred one
+		"HDMI-A-1": {
+			Scale: 1, Make: "LG Electronics",
+			Current: "none", Mode: "2560x1440@143912", Modes: 2,
+		},
+	}
+	for name, w := range want {
+		if got := heads[name]; got != w {
+			t.Errorf("%s = %+v, want %+v", name, got, w)
+		}
+	}
+
+	changes := watchChanges(manager)
+
+	roundtrip(t, manager)
+	hdmi := nextChange(t, changes)["HDMI-A-1"]
+	if !hdmi.Enabled || hdmi.X != 2560 || hdmi.Current != "2560x1440@143912" || hdmi.AdaptiveSync {
+		t.Errorf("HDMI-A-1 not enabled: %+v", hdmi)
+	}
+
+	roundtrip(t, manager)
+	if dp := nextChange(t, changes)["DP-1"]; dp.AdaptiveSync || !dp.Enabled {
+		t.Errorf("Adaptive sync still on: %+v", dp)
+	}
+
+	roundtrip(t, manager)
+	heads = nextChange(t, changes)
+	if _, ok := heads["DP-1"]; ok || len(heads) != 1 {
+		t.Errorf("DP-1 was not removed: %+v", heads)
+	}
+
+	finishReplay(t, manager, replay)
+}
+
+func TestReplayLateHeads(t *testing.T) {
+	manager, replay := replayManager(t, "late_heads.wire")
+
+	// The first done came without heads
+	if heads := manager.GetHeads(); len(heads) != 0 {
+		t.Fatalf("Expected no heads yet, got %d", len(heads))
+	}
+
+	added := make(chan string, 4)
+	changes := make(chan map[string]headSummary, 4)
+	manager.SetHandlers(OutputHandlers{
+		OnHeadAdded: func(head *OutputHead) {
+			added <- head.Name
+		},
+		OnConfigurationChanged: func(heads []*OutputHead) {
+			changes <- summarize(heads)
+		},
+	})
+
+	roundtrip(t, manager)
+	dp := nextChange(t, changes)["DP-1"]
+	if !dp.Enabled || !dp.AdaptiveSync || dp.Make != "Dell Inc." || dp.Current != "3840x2160@59997" {
+		t.Errorf("Unexpected DP-1: %+v", dp)
+	}
+	select {
+	case name := <-added:
+		if name != "DP-1" {
+			t.Errorf("OnHeadAdded(%s), want DP-1", name)
+		}
+	case <-time.After(time.Second):
+		t.Error("OnHeadAdded was not called for a head announced after the first done")
+	}
+
+	manager.mu.RLock()
+	serial := manager.serial
+	manager.mu.RUnlock()
+	if serial != 0 {
+		t.Errorf("serial = %d, want the last one received, 0", serial)
+	}
+
+	finishReplay(t, manager, replay)
+}
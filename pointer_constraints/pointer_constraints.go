@@ -14,6 +14,18 @@
 //	// Or confine pointer to a region
 //	confinedPointer := manager.ConfinePointer(surface, pointer, region, lifetime)
 //
+// # Constraint Events
+//
+// A constraint only takes effect once the compositor activates it, usually
+// when the surface gets focus, and the compositor may deactivate it at any
+// time. SetConstraintHandlers reports both, before the first constraint is
+// created so that no event is missed:
+//
+//	manager.SetConstraintHandlers(ConstraintHandlers{
+//		OnLocked:   func(*LockedPointer) { ... },
+//		OnUnlocked: func(*LockedPointer) { ... },
+//	})
+//
 // # Protocol Specification
 //
 // Based on pointer-constraints-unstable-v1 from Wayland protocols.
@@ -23,9 +35,11 @@ package pointer_constraints
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"github.com/bnema/libwldevices-go/internal/client"
 	"github.com/bnema/libwldevices-go/internal/protocols"
+	"github.com/bnema/libwldevices-go/session"
 	"github.com/bnema/wlturbo/wl"
 )
 
@@ -44,10 +58,38 @@ const (
 	ERROR_ALREADY_CONSTRAINED = 1 // Pointer constraint already requested on that surface
 )
 
+// ErrAlreadyConstrained matches the protocol error the compositor raises when
+// the pointer is locked or confined twice on the same surface:
+//
+//	if errors.Is(err, pointer_constraints.ErrAlreadyConstrained) { ... }
+var ErrAlreadyConstrained = &session.ProtocolError{
+	Interface: protocols.PointerConstraintsInterface,
+	Code:      ERROR_ALREADY_CONSTRAINED,
+	Name:      "already_constrained",
+}
+
 // PointerConstraintsManager manages pointer constraints
 type PointerConstraintsManager struct {
-	client  *client.Client
-	manager *protocols.PointerConstraintsManager
+	session     *session.Session
+	client      *client.Client
+	manager     *protocols.PointerConstraintsManager
+	ownsSession bool
+
+	mu            sync.Mutex
+	unavailable   bool
+	onUnavailable func()
+	handlers      ConstraintHandlers
+	unwatch       func()
+	removeHook    func()
+}
+
+// ConstraintHandlers contains callback functions for the events of the locked
+// and confined pointers created by a manager. Any of them may be nil.
+type ConstraintHandlers struct {
+	OnLocked     func(*LockedPointer)
+	OnUnlocked   func(*LockedPointer)
+	OnConfined   func(*ConfinedPointer)
+	OnUnconfined func(*ConfinedPointer)
 }
 
 // LockedPointer represents a locked pointer constraint
@@ -66,87 +108,191 @@ type ConfinedPointer struct {
 type PointerConstraintsError struct {
 	Code    int
 	Message string
+	Err     error // Sentinel error of the session package, if any
 }
 
 func (e *PointerConstraintsError) Error() string {
 	return fmt.Sprintf("pointer constraints error %d: %s", e.Code, e.Message)
 }
 
-// NewPointerConstraintsManager creates a new pointer constraints manager.
-func NewPointerConstraintsManager(ctx context.Context) (*PointerConstraintsManager, error) {
-	// Check if context is already cancelled
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
+// Unwrap returns the sentinel error behind e, for use with errors.Is
+func (e *PointerConstraintsError) Unwrap() error {
+	return e.Err
+}
+
+// NewPointerConstraintsManager creates a new pointer constraints manager on its own Wayland connection.
+// The options select the compositor to connect to, see session.NewSession.
+func NewPointerConstraintsManager(ctx context.Context, opts ...session.Option) (*PointerConstraintsManager, error) {
+	s, err := session.NewSession(ctx, opts...)
+	if err != nil {
+		return nil, err
 	}
-	
-	// Create Wayland client with timeout
-	type clientResult struct {
-		client *client.Client
-		err    error
+
+	pcm, err := newPointerConstraintsManager(ctx, s, true)
+	if err != nil {
+		_ = s.Close()
+		return nil, err
 	}
-	
-	clientCh := make(chan clientResult, 1)
-	go func() {
-		c, err := client.NewClient()
-		clientCh <- clientResult{client: c, err: err}
-	}()
-	
-	// Wait for client creation or context cancellation
-	var c *client.Client
-	select {
-	case result := <-clientCh:
-		if result.err != nil {
-			return nil, fmt.Errorf("failed to create client: %w", result.err)
+	return pcm, nil
+}
+
+// NewPointerConstraintsManagerFromSession creates a pointer constraints manager on a shared session.
+// The manager is closed automatically when the session is closed.
+func NewPointerConstraintsManagerFromSession(ctx context.Context, s *session.Session) (*PointerConstraintsManager, error) {
+	return newPointerConstraintsManager(ctx, s, false)
+}
+
+func newPointerConstraintsManager(ctx context.Context, s *session.Session, ownsSession bool) (*PointerConstraintsManager, error) {
+	c := s.Client()
+	manager, managerName, err := bindManager(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	pcm := &PointerConstraintsManager{
+		session:     s,
+		client:      c,
+		manager:     manager,
+		ownsSession: ownsSession,
+	}
+
+	if !ownsSession {
+		if err := s.Attach(pcm); err != nil {
+			_ = pcm.manager.Destroy()
+			return nil, err
 		}
-		c = result.client
-	case <-ctx.Done():
-		return nil, fmt.Errorf("context cancelled during client creation: %w", ctx.Err())
 	}
+	pcm.unwatch = c.WatchGlobal(managerName, pcm.handleGlobalRemoved)
+	pcm.removeHook = s.AddReconnectHook(pcm.rebind)
 
+	return pcm, nil
+}
+
+// bindManager binds the pointer constraints global of c and returns it with its registry name
+func bindManager(ctx context.Context, c *client.Client) (*protocols.PointerConstraintsManager, uint32, error) {
 	// Check if pointer constraints protocol is available using the client's detection
 	if !c.HasPointerConstraints() {
-		_ = c.Close()
-		return nil, fmt.Errorf("zwp_pointer_constraints_v1 not available - compositor may not support pointer-constraints protocol")
-	}
-
-	pcm := &PointerConstraintsManager{
-		client: c,
+		return nil, 0, fmt.Errorf("zwp_pointer_constraints_v1: %w - compositor may not support pointer-constraints protocol", session.ErrProtocolUnavailable)
 	}
 
 	// Check context before binding
 	select {
 	case <-ctx.Done():
-		_ = c.Close()
-		return nil, fmt.Errorf("context cancelled before binding: %w", ctx.Err())
+		return nil, 0, fmt.Errorf("context cancelled before binding: %w", ctx.Err())
 	default:
 	}
 	
 	// Use the constraints manager name from the client
 	managerName := c.GetConstraintsManagerName()
-	registry := c.GetRegistry()
 	wayland_context := c.GetContext()
 
 	// Create and bind pointer constraints manager using detected name
-	pcm.manager = protocols.NewPointerConstraintsManager(wayland_context)
-	err := registry.Bind(managerName, protocols.PointerConstraintsInterface, 1, pcm.manager)
+	manager := protocols.NewPointerConstraintsManager(wayland_context)
+	version := protocols.NegotiateVersion(c.GlobalVersion(managerName), protocols.PointerConstraintsVersion)
+	manager.SetVersion(version)
+	err := c.Bind(managerName, protocols.PointerConstraintsInterface, version, manager)
 	if err != nil {
-		_ = c.Close()
-		return nil, fmt.Errorf("failed to bind pointer constraints manager: %w", err)
+		return nil, 0, fmt.Errorf("failed to bind pointer constraints manager: %w", err)
 	}
+	c.Logger().Debug("bound pointer constraints manager", "name", managerName, "version", version)
+	return manager, managerName, nil
+}
 
-	return pcm, nil
+// Version returns the protocol version the manager was bound at: the lower of
+// the version advertised by the compositor and the one this package implements.
+// It returns 0 once the manager is closed.
+func (pcm *PointerConstraintsManager) Version() uint32 {
+	manager := pcm.currentManager()
+	if manager == nil {
+		return 0
+	}
+	return manager.Version()
+}
+
+// currentManager returns the bound manager proxy, which changes when the session reconnects
+func (pcm *PointerConstraintsManager) currentManager() *protocols.PointerConstraintsManager {
+	pcm.mu.Lock()
+	defer pcm.mu.Unlock()
+	return pcm.manager
 }
 
-// Close closes the pointer constraints manager
+// Available reports whether the compositor still advertises zwp_pointer_constraints_v1.
+// Removal is only noticed while events are dispatched, see SetUnavailableHandler.
+func (pcm *PointerConstraintsManager) Available() bool {
+	pcm.mu.Lock()
+	defer pcm.mu.Unlock()
+	return !pcm.unavailable
+}
+
+// SetUnavailableHandler sets a callback that is called once when the compositor
+// removes zwp_pointer_constraints_v1, for example when it reloads. Constraints created
+// from the manager stop working and new ones can't be created; build a new
+// manager once the protocol is announced again. Events are dispatched by the
+// event loop of the session, which this call starts.
+func (pcm *PointerConstraintsManager) SetUnavailableHandler(handler func()) {
+	pcm.mu.Lock()
+	pcm.onUnavailable = handler
+	c := pcm.client
+	pcm.mu.Unlock()
+	c.StartDispatch()
+}
+
+// SetConstraintHandlers sets the callbacks for the compositor activating and
+// deactivating the constraints of the manager. Set them before creating the
+// constraints so no event is missed. Events are dispatched by the event loop
+// of the session, which this call starts.
+func (pcm *PointerConstraintsManager) SetConstraintHandlers(handlers ConstraintHandlers) {
+	pcm.mu.Lock()
+	pcm.handlers = handlers
+	c := pcm.client
+	pcm.mu.Unlock()
+	c.StartDispatch()
+}
+
+// constraintHandlers returns the callbacks set by SetConstraintHandlers
+func (pcm *PointerConstraintsManager) constraintHandlers() ConstraintHandlers {
+	pcm.mu.Lock()
+	defer pcm.mu.Unlock()
+	return pcm.handlers
+}
+
+// handleGlobalRemoved marks the manager unavailable when its global goes away
+func (pcm *PointerConstraintsManager) handleGlobalRemoved(client.Global) {
+	pcm.mu.Lock()
+	pcm.unavailable = true
+	handler := pcm.onUnavailable
+	pcm.client.Logger().Warn("compositor removed zwp_pointer_constraints_v1")
+	pcm.mu.Unlock()
+
+	if handler != nil {
+		handler()
+	}
+}
+
+// Close closes the pointer constraints manager. The underlying connection is only
+// closed if the manager was not created from a shared session.
 func (pcm *PointerConstraintsManager) Close() error {
-	if pcm.manager != nil {
-		_ = pcm.manager.Destroy()
+	pcm.mu.Lock()
+	manager, unwatch, removeHook := pcm.manager, pcm.unwatch, pcm.removeHook
+	pcm.manager, pcm.unwatch, pcm.removeHook = nil, nil, nil
+	pcm.mu.Unlock()
+
+	if removeHook != nil {
+		removeHook()
+	}
+	if unwatch != nil {
+		unwatch()
+	}
+	if manager != nil {
+		_ = manager.Destroy()
+	}
+	if pcm.session == nil {
+		return nil
 	}
-	if pcm.client != nil {
-		return pcm.client.Close()
+	if pcm.ownsSession {
+		return pcm.session.Close()
 	}
+	pcm.session.Detach(pcm)
 	return nil
 }
 
@@ -157,10 +303,19 @@ func (pcm *PointerConstraintsManager) Destroy() error {
 
 // LockPointer locks the pointer to its current position
 func (pcm *PointerConstraintsManager) LockPointer(surface interface{}, pointer interface{}, region interface{}, lifetime uint32) (*LockedPointer, error) {
-	if pcm.manager == nil {
+	manager := pcm.currentManager()
+	if manager == nil {
 		return nil, &PointerConstraintsError{
 			Code:    -1,
 			Message: "manager not connected",
+			Err:     session.ErrManagerClosed,
+		}
+	}
+	if !pcm.Available() {
+		return nil, &PointerConstraintsError{
+			Code:    -1,
+			Message: "zwp_pointer_constraints_v1 is no longer available",
+			Err:     session.ErrProtocolUnavailable,
 		}
 	}
 
@@ -196,23 +351,30 @@ func (pcm *PointerConstraintsManager) LockPointer(surface interface{}, pointer i
 		}
 	}
 
-	locked, err := pcm.manager.LockPointer(wlSurface, wlPointer, wlRegion, lifetime)
+	lp := &LockedPointer{manager: pcm}
+	locked, err := manager.LockPointer(wlSurface, wlPointer, wlRegion, lifetime, lockedEvents{lp})
 	if err != nil {
 		return nil, fmt.Errorf("failed to lock pointer: %w", err)
 	}
-
-	return &LockedPointer{
-		manager: pcm,
-		locked:  locked,
-	}, nil
+	lp.locked = locked
+	return lp, nil
 }
 
 // ConfinePointer confines the pointer to a region
 func (pcm *PointerConstraintsManager) ConfinePointer(surface interface{}, pointer interface{}, region interface{}, lifetime uint32) (*ConfinedPointer, error) {
-	if pcm.manager == nil {
+	manager := pcm.currentManager()
+	if manager == nil {
 		return nil, &PointerConstraintsError{
 			Code:    -1,
 			Message: "manager not connected",
+			Err:     session.ErrManagerClosed,
+		}
+	}
+	if !pcm.Available() {
+		return nil, &PointerConstraintsError{
+			Code:    -1,
+			Message: "zwp_pointer_constraints_v1 is no longer available",
+			Err:     session.ErrProtocolUnavailable,
 		}
 	}
 
@@ -248,15 +410,13 @@ func (pcm *PointerConstraintsManager) ConfinePointer(surface interface{}, pointe
 		}
 	}
 
-	confined, err := pcm.manager.ConfinePointer(wlSurface, wlPointer, wlRegion, lifetime)
+	cp := &ConfinedPointer{manager: pcm}
+	confined, err := manager.ConfinePointer(wlSurface, wlPointer, wlRegion, lifetime, confinedEvents{cp})
 	if err != nil {
 		return nil, fmt.Errorf("failed to confine pointer: %w", err)
 	}
-
-	return &ConfinedPointer{
-		manager:  pcm,
-		confined: confined,
-	}, nil
+	cp.confined = confined
+	return cp, nil
 }
 
 // LockedPointer methods
@@ -300,6 +460,24 @@ func (lp *LockedPointer) SetRegion(region interface{}) error {
 	return lp.locked.SetRegion(wlRegion)
 }
 
+// lockedEvents passes the events of a locked pointer to the handlers of its
+// manager
+type lockedEvents struct {
+	lp *LockedPointer
+}
+
+func (e lockedEvents) HandleLocked(*protocols.LockedPointer) {
+	if handler := e.lp.manager.constraintHandlers().OnLocked; handler != nil {
+		handler(e.lp)
+	}
+}
+
+func (e lockedEvents) HandleUnlocked(*protocols.LockedPointer) {
+	if handler := e.lp.manager.constraintHandlers().OnUnlocked; handler != nil {
+		handler(e.lp)
+	}
+}
+
 // ConfinedPointer methods
 
 // Destroy destroys the confined pointer object
@@ -330,6 +508,24 @@ func (cp *ConfinedPointer) SetRegion(region interface{}) error {
 	return cp.confined.SetRegion(wlRegion)
 }
 
+// confinedEvents passes the events of a confined pointer to the handlers of
+// its manager
+type confinedEvents struct {
+	cp *ConfinedPointer
+}
+
+func (e confinedEvents) HandleConfined(*protocols.ConfinedPointer) {
+	if handler := e.cp.manager.constraintHandlers().OnConfined; handler != nil {
+		handler(e.cp)
+	}
+}
+
+func (e confinedEvents) HandleUnconfined(*protocols.ConfinedPointer) {
+	if handler := e.cp.manager.constraintHandlers().OnUnconfined; handler != nil {
+		handler(e.cp)
+	}
+}
+
 // Convenience functions for common operations
 
 // LockPointerAtCurrentPosition locks the pointer at its current position with oneshot lifetime.
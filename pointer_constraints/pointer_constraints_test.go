@@ -2,7 +2,14 @@ package pointer_constraints
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
+	"time"
+
+	"github.com/bnema/libwldevices-go/fake_compositor"
+	"github.com/bnema/libwldevices-go/session"
+	"github.com/bnema/wlturbo/wl"
 )
 
 // Test lifetime constants
@@ -51,12 +58,34 @@ func TestPointerConstraintsError(t *testing.T) {
 	}
 }
 
-// Test NewPointerConstraintsManager with no Wayland
+// Test that errors match the sentinels of the session package
+func TestPointerConstraintsErrorIs(t *testing.T) {
+	err := &PointerConstraintsError{
+		Code:    -1,
+		Message: "manager not connected",
+		Err:     session.ErrManagerClosed,
+	}
+	if !errors.Is(err, session.ErrManagerClosed) {
+		t.Error("Expected error to match session.ErrManagerClosed")
+	}
+
+	protocolErr := &session.ProtocolError{
+		Interface: "zwp_pointer_constraints_v1",
+		ObjectID:  9,
+		Code:      ERROR_ALREADY_CONSTRAINED,
+		Name:      "already_constrained",
+	}
+	if !errors.Is(fmt.Errorf("lock failed: %w", protocolErr), ErrAlreadyConstrained) {
+		t.Error("Expected protocol error to match ErrAlreadyConstrained")
+	}
+}
+
+// Test NewPointerConstraintsManager against a compositor
 func TestNewPointerConstraintsManager(t *testing.T) {
-	ctx := context.Background()
-	manager, err := NewPointerConstraintsManager(ctx)
+	fc := fake_compositor.NewT(t)
+	manager, err := NewPointerConstraintsManager(context.Background(), session.WithSocketPath(fc.Path()))
 	if err != nil {
-		t.Skipf("Cannot test without Wayland: %v", err)
+		t.Fatalf("Failed to create manager: %v", err)
 	}
 	defer func() {
 		if manager != nil {
@@ -69,6 +98,134 @@ func TestNewPointerConstraintsManager(t *testing.T) {
 	}
 }
 
+// constraintEvent is an event of a locked or confined pointer
+type constraintEvent struct {
+	name       string
+	constraint any
+}
+
+// expectEvent waits for the next event and checks it is want on constraint
+func expectEvent(t *testing.T, events <-chan constraintEvent, want string, constraint any) {
+	t.Helper()
+	select {
+	case got := <-events:
+		if got.name != want || got.constraint != constraint {
+			t.Errorf("Got %s on %p, want %s on %p", got.name, got.constraint, want, constraint)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for %s", want)
+	}
+}
+
+// clientObjects creates a wl_pointer on the default seat and n wl_surfaces,
+// as the application constraining the pointer would. The compositor must
+// advertise wl_compositor.
+func clientObjects(t *testing.T, s *session.Session, n int) (*wl.Pointer, []*wl.Surface) {
+	t.Helper()
+	c := s.Client()
+	compositor := wl.NewCompositor(c.GetContext())
+	for _, g := range s.Globals() {
+		if g.Interface == "wl_compositor" {
+			if err := c.Bind(g.Name, g.Interface, 4, compositor); err != nil {
+				t.Fatalf("Failed to bind wl_compositor: %v", err)
+			}
+		}
+	}
+	if compositor.ID() == 0 {
+		t.Fatal("Compositor doesn't advertise wl_compositor")
+	}
+
+	pointer, err := c.GetSeat().GetPointer()
+	if err != nil {
+		t.Fatalf("Failed to get pointer: %v", err)
+	}
+	var surfaces []*wl.Surface
+	for range n {
+		surface, err := compositor.CreateSurface()
+		if err != nil {
+			t.Fatalf("Failed to create surface: %v", err)
+		}
+		surfaces = append(surfaces, surface)
+	}
+	return pointer, surfaces
+}
+
+func TestConstraintEvents(t *testing.T) {
+	fc := fake_compositor.NewT(t, fake_compositor.WithGlobal("wl_compositor", 4))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s, err := session.NewSession(ctx, session.WithSocketPath(fc.Path()))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+	manager, err := NewPointerConstraintsManagerFromSession(ctx, s)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	defer func() { _ = manager.Close() }()
+	pointer, surfaces := clientObjects(t, s, 2)
+
+	events := make(chan constraintEvent, 4)
+	manager.SetConstraintHandlers(ConstraintHandlers{
+		OnLocked:     func(lp *LockedPointer) { events <- constraintEvent{"locked", lp} },
+		OnUnlocked:   func(lp *LockedPointer) { events <- constraintEvent{"unlocked", lp} },
+		OnConfined:   func(cp *ConfinedPointer) { events <- constraintEvent{"confined", cp} },
+		OnUnconfined: func(cp *ConfinedPointer) { events <- constraintEvent{"unconfined", cp} },
+	})
+
+	// A surface takes one constraint per pointer
+	locked, err := LockPointerAtCurrentPosition(manager, surfaces[0], pointer)
+	if err != nil {
+		t.Fatalf("LockPointer failed: %v", err)
+	}
+	confined, err := ConfinePointerToRegion(manager, surfaces[1], pointer, nil)
+	if err != nil {
+		t.Fatalf("ConfinePointer failed: %v", err)
+	}
+
+	lock, err := fc.WaitForRequest(ctx, fake_compositor.Named("zwp_pointer_constraints_v1", "lock_pointer"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lockObject, ok := lock.NewObject()
+	if !ok {
+		t.Fatalf("lock_pointer created no object: %s", lock.Format())
+	}
+	if lock.Uint(1) != surfaces[0].ID() || lock.Uint(2) != pointer.ID() || lock.Uint(4) != LifetimeOneshot {
+		t.Errorf("Unexpected lock_pointer arguments: %s", lock.Format())
+	}
+	confine, err := fc.WaitForRequest(ctx, fake_compositor.Named("zwp_pointer_constraints_v1", "confine_pointer"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	confineObject, _ := confine.NewObject()
+
+	if err := fc.Lock(lockObject); err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	expectEvent(t, events, "locked", locked)
+	if err := fc.Unlock(lockObject); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	expectEvent(t, events, "unlocked", locked)
+	if err := fc.Confine(confineObject); err != nil {
+		t.Fatalf("Confine failed: %v", err)
+	}
+	expectEvent(t, events, "confined", confined)
+	if err := fc.Lock(confineObject); err == nil {
+		t.Error("Lock should reject a confined pointer")
+	}
+
+	if err := locked.Destroy(); err != nil {
+		t.Fatalf("Destroy failed: %v", err)
+	}
+	if _, err := fc.WaitForRequest(ctx, fake_compositor.Named("zwp_locked_pointer_v1", "destroy")); err != nil {
+		t.Fatal(err)
+	}
+}
+
 // Test manager operations with invalid arguments
 func TestManagerInvalidArguments(t *testing.T) {
 	// Create a mock manager for testing (this would fail to connect but we can test the struct)
@@ -141,22 +298,30 @@ func TestConfinedPointerNilOperations(t *testing.T) {
 
 // Test convenience functions
 func TestConvenienceFunctions(t *testing.T) {
+	fc := fake_compositor.NewT(t, fake_compositor.WithGlobal("wl_compositor", 4))
+	s, err := session.NewSession(context.Background(), session.WithSocketPath(fc.Path()))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+	pointer, surfaces := clientObjects(t, s, 1)
+
 	manager := &PointerConstraintsManager{} // nil internal manager for testing
 
 	// Test LockPointerAtCurrentPosition
-	_, err := LockPointerAtCurrentPosition(manager, nil, nil)
+	_, err = LockPointerAtCurrentPosition(manager, surfaces[0], pointer)
 	if err == nil {
 		t.Fatal("LockPointerAtCurrentPosition should fail with nil internal manager")
 	}
 
 	// Test LockPointerPersistent
-	_, err = LockPointerPersistent(manager, nil, nil)
+	_, err = LockPointerPersistent(manager, surfaces[0], pointer)
 	if err == nil {
 		t.Fatal("LockPointerPersistent should fail with nil internal manager")
 	}
 
 	// Test ConfinePointerToRegion
-	_, err = ConfinePointerToRegion(manager, nil, nil, nil)
+	_, err = ConfinePointerToRegion(manager, surfaces[0], pointer, nil)
 	if err == nil {
 		t.Fatal("ConfinePointerToRegion should fail with nil internal manager")
 	}
@@ -195,4 +360,4 @@ func BenchmarkErrorCreation(b *testing.B) {
 		}
 		_ = err.Error()
 	}
-}
\ No newline at end of file
+}
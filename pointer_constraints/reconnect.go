@@ -0,0 +1,42 @@
+package pointer_constraints
+
+import (
+	"context"
+)
+
+// rebind is the session reconnect hook: it binds the manager on the new
+// connection. Constraints are tied to surfaces of the old connection and
+// are not restored.
+func (pcm *PointerConstraintsManager) rebind(ctx context.Context) error {
+	c := pcm.session.Client()
+	manager, name, err := bindManager(ctx, c)
+
+	pcm.mu.Lock()
+	if pcm.manager == nil {
+		// Closed while reconnecting
+		pcm.mu.Unlock()
+		if manager != nil {
+			_ = manager.Destroy()
+		}
+		return nil
+	}
+	pcm.client = c
+	if err != nil {
+		pcm.unavailable = true
+		handler := pcm.onUnavailable
+		pcm.mu.Unlock()
+
+		if handler != nil {
+			handler()
+		}
+		return err
+	}
+	pcm.manager = manager
+	pcm.unavailable = false
+	if pcm.unwatch != nil {
+		pcm.unwatch()
+	}
+	pcm.unwatch = c.WatchGlobal(name, pcm.handleGlobalRemoved)
+	pcm.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,115 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bnema/libwldevices-go/internal/client"
+	"github.com/bnema/wlturbo/wl"
+)
+
+// WithRegistry binds globals through a registry the application already
+// created, for sessions built with NewSessionFromDisplay or
+// NewSessionFromContext. By default the registry of the display is used, or
+// on a context a registry of the session's own.
+func WithRegistry(registry *wl.Registry) Option {
+	return func(o *options) {
+		o.adopt.Registry = registry
+	}
+}
+
+// WithSeatProxy makes a seat the application already bound the default seat
+// of an adopted session. Devices created without a seat name use it.
+func WithSeatProxy(seat *wl.Seat) Option {
+	return func(o *options) {
+		o.adopt.Seat = seat
+	}
+}
+
+// NewSessionFromDisplay builds a session on a connection owned by the
+// application, for example the one of a toolkit. Managers built from the
+// session bind their globals on that connection, next to the objects of the
+// application. The session never takes ownership of display: Close destroys
+// the objects created by the managers and leaves the connection open.
+//
+// The session starts no dispatch goroutine. Events for the managers, such as
+// output changes, are delivered when the application dispatches the display.
+// Connection options (WithDisplay, WithSocketPath, WithSocketFD),
+// WithWireTrace and WithWireCapture don't apply and are ignored;
+// WithReconnect is an error since the application owns the connection.
+func NewSessionFromDisplay(ctx context.Context, display *wl.Display, opts ...Option) (*Session, error) {
+	if display == nil {
+		return nil, errors.New("display is nil")
+	}
+	return adopt(ctx, opts, func(cfg client.AdoptConfig) (*client.Client, error) {
+		return client.NewClientFromDisplay(display, cfg)
+	})
+}
+
+// NewSessionFromContext is NewSessionFromDisplay for applications that hold
+// the wlturbo context of their connection rather than its display. Unless
+// WithRegistry is given, globals are bound through a registry of the
+// session's own. The client of the session has no display.
+func NewSessionFromContext(ctx context.Context, wctx *wl.Context, opts ...Option) (*Session, error) {
+	if wctx == nil {
+		return nil, errors.New("context is nil")
+	}
+	return adopt(ctx, opts, func(cfg client.AdoptConfig) (*client.Client, error) {
+		return client.NewClientFromContext(wctx, cfg)
+	})
+}
+
+// adopt builds a session on the client created by newClient
+func adopt(ctx context.Context, opts []Option, newClient func(client.AdoptConfig) (*client.Client, error)) (*Session, error) {
+	o := newOptions(opts)
+	if o.reconnect != nil {
+		return nil, errors.New("WithReconnect can't be used with a connection owned by the application")
+	}
+
+	cfg := o.adopt
+	cfg.Logger = o.config.Logger
+	if o.config.Display != "" || o.config.UseSocketFD || o.config.Trace != nil || o.config.Capture != nil {
+		if cfg.Logger != nil {
+			cfg.Logger.Warn("connection and trace options are ignored on an adopted display")
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	type clientResult struct {
+		client *client.Client
+		err    error
+	}
+
+	clientCh := make(chan clientResult, 1)
+	go func() {
+		c, err := newClient(cfg)
+		clientCh <- clientResult{client: c, err: err}
+	}()
+
+	select {
+	case result := <-clientCh:
+		if result.err != nil {
+			return nil, fmt.Errorf("failed to adopt Wayland display: %w", result.err)
+		}
+		return &Session{options: o, client: result.client}, nil
+	case <-ctx.Done():
+		go func() {
+			if result := <-clientCh; result.client != nil {
+				_ = result.client.Close()
+			}
+		}()
+		return nil, fmt.Errorf("context cancelled during client creation: %w", ctx.Err())
+	}
+}
+
+// Adopted reports whether the session runs on a connection owned by the
+// application, see NewSessionFromDisplay and NewSessionFromContext
+func (s *Session) Adopted() bool {
+	return s.Client().Adopted()
+}
@@ -0,0 +1,130 @@
+package session
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bnema/wlturbo/wl"
+)
+
+// applicationDisplay connects a display the way an application would, to a
+// fake compositor advertising seats
+func applicationDisplay(t *testing.T, seats []Seat) *wl.Display {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "wayland-test")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		newFakeRegistry(conn, seats)
+	}()
+
+	display, err := wl.Connect(path)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { _ = display.Close() })
+	return display
+}
+
+func TestNewSessionFromDisplay(t *testing.T) {
+	display := applicationDisplay(t, []Seat{
+		{Name: "seat0", Capabilities: SeatCapabilityPointer},
+		{Name: "kiosk", Capabilities: SeatCapabilityKeyboard},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s, err := NewSessionFromDisplay(ctx, display)
+	if err != nil {
+		t.Fatalf("Failed to adopt display: %v", err)
+	}
+	if !s.Adopted() {
+		t.Error("Session should report an adopted display")
+	}
+	if s.Client().GetDisplay() != display {
+		t.Error("Session should use the display of the application")
+	}
+
+	seats := s.Seats()
+	if len(seats) != 2 || seats[0].Name != "seat0" || seats[1].Name != "kiosk" {
+		t.Fatalf("Unexpected seats: %+v", seats)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if len(s.Seats()) != 0 {
+		t.Error("Close should release the seats bound by the session")
+	}
+
+	// The connection belongs to the application and must survive Close
+	if err := display.Roundtrip(); err != nil {
+		t.Errorf("Display unusable after closing the session: %v", err)
+	}
+}
+
+func TestNewSessionFromContext(t *testing.T) {
+	display := applicationDisplay(t, []Seat{{Name: "seat0", Capabilities: SeatCapabilityPointer}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s, err := NewSessionFromContext(ctx, display.Context())
+	if err != nil {
+		t.Fatalf("Failed to adopt context: %v", err)
+	}
+
+	// Seats are bound through the session's own registry
+	if s.Client().GetDisplay() != nil || s.Client().GetRegistry() != nil {
+		t.Error("A session adopted from a context has no display or registry")
+	}
+	seats := s.Seats()
+	if len(seats) != 1 || seats[0].Name != "seat0" {
+		t.Fatalf("Unexpected seats: %+v", seats)
+	}
+	if err := s.Client().Roundtrip(); err != nil {
+		t.Errorf("Roundtrip failed: %v", err)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := display.Roundtrip(); err != nil {
+		t.Errorf("Display unusable after closing the session: %v", err)
+	}
+}
+
+func TestNewSessionFromDisplaySeatProxy(t *testing.T) {
+	display := applicationDisplay(t, []Seat{{Name: "seat0", Capabilities: SeatCapabilityPointer}})
+
+	seat := &wl.Seat{}
+	s, err := NewSessionFromDisplay(context.Background(), display, WithSeatProxy(seat))
+	if err != nil {
+		t.Fatalf("Failed to adopt display: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	if s.Client().GetSeat() != seat {
+		t.Error("The seat of the application should be the default seat")
+	}
+}
+
+func TestNewSessionFromDisplayRejectsReconnect(t *testing.T) {
+	display := applicationDisplay(t, nil)
+
+	_, err := NewSessionFromDisplay(context.Background(), display, WithReconnect(Backoff{}))
+	if err == nil {
+		t.Fatal("WithReconnect should be rejected on an adopted display")
+	}
+}
@@ -0,0 +1,42 @@
+package session
+
+import (
+	"github.com/bnema/libwldevices-go/internal/protocols"
+)
+
+// Errors returned by every package of this module, for use with errors.Is:
+//
+//	if _, err := virtual_keyboard.NewVirtualKeyboardManager(ctx); errors.Is(err, session.ErrProtocolUnavailable) {
+//		// fall back to another input method
+//	}
+var (
+	// ErrProtocolUnavailable is returned when the compositor does not
+	// advertise a protocol, or removed it at runtime
+	ErrProtocolUnavailable = protocols.ErrProtocolUnavailable
+	// ErrConnectionClosed is returned once the connection to the compositor
+	// is gone. A ProtocolError matches it too.
+	ErrConnectionClosed = protocols.ErrConnectionClosed
+	// ErrManagerClosed is returned by the methods of a closed manager
+	ErrManagerClosed = protocols.ErrManagerClosed
+	// ErrSeatNotFound is returned when no seat has the requested name
+	ErrSeatNotFound = protocols.ErrSeatNotFound
+	// ErrOutputNotFound is returned when no output has the requested name
+	ErrOutputNotFound = protocols.ErrOutputNotFound
+	// ErrKeymapNotSet is returned when keys are sent before a keymap
+	ErrKeymapNotSet = protocols.ErrKeymapNotSet
+)
+
+// ProtocolError is a fatal error raised by the compositor with
+// wl_display.error, after which the connection is closed. It carries the
+// interface and ID of the object the offending request was sent to, the error
+// code and its name in the protocol's error enum:
+//
+//	var perr *session.ProtocolError
+//	if errors.As(err, &perr) {
+//		log.Printf("%s@%d: %s", perr.Interface, perr.ObjectID, perr.Name)
+//	}
+//
+// The device packages declare their protocol's errors as ProtocolError values
+// matching any object, such as pointer_constraints.ErrAlreadyConstrained, for
+// use with errors.Is.
+type ProtocolError = protocols.ProtocolError
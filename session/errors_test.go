@@ -0,0 +1,81 @@
+package session
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// serveError answers wl_display.get_registry with a wl_display.error raised
+// on the new registry
+func serveError(conn net.Conn, code uint32, message string) {
+	defer func() { _ = conn.Close() }()
+
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		objectID := binary.LittleEndian.Uint32(header[0:4])
+		sizeOpcode := binary.LittleEndian.Uint32(header[4:8])
+		body := make([]byte, (sizeOpcode>>16)-8)
+		if _, err := io.ReadFull(conn, body); err != nil {
+			return
+		}
+		if objectID == 1 && sizeOpcode&0xffff == 1 {
+			registry := binary.LittleEndian.Uint32(body[0:4])
+			// Keep reading so the client's pending requests don't fail first
+			_ = writeEvent(conn, 1, 0, uint32Arg(registry), uint32Arg(code), waylandString(message))
+		}
+	}
+}
+
+func TestProtocolError(t *testing.T) {
+	fd, server := socketPair(t)
+	go serveError(server, 1, "bad request")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := NewSession(ctx, WithSocketFD(fd))
+	if err == nil {
+		t.Fatal("Expected the protocol error to fail the session")
+	}
+
+	var perr *ProtocolError
+	if !errors.As(err, &perr) {
+		t.Fatalf("Expected a ProtocolError, got %v", err)
+	}
+	if perr.Interface != "wl_registry" || perr.ObjectID != 2 || perr.Code != 1 {
+		t.Errorf("Unexpected error fields: %+v", perr)
+	}
+	// wl_registry has no error enum, wl_display's is used instead
+	if perr.Name != "invalid_method" || perr.Message != "bad request" {
+		t.Errorf("Unexpected error name or message: %+v", perr)
+	}
+	if !errors.Is(err, ErrConnectionClosed) {
+		t.Error("A protocol error should match ErrConnectionClosed")
+	}
+}
+
+func TestProtocolErrorIs(t *testing.T) {
+	template := &ProtocolError{Interface: "zwp_virtual_keyboard_v1", Code: 0}
+	err := &ProtocolError{Interface: "zwp_virtual_keyboard_v1", ObjectID: 12, Code: 0, Name: "no_keymap"}
+
+	if !errors.Is(err, template) {
+		t.Error("Expected a match on interface and code for any object")
+	}
+	if errors.Is(err, &ProtocolError{Interface: "zwp_virtual_keyboard_v1", ObjectID: 13}) {
+		t.Error("A different object ID should not match")
+	}
+	if errors.Is(err, &ProtocolError{Interface: "zwlr_virtual_pointer_v1"}) {
+		t.Error("A different interface should not match")
+	}
+	if errors.Is(err, ErrProtocolUnavailable) {
+		t.Error("A protocol error should not match ErrProtocolUnavailable")
+	}
+}
@@ -0,0 +1,129 @@
+package session
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bnema/libwldevices-go/internal/client"
+)
+
+// ErrDispatchRunning is returned by DispatchPending while the event loop
+// goroutine of the session runs
+var ErrDispatchRunning = client.ErrDispatchRunning
+
+// ErrDisplayAdopted is returned by FD and DispatchPending on sessions built
+// with NewSessionFromDisplay: the application reads its display itself
+var ErrDisplayAdopted = client.ErrDisplayAdopted
+
+// ErrRoundtripInHandler is returned by a roundtrip made while an event handler
+// runs: the handler runs on the goroutine that would have to read the reply
+var ErrRoundtripInHandler = client.ErrRoundtripInHandler
+
+// errExternalDispatch is returned by Run when the application dispatches
+var errExternalDispatch = errors.New("events are dispatched by the application")
+
+// Start runs the event loop of the session on a goroutine until ctx is done,
+// Stop or Close is called, or the connection fails. Managers start the loop
+// on their own when they need events; Start ties it to ctx. Once the loop
+// has stopped, Done is closed and Err reports why.
+//
+// Start does nothing on sessions dispatched by the application, see
+// WithExternalDispatch and NewSessionFromDisplay.
+func (s *Session) Start(ctx context.Context) {
+	s.mu.Lock()
+	s.loopCtx = ctx
+	c := s.client
+	s.mu.Unlock()
+	c.StartDispatchContext(ctx)
+}
+
+// Run is Start followed by waiting for the event loop to stop. It returns
+// the terminal error of the loop: the cause of ctx, ErrConnectionClosed once
+// the session is closed, or the error that broke the connection. A session
+// created WithReconnect keeps running across reconnections and returns once
+// reconnecting is given up.
+func (s *Session) Run(ctx context.Context) error {
+	for {
+		s.Start(ctx)
+		c := s.Client()
+		done := c.Done()
+		if done == nil {
+			if c.Closed() {
+				return ErrConnectionClosed
+			}
+			return errExternalDispatch
+		}
+		<-done
+		err := c.Err()
+
+		if s.options.reconnect == nil || s.Closed() || ctx.Err() != nil || !errors.Is(err, ErrConnectionClosed) {
+			return err
+		}
+		if err := s.awaitReconnect(ctx, c); err != nil {
+			return err
+		}
+	}
+}
+
+// awaitReconnect waits for the monitor to replace c with a new connection
+func (s *Session) awaitReconnect(ctx context.Context, c *client.Client) error {
+	for {
+		s.mu.Lock()
+		current, state, stateErr := s.client, s.state, s.stateErr
+		changed := s.watchState()
+		s.mu.Unlock()
+
+		switch {
+		case s.Closed():
+			return ErrConnectionClosed
+		case state == StateFailed:
+			return stateErr
+		case current != c && state == StateConnected:
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		}
+	}
+}
+
+// Stop stops the event loop goroutine and waits for it. The connection stays
+// open: roundtrips keep working by reading it themselves, and the
+// application can take over with FD and DispatchPending.
+func (s *Session) Stop() {
+	s.mu.Lock()
+	s.loopCtx = nil
+	c := s.client
+	s.mu.Unlock()
+	c.StopDispatch()
+}
+
+// Done returns a channel that is closed when the event loop goroutine stops,
+// or nil if it was never started. It follows the current connection of a
+// session created WithReconnect.
+func (s *Session) Done() <-chan struct{} {
+	return s.Client().Done()
+}
+
+// Err returns the error that stopped the event loop goroutine, see Run
+func (s *Session) Err() error {
+	return s.Client().Err()
+}
+
+// FD returns the socket of the connection for applications that run their own
+// poll loop, see WithExternalDispatch. Call DispatchPending whenever it is
+// readable. The descriptor belongs to the session and must not be closed.
+// Adopted sessions have none and return ErrDisplayAdopted.
+func (s *Session) FD() (int, error) {
+	return s.Client().FD()
+}
+
+// DispatchPending dispatches every event that can be read without blocking,
+// calling the handlers of the managers on the current goroutine. It returns
+// ErrDispatchRunning if the event loop goroutine runs, and the connection
+// error once the connection is gone.
+func (s *Session) DispatchPending() error {
+	return s.Client().DispatchPending()
+}
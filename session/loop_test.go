@@ -0,0 +1,159 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// loopSession connects a session to a fake compositor with one seat
+func loopSession(t *testing.T, opts ...Option) (*Session, *fakeRegistry) {
+	t.Helper()
+	fd, server := socketPair(t)
+	fake := newFakeRegistry(server, []Seat{{Name: "seat0", Capabilities: SeatCapabilityPointer}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s, err := NewSession(ctx, append([]Option{WithSocketFD(fd)}, opts...)...)
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s, fake
+}
+
+func TestSessionRunStopsOnCancel(t *testing.T) {
+	s, _ := loopSession(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() { result <- s.Run(ctx) }()
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	if err := waitFor(t, result, "Run to return"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Run returned %v, want context.Canceled", err)
+	}
+
+	// The connection outlives the loop
+	if err := s.Client().Roundtrip(); err != nil {
+		t.Errorf("Roundtrip after the loop stopped: %v", err)
+	}
+}
+
+func TestSessionStartStopsOnClose(t *testing.T) {
+	s, _ := loopSession(t)
+
+	s.Start(context.Background())
+	done := s.Done()
+	if done == nil {
+		t.Fatal("Start should run the event loop")
+	}
+	if err := s.DispatchPending(); !errors.Is(err, ErrDispatchRunning) {
+		t.Errorf("DispatchPending while running returned %v", err)
+	}
+
+	_ = s.Close()
+	waitFor(t, done, "event loop to stop")
+	if err := s.Err(); !errors.Is(err, ErrConnectionClosed) {
+		t.Errorf("Err() = %v, want ErrConnectionClosed", err)
+	}
+}
+
+func TestSessionStop(t *testing.T) {
+	s, _ := loopSession(t)
+
+	s.Start(context.Background())
+	s.Stop()
+	if err := s.Err(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Err() = %v, want context.Canceled", err)
+	}
+	if err := s.Client().Roundtrip(); err != nil {
+		t.Errorf("Roundtrip after Stop: %v", err)
+	}
+	if err := s.DispatchPending(); err != nil {
+		t.Errorf("DispatchPending after Stop: %v", err)
+	}
+}
+
+func TestRoundtripInHandler(t *testing.T) {
+	s, fake := loopSession(t)
+
+	result := make(chan error, 1)
+	s.SetRegistryHandlers(RegistryHandlers{
+		OnGlobalAdded: func(Global) { result <- s.Client().Roundtrip() },
+	})
+	s.Start(context.Background())
+
+	if err := fake.announce(10, "zwlr_virtual_pointer_manager_v1", 2); err != nil {
+		t.Fatalf("Failed to announce global: %v", err)
+	}
+	if err := waitFor(t, result, "the handler's roundtrip"); !errors.Is(err, ErrRoundtripInHandler) {
+		t.Errorf("Roundtrip in a handler returned %v, want ErrRoundtripInHandler", err)
+	}
+
+	// Other goroutines still get their reply from the event loop
+	if err := s.Client().Roundtrip(); err != nil {
+		t.Errorf("Roundtrip outside a handler: %v", err)
+	}
+}
+
+func TestSessionExternalDispatch(t *testing.T) {
+	s, fake := loopSession(t, WithExternalDispatch())
+
+	added := make(chan Global, 1)
+	s.SetRegistryHandlers(RegistryHandlers{
+		OnGlobalAdded: func(g Global) { added <- g },
+	})
+	if s.Done() != nil {
+		t.Fatal("No event loop should run with WithExternalDispatch")
+	}
+	if err := s.Run(context.Background()); err == nil {
+		t.Error("Run should fail with WithExternalDispatch")
+	}
+
+	if err := fake.announce(10, "zwlr_virtual_pointer_manager_v1", 2); err != nil {
+		t.Fatalf("Failed to announce global: %v", err)
+	}
+
+	fd, err := s.FD()
+	if err != nil {
+		t.Fatalf("FD failed: %v", err)
+	}
+	fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+	if n, err := unix.Poll(fds, 5000); err != nil || n != 1 {
+		t.Fatalf("Socket never became readable: %d, %v", n, err)
+	}
+	if err := s.DispatchPending(); err != nil {
+		t.Fatalf("DispatchPending failed: %v", err)
+	}
+
+	// Handlers run on the goroutine calling DispatchPending
+	select {
+	case g := <-added:
+		if g.Name != 10 {
+			t.Errorf("Unexpected global: %+v", g)
+		}
+	default:
+		t.Error("DispatchPending should have delivered the global")
+	}
+
+	_ = s.Close()
+	if err := s.DispatchPending(); !errors.Is(err, ErrConnectionClosed) {
+		t.Errorf("DispatchPending after Close returned %v", err)
+	}
+}
+
+func TestExternalDispatchRejectsReconnect(t *testing.T) {
+	fd, server := socketPair(t)
+	defer func() { _ = server.Close() }()
+
+	_, err := NewSession(context.Background(), WithSocketFD(fd), WithExternalDispatch(), WithReconnect(Backoff{}))
+	if err == nil {
+		t.Fatal("WithReconnect should be rejected with WithExternalDispatch")
+	}
+}
@@ -0,0 +1,116 @@
+package session
+
+import (
+	"io"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/bnema/libwldevices-go/internal/client"
+)
+
+// Option configures how a Session connects to the compositor
+type Option func(*options)
+
+// options holds the settings collected from Option values
+type options struct {
+	config       client.Config
+	adopt        client.AdoptConfig // Used by NewSessionFromDisplay
+	reconnect    *Backoff
+	stateHandler StateHandler
+}
+
+// WithDisplay connects to the named display socket (for example "wayland-1")
+// in XDG_RUNTIME_DIR instead of the one named by WAYLAND_DISPLAY.
+func WithDisplay(name string) Option {
+	return func(o *options) {
+		o.config.Display = name
+		o.config.UseSocketFD = false
+	}
+}
+
+// WithSocketPath connects to the compositor socket at path. Relative paths are
+// resolved against the working directory, not XDG_RUNTIME_DIR.
+func WithSocketPath(path string) Option {
+	return func(o *options) {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		o.config.Display = path
+		o.config.UseSocketFD = false
+	}
+}
+
+// WithSocketFD uses an already connected socket, such as one handed over by a
+// supervisor process. The session takes ownership of fd and closes it on Close.
+//
+// Without any connection option, a socket passed through the WAYLAND_SOCKET
+// environment variable is used automatically, as libwayland does.
+func WithSocketFD(fd int) Option {
+	return func(o *options) {
+		o.config.SocketFD = fd
+		o.config.UseSocketFD = true
+	}
+}
+
+// WithLogger sends the diagnostics of the session and of the managers built
+// on it to logger. Nothing is logged by default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.config.Logger = logger
+	}
+}
+
+// WithWireTrace writes every request sent and event received on the
+// connection to w, with decoded arguments, like WAYLAND_DEBUG=1 does for
+// libwayland clients:
+//
+//	[     0.412]  -> zwlr_virtual_pointer_v1@7.motion(1723, 10, 0)
+//	[     0.655] wl_seat@6.name("seat0")
+//
+// Messages go through a relay goroutine while tracing, which adds latency;
+// it is meant for debugging.
+func WithWireTrace(w io.Writer) Option {
+	return func(o *options) {
+		o.config.Trace = w
+	}
+}
+
+// WithWireCapture records every request sent and event received on the
+// connection to w in the format of wire_capture, to replay them later as a
+// test fixture with wire_capture.NewReplay. Capturing goes through the same
+// relay as WithWireTrace, and both can be used together. A session created
+// WithReconnect appends the capture of each new connection to w; replays
+// play a single connection.
+func WithWireCapture(w io.Writer) Option {
+	return func(o *options) {
+		o.config.Capture = w
+	}
+}
+
+// WithExternalDispatch leaves reading the connection to the application.
+// No event loop goroutine is started; instead the application polls FD in
+// its own loop and calls DispatchPending when it is readable. Roundtrips made
+// by the managers still read the connection themselves while they wait.
+//
+// For sessions created with NewSessionFromDisplay, it tells the session that
+// the application dispatches its display on a goroutine of its own, as a
+// toolkit event loop does: roundtrips then wait for that goroutine to deliver
+// their callback. Without it the session reads the connection itself whenever
+// it needs a reply, which must not overlap with the application doing so.
+func WithExternalDispatch() Option {
+	return func(o *options) {
+		o.config.ExternalDispatch = true
+		o.adopt.ExternalDispatch = true
+	}
+}
+
+// newOptions applies opts on top of the defaults
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	return o
+}
@@ -0,0 +1,154 @@
+package session
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// serveSync answers wl_display.sync requests on conn until it is closed,
+// which is all NewSession needs to complete its initial roundtrip.
+func serveSync(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		objectID := binary.LittleEndian.Uint32(header[0:4])
+		sizeOpcode := binary.LittleEndian.Uint32(header[4:8])
+		body := make([]byte, (sizeOpcode>>16)-8)
+		if _, err := io.ReadFull(conn, body); err != nil {
+			return
+		}
+
+		// wl_display.sync(callback): reply with wl_callback.done(serial)
+		if objectID == 1 && sizeOpcode&0xffff == 0 {
+			callback := binary.LittleEndian.Uint32(body[0:4])
+			event := make([]byte, 12)
+			binary.LittleEndian.PutUint32(event[0:4], callback)
+			binary.LittleEndian.PutUint32(event[4:8], 12<<16)
+			if _, err := conn.Write(event); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// listenSync starts a fake compositor socket in a temporary directory
+func listenSync(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "wayland-test")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSync(conn)
+		}
+	}()
+	return path
+}
+
+func TestWithSocketPath(t *testing.T) {
+	path := listenSync(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s, err := NewSession(ctx, WithSocketPath(path))
+	if err != nil {
+		t.Fatalf("Failed to connect to %s: %v", path, err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Failed to close session: %v", err)
+	}
+}
+
+func TestWithDisplay(t *testing.T) {
+	path := listenSync(t)
+	t.Setenv("XDG_RUNTIME_DIR", filepath.Dir(path))
+	t.Setenv("WAYLAND_DISPLAY", "wayland-does-not-exist")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s, err := NewSession(ctx, WithDisplay(filepath.Base(path)))
+	if err != nil {
+		t.Fatalf("Failed to connect to display %s: %v", filepath.Base(path), err)
+	}
+	_ = s.Close()
+}
+
+// socketPair returns a connected pair: the client fd and the server side
+func socketPair(t *testing.T) (int, net.Conn) {
+	t.Helper()
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		t.Fatalf("socketpair failed: %v", err)
+	}
+	serverFile := os.NewFile(uintptr(fds[1]), "server")
+	server, err := net.FileConn(serverFile)
+	_ = serverFile.Close()
+	if err != nil {
+		t.Fatalf("FileConn failed: %v", err)
+	}
+	return fds[0], server
+}
+
+func TestWithSocketFD(t *testing.T) {
+	fd, server := socketPair(t)
+	go serveSync(server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s, err := NewSession(ctx, WithSocketFD(fd))
+	if err != nil {
+		t.Fatalf("Failed to connect over fd %d: %v", fd, err)
+	}
+	_ = s.Close()
+}
+
+func TestWaylandSocketEnv(t *testing.T) {
+	fd, server := socketPair(t)
+	go serveSync(server)
+
+	t.Setenv("WAYLAND_SOCKET", strconv.Itoa(fd))
+	t.Setenv("WAYLAND_DISPLAY", "wayland-does-not-exist")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s, err := NewSession(ctx)
+	if err != nil {
+		t.Fatalf("Failed to connect through WAYLAND_SOCKET: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	if _, ok := os.LookupEnv("WAYLAND_SOCKET"); ok {
+		t.Fatal("WAYLAND_SOCKET should be unset once consumed")
+	}
+}
+
+func TestWithSocketFDInvalid(t *testing.T) {
+	ctx := context.Background()
+	if _, err := NewSession(ctx, WithSocketFD(-1)); err == nil {
+		t.Fatal("Expected an error for an invalid socket fd")
+	}
+}
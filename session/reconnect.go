@@ -0,0 +1,262 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bnema/libwldevices-go/internal/client"
+)
+
+// State describes the connection of a session to the compositor
+type State int
+
+// Connection states reported to the StateHandler
+const (
+	StateConnected    State = iota // Connected, or reconnected and managers restored
+	StateDisconnected              // The connection dropped
+	StateReconnecting              // A reconnection attempt is starting
+	StateFailed                    // Reconnection was given up; the session must be closed
+)
+
+// String returns the name of the state
+func (s State) String() string {
+	switch s {
+	case StateConnected:
+		return "connected"
+	case StateDisconnected:
+		return "disconnected"
+	case StateReconnecting:
+		return "reconnecting"
+	case StateFailed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
+// StateHandler is called on every state change of a session. err is the
+// reason for the change: the connection error for StateDisconnected, the last
+// attempt's error for StateFailed, and any manager that couldn't be restored
+// for StateConnected.
+type StateHandler func(state State, err error)
+
+// Backoff controls the delay between reconnection attempts. Zero fields use
+// the defaults: 100ms initial delay, 5s maximum delay, a multiplier of 2 and
+// unlimited attempts.
+type Backoff struct {
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	Multiplier   float64
+	MaxAttempts  int
+}
+
+// delay returns the wait after the given failed attempt, starting at 1
+func (b Backoff) delay(attempt int) time.Duration {
+	initial := b.InitialDelay
+	if initial <= 0 {
+		initial = 100 * time.Millisecond
+	}
+	maxDelay := b.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = 5 * time.Second
+	}
+	multiplier := b.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+
+	d := float64(initial)
+	for i := 1; i < attempt && d < float64(maxDelay); i++ {
+		d *= multiplier
+	}
+	if d > float64(maxDelay) {
+		return maxDelay
+	}
+	return time.Duration(d)
+}
+
+// WithReconnect makes the session reconnect when the compositor goes away,
+// for example when it crashes or restarts. Managers built on the session
+// bind their globals again and recreate their devices; keyboards get their
+// keymap back. Sessions created from an inherited socket can't reconnect.
+//
+// Reconnection needs the connection to be monitored, so events are dispatched
+// on a background goroutine for the whole life of the session, and
+// WithExternalDispatch is not supported. A drop goes unnoticed while the loop
+// is stopped, see Stop.
+func WithReconnect(backoff Backoff) Option {
+	return func(o *options) {
+		o.reconnect = &backoff
+	}
+}
+
+// WithStateHandler sets a callback for connection state changes
+func WithStateHandler(handler StateHandler) Option {
+	return func(o *options) {
+		o.stateHandler = handler
+	}
+}
+
+// reconnectHook is a registered manager callback
+type reconnectHook struct {
+	fn func(ctx context.Context) error
+}
+
+// AddReconnectHook registers a function that restores a manager on the new
+// connection after a reconnection. It is intended for the device packages of
+// this module; hooks run in registration order. The returned function
+// unregisters the hook.
+func (s *Session) AddReconnectHook(fn func(ctx context.Context) error) func() {
+	hook := &reconnectHook{fn: fn}
+
+	s.mu.Lock()
+	s.hooks = append(s.hooks, hook)
+	s.mu.Unlock()
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for i, h := range s.hooks {
+			if h == hook {
+				s.hooks = append(s.hooks[:i], s.hooks[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// State returns the current connection state
+func (s *Session) State() State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state
+}
+
+// setState records a state change and reports it
+func (s *Session) setState(state State, err error) {
+	s.mu.Lock()
+	s.state = state
+	s.stateErr = err
+	if s.changed != nil {
+		close(s.changed)
+		s.changed = nil
+	}
+	logger := s.client.Logger()
+	s.mu.Unlock()
+
+	switch {
+	case state == StateFailed:
+		logger.Error("giving up reconnecting to the compositor", "error", err)
+	case state == StateDisconnected:
+		logger.Warn("lost the connection to the compositor", "error", err)
+	case err != nil:
+		logger.Warn("connection state changed", "state", state, "error", err)
+	default:
+		logger.Info("connection state changed", "state", state)
+	}
+
+	if s.options != nil && s.options.stateHandler != nil {
+		s.options.stateHandler(state, err)
+	}
+}
+
+// watchState returns a channel closed on the next state change. Called with
+// s.mu held.
+func (s *Session) watchState() <-chan struct{} {
+	if s.changed == nil {
+		s.changed = make(chan struct{})
+	}
+	return s.changed
+}
+
+// monitor waits for the connection to drop and reconnects until ctx is cancelled
+func (s *Session) monitor(ctx context.Context, c *client.Client) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.Lost():
+		}
+		if s.Closed() {
+			return
+		}
+
+		s.setState(StateDisconnected, c.LostErr())
+		_ = c.Close()
+
+		next, err := s.reconnect(ctx, c.FromSocketFD())
+		if err != nil {
+			if !s.Closed() {
+				s.setState(StateFailed, err)
+			}
+			return
+		}
+		c = next
+	}
+}
+
+// reconnect dials the compositor again with backoff, then restores the
+// session handlers and runs the reconnect hooks
+func (s *Session) reconnect(ctx context.Context, fromFD bool) (*client.Client, error) {
+	if fromFD {
+		return nil, errors.New("cannot reconnect a session connected over an inherited socket")
+	}
+
+	backoff := *s.options.reconnect
+	for attempt := 1; ; attempt++ {
+		s.setState(StateReconnecting, nil)
+
+		c, err := client.NewClientWithConfig(s.options.config)
+		if err == nil {
+			return c, s.restore(ctx, c)
+		}
+
+		s.Logger().Debug("reconnection attempt failed", "attempt", attempt, "error", err)
+		if backoff.MaxAttempts > 0 && attempt >= backoff.MaxAttempts {
+			return nil, fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff.delay(attempt)):
+		}
+	}
+}
+
+// restore makes c the session's connection and rebuilds everything on it
+func (s *Session) restore(ctx context.Context, c *client.Client) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		_ = c.Close()
+		return ErrSessionClosed
+	}
+	s.client = c
+	hooks := make([]*reconnectHook, len(s.hooks))
+	copy(hooks, s.hooks)
+	if s.seatAdded != nil || s.seatRemoved != nil {
+		c.SetSeatHandlers(s.seatAdded, s.seatRemoved)
+	}
+	if s.registryListener != nil {
+		c.AddGlobalListener(s.registryListener)
+	}
+	loopCtx := s.loopCtx
+	s.mu.Unlock()
+
+	if loopCtx != nil {
+		c.StartDispatchContext(loopCtx)
+	} else {
+		c.StartDispatch()
+	}
+
+	var errs []error
+	for _, hook := range hooks {
+		if err := hook.fn(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	s.setState(StateConnected, errors.Join(errs...))
+	return nil
+}
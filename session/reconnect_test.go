@@ -0,0 +1,112 @@
+package session
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	b := Backoff{InitialDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond, Multiplier: 2}
+	want := []time.Duration{10, 20, 40, 50, 50}
+	for i, w := range want {
+		if got := b.delay(i + 1); got != w*time.Millisecond {
+			t.Errorf("delay(%d) = %v, want %v", i+1, got, w*time.Millisecond)
+		}
+	}
+
+	if got := (Backoff{}).delay(1); got != 100*time.Millisecond {
+		t.Errorf("Default initial delay = %v, want 100ms", got)
+	}
+}
+
+// stateRecorder collects the states reported to a StateHandler
+func stateRecorder() (StateHandler, <-chan State) {
+	states := make(chan State, 16)
+	return func(state State, _ error) { states <- state }, states
+}
+
+func TestSessionReconnect(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wayland-test")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer func() { _ = listener.Close() }()
+
+	conns := make(chan net.Conn, 2)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			newFakeRegistry(conn, []Seat{{Name: "seat0", Capabilities: SeatCapabilityPointer}})
+			conns <- conn
+		}
+	}()
+
+	handler, states := stateRecorder()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s, err := NewSession(ctx,
+		WithSocketPath(path),
+		WithReconnect(Backoff{InitialDelay: 10 * time.Millisecond}),
+		WithStateHandler(handler),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	hooked := make(chan struct{}, 1)
+	s.AddReconnectHook(func(context.Context) error {
+		hooked <- struct{}{}
+		return nil
+	})
+
+	// Drop the first connection as a crashing compositor would
+	first := waitFor(t, conns, "first connection")
+	_ = first.Close()
+
+	for _, want := range []State{StateDisconnected, StateReconnecting, StateConnected} {
+		if got := waitFor(t, states, want.String()); got != want {
+			t.Fatalf("Expected state %v, got %v", want, got)
+		}
+	}
+	waitFor(t, hooked, "reconnect hook")
+	waitFor(t, conns, "second connection")
+
+	if s.State() != StateConnected {
+		t.Errorf("Expected connected state, got %v", s.State())
+	}
+	if _, ok := s.FindSeat("seat0"); !ok {
+		t.Error("Seats should be known after reconnecting")
+	}
+}
+
+func TestSessionReconnectFromFD(t *testing.T) {
+	fd, server := socketPair(t)
+	newFakeRegistry(server, nil)
+
+	handler, states := stateRecorder()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s, err := NewSession(ctx, WithSocketFD(fd), WithReconnect(Backoff{}), WithStateHandler(handler))
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	_ = server.Close()
+
+	for _, want := range []State{StateDisconnected, StateFailed} {
+		if got := waitFor(t, states, want.String()); got != want {
+			t.Fatalf("Expected state %v, got %v", want, got)
+		}
+	}
+}
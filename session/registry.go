@@ -0,0 +1,45 @@
+package session
+
+import (
+	"github.com/bnema/libwldevices-go/internal/client"
+)
+
+// Global is a global object advertised by the compositor, such as
+// "zwlr_virtual_pointer_manager_v1" or "wl_seat"
+type Global = client.Global
+
+// RegistryHandlers contains callback functions for registry changes
+type RegistryHandlers struct {
+	// OnGlobalAdded is called when the compositor announces a new global
+	OnGlobalAdded func(global Global)
+	// OnGlobalRemoved is called when a global goes away, e.g. on a compositor
+	// reload or when a protocol is disabled
+	OnGlobalRemoved func(global Global)
+}
+
+// Globals returns every global currently advertised by the compositor
+func (s *Session) Globals() []Global {
+	return s.Client().Globals()
+}
+
+// SetRegistryHandlers sets the callbacks for globals being added or removed,
+// replacing any previous ones. Events are dispatched by the event loop,
+// which this call starts, or by DispatchPending with WithExternalDispatch.
+func (s *Session) SetRegistryHandlers(handlers RegistryHandlers) {
+	listener := &client.GlobalListener{
+		Added:   handlers.OnGlobalAdded,
+		Removed: handlers.OnGlobalRemoved,
+	}
+
+	s.mu.Lock()
+	previous := s.registryListener
+	s.registryListener = listener
+	c := s.client
+	s.mu.Unlock()
+
+	if previous != nil {
+		c.RemoveGlobalListener(previous)
+	}
+	c.AddGlobalListener(listener)
+	c.StartDispatch()
+}
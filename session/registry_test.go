@@ -0,0 +1,85 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// waitFor receives from ch or fails the test after a timeout
+func waitFor[T any](t *testing.T, ch <-chan T, what string) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for %s", what)
+		var zero T
+		return zero
+	}
+}
+
+func TestSessionRegistryHandlers(t *testing.T) {
+	fd, server := socketPair(t)
+	fake := newFakeRegistry(server, []Seat{
+		{Name: "seat0", Capabilities: SeatCapabilityPointer},
+		{Name: "kiosk", Capabilities: SeatCapabilityKeyboard},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s, err := NewSession(ctx, WithSocketFD(fd))
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	added := make(chan Global, 1)
+	removed := make(chan Global, 1)
+	seatRemoved := make(chan Seat, 1)
+	s.SetRegistryHandlers(RegistryHandlers{
+		OnGlobalAdded:   func(g Global) { added <- g },
+		OnGlobalRemoved: func(g Global) { removed <- g },
+	})
+	s.SetSeatHandlers(SeatHandlers{
+		OnSeatRemoved: func(seat Seat) { seatRemoved <- seat },
+	})
+
+	if err := fake.announce(10, "zwlr_virtual_pointer_manager_v1", 2); err != nil {
+		t.Fatalf("Failed to announce global: %v", err)
+	}
+	g := waitFor(t, added, "global added")
+	if g.Name != 10 || g.Interface != "zwlr_virtual_pointer_manager_v1" || g.Version != 2 {
+		t.Errorf("Unexpected global: %+v", g)
+	}
+	if !s.Client().HasVirtualPointer() {
+		t.Error("Late virtual pointer global should be recorded")
+	}
+
+	if err := fake.remove(10); err != nil {
+		t.Fatalf("Failed to remove global: %v", err)
+	}
+	if g := waitFor(t, removed, "global removed"); g.Name != 10 {
+		t.Errorf("Unexpected removed global: %+v", g)
+	}
+	if s.Client().HasVirtualPointer() {
+		t.Error("Removed virtual pointer global should be forgotten")
+	}
+
+	if err := fake.remove(2); err != nil {
+		t.Fatalf("Failed to remove seat: %v", err)
+	}
+	if seat := waitFor(t, seatRemoved, "seat removed"); seat.Name != "kiosk" {
+		t.Errorf("Unexpected removed seat: %+v", seat)
+	}
+	if g := waitFor(t, removed, "seat global removed"); g.Interface != "wl_seat" {
+		t.Errorf("Unexpected removed global: %+v", g)
+	}
+
+	for _, g := range s.Globals() {
+		if g.Name == 2 || g.Name == 10 {
+			t.Errorf("Removed global still listed: %+v", g)
+		}
+	}
+}
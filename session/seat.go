@@ -0,0 +1,92 @@
+package session
+
+import (
+	"github.com/bnema/libwldevices-go/internal/client"
+)
+
+// Seat capability flags (from wl_seat.capability)
+const (
+	SeatCapabilityPointer  = 1
+	SeatCapabilityKeyboard = 2
+	SeatCapabilityTouch    = 4
+)
+
+// Seat describes a wl_seat advertised by the compositor
+type Seat struct {
+	// Name identifies the seat, e.g. "seat0". Use it to pick the seat
+	// virtual devices are created on.
+	Name string
+	// Capabilities is the wl_seat capability bitmask
+	Capabilities uint32
+}
+
+// HasPointer reports whether the seat has pointer devices
+func (s Seat) HasPointer() bool {
+	return s.Capabilities&SeatCapabilityPointer != 0
+}
+
+// HasKeyboard reports whether the seat has keyboard devices
+func (s Seat) HasKeyboard() bool {
+	return s.Capabilities&SeatCapabilityKeyboard != 0
+}
+
+// HasTouch reports whether the seat has touch devices
+func (s Seat) HasTouch() bool {
+	return s.Capabilities&SeatCapabilityTouch != 0
+}
+
+// SeatHandlers contains callback functions for seat hotplug events
+type SeatHandlers struct {
+	// OnSeatAdded is called when a new seat is announced and its name is known
+	OnSeatAdded func(seat Seat)
+	// OnSeatRemoved is called when a seat disappears
+	OnSeatRemoved func(seat Seat)
+}
+
+// Seats returns every seat advertised by the compositor, in announcement order.
+// Devices are created on the first one unless another seat is selected by name.
+func (s *Session) Seats() []Seat {
+	seats := s.Client().Seats()
+	result := make([]Seat, 0, len(seats))
+	for _, seat := range seats {
+		result = append(result, seatInfo(seat))
+	}
+	return result
+}
+
+// FindSeat returns the seat with the given name
+func (s *Session) FindSeat(name string) (Seat, bool) {
+	seat, ok := s.Client().FindSeat(name)
+	if !ok {
+		return Seat{}, false
+	}
+	return seatInfo(seat), true
+}
+
+// SetSeatHandlers sets the callbacks for seats being added or removed.
+// Events are dispatched by the event loop, which this call starts, or by
+// DispatchPending with WithExternalDispatch.
+func (s *Session) SetSeatHandlers(handlers SeatHandlers) {
+	var added, removed func(*client.Seat)
+	if handlers.OnSeatAdded != nil {
+		added = func(seat *client.Seat) { handlers.OnSeatAdded(seatInfo(seat)) }
+	}
+	if handlers.OnSeatRemoved != nil {
+		removed = func(seat *client.Seat) { handlers.OnSeatRemoved(seatInfo(seat)) }
+	}
+
+	s.mu.Lock()
+	s.seatAdded, s.seatRemoved = added, removed
+	c := s.client
+	s.mu.Unlock()
+
+	c.SetSeatHandlers(added, removed)
+	c.StartDispatch()
+}
+
+func seatInfo(seat *client.Seat) Seat {
+	return Seat{
+		Name:         seat.Name(),
+		Capabilities: seat.Capabilities(),
+	}
+}
@@ -0,0 +1,184 @@
+package session
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waylandString encodes s as a wire protocol string argument
+func waylandString(s string) []byte {
+	size := len(s) + 1
+	buf := make([]byte, 4+(size+3)&^3)
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(size))
+	copy(buf[4:], s)
+	return buf
+}
+
+// writeEvent sends an event with the given arguments to the client
+func writeEvent(conn net.Conn, objectID uint32, opcode uint16, args ...[]byte) error {
+	size := 8
+	for _, arg := range args {
+		size += len(arg)
+	}
+	msg := make([]byte, 8, size)
+	binary.LittleEndian.PutUint32(msg[0:4], objectID)
+	binary.LittleEndian.PutUint32(msg[4:8], uint32(size)<<16|uint32(opcode))
+	for _, arg := range args {
+		msg = append(msg, arg...)
+	}
+	_, err := conn.Write(msg)
+	return err
+}
+
+func uint32Arg(v uint32) []byte {
+	buf := make([]byte, 4)
+	binary.LittleEndian.PutUint32(buf, v)
+	return buf
+}
+
+// fakeRegistry is a fake compositor advertising one wl_seat global per entry
+// of seats, each reporting its name and capabilities once bound. Tests can
+// announce and remove globals once the client is connected.
+type fakeRegistry struct {
+	conn  net.Conn
+	seats []Seat
+
+	mu       sync.Mutex
+	registry uint32
+}
+
+func newFakeRegistry(conn net.Conn, seats []Seat) *fakeRegistry {
+	f := &fakeRegistry{conn: conn, seats: seats}
+	go f.serve()
+	return f
+}
+
+// write sends an event, serialised with the events sent by serve
+func (f *fakeRegistry) write(objectID uint32, opcode uint16, args ...[]byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return writeEvent(f.conn, objectID, opcode, args...)
+}
+
+// announce sends wl_registry.global
+func (f *fakeRegistry) announce(name uint32, iface string, version uint32) error {
+	f.mu.Lock()
+	registry := f.registry
+	f.mu.Unlock()
+	return f.write(registry, 0, uint32Arg(name), waylandString(iface), uint32Arg(version))
+}
+
+// remove sends wl_registry.global_remove
+func (f *fakeRegistry) remove(name uint32) error {
+	f.mu.Lock()
+	registry := f.registry
+	f.mu.Unlock()
+	return f.write(registry, 1, uint32Arg(name))
+}
+
+func (f *fakeRegistry) serve() {
+	defer func() { _ = f.conn.Close() }()
+
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(f.conn, header); err != nil {
+			return
+		}
+		objectID := binary.LittleEndian.Uint32(header[0:4])
+		sizeOpcode := binary.LittleEndian.Uint32(header[4:8])
+		opcode := sizeOpcode & 0xffff
+		body := make([]byte, (sizeOpcode>>16)-8)
+		if _, err := io.ReadFull(f.conn, body); err != nil {
+			return
+		}
+
+		switch {
+		case objectID == 1 && opcode == 0:
+			// wl_display.sync: wl_callback.done
+			callback := binary.LittleEndian.Uint32(body[0:4])
+			if err := f.write(callback, 0, uint32Arg(0)); err != nil {
+				return
+			}
+
+		case objectID == 1 && opcode == 1:
+			// wl_display.get_registry: wl_registry.global for each seat
+			f.mu.Lock()
+			f.registry = binary.LittleEndian.Uint32(body[0:4])
+			f.mu.Unlock()
+			for i := range f.seats {
+				if err := f.announce(uint32(i+1), "wl_seat", 7); err != nil {
+					return
+				}
+			}
+
+		case opcode == 0 && objectID != 1:
+			// wl_registry.bind(name, interface, version, id)
+			name := binary.LittleEndian.Uint32(body[0:4])
+			ifaceLen := binary.LittleEndian.Uint32(body[4:8])
+			id := binary.LittleEndian.Uint32(body[8+(ifaceLen+3)&^3+4:])
+			if name == 0 || int(name) > len(f.seats) {
+				continue
+			}
+			seat := f.seats[name-1]
+			// wl_seat.capabilities then wl_seat.name
+			if err := f.write(id, 0, uint32Arg(seat.Capabilities)); err != nil {
+				return
+			}
+			if err := f.write(id, 1, waylandString(seat.Name)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func TestSeatCapabilities(t *testing.T) {
+	seat := Seat{Name: "seat0", Capabilities: SeatCapabilityPointer | SeatCapabilityKeyboard}
+	if !seat.HasPointer() || !seat.HasKeyboard() {
+		t.Fatalf("Expected pointer and keyboard capabilities: %+v", seat)
+	}
+	if seat.HasTouch() {
+		t.Fatalf("Unexpected touch capability: %+v", seat)
+	}
+}
+
+func TestSessionSeats(t *testing.T) {
+	fd, server := socketPair(t)
+	newFakeRegistry(server, []Seat{
+		{Name: "seat0", Capabilities: SeatCapabilityPointer | SeatCapabilityKeyboard},
+		{Name: "kiosk", Capabilities: SeatCapabilityTouch},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s, err := NewSession(ctx, WithSocketFD(fd))
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	seats := s.Seats()
+	if len(seats) != 2 {
+		t.Fatalf("Expected 2 seats, got %+v", seats)
+	}
+	if seats[0].Name != "seat0" || !seats[0].HasKeyboard() {
+		t.Errorf("Unexpected first seat: %+v", seats[0])
+	}
+
+	kiosk, ok := s.FindSeat("kiosk")
+	if !ok {
+		t.Fatal("Seat kiosk not found")
+	}
+	if !kiosk.HasTouch() || kiosk.HasPointer() {
+		t.Errorf("Unexpected kiosk capabilities: %+v", kiosk)
+	}
+
+	if _, ok := s.FindSeat("missing"); ok {
+		t.Error("FindSeat should not find an unknown seat")
+	}
+}
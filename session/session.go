@@ -0,0 +1,208 @@
+// Package session provides a Wayland connection that can be shared by all device managers.
+//
+// Every manager constructor in this module (virtual_pointer, virtual_keyboard, pointer_constraints
+// and output_management) opens its own connection by default. Applications that use several
+// managers at once can open a single Session instead and build each manager from it, so the
+// socket, registry and seat are only set up once.
+//
+// # Basic Usage
+//
+//	ctx := context.Background()
+//	sess, err := session.NewSession(ctx)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer sess.Close() // Also closes every manager built from the session
+//
+//	// Or target a specific compositor
+//	sess, err = session.NewSession(ctx, session.WithDisplay("wayland-1"))
+//	sess, err = session.NewSession(ctx, session.WithSocketPath("/run/user/1000/kiosk.sock"))
+//	sess, err = session.NewSession(ctx, session.WithSocketFD(fd))
+//
+//	pointers, err := virtual_pointer.NewVirtualPointerManagerFromSession(ctx, sess)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//
+//	keyboards, err := virtual_keyboard.NewVirtualKeyboardManagerFromSession(ctx, sess)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//
+// # Adopting a Connection
+//
+// NewSessionFromDisplay and NewSessionFromContext build a session on a connection the
+// application already owns. Managers bind their globals on it and Close leaves it open.
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+
+	"github.com/bnema/libwldevices-go/internal/client"
+)
+
+// ErrSessionClosed is returned when a manager is attached to a session that was already closed
+var ErrSessionClosed = errors.New("session is closed")
+
+// Session is a shared Wayland connection used by one or more device managers
+type Session struct {
+	options *options
+
+	mu               sync.Mutex
+	client           *client.Client
+	managers         []io.Closer
+	closed           bool
+	registryListener *client.GlobalListener
+	seatAdded        func(*client.Seat)
+	seatRemoved      func(*client.Seat)
+
+	// Reconnection state, see WithReconnect
+	state    State
+	stateErr error
+	changed  chan struct{} // Closed on the next state change
+	hooks    []*reconnectHook
+	cancel   context.CancelFunc
+
+	// Context given to Start, also used for the loop of a new connection
+	loopCtx context.Context
+}
+
+// NewSession connects to the Wayland compositor and performs the initial registry roundtrip.
+// By default the compositor is found through WAYLAND_SOCKET or WAYLAND_DISPLAY; use
+// WithDisplay, WithSocketPath or WithSocketFD to pick a specific one.
+func NewSession(ctx context.Context, opts ...Option) (*Session, error) {
+	o := newOptions(opts)
+	if o.reconnect != nil && o.config.ExternalDispatch {
+		return nil, errors.New("WithReconnect can't be used with WithExternalDispatch")
+	}
+
+	// Check if context is already cancelled
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	// Create Wayland client with timeout
+	type clientResult struct {
+		client *client.Client
+		err    error
+	}
+
+	clientCh := make(chan clientResult, 1)
+	go func() {
+		c, err := client.NewClientWithConfig(o.config)
+		clientCh <- clientResult{client: c, err: err}
+	}()
+
+	// Wait for client creation or context cancellation
+	select {
+	case result := <-clientCh:
+		if result.err != nil {
+			return nil, fmt.Errorf("failed to create Wayland client: %w", result.err)
+		}
+		s := &Session{options: o, client: result.client}
+		if o.reconnect != nil {
+			// The monitor outlives ctx, which only bounds the initial connection
+			monitorCtx, cancel := context.WithCancel(context.Background())
+			s.cancel = cancel
+			result.client.StartDispatch()
+			go s.monitor(monitorCtx, result.client)
+		}
+		return s, nil
+	case <-ctx.Done():
+		// Don't leak the connection if it completes after cancellation
+		go func() {
+			if result := <-clientCh; result.client != nil {
+				_ = result.client.Close()
+			}
+		}()
+		return nil, fmt.Errorf("context cancelled during client creation: %w", ctx.Err())
+	}
+}
+
+// Client returns the underlying connection. It is intended for the device packages of this module.
+// The connection changes when a session created WithReconnect reconnects.
+func (s *Session) Client() *client.Client {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.client
+}
+
+// Logger returns the logger set with WithLogger, or one that discards
+// everything. Managers built on the session log to it.
+func (s *Session) Logger() *slog.Logger {
+	return s.Client().Logger()
+}
+
+// Attach registers a manager so that it is closed together with the session
+func (s *Session) Attach(manager io.Closer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrSessionClosed
+	}
+	s.managers = append(s.managers, manager)
+	return nil
+}
+
+// Detach removes a manager previously registered with Attach
+func (s *Session) Detach(manager io.Closer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, m := range s.managers {
+		if m == manager {
+			s.managers = append(s.managers[:i], s.managers[i+1:]...)
+			return
+		}
+	}
+}
+
+// Closed reports whether Close has been called
+func (s *Session) Closed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+// Close closes every attached manager, most recent first, then the connection.
+// The connection of an adopted session stays open, see NewSessionFromDisplay.
+func (s *Session) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	managers := s.managers
+	s.managers = nil
+	c := s.client
+	cancel := s.cancel
+	s.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+
+	var errs []error
+	for i := len(managers) - 1; i >= 0; i-- {
+		if err := managers[i].Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if c != nil {
+		if err := c.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,118 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// fakeManager records when it is closed
+type fakeManager struct {
+	name   string
+	closed *[]string
+	err    error
+}
+
+func (f *fakeManager) Close() error {
+	*f.closed = append(*f.closed, f.name)
+	return f.err
+}
+
+func TestNewSession(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewSession(ctx)
+	if err != nil {
+		t.Skipf("Skipping test - Wayland compositor not available: %v", err)
+	}
+	if s.Client() == nil {
+		t.Fatal("Session client should not be nil")
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Failed to close session: %v", err)
+	}
+	if !s.Closed() {
+		t.Fatal("Session should report closed after Close")
+	}
+}
+
+func TestNewSessionCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewSession(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestSessionCloseClosesManagers(t *testing.T) {
+	var closed []string
+	s := &Session{}
+
+	first := &fakeManager{name: "pointer", closed: &closed}
+	second := &fakeManager{name: "keyboard", closed: &closed}
+	if err := s.Attach(first); err != nil {
+		t.Fatalf("Attach failed: %v", err)
+	}
+	if err := s.Attach(second); err != nil {
+		t.Fatalf("Attach failed: %v", err)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// Managers are closed most recent first
+	if len(closed) != 2 || closed[0] != "keyboard" || closed[1] != "pointer" {
+		t.Fatalf("Unexpected close order: %v", closed)
+	}
+
+	// Closing twice is a no-op
+	if err := s.Close(); err != nil {
+		t.Fatalf("Second Close failed: %v", err)
+	}
+	if len(closed) != 2 {
+		t.Fatalf("Managers closed twice: %v", closed)
+	}
+}
+
+func TestSessionDetach(t *testing.T) {
+	var closed []string
+	s := &Session{}
+
+	manager := &fakeManager{name: "output", closed: &closed}
+	if err := s.Attach(manager); err != nil {
+		t.Fatalf("Attach failed: %v", err)
+	}
+	s.Detach(manager)
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if len(closed) != 0 {
+		t.Fatalf("Detached manager should not be closed: %v", closed)
+	}
+}
+
+func TestSessionAttachAfterClose(t *testing.T) {
+	var closed []string
+	s := &Session{}
+	_ = s.Close()
+
+	err := s.Attach(&fakeManager{name: "late", closed: &closed})
+	if !errors.Is(err, ErrSessionClosed) {
+		t.Fatalf("Expected ErrSessionClosed, got %v", err)
+	}
+}
+
+func TestSessionCloseJoinsErrors(t *testing.T) {
+	var closed []string
+	s := &Session{}
+	failure := errors.New("destroy failed")
+
+	_ = s.Attach(&fakeManager{name: "broken", closed: &closed, err: failure})
+	if err := s.Close(); !errors.Is(err, failure) {
+		t.Fatalf("Expected manager error to be reported, got %v", err)
+	}
+}
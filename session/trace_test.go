@@ -0,0 +1,128 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bnema/libwldevices-go/wire_capture"
+)
+
+// syncBuffer is a bytes.Buffer safe for the concurrent writes of the relay
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestWithWireTrace(t *testing.T) {
+	fd, server := socketPair(t)
+	newFakeRegistry(server, []Seat{{Name: "seat0", Capabilities: SeatCapabilityKeyboard}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var trace syncBuffer
+	s, err := NewSession(ctx, WithSocketFD(fd), WithWireTrace(&trace))
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	if _, ok := s.FindSeat("seat0"); !ok {
+		t.Fatal("Seat not found through the tracing relay")
+	}
+
+	out := trace.String()
+	for _, want := range []string{
+		` -> wl_display@1.sync(new id wl_callback@3)`,
+		`] wl_registry@2.global(1, "wl_seat", 7)`,
+		` -> wl_registry@2.bind(1, "wl_seat", 7, new id wl_seat@6)`,
+		`] wl_seat@6.capabilities(2)`,
+		`] wl_seat@6.name("seat0")`,
+		`] wl_callback@5.done(0)`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Trace is missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestWithWireCapture(t *testing.T) {
+	fd, server := socketPair(t)
+	newFakeRegistry(server, []Seat{{Name: "seat0", Capabilities: SeatCapabilityKeyboard}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var out syncBuffer
+	s, err := NewSession(ctx, WithSocketFD(fd), WithWireCapture(&out))
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	_ = s.Close()
+
+	capture, err := wire_capture.Parse(strings.NewReader(out.String()))
+	if err != nil {
+		t.Fatalf("Capture can't be read back: %v\n%s", err, out.String())
+	}
+	if first := capture.Messages[0].String(); first != "-> wl_display@1.get_registry(new id wl_registry@2)" {
+		t.Errorf("The capture should start with get_registry, got %s", first)
+	}
+
+	// A new session sees the same seat from the replay
+	replay, err := wire_capture.NewReplay(capture)
+	if err != nil {
+		t.Fatalf("Failed to start replay: %v", err)
+	}
+	defer func() { _ = replay.Close() }()
+	replayed, err := NewSession(ctx, WithSocketPath(replay.Path()))
+	if err != nil {
+		t.Fatalf("Failed to connect to the replay: %v (%v)", err, replay.Err())
+	}
+	defer func() { _ = replayed.Close() }()
+	if seat, ok := replayed.FindSeat("seat0"); !ok || !seat.HasKeyboard() {
+		t.Errorf("Seat not replayed: %+v", replayed.Seats())
+	}
+	if err := replay.Wait(ctx); err != nil {
+		t.Errorf("Replay failed: %v", err)
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	fd, server := socketPair(t)
+	newFakeRegistry(server, []Seat{{Name: "seat0"}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var logs syncBuffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	s, err := NewSession(ctx, WithSocketFD(fd), WithLogger(logger))
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	if s.Logger() != logger {
+		t.Error("Session should expose the configured logger")
+	}
+	if out := logs.String(); !strings.Contains(out, `msg="global announced" interface=wl_seat version=7`) {
+		t.Errorf("Expected the seat global to be logged:\n%s", out)
+	}
+}
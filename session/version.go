@@ -0,0 +1,16 @@
+package session
+
+import (
+	"github.com/bnema/libwldevices-go/internal/protocols"
+)
+
+// VersionError is returned when a request or event needs a newer protocol
+// version than the one negotiated with the compositor. Globals are bound at
+// the lower of the advertised version and the version this module implements;
+// each manager reports it through its Version method.
+//
+//	var verr *session.VersionError
+//	if errors.As(err, &verr) {
+//		log.Printf("%s needs %s v%d", verr.Feature, verr.Interface, verr.Since)
+//	}
+type VersionError = protocols.VersionError
@@ -0,0 +1,29 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestVersionError(t *testing.T) {
+	err := fmt.Errorf("release failed: %w", &VersionError{
+		Interface: "zwlr_output_head_v1",
+		Feature:   "release",
+		Since:     3,
+		Version:   2,
+	})
+
+	var verr *VersionError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Expected a VersionError, got %v", err)
+	}
+	if verr.Since != 3 || verr.Version != 2 {
+		t.Errorf("Unexpected versions: %+v", verr)
+	}
+
+	want := "release failed: zwlr_output_head_v1.release requires version 3, bound version is 2"
+	if err.Error() != want {
+		t.Errorf("Expected %q, got %q", want, err.Error())
+	}
+}
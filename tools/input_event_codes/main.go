@@ -0,0 +1,156 @@
+// Command input_event_codes generates the key and button tables of package
+// input_event_codes from the kernel header linux/input-event-codes.h.
+//
+// Usage:
+//
+//	go run ./tools/input_event_codes -header=/usr/include/linux/input-event-codes.h -output=input_event_codes/codes.go
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// #define KEY_VOLUMEUP		115
+	// #define KEY_HANGUEL		KEY_HANGEUL
+	// #define KEY_CNT			(KEY_MAX+1)
+	defineRe = regexp.MustCompile(`^#define\s+((?:KEY|BTN)_\w+)\s+(0x[0-9a-fA-F]+|\d+|(?:KEY|BTN)_\w+|\(((?:KEY|BTN)_\w+)\+(\d+)\))\s*(.*)$`)
+	spaceRe  = regexp.MustCompile(`\s+`)
+)
+
+// code is a define of the header
+type code struct {
+	name    string
+	value   uint32
+	expr    string // The Go expression when the define refers to another one
+	comment string
+}
+
+func parse(path string) ([]code, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var codes []code
+	values := make(map[string]uint32)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		m := defineRe.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		c := code{name: m[1]}
+		switch {
+		case m[3] != "":
+			// (KEY_MAX+1)
+			base, ok := values[m[3]]
+			if !ok {
+				return nil, fmt.Errorf("%s: undefined %s", c.name, m[3])
+			}
+			n, _ := strconv.ParseUint(m[4], 10, 32)
+			c.value, c.expr = base+uint32(n), m[3]+" + "+m[4]
+		case strings.HasPrefix(m[2], "KEY_") || strings.HasPrefix(m[2], "BTN_"):
+			v, ok := values[m[2]]
+			if !ok {
+				return nil, fmt.Errorf("%s: undefined %s", c.name, m[2])
+			}
+			c.value, c.expr = v, m[2]
+		default:
+			v, err := strconv.ParseUint(m[2], 0, 32)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", c.name, err)
+			}
+			c.value = uint32(v)
+		}
+
+		// Comments may continue on the next lines
+		comment := m[5]
+		for strings.HasPrefix(comment, "/*") && !strings.Contains(comment, "*/") && scanner.Scan() {
+			comment += " " + strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(scanner.Text()), "*"))
+		}
+		comment = strings.TrimSuffix(strings.TrimPrefix(comment, "/*"), "*/")
+		c.comment = spaceRe.ReplaceAllString(strings.TrimSpace(comment), " ")
+
+		values[c.name] = c.value
+		codes = append(codes, c)
+	}
+	return codes, scanner.Err()
+}
+
+func main() {
+	header := flag.String("header", "/usr/include/linux/input-event-codes.h", "path of input-event-codes.h")
+	output := flag.String("output", "input_event_codes/codes.go", "file to write")
+	flag.Parse()
+
+	codes, err := parse(*header)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// The name of a code is its last numeric define: BTN_LEFT rather than
+	// BTN_MOUSE, which marks the start of the mouse buttons. Defines
+	// referring to others are old names or markers.
+	names := make(map[uint32]string)
+	var order []uint32
+	for _, c := range codes {
+		if c.expr != "" {
+			continue
+		}
+		if _, ok := names[c.value]; !ok {
+			order = append(order, c.value)
+		}
+		names[c.value] = c.name
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by tools/input_event_codes from linux/input-event-codes.h; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package input_event_codes\n\n")
+	fmt.Fprintf(&buf, "// Keys and buttons, in the order of the header\n")
+	fmt.Fprintf(&buf, "const (\n")
+	for _, c := range codes {
+		value := c.expr
+		if value == "" {
+			value = fmt.Sprintf("%d", c.value)
+			if c.value >= 0x100 {
+				value = fmt.Sprintf("0x%x", c.value)
+			}
+		}
+		fmt.Fprintf(&buf, "\t%s = %s", c.name, value)
+		if c.comment != "" {
+			fmt.Fprintf(&buf, " // %s", c.comment)
+		}
+		fmt.Fprintf(&buf, "\n")
+	}
+	fmt.Fprintf(&buf, ")\n\n")
+	fmt.Fprintf(&buf, "// codesByName holds every define of the header\n")
+	fmt.Fprintf(&buf, "var codesByName = map[string]uint32{\n")
+	for _, c := range codes {
+		fmt.Fprintf(&buf, "\t%q: %s,\n", c.name, c.name)
+	}
+	fmt.Fprintf(&buf, "}\n\n")
+	fmt.Fprintf(&buf, "// namesByCode holds the name of each code\n")
+	fmt.Fprintf(&buf, "var namesByCode = map[uint32]string{\n")
+	for _, v := range order {
+		fmt.Fprintf(&buf, "\t%s: %q,\n", names[v], names[v])
+	}
+	fmt.Fprintf(&buf, "}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*output, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,129 @@
+// Command keysyms generates the keysym table of package xkb from the X11
+// keysym headers.
+//
+// Usage:
+//
+//	go run ./tools/keysyms -include=/usr/include/X11 -output=xkb/keysym_table.go
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+var (
+	// #define XK_adiaeresis 0x00e4  /* U+00E4 LATIN SMALL LETTER A WITH DIAERESIS */
+	defineRe = regexp.MustCompile(`^#define\s+(XF86XK_|XK_)(\w+)\s+(0x[0-9a-fA-F]+|_EVDEVK\((0x[0-9a-fA-F]+)\))\s*(?:/\*\s*\(?U\+([0-9A-Fa-f]{4,6}))?`)
+)
+
+// keysym is a name from the headers
+type keysym struct {
+	name  string
+	value uint32
+	char  rune // 0 when the header gives no character
+}
+
+func parse(path string) ([]keysym, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var syms []keysym
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		m := defineRe.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		name := m[2]
+		if m[1] == "XF86XK_" {
+			name = "XF86" + name
+		}
+		var value uint64
+		if m[4] != "" {
+			// _EVDEVK(v) is 0x10081000 + v
+			value, err = strconv.ParseUint(m[4], 0, 32)
+			value += 0x10081000
+		} else {
+			value, err = strconv.ParseUint(m[3], 0, 32)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		s := keysym{name: name, value: uint32(value)}
+		if m[5] != "" {
+			c, _ := strconv.ParseUint(m[5], 16, 32)
+			s.char = rune(c)
+		}
+		syms = append(syms, s)
+	}
+	return syms, scanner.Err()
+}
+
+func main() {
+	include := flag.String("include", "/usr/include/X11", "directory holding keysymdef.h and XF86keysym.h")
+	output := flag.String("output", "xkb/keysym_table.go", "file to write")
+	flag.Parse()
+
+	var syms []keysym
+	for _, header := range []string{"keysymdef.h", "XF86keysym.h"} {
+		s, err := parse(filepath.Join(*include, header))
+		if err != nil {
+			log.Fatal(err)
+		}
+		syms = append(syms, s...)
+	}
+
+	// Characters of the keysyms that aren't Latin-1 or Unicode keysyms,
+	// which Keysym.Rune computes
+	chars := make(map[uint32]rune)
+	for _, s := range syms {
+		computed := s.value <= 0xff || (s.value >= 0x1000100 && s.value <= 0x110ffff)
+		if s.char != 0 && !computed {
+			if _, ok := chars[s.value]; !ok {
+				chars[s.value] = s.char
+			}
+		}
+	}
+	values := make([]uint32, 0, len(chars))
+	for v := range chars {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by tools/keysyms from keysymdef.h and XF86keysym.h; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package xkb\n\n")
+	fmt.Fprintf(&buf, "// keysymNames lists the keysyms by name, in the order of the headers:\n")
+	fmt.Fprintf(&buf, "// the first name of a keysym is its canonical one\n")
+	fmt.Fprintf(&buf, "var keysymNames = []struct {\n\tname string\n\tsym  Keysym\n}{\n")
+	for _, s := range syms {
+		fmt.Fprintf(&buf, "\t{%q, 0x%x},\n", s.name, s.value)
+	}
+	fmt.Fprintf(&buf, "}\n\n")
+	fmt.Fprintf(&buf, "// keysymChars maps the legacy keysyms to their character\n")
+	fmt.Fprintf(&buf, "var keysymChars = map[Keysym]rune{\n")
+	for _, v := range values {
+		fmt.Fprintf(&buf, "\t0x%x: 0x%x,\n", v, chars[v])
+	}
+	fmt.Fprintf(&buf, "}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*output, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,49 @@
+package virtual_keyboard
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// cadence is the timing of typing, set with WithPressDuration and the
+// options after it
+type cadence struct {
+	press    time.Duration // Key down in TypeKey
+	modifier time.Duration // After pressing modifiers, before releasing them
+	key      time.Duration // Between characters and chords
+	jitter   time.Duration // Random change of each pause, up to this much
+	burst    bool          // No pauses, one roundtrip at the end
+}
+
+var defaultCadence = cadence{
+	press:    10 * time.Millisecond,
+	modifier: 5 * time.Millisecond,
+	key:      20 * time.Millisecond,
+}
+
+// pause waits for d, changed by the jitter, or until ctx is done
+func (c cadence) pause(ctx context.Context, d time.Duration) error {
+	if c.burst {
+		return ctx.Err()
+	}
+	if c.jitter > 0 {
+		d += time.Duration(rand.Int64N(int64(2*c.jitter)+1)) - c.jitter
+	}
+	return sleep(ctx, d)
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,178 @@
+package virtual_keyboard
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bnema/libwldevices-go/input_event_codes"
+	"github.com/bnema/libwldevices-go/xkb"
+)
+
+// Chord is a keyboard shortcut: Key tapped while the Modifiers keys are held.
+// Keys are input event codes of the keymap the chord was parsed with.
+type Chord struct {
+	Modifiers []uint32 // Pressed in order, released in reverse order
+	Key       uint32
+}
+
+// modifierAliases maps the modifier names of shortcuts to modifiers of
+// keymaps. Other names are looked up in the keymap, such as Mod5 or NumLock.
+var modifierAliases = map[string]string{
+	"shift":   "Shift",
+	"ctrl":    "Control",
+	"control": "Control",
+	"alt":     "Alt",
+	"super":   "Super",
+	"win":     "Super",
+	"logo":    "Super",
+	"meta":    "Meta",
+	"hyper":   "Hyper",
+	"altgr":   "LevelThree",
+}
+
+// ParseChords parses shortcuts separated by spaces, such as "ctrl+k ctrl+c",
+// with the keys of keymap. Each shortcut is modifiers and a key joined by
+// +: ctrl+shift+t, super+Return, alt+F4.
+//
+// Modifiers are shift, ctrl, alt, super (or win, logo), meta, hyper, altgr,
+// or a modifier of the keymap such as Mod5. The key is a keysym name, in any
+// case (Return, f4, XF86AudioMute), a character (ctrl+/ or ctrl++), a key
+// name of the keymap in angle brackets (<AE01>), an input event code name
+// (KEY_VOLUMEUP), or a modifier alone (super). Modifiers the key needs in
+// the keymap are added, so ctrl+at is typed with Shift on a US keymap.
+func ParseChords(keymap *xkb.Keymap, chords string) ([]Chord, error) {
+	fields := strings.Fields(chords)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty chord %q", chords)
+	}
+	parsed := make([]Chord, 0, len(fields))
+	for _, field := range fields {
+		chord, err := parseChord(keymap, field)
+		if err != nil {
+			return nil, fmt.Errorf("chord %q: %w", field, err)
+		}
+		parsed = append(parsed, chord)
+	}
+	return parsed, nil
+}
+
+func parseChord(keymap *xkb.Keymap, field string) (Chord, error) {
+	parts := strings.Split(field, "+")
+	if strings.HasSuffix(field, "++") || field == "+" {
+		// The key is + itself
+		parts = append(parts[:len(parts)-2], "+")
+	}
+	for _, part := range parts {
+		if part == "" {
+			return Chord{}, fmt.Errorf("missing key around +")
+		}
+	}
+
+	var chord Chord
+	var held xkb.ModMask
+	addModifiers := func(mods xkb.ModMask) error {
+		keys, err := keymap.ModifierKeys(mods)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if !slices.Contains(chord.Modifiers, key) {
+				chord.Modifiers = append(chord.Modifiers, key)
+			}
+		}
+		held |= mods
+		return nil
+	}
+	for _, part := range parts[:len(parts)-1] {
+		mods, err := modifierMask(keymap, part)
+		if err != nil {
+			return Chord{}, err
+		}
+		if err := addModifiers(mods); err != nil {
+			return Chord{}, err
+		}
+	}
+
+	last := parts[len(parts)-1]
+	stroke, err := chordKey(keymap, last)
+	if err != nil {
+		// A modifier alone, such as super to open a launcher
+		mods, modErr := modifierMask(keymap, last)
+		if modErr != nil {
+			return Chord{}, err
+		}
+		keys, modErr := keymap.ModifierKeys(mods)
+		if modErr != nil {
+			return Chord{}, modErr
+		}
+		stroke = xkb.Keystroke{Key: keys[0]}
+	}
+	if extra := stroke.Modifiers &^ held; extra != 0 {
+		if err := addModifiers(extra); err != nil {
+			return Chord{}, err
+		}
+	}
+	chord.Key = stroke.Key
+	return chord, nil
+}
+
+// modifierMask returns the real modifiers of a modifier name or alias
+func modifierMask(keymap *xkb.Keymap, name string) (xkb.ModMask, error) {
+	if alias, ok := modifierAliases[strings.ToLower(name)]; ok {
+		name = alias
+	}
+	return keymap.ModifierMask(name)
+}
+
+// chordKey returns how the keymap types the key of a chord
+func chordKey(keymap *xkb.Keymap, name string) (xkb.Keystroke, error) {
+	if strings.HasPrefix(name, "<") && strings.HasSuffix(name, ">") && len(name) > 2 {
+		key, err := keymap.KeyFromName(name[1 : len(name)-1])
+		return xkb.Keystroke{Key: key}, err
+	}
+	if code, ok := input_event_codes.Code(name); ok && strings.HasPrefix(name, "KEY_") {
+		return xkb.Keystroke{Key: code}, nil
+	}
+	if r, size := utf8.DecodeRuneInString(name); size == len(name) && r != utf8.RuneError {
+		strokes, err := keymap.Keystrokes(r)
+		if err != nil {
+			return xkb.Keystroke{}, err
+		}
+		return strokes[0], nil
+	}
+	sym, ok := xkb.KeysymFromNameFold(name)
+	if !ok {
+		return xkb.Keystroke{}, fmt.Errorf("unknown key %s", name)
+	}
+	strokes, err := keymap.KeystrokesForKeysym(sym)
+	if err != nil {
+		return xkb.Keystroke{}, err
+	}
+	return strokes[0], nil
+}
+
+// TypeChord sends shortcuts such as "ctrl+shift+t" or "ctrl+k ctrl+c", see
+// ParseChords: for each one it presses the modifiers, taps the key and
+// releases the modifiers in reverse order. Nothing is sent when a chord
+// doesn't parse.
+func (k *VirtualKeyboard) TypeChord(chords string) error {
+	parsed, err := ParseChords(k.Keymap(), chords)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	for i, chord := range parsed {
+		if i > 0 {
+			if err := k.cadence.pause(ctx, k.cadence.key); err != nil {
+				return err
+			}
+		}
+		if err := k.typeWithModifiers(ctx, chord.Modifiers, chord.Key); err != nil {
+			return err
+		}
+	}
+	return k.flush(ctx)
+}
@@ -0,0 +1,55 @@
+package virtual_keyboard
+
+import "github.com/bnema/libwldevices-go/xkb"
+
+// LockHandling is how TypeString deals with the modifiers locked on the
+// keyboard, such as Caps Lock. Only the locks of the virtual keyboard count:
+// those of other keyboards of the seat don't change what it types.
+type LockHandling int
+
+const (
+	// CompensateLocks types with the locks on, picking the keys and
+	// modifiers that type the text anyway: Shift for lowercase letters with
+	// Caps Lock. Characters typed WithUnicode are made uppercase by Caps
+	// Lock.
+	CompensateLocks LockHandling = iota
+	// NeutralizeLocks unlocks the modifiers while typing and locks them
+	// again after. Applications see the locks change.
+	NeutralizeLocks
+)
+
+// SetLockedModifiers locks the modifiers of locked and unlocks the others,
+// keeping those held down and latched: SetLockedModifiers(xkb.ModLock) turns
+// Caps Lock on and any other lock off. Only a modifiers event is sent, no
+// lock key is pressed.
+func (k *VirtualKeyboard) SetLockedModifiers(locked xkb.ModMask) error {
+	m := k.ModifierState()
+	return k.Modifiers(uint32(m.Depressed), uint32(m.Latched), uint32(locked), m.Group)
+}
+
+// SetCapsLock turns Caps Lock on or off, see SetLockedModifiers
+func (k *VirtualKeyboard) SetCapsLock(on bool) error {
+	return k.setLock("Lock", on)
+}
+
+// SetNumLock turns Num Lock on or off, see SetLockedModifiers. It fails
+// when the keymap has no Num Lock key.
+func (k *VirtualKeyboard) SetNumLock(on bool) error {
+	return k.setLock("NumLock", on)
+}
+
+// setLock locks or unlocks the modifier called name, leaving the other
+// locks as they are
+func (k *VirtualKeyboard) setLock(name string, on bool) error {
+	mods, err := k.Keymap().ModifierMask(name)
+	if err != nil {
+		return err
+	}
+	locked := k.ModifierState().Locked
+	if on {
+		locked |= mods
+	} else {
+		locked &^= mods
+	}
+	return k.SetLockedModifiers(locked)
+}
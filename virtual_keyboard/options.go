@@ -0,0 +1,175 @@
+package virtual_keyboard
+
+import (
+	"context"
+	"time"
+
+	"github.com/bnema/libwldevices-go/xkb"
+)
+
+// KeyboardOption configures a virtual keyboard created by CreateKeyboard
+type KeyboardOption func(*keyboardOptions)
+
+// keyboardOptions holds the settings collected from KeyboardOption values
+type keyboardOptions struct {
+	seat    string
+	keymap  *xkb.Keymap
+	err     error // From building the keymap, returned by CreateKeyboard
+	unicode bool
+	compose *xkb.ComposeTable
+	locks   LockHandling
+	cadence cadence
+
+	releaseCtx context.Context
+	watchdog   time.Duration
+}
+
+// WithSeat creates the keyboard on the seat with the given name (see
+// session.Session.Seats) instead of the first seat announced by the compositor.
+func WithSeat(name string) KeyboardOption {
+	return func(o *keyboardOptions) {
+		o.seat = name
+	}
+}
+
+// WithKeymap gives the keyboard keymap instead of xkb.DefaultKeymap. The
+// keymap decides which characters the key codes sent by the keyboard produce.
+func WithKeymap(keymap *xkb.Keymap) KeyboardOption {
+	return func(o *keyboardOptions) {
+		o.keymap, o.err = keymap, nil
+	}
+}
+
+// WithKeymapString gives the keyboard the keymap in text, see
+// xkb.NewKeymapFromString
+func WithKeymapString(text string) KeyboardOption {
+	return func(o *keyboardOptions) {
+		o.keymap, o.err = xkb.NewKeymapFromString(text)
+	}
+}
+
+// WithKeymapFile gives the keyboard the keymap in the file at path, see
+// xkb.NewKeymapFromFile
+func WithKeymapFile(path string) KeyboardOption {
+	return func(o *keyboardOptions) {
+		o.keymap, o.err = xkb.NewKeymapFromFile(path)
+	}
+}
+
+// WithKeymapNames gives the keyboard the keymap selected by rules, model,
+// layout, variant and options, for example:
+//
+//	manager.CreateKeyboard(virtual_keyboard.WithKeymapNames(xkb.RuleNames{Layout: "fr"}))
+func WithKeymapNames(names xkb.RuleNames) KeyboardOption {
+	return func(o *keyboardOptions) {
+		o.keymap, o.err = xkb.NewKeymapFromNames(names)
+	}
+}
+
+// WithUnicode lets TypeString type characters missing from the keymap, such
+// as emoji, like wtype does: it binds them to spare keys of a temporary
+// keymap (see xkb.Keymap.BindKeysyms), types the text and restores the
+// keymap. Text with more such characters than spare keys is typed in
+// chunks, each with its own keymap. Applications see the keymap change.
+func WithUnicode() KeyboardOption {
+	return func(o *keyboardOptions) {
+		o.unicode = true
+	}
+}
+
+// WithCompose lets TypeString type characters missing from the keymap with
+// the dead keys or the Compose key (Multi_key) of the keymap, using the
+// sequences of table, for example:
+//
+//	table, err := xkb.NewComposeTableFromLocale("")
+//	keyboard, err := manager.CreateKeyboard(
+//		virtual_keyboard.WithKeymapNames(xkb.RuleNames{Layout: "us", Variant: "intl"}),
+//		virtual_keyboard.WithCompose(table))
+//
+// Applications compose the characters with the table of their own locale,
+// so table must match it. Characters still missing are left to WithUnicode.
+func WithCompose(table *xkb.ComposeTable) KeyboardOption {
+	return func(o *keyboardOptions) {
+		o.compose = table
+	}
+}
+
+// WithLockHandling sets how TypeString deals with Caps Lock, Num Lock and
+// the other modifiers locked on the keyboard, CompensateLocks by default
+func WithLockHandling(h LockHandling) KeyboardOption {
+	return func(o *keyboardOptions) {
+		o.locks = h
+	}
+}
+
+// WithPressDuration sets how long TypeKey and TypeString hold each key down,
+// 10ms by default
+func WithPressDuration(d time.Duration) KeyboardOption {
+	return func(o *keyboardOptions) {
+		o.cadence.press = d
+	}
+}
+
+// WithKeyDelay sets the pause between the characters of TypeString and the
+// chords of TypeChord, 20ms by default
+func WithKeyDelay(d time.Duration) KeyboardOption {
+	return func(o *keyboardOptions) {
+		o.cadence.key = d
+	}
+}
+
+// WithModifierDelay sets the pause between pressing modifiers such as Shift
+// and the key they modify, and before releasing them, 5ms by default
+func WithModifierDelay(d time.Duration) KeyboardOption {
+	return func(o *keyboardOptions) {
+		o.cadence.modifier = d
+	}
+}
+
+// WithJitter makes typing look human: each pause is lengthened or shortened
+// by a random duration up to max
+func WithJitter(max time.Duration) KeyboardOption {
+	return func(o *keyboardOptions) {
+		o.cadence.jitter = max
+	}
+}
+
+// WithBurst types as fast as possible: no pauses at all, and a single
+// roundtrip once everything is sent, so that TypeString returns when the
+// compositor has processed the keys. Some applications drop keys that come
+// this fast.
+func WithBurst() KeyboardOption {
+	return func(o *keyboardOptions) {
+		o.cadence.burst = true
+	}
+}
+
+// WithReleaseContext releases the keys held when ctx is done, so that a
+// cancelled job doesn't leave Shift stuck down. The keyboard stays usable.
+func WithReleaseContext(ctx context.Context) KeyboardOption {
+	return func(o *keyboardOptions) {
+		o.releaseCtx = ctx
+	}
+}
+
+// WithWatchdog releases the keys held whenever Heartbeat isn't called for
+// timeout, for callers that may hang between a press and its release
+func WithWatchdog(timeout time.Duration) KeyboardOption {
+	return func(o *keyboardOptions) {
+		o.watchdog = timeout
+	}
+}
+
+// newKeyboardOptions applies opts on top of the defaults
+func newKeyboardOptions(opts []KeyboardOption) *keyboardOptions {
+	o := &keyboardOptions{cadence: defaultCadence}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	if o.keymap == nil && o.err == nil {
+		o.keymap = xkb.DefaultKeymap()
+	}
+	return o
+}
@@ -0,0 +1,64 @@
+package virtual_keyboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// rebind is the session reconnect hook: it binds the manager on the new
+// connection and gives every open keyboard a new proxy on the same seat,
+// with its keymap sent again and no keys or modifiers down
+func (m *VirtualKeyboardManager) rebind(ctx context.Context) error {
+	c := m.session.Client()
+	manager, name, err := bindManager(ctx, c)
+
+	m.mu.Lock()
+	if m.manager == nil {
+		// Closed while reconnecting
+		m.mu.Unlock()
+		if manager != nil {
+			_ = manager.Destroy()
+		}
+		return nil
+	}
+	m.client = c
+	if err != nil {
+		m.unavailable = true
+		handler := m.onUnavailable
+		m.mu.Unlock()
+
+		if handler != nil {
+			handler()
+		}
+		return err
+	}
+	m.manager = manager
+	m.unavailable = false
+	if m.unwatch != nil {
+		m.unwatch()
+	}
+	m.unwatch = c.WatchGlobal(name, m.handleGlobalRemoved)
+	keyboards := make([]*VirtualKeyboard, 0, len(m.keyboards))
+	for k := range m.keyboards {
+		keyboards = append(keyboards, k)
+	}
+	m.mu.Unlock()
+
+	var errs []error
+	for _, k := range keyboards {
+		keyboard, err := k.create(c, manager)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to recreate virtual keyboard: %w", err))
+			continue
+		}
+		k.keyboard.Store(keyboard)
+
+		// The new keyboard has no keys down
+		k.mu.Lock()
+		k.state = nil
+		k.mu.Unlock()
+		k.held.Forget()
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,86 @@
+package virtual_keyboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// HoldKey presses key, keeps it down for d and releases it. Clients repeat
+// the key meanwhile, at the rate and after the delay the compositor sends
+// them (wl_keyboard.repeat_info), as for a key held on a real keyboard. With
+// d <= 0 the key is held until ctx is done.
+//
+// The key is released when ctx is done, and ctx.Err() returned. Like every
+// key, it is also released by ReleaseAll, Close and the watchdog.
+func (k *VirtualKeyboard) HoldKey(ctx context.Context, key uint32, d time.Duration) error {
+	if err := k.PressKey(key); err != nil {
+		return err
+	}
+	var err error
+	if d > 0 {
+		err = sleep(ctx, d)
+	} else {
+		<-ctx.Done()
+		err = ctx.Err()
+	}
+	return errors.Join(err, k.ReleaseKey(key))
+}
+
+// RepeatKey repeats key itself rather than leaving it to clients: it taps
+// the key once, then after delay rate times per second, for d. Each tap is
+// held for the press duration of the keyboard (WithPressDuration), cut down
+// to half of delay and of the repeat interval, so a key is always released
+// well before it is pressed again and clients see separate key presses.
+// With d <= 0 the key is repeated until ctx is done. rate must be positive
+// and finite, and delay not negative.
+//
+// RepeatKey stops when ctx is done, releasing the key if it is down, and
+// returns ctx.Err().
+func (k *VirtualKeyboard) RepeatKey(ctx context.Context, key uint32, d, delay time.Duration, rate float64) error {
+	if rate <= 0 || math.IsNaN(rate) || math.IsInf(rate, 0) {
+		return fmt.Errorf("repeat rate must be positive, got %v", rate)
+	}
+	if delay < 0 {
+		return fmt.Errorf("repeat delay must not be negative, got %v", delay)
+	}
+	interval := time.Duration(float64(time.Second) / rate)
+	if interval <= 0 {
+		return fmt.Errorf("repeat rate %v is too high", rate)
+	}
+	press := k.cadence.press
+	if k.cadence.burst {
+		press = 0
+	}
+	press = min(press, interval/2)
+	if delay > 0 {
+		press = min(press, delay/2)
+	}
+
+	start := time.Now()
+	for next, taps := start, 0; d <= 0 || next.Sub(start) < d; taps++ {
+		if err := sleep(ctx, time.Until(next)); err != nil {
+			return err
+		}
+		if err := k.tapKey(ctx, key, press); err != nil {
+			return err
+		}
+		if taps == 0 {
+			next = next.Add(delay)
+		} else {
+			next = next.Add(interval)
+		}
+	}
+	return nil
+}
+
+// tapKey presses key and releases it after press, or once ctx is done
+func (k *VirtualKeyboard) tapKey(ctx context.Context, key uint32, press time.Duration) error {
+	if err := k.PressKey(key); err != nil {
+		return err
+	}
+	err := sleep(ctx, press)
+	return errors.Join(err, k.ReleaseKey(key))
+}
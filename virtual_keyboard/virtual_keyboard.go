@@ -19,7 +19,7 @@
 //	}
 //	defer keyboard.Close()
 //
-//	// Type text (handles uppercase/lowercase automatically)
+//	// Type text with the keys and modifiers of the keymap
 //	keyboard.TypeString("Hello World!")
 //
 //	// Press individual keys
@@ -38,76 +38,87 @@ package virtual_keyboard
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/bnema/libwldevices-go/input_event_codes"
 	"github.com/bnema/libwldevices-go/internal/client"
+	"github.com/bnema/libwldevices-go/internal/held"
 	"github.com/bnema/libwldevices-go/internal/protocols"
+	"github.com/bnema/libwldevices-go/session"
+	"github.com/bnema/libwldevices-go/xkb"
 )
 
-// Common key constants (Linux input event codes)
+// Common key constants (Linux input event codes). Package input_event_codes
+// has all of them, which Key and the other methods accept too.
 const (
-	KEY_A         = 30
-	KEY_B         = 48
-	KEY_C         = 46
-	KEY_D         = 32
-	KEY_E         = 18
-	KEY_F         = 33
-	KEY_G         = 34
-	KEY_H         = 35
-	KEY_I         = 23
-	KEY_J         = 36
-	KEY_K         = 37
-	KEY_L         = 38
-	KEY_M         = 50
-	KEY_N         = 49
-	KEY_O         = 24
-	KEY_P         = 25
-	KEY_Q         = 16
-	KEY_R         = 19
-	KEY_S         = 31
-	KEY_T         = 20
-	KEY_U         = 22
-	KEY_V         = 47
-	KEY_W         = 17
-	KEY_X         = 45
-	KEY_Y         = 21
-	KEY_Z         = 44
-	KEY_1         = 2
-	KEY_2         = 3
-	KEY_3         = 4
-	KEY_4         = 5
-	KEY_5         = 6
-	KEY_6         = 7
-	KEY_7         = 8
-	KEY_8         = 9
-	KEY_9         = 10
-	KEY_0         = 11
-	KEY_SPACE     = 57
-	KEY_ENTER     = 28
-	KEY_TAB       = 15
-	KEY_BACKSPACE = 14
-	KEY_ESC       = 1
-	KEY_CAPSLOCK  = 58
-	KEY_LEFTSHIFT = 42
-	KEY_LEFTCTRL  = 29
-	KEY_LEFTALT   = 56
-	KEY_LEFTMETA  = 125
-	
+	KEY_A         = input_event_codes.KEY_A
+	KEY_B         = input_event_codes.KEY_B
+	KEY_C         = input_event_codes.KEY_C
+	KEY_D         = input_event_codes.KEY_D
+	KEY_E         = input_event_codes.KEY_E
+	KEY_F         = input_event_codes.KEY_F
+	KEY_G         = input_event_codes.KEY_G
+	KEY_H         = input_event_codes.KEY_H
+	KEY_I         = input_event_codes.KEY_I
+	KEY_J         = input_event_codes.KEY_J
+	KEY_K         = input_event_codes.KEY_K
+	KEY_L         = input_event_codes.KEY_L
+	KEY_M         = input_event_codes.KEY_M
+	KEY_N         = input_event_codes.KEY_N
+	KEY_O         = input_event_codes.KEY_O
+	KEY_P         = input_event_codes.KEY_P
+	KEY_Q         = input_event_codes.KEY_Q
+	KEY_R         = input_event_codes.KEY_R
+	KEY_S         = input_event_codes.KEY_S
+	KEY_T         = input_event_codes.KEY_T
+	KEY_U         = input_event_codes.KEY_U
+	KEY_V         = input_event_codes.KEY_V
+	KEY_W         = input_event_codes.KEY_W
+	KEY_X         = input_event_codes.KEY_X
+	KEY_Y         = input_event_codes.KEY_Y
+	KEY_Z         = input_event_codes.KEY_Z
+	KEY_1         = input_event_codes.KEY_1
+	KEY_2         = input_event_codes.KEY_2
+	KEY_3         = input_event_codes.KEY_3
+	KEY_4         = input_event_codes.KEY_4
+	KEY_5         = input_event_codes.KEY_5
+	KEY_6         = input_event_codes.KEY_6
+	KEY_7         = input_event_codes.KEY_7
+	KEY_8         = input_event_codes.KEY_8
+	KEY_9         = input_event_codes.KEY_9
+	KEY_0         = input_event_codes.KEY_0
+	KEY_SPACE     = input_event_codes.KEY_SPACE
+	KEY_ENTER     = input_event_codes.KEY_ENTER
+	KEY_TAB       = input_event_codes.KEY_TAB
+	KEY_BACKSPACE = input_event_codes.KEY_BACKSPACE
+	KEY_ESC       = input_event_codes.KEY_ESC
+	KEY_CAPSLOCK  = input_event_codes.KEY_CAPSLOCK
+	KEY_LEFTSHIFT = input_event_codes.KEY_LEFTSHIFT
+	KEY_LEFTCTRL  = input_event_codes.KEY_LEFTCTRL
+	KEY_LEFTALT   = input_event_codes.KEY_LEFTALT
+	KEY_LEFTMETA  = input_event_codes.KEY_LEFTMETA
+
 	// Additional keys for special characters
-	KEY_MINUS        = 12  // - and _
-	KEY_EQUAL        = 13  // = and +
-	KEY_LEFTBRACE    = 26  // [ and {
-	KEY_RIGHTBRACE   = 27  // ] and }
-	KEY_SEMICOLON    = 39  // ; and :
-	KEY_APOSTROPHE   = 40  // ' and "
-	KEY_GRAVE        = 41  // ` and ~
-	KEY_BACKSLASH    = 43  // \ and |
-	KEY_COMMA        = 51  // , and <
-	KEY_DOT          = 52  // . and >
-	KEY_SLASH        = 53  // / and ?
-	KEY_RIGHTSHIFT   = 54
+	KEY_MINUS      = input_event_codes.KEY_MINUS      // - and _
+	KEY_EQUAL      = input_event_codes.KEY_EQUAL      // = and +
+	KEY_LEFTBRACE  = input_event_codes.KEY_LEFTBRACE  // [ and {
+	KEY_RIGHTBRACE = input_event_codes.KEY_RIGHTBRACE // ] and }
+	KEY_SEMICOLON  = input_event_codes.KEY_SEMICOLON  // ; and :
+	KEY_APOSTROPHE = input_event_codes.KEY_APOSTROPHE // ' and "
+	KEY_GRAVE      = input_event_codes.KEY_GRAVE      // ` and ~
+	KEY_BACKSLASH  = input_event_codes.KEY_BACKSLASH  // \ and |
+	KEY_COMMA      = input_event_codes.KEY_COMMA      // , and <
+	KEY_DOT        = input_event_codes.KEY_DOT        // . and >
+	KEY_SLASH      = input_event_codes.KEY_SLASH      // / and ?
+	KEY_RIGHTSHIFT = input_event_codes.KEY_RIGHTSHIFT
 )
 
 // Key state constants
@@ -131,63 +142,139 @@ const (
 	KeyStatePressed  KeyState = 1 // Key is pressed
 )
 
+// Protocol errors of virtual-keyboard-unstable-v1, for use with errors.Is
+var (
+	// ErrUnauthorized is raised when the compositor doesn't let the client
+	// create virtual keyboards
+	ErrUnauthorized = &session.ProtocolError{
+		Interface: protocols.VirtualKeyboardManagerInterface,
+		Code:      0,
+		Name:      "unauthorized",
+	}
+	// ErrNoKeymap is raised when a key is sent before the keymap; the
+	// keyboard methods check for it and return session.ErrKeymapNotSet instead
+	ErrNoKeymap = &session.ProtocolError{
+		Interface: protocols.VirtualKeyboardInterface,
+		Code:      0,
+		Name:      "no_keymap",
+	}
+)
+
 // VirtualKeyboardManager manages virtual keyboard devices
 type VirtualKeyboardManager struct {
-	client  *client.Client
-	manager *protocols.VirtualKeyboardManager
+	session     *session.Session
+	client      *client.Client
+	manager     *protocols.VirtualKeyboardManager
+	ownsSession bool
+
+	mu            sync.Mutex
+	unavailable   bool
+	onUnavailable func()
+	unwatch       func()
+	removeHook    func()
+	keyboards     map[*VirtualKeyboard]struct{} // Recreated after a reconnection
 }
 
 // VirtualKeyboard represents a virtual keyboard device
 type VirtualKeyboard struct {
-	keyboard  *protocols.VirtualKeyboard
-	client    *client.Client
+	// Swapped for a new proxy when the session reconnects
+	keyboard  atomic.Pointer[protocols.VirtualKeyboard]
+	manager   *VirtualKeyboardManager
+	seat      string
 	keymapSet bool
+	unicode   bool              // Types missing characters with temporary keymaps
+	compose   *xkb.ComposeTable // Types missing characters with dead keys
+	locks     LockHandling
+	cadence   cadence
+	held      *held.Tracker
+
+	mu     sync.Mutex
+	keymap *xkb.Keymap // Sent again after a reconnection
+	state  *xkb.State  // Modifiers of the keys sent, nil until the first key
 }
 
-// NewVirtualKeyboardManager creates a new virtual keyboard manager
-func NewVirtualKeyboardManager(ctx context.Context) (*VirtualKeyboardManager, error) {
-	// Check if context is already cancelled
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
+// Keyboard is what VirtualKeyboard offers to callers. Accept a Keyboard
+// rather than a *VirtualKeyboard to swap in fake_input.Keyboard in tests.
+type Keyboard interface {
+	SetKeymap(keymap *xkb.Keymap) error
+	Key(timestamp time.Time, key uint32, state KeyState) error
+	Modifiers(modsDepressed, modsLatched, modsLocked, group uint32) error
+	SetLockedModifiers(locked xkb.ModMask) error
+	SetCapsLock(on bool) error
+	SetNumLock(on bool) error
+	PressKey(key uint32) error
+	ReleaseKey(key uint32) error
+	TypeKey(key uint32) error
+	HoldKey(ctx context.Context, key uint32, d time.Duration) error
+	RepeatKey(ctx context.Context, key uint32, d, delay time.Duration, rate float64) error
+	TypeString(text string) error
+	TypeStringContext(ctx context.Context, text string) error
+	TypeChord(chords string) error
+	ReleaseAll() error
+	Close() error
+}
+
+var _ Keyboard = (*VirtualKeyboard)(nil)
+
+// NewVirtualKeyboardManager creates a new virtual keyboard manager on its own Wayland connection.
+// The options select the compositor to connect to, see session.NewSession.
+func NewVirtualKeyboardManager(ctx context.Context, opts ...session.Option) (*VirtualKeyboardManager, error) {
+	s, err := session.NewSession(ctx, opts...)
+	if err != nil {
+		return nil, err
 	}
-	
-	// Create Wayland client with timeout
-	type clientResult struct {
-		client *client.Client
-		err    error
+
+	manager, err := newVirtualKeyboardManager(ctx, s, true)
+	if err != nil {
+		_ = s.Close()
+		return nil, err
 	}
-	
-	clientCh := make(chan clientResult, 1)
-	go func() {
-		c, err := client.NewClient()
-		clientCh <- clientResult{client: c, err: err}
-	}()
-	
-	// Wait for client creation or context cancellation
-	var c *client.Client
-	select {
-	case result := <-clientCh:
-		if result.err != nil {
-			return nil, fmt.Errorf("failed to create Wayland client: %w", result.err)
+	return manager, nil
+}
+
+// NewVirtualKeyboardManagerFromSession creates a virtual keyboard manager on a shared session.
+// The manager is closed automatically when the session is closed.
+func NewVirtualKeyboardManagerFromSession(ctx context.Context, s *session.Session) (*VirtualKeyboardManager, error) {
+	return newVirtualKeyboardManager(ctx, s, false)
+}
+
+func newVirtualKeyboardManager(ctx context.Context, s *session.Session, ownsSession bool) (*VirtualKeyboardManager, error) {
+	c := s.Client()
+	manager, name, err := bindManager(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &VirtualKeyboardManager{
+		session:     s,
+		client:      c,
+		manager:     manager,
+		ownsSession: ownsSession,
+		keyboards:   make(map[*VirtualKeyboard]struct{}),
+	}
+	if !ownsSession {
+		if err := s.Attach(m); err != nil {
+			_ = manager.Destroy()
+			return nil, err
 		}
-		c = result.client
-	case <-ctx.Done():
-		return nil, fmt.Errorf("context cancelled during client creation: %w", ctx.Err())
 	}
+	m.unwatch = c.WatchGlobal(name, m.handleGlobalRemoved)
+	m.removeHook = s.AddReconnectHook(m.rebind)
+	return m, nil
+}
 
+// bindManager binds the virtual keyboard manager global of c and returns it
+// with its registry name
+func bindManager(ctx context.Context, c *client.Client) (*protocols.VirtualKeyboardManager, uint32, error) {
 	// Check if virtual keyboard protocol is available
 	if !c.HasVirtualKeyboard() {
-		c.Close()
-		return nil, fmt.Errorf("zwp_virtual_keyboard_manager_v1 not available")
+		return nil, 0, fmt.Errorf("zwp_virtual_keyboard_manager_v1: %w", session.ErrProtocolUnavailable)
 	}
 
 	// Check context before binding
 	select {
 	case <-ctx.Done():
-		_ = c.Close()
-		return nil, fmt.Errorf("context cancelled before binding: %w", ctx.Err())
+		return nil, 0, fmt.Errorf("context cancelled before binding: %w", ctx.Err())
 	default:
 	}
 	
@@ -196,73 +283,119 @@ func NewVirtualKeyboardManager(ctx context.Context) (*VirtualKeyboardManager, er
 
 	// Bind to the global
 	name := c.GetKeyboardManagerName()
-	err := c.GetRegistry().Bind(name, protocols.VirtualKeyboardManagerInterface, 1, manager)
+	version := protocols.NegotiateVersion(c.GlobalVersion(name), protocols.VirtualKeyboardManagerVersion)
+	manager.SetVersion(version)
+	err := c.Bind(name, protocols.VirtualKeyboardManagerInterface, version, manager)
 	if err != nil {
-		_ = c.Close()
-		return nil, fmt.Errorf("failed to bind virtual keyboard manager: %w", err)
+		return nil, 0, fmt.Errorf("failed to bind virtual keyboard manager: %w", err)
 	}
 
 	// Sync to ensure binding is complete
-	if err := c.GetDisplay().Roundtrip(); err != nil {
-		c.Close()
-		return nil, fmt.Errorf("failed to roundtrip after binding: %w", err)
+	if err := c.RoundtripContext(ctx); err != nil {
+		_ = manager.Destroy()
+		return nil, 0, fmt.Errorf("failed to roundtrip after binding: %w", err)
 	}
+	c.Logger().Debug("bound virtual keyboard manager", "name", name, "version", version)
+	return manager, name, nil
+}
+
+// CreateKeyboard creates a new virtual keyboard device on the default seat,
+// or on the seat selected with WithSeat
+func (m *VirtualKeyboardManager) CreateKeyboard(opts ...KeyboardOption) (*VirtualKeyboard, error) {
+	m.mu.Lock()
+	c, manager, unavailable := m.client, m.manager, m.unavailable
+	m.mu.Unlock()
 
-	return &VirtualKeyboardManager{
-		client:  c,
-		manager: manager,
-	}, nil
+	if manager == nil {
+		return nil, fmt.Errorf("virtual keyboard %w", session.ErrManagerClosed)
+	}
+	if unavailable {
+		return nil, fmt.Errorf("zwp_virtual_keyboard_manager_v1 was removed: %w", session.ErrProtocolUnavailable)
+	}
+	o := newKeyboardOptions(opts)
+	if o.err != nil {
+		return nil, fmt.Errorf("invalid keymap: %w", o.err)
+	}
+
+	vk := &VirtualKeyboard{
+		manager: m,
+		seat:    o.seat,
+		keymap:  o.keymap,
+		unicode: o.unicode,
+		compose: o.compose,
+		locks:   o.locks,
+		cadence: o.cadence,
+	}
+
+	// Create virtual keyboard on the selected seat, with its keymap
+	keyboard, err := vk.create(c, manager)
+	if err != nil {
+		return nil, err
+	}
+	vk.keyboard.Store(keyboard)
+	vk.keymapSet = true
+	vk.held = held.NewTracker(vk.release)
+	if o.releaseCtx != nil {
+		vk.held.ReleaseWhenDone(o.releaseCtx)
+	}
+	if o.watchdog > 0 {
+		vk.held.StartWatchdog(o.watchdog)
+	}
+
+	m.mu.Lock()
+	m.keyboards[vk] = struct{}{}
+	m.mu.Unlock()
+	return vk, nil
 }
 
-// CreateKeyboard creates a new virtual keyboard device
-func (m *VirtualKeyboardManager) CreateKeyboard() (*VirtualKeyboard, error) {
-	// Create virtual keyboard using the current seat
-	keyboard, err := m.manager.CreateVirtualKeyboard(m.client.GetSeat())
+// create creates a keyboard proxy on the keyboard's seat and sends it the keymap
+func (k *VirtualKeyboard) create(c *client.Client, manager *protocols.VirtualKeyboardManager) (*protocols.VirtualKeyboard, error) {
+	seat := c.GetSeat()
+	if k.seat != "" {
+		s, ok := c.FindSeat(k.seat)
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", session.ErrSeatNotFound, k.seat)
+		}
+		seat = s.Proxy()
+	}
+
+	keyboard, err := manager.CreateVirtualKeyboard(seat)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create virtual keyboard: %w", err)
 	}
 
 	// Sync to ensure the keyboard is created
-	if err := m.client.GetDisplay().Roundtrip(); err != nil {
+	if err := c.Roundtrip(); err != nil {
 		_ = keyboard.Destroy()
 		return nil, fmt.Errorf("failed to roundtrip after creating keyboard: %w", err)
 	}
 
-	vk := &VirtualKeyboard{
-		keyboard: keyboard,
-		client:   m.client,
-	}
-
-	// Set default keymap
-	if err := vk.setDefaultKeymap(); err != nil {
+	if err := sendKeymap(c, keyboard, k.Keymap().String()); err != nil {
 		_ = keyboard.Destroy()
-		return nil, fmt.Errorf("failed to set default keymap: %w", err)
+		return nil, fmt.Errorf("failed to set keymap: %w", err)
 	}
-
-	return vk, nil
+	return keyboard, nil
 }
 
-// setDefaultKeymap sets a minimal default keymap
-func (k *VirtualKeyboard) setDefaultKeymap() error {
-	fd, size, err := protocols.CreateDefaultKeymap()
+// sendKeymap uploads an XKB keymap to a keyboard proxy
+func sendKeymap(c *client.Client, keyboard *protocols.VirtualKeyboard, keymap string) error {
+	fd, size, err := protocols.CreateKeymap(keymap)
 	if err != nil {
 		return err
 	}
 
 	// Send the keymap
-	err = k.keyboard.Keymap(KEYMAP_FORMAT_XKB_V1, fd, size)
+	err = keyboard.Keymap(KEYMAP_FORMAT_XKB_V1, fd, size)
 	if err != nil {
 		syscall.Close(fd)
 		return err
 	}
 
-	k.keymapSet = true
-	
 	// Don't close the FD - the compositor needs to read it
 	// The compositor will close it when done
 	
 	// Do a roundtrip to ensure the keymap is processed
-	err = k.client.GetDisplay().Roundtrip()
+	err = c.Roundtrip()
 	if err != nil {
 		return fmt.Errorf("failed to roundtrip after keymap: %w", err)
 	}
@@ -272,38 +405,229 @@ func (k *VirtualKeyboard) setDefaultKeymap() error {
 	return nil
 }
 
-// Key sends a key press/release event
+// SetKeymap replaces the keymap of the keyboard, which may be in use: the
+// compositor sends the new keymap to focused clients and later keys are
+// interpreted with it. Release held keys first, the compositor doesn't; the
+// modifier state starts over with the new keymap.
+func (k *VirtualKeyboard) SetKeymap(keymap *xkb.Keymap) error {
+	if keymap == nil {
+		return errors.New("nil keymap")
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	c := k.client()
+	if err := sendKeymap(c, k.keyboard.Load(), keymap.String()); err != nil {
+		return fmt.Errorf("failed to set keymap: %w", err)
+	}
+	k.keymap = keymap
+	k.state = nil
+	c.Logger().Debug("changed virtual keyboard keymap")
+	return nil
+}
+
+// Keymap returns the keymap the keyboard currently uses
+func (k *VirtualKeyboard) Keymap() *xkb.Keymap {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.keymap
+}
+
+// client returns the connection of the keyboard, which changes when the
+// session reconnects
+func (k *VirtualKeyboard) client() *client.Client {
+	k.manager.mu.Lock()
+	defer k.manager.mu.Unlock()
+	return k.manager.client
+}
+
+// Key sends a key press/release event. When the key changes the modifiers,
+// as Shift or Caps Lock do in the keymap, a modifiers event with the new
+// state follows.
 func (k *VirtualKeyboard) Key(timestamp time.Time, key uint32, state KeyState) error {
 	if !k.keymapSet {
-		return fmt.Errorf("keymap not set")
+		return session.ErrKeymapNotSet
 	}
 
 	timeMs := uint32(timestamp.UnixNano() / 1000000)
-	return k.keyboard.Key(timeMs, key, uint32(state))
+	if err := k.keyboard.Load().Key(timeMs, key, uint32(state)); err != nil {
+		return err
+	}
+	k.held.Update(key, state == KeyStatePressed)
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	s := k.modifierState()
+	if s == nil || !s.UpdateKey(key, state == KeyStatePressed) {
+		return nil
+	}
+	m := s.Modifiers()
+	return k.keyboard.Load().Modifiers(uint32(m.Depressed), uint32(m.Latched), uint32(m.Locked), m.Group)
 }
 
-// Modifiers updates the modifier state
+// Modifiers updates the modifier state. The masks are real modifiers of the
+// keymap, see xkb.ModMask; keys pressed later change the state from there.
 func (k *VirtualKeyboard) Modifiers(modsDepressed, modsLatched, modsLocked, group uint32) error {
 	if !k.keymapSet {
-		return fmt.Errorf("keymap not set")
+		return session.ErrKeymapNotSet
+	}
+
+	if err := k.keyboard.Load().Modifiers(modsDepressed, modsLatched, modsLocked, group); err != nil {
+		return err
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if s := k.modifierState(); s != nil {
+		s.SetModifiers(xkb.ModifierState{
+			Depressed: xkb.ModMask(modsDepressed),
+			Latched:   xkb.ModMask(modsLatched),
+			Locked:    xkb.ModMask(modsLocked),
+			Group:     group,
+		})
 	}
+	return nil
+}
 
-	return k.keyboard.Modifiers(modsDepressed, modsLatched, modsLocked, group)
+// ModifierState returns the modifiers and group of the keyboard, following
+// the keys sent and the Modifiers calls. It is zero when the keymap can't be
+// compiled, see xkb.Keymap.Compile: keys then send no modifiers events.
+func (k *VirtualKeyboard) ModifierState() xkb.ModifierState {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if s := k.modifierState(); s != nil {
+		return s.Modifiers()
+	}
+	return xkb.ModifierState{}
 }
 
-// Close releases the virtual keyboard device
+// modifierState returns the state of the keyboard, created on first use as
+// compiling the keymap takes a while. k.mu must be held.
+func (k *VirtualKeyboard) modifierState() *xkb.State {
+	if k.state == nil {
+		k.state, _ = xkb.NewState(k.keymap)
+	}
+	return k.state
+}
+
+// Close releases the keys held and the virtual keyboard device
 func (k *VirtualKeyboard) Close() error {
-	return k.keyboard.Destroy()
+	if k.manager != nil {
+		k.manager.mu.Lock()
+		delete(k.manager.keyboards, k)
+		k.manager.mu.Unlock()
+	}
+	k.held.Stop()
+	releaseErr := k.held.ReleaseAll()
+	return errors.Join(releaseErr, k.keyboard.Load().Destroy())
+}
+
+// HeldKeys returns the keys pressed and not released yet, in the order they
+// were pressed
+func (k *VirtualKeyboard) HeldKeys() []uint32 {
+	return k.held.Held()
+}
+
+// ReleaseAll releases the keys held, last pressed first, so that none stays
+// stuck down in the compositor
+func (k *VirtualKeyboard) ReleaseAll() error {
+	return k.held.ReleaseAll()
+}
+
+// release sends the release of keys
+func (k *VirtualKeyboard) release(keys []uint32) error {
+	var errs []error
+	for _, key := range keys {
+		errs = append(errs, k.ReleaseKey(key))
+	}
+	return errors.Join(errs...)
+}
+
+// ReleaseOnPanic releases the keys held when the calling function panics,
+// and lets the panic go on. Defer it right after pressing keys:
+//
+//	defer keyboard.ReleaseOnPanic()
+func (k *VirtualKeyboard) ReleaseOnPanic() {
+	if r := recover(); r != nil {
+		_ = k.held.ReleaseAll()
+		panic(r)
+	}
+}
+
+// Heartbeat tells the watchdog set WithWatchdog that the caller is alive
+func (k *VirtualKeyboard) Heartbeat() {
+	k.held.Heartbeat()
+}
+
+// Version returns the protocol version the manager was bound at: the lower of
+// the version advertised by the compositor and the one this package implements.
+// It returns 0 once the manager is closed.
+func (m *VirtualKeyboardManager) Version() uint32 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.manager == nil {
+		return 0
+	}
+	return m.manager.Version()
+}
+
+// Available reports whether the compositor still advertises zwp_virtual_keyboard_manager_v1.
+// Removal is only noticed while events are dispatched, see SetUnavailableHandler.
+func (m *VirtualKeyboardManager) Available() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return !m.unavailable
+}
+
+// SetUnavailableHandler sets a callback that is called once when the compositor
+// removes zwp_virtual_keyboard_manager_v1, for example when it reloads. Devices created
+// from the manager stop working and new ones can't be created; build a new
+// manager once the protocol is announced again. Events are dispatched by the
+// event loop of the session, which this call starts.
+func (m *VirtualKeyboardManager) SetUnavailableHandler(handler func()) {
+	m.mu.Lock()
+	m.onUnavailable = handler
+	c := m.client
+	m.mu.Unlock()
+	c.StartDispatch()
 }
 
-// Close releases the virtual keyboard manager
+// handleGlobalRemoved marks the manager unavailable when its global goes away
+func (m *VirtualKeyboardManager) handleGlobalRemoved(client.Global) {
+	m.mu.Lock()
+	m.unavailable = true
+	handler := m.onUnavailable
+	m.client.Logger().Warn("compositor removed zwp_virtual_keyboard_manager_v1")
+	m.mu.Unlock()
+
+	if handler != nil {
+		handler()
+	}
+}
+
+// Close releases the virtual keyboard manager. The underlying connection is only
+// closed if the manager was not created from a shared session.
 func (m *VirtualKeyboardManager) Close() error {
-	if m.manager != nil {
-		_ = m.manager.Destroy()
+	m.mu.Lock()
+	manager, unwatch, removeHook := m.manager, m.unwatch, m.removeHook
+	m.manager, m.unwatch, m.removeHook = nil, nil, nil
+	m.mu.Unlock()
+
+	if removeHook != nil {
+		removeHook()
+	}
+	if unwatch != nil {
+		unwatch()
+	}
+	if manager != nil {
+		_ = manager.Destroy()
 	}
-	if m.client != nil {
-		return m.client.Close()
+	if m.session == nil {
+		return nil
 	}
+	if m.ownsSession {
+		return m.session.Close()
+	}
+	m.session.Detach(m)
 	return nil
 }
 
@@ -319,92 +643,282 @@ func (k *VirtualKeyboard) ReleaseKey(key uint32) error {
 	return k.Key(time.Now(), key, KeyStateReleased)
 }
 
-// TypeKey presses and releases a key
+// TypeKey presses and releases a key, held down for the press duration (see
+// WithPressDuration)
 func (k *VirtualKeyboard) TypeKey(key uint32) error {
-	now := time.Now()
-	if err := k.Key(now, key, KeyStatePressed); err != nil {
+	return k.typeKey(context.Background(), key)
+}
+
+// typeKey is TypeKey, releasing the key early when ctx is done
+func (k *VirtualKeyboard) typeKey(ctx context.Context, key uint32) error {
+	if err := k.PressKey(key); err != nil {
 		return err
 	}
-	// Small delay between press and release
-	time.Sleep(10 * time.Millisecond)
-	now = time.Now()
-	if err := k.Key(now, key, KeyStateReleased); err != nil {
+	if err := k.cadence.pause(ctx, k.cadence.press); err != nil {
+		_ = k.ReleaseKey(key)
 		return err
 	}
 	// Don't do roundtrip after every key - let the example control this
-	return nil
+	return k.ReleaseKey(key)
 }
 
-// TypeString types a string (basic ASCII support)
+// TypeString types text with the keys of the keyboard's keymap, holding the
+// modifiers each character needs, such as Shift or AltGr. Newlines are typed
+// with Return. Characters without a key are typed with dead keys when the
+// keyboard was created WithCompose. Nothing is typed when some characters
+// are still missing: an *UntypableError lists them, unless the keyboard was
+// created WithUnicode. Locked modifiers such as Caps Lock are dealt with as
+// set by WithLockHandling. The pace is set with WithKeyDelay and the other
+// options.
 func (k *VirtualKeyboard) TypeString(text string) error {
-	// Basic key mappings (no shift needed)
-	keyMap := map[rune]uint32{
-		'a': KEY_A, 'b': KEY_B, 'c': KEY_C, 'd': KEY_D, 'e': KEY_E,
-		'f': KEY_F, 'g': KEY_G, 'h': KEY_H, 'i': KEY_I, 'j': KEY_J,
-		'k': KEY_K, 'l': KEY_L, 'm': KEY_M, 'n': KEY_N, 'o': KEY_O,
-		'p': KEY_P, 'q': KEY_Q, 'r': KEY_R, 's': KEY_S, 't': KEY_T,
-		'u': KEY_U, 'v': KEY_V, 'w': KEY_W, 'x': KEY_X, 'y': KEY_Y,
-		'z': KEY_Z,
-		'0': KEY_0, '1': KEY_1, '2': KEY_2, '3': KEY_3, '4': KEY_4,
-		'5': KEY_5, '6': KEY_6, '7': KEY_7, '8': KEY_8, '9': KEY_9,
-		' ': KEY_SPACE, '\n': KEY_ENTER, '\t': KEY_TAB,
-		'-': KEY_MINUS, '=': KEY_EQUAL, '[': KEY_LEFTBRACE, ']': KEY_RIGHTBRACE,
-		';': KEY_SEMICOLON, '\'': KEY_APOSTROPHE, '`': KEY_GRAVE,
-		'\\': KEY_BACKSLASH, ',': KEY_COMMA, '.': KEY_DOT, '/': KEY_SLASH,
-	}
-
-	// Characters that need shift
-	shiftMap := map[rune]uint32{
-		'A': KEY_A, 'B': KEY_B, 'C': KEY_C, 'D': KEY_D, 'E': KEY_E,
-		'F': KEY_F, 'G': KEY_G, 'H': KEY_H, 'I': KEY_I, 'J': KEY_J,
-		'K': KEY_K, 'L': KEY_L, 'M': KEY_M, 'N': KEY_N, 'O': KEY_O,
-		'P': KEY_P, 'Q': KEY_Q, 'R': KEY_R, 'S': KEY_S, 'T': KEY_T,
-		'U': KEY_U, 'V': KEY_V, 'W': KEY_W, 'X': KEY_X, 'Y': KEY_Y,
-		'Z': KEY_Z,
-		'!': KEY_1, '@': KEY_2, '#': KEY_3, '$': KEY_4, '%': KEY_5,
-		'^': KEY_6, '&': KEY_7, '*': KEY_8, '(': KEY_9, ')': KEY_0,
-		'_': KEY_MINUS, '+': KEY_EQUAL, '{': KEY_LEFTBRACE, '}': KEY_RIGHTBRACE,
-		':': KEY_SEMICOLON, '"': KEY_APOSTROPHE, '~': KEY_GRAVE,
-		'|': KEY_BACKSLASH, '<': KEY_COMMA, '>': KEY_DOT, '?': KEY_SLASH,
+	return k.TypeStringContext(context.Background(), text)
+}
+
+// TypeStringContext is TypeString stopping when ctx is done: the keys held
+// are released and ctx.Err() is returned, with part of text typed.
+func (k *VirtualKeyboard) TypeStringContext(ctx context.Context, text string) (err error) {
+	keymap := k.Keymap()
+	locked := k.ModifierState().Locked
+	typeLocked := locked
+	if k.locks == NeutralizeLocks {
+		typeLocked = 0
+	}
+	strokes, err := keystrokes(keymap, k.compose, typeLocked, text)
+	var untypable *UntypableError
+	unicode := k.unicode && errors.As(err, &untypable)
+	if err != nil && !unicode {
+		return err
 	}
 
-	for _, char := range text {
-		var key uint32
-		var needShift bool
-
-		// Check if it needs shift
-		if shiftKey, ok := shiftMap[char]; ok {
-			key = shiftKey
-			needShift = true
-		} else if normalKey, ok := keyMap[char]; ok {
-			key = normalKey
-			needShift = false
-		} else {
-			// Skip unsupported characters
-			continue
+	if typeLocked != locked {
+		if err := k.SetLockedModifiers(typeLocked); err != nil {
+			return err
 		}
+		defer func() {
+			if lockErr := k.SetLockedModifiers(locked); err == nil {
+				err = lockErr
+			}
+		}()
+	}
+	if unicode {
+		err = k.typeUnicode(ctx, keymap, typeLocked, text)
+	} else {
+		err = k.typeKeystrokes(ctx, keymap, strokes)
+	}
+	if err != nil {
+		return err
+	}
+	return k.flush(ctx)
+}
 
-		if needShift {
-			if err := k.PressKey(KEY_LEFTSHIFT); err != nil {
+// flush waits for the compositor to process the keys sent in burst mode, see
+// WithBurst
+func (k *VirtualKeyboard) flush(ctx context.Context) error {
+	if !k.cadence.burst {
+		return nil
+	}
+	return k.client().RoundtripContext(ctx)
+}
+
+// typeKeystrokes types keystrokes, holding their modifiers with the keys of
+// keymap
+func (k *VirtualKeyboard) typeKeystrokes(ctx context.Context, keymap *xkb.Keymap, strokes []xkb.Keystroke) error {
+	for i, stroke := range strokes {
+		if i > 0 {
+			if err := k.cadence.pause(ctx, k.cadence.key); err != nil {
 				return err
 			}
-			time.Sleep(5 * time.Millisecond) // Small delay after shift press
 		}
+		mods, err := keymap.ModifierKeys(stroke.Modifiers)
+		if err != nil {
+			return err
+		}
+		if err := k.typeWithModifiers(ctx, mods, stroke.Key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
 
-		if err := k.TypeKey(key); err != nil {
+// typeWithModifiers types key while mods are held, pressed in order and
+// released in reverse order. The modifiers pressed are released when ctx is
+// done.
+func (k *VirtualKeyboard) typeWithModifiers(ctx context.Context, mods []uint32, key uint32) (err error) {
+	pressed := 0
+	defer func() {
+		// Release what is still held after an error
+		for i := pressed - 1; i >= 0; i-- {
+			_ = k.ReleaseKey(mods[i])
+		}
+	}()
+	for _, mod := range mods {
+		if err := k.PressKey(mod); err != nil {
+			return err
+		}
+		pressed++
+	}
+	if len(mods) > 0 {
+		if err := k.cadence.pause(ctx, k.cadence.modifier); err != nil {
 			return err
 		}
+	}
 
-		if needShift {
-			time.Sleep(5 * time.Millisecond) // Small delay before shift release
-			if err := k.ReleaseKey(KEY_LEFTSHIFT); err != nil {
-				return err
+	if err := k.typeKey(ctx, key); err != nil {
+		return err
+	}
+
+	if len(mods) > 0 {
+		if err := k.cadence.pause(ctx, k.cadence.modifier); err != nil {
+			return err
+		}
+	}
+	for ; pressed > 0; pressed-- {
+		if err := k.ReleaseKey(mods[pressed-1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// typeUnicode types text, binding the characters keymap lacks to its spare
+// keys. Each chunk of text gets a keymap with as many of those characters as
+// there are spare keys; keymap is sent again at the end. The keys of keymap
+// are typed with the locked modifiers active.
+func (k *VirtualKeyboard) typeUnicode(ctx context.Context, keymap *xkb.Keymap, locked xkb.ModMask, text string) (err error) {
+	spare, err := keymap.SpareKeys()
+	if err != nil {
+		return err
+	}
+
+	// Sort the characters out first, so that nothing is typed on error
+	type char struct {
+		strokes []xkb.Keystroke // When the keymap types it
+		sym     xkb.Keysym      // Otherwise
+	}
+	var chars []char
+	var missing []rune
+	for _, r := range text {
+		if strokes, err := charKeystrokes(keymap, k.compose, locked, r); err == nil {
+			chars = append(chars, char{strokes: strokes})
+		} else if sym := xkb.KeysymFromRune(r); sym != xkb.NoSymbol {
+			chars = append(chars, char{sym: sym})
+		} else if !slices.Contains(missing, r) {
+			missing = append(missing, r) // Control characters
+		}
+	}
+	if len(missing) > 0 {
+		return &UntypableError{Runes: missing}
+	}
+	if len(spare) == 0 {
+		return errors.New("no spare keys in the keymap to type missing characters with")
+	}
+
+	defer func() {
+		// Back to the keymap of the keyboard, even when typing failed
+		if restoreErr := k.uploadKeymap(keymap); err == nil {
+			err = restoreErr
+		}
+	}()
+	for len(chars) > 0 {
+		// The longest chunk needing no more keysyms than there are spare keys
+		var syms []xkb.Keysym
+		n := 0
+		for ; n < len(chars); n++ {
+			sym := chars[n].sym
+			if sym == xkb.NoSymbol || slices.Contains(syms, sym) {
+				continue
+			}
+			if len(syms) == len(spare) {
+				break
 			}
+			syms = append(syms, sym)
 		}
 
-		// Small delay between characters
-		time.Sleep(20 * time.Millisecond)
+		bound, bindings, err := keymap.BindKeysyms(syms)
+		if err != nil {
+			return err
+		}
+		if err := k.uploadKeymap(bound); err != nil {
+			return err
+		}
+		var strokes []xkb.Keystroke
+		for _, c := range chars[:n] {
+			if c.sym != xkb.NoSymbol {
+				strokes = append(strokes, bindings[slices.Index(syms, c.sym)])
+			} else {
+				strokes = append(strokes, c.strokes...)
+			}
+		}
+		if err := k.typeKeystrokes(ctx, keymap, strokes); err != nil {
+			return err
+		}
+		chars = chars[n:]
 	}
+	return nil
+}
 
+// uploadKeymap sends keymap to the compositor without making it the keymap
+// of the keyboard
+func (k *VirtualKeyboard) uploadKeymap(keymap *xkb.Keymap) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if err := sendKeymap(k.client(), k.keyboard.Load(), keymap.String()); err != nil {
+		return fmt.Errorf("failed to set keymap: %w", err)
+	}
 	return nil
 }
+
+// keystrokes returns the best keystrokes for the characters of text, typed
+// with the locked modifiers active
+func keystrokes(keymap *xkb.Keymap, compose *xkb.ComposeTable, locked xkb.ModMask, text string) ([]xkb.Keystroke, error) {
+	if err := keymap.Compile(); err != nil {
+		return nil, err
+	}
+	strokes := make([]xkb.Keystroke, 0, len(text))
+	var missing []rune
+	for _, char := range text {
+		found, err := charKeystrokes(keymap, compose, locked, char)
+		if err != nil {
+			if !slices.Contains(missing, char) {
+				missing = append(missing, char)
+			}
+			continue
+		}
+		strokes = append(strokes, found...)
+	}
+	if len(missing) > 0 {
+		return nil, &UntypableError{Runes: missing}
+	}
+	return strokes, nil
+}
+
+// charKeystrokes returns the keystrokes typing r with the locked modifiers
+// active: the best key of keymap, or else a sequence of compose when it
+// isn't nil
+func charKeystrokes(keymap *xkb.Keymap, compose *xkb.ComposeTable, locked xkb.ModMask, r rune) ([]xkb.Keystroke, error) {
+	strokes, err := keymap.KeystrokesLocked(r, locked)
+	if err == nil {
+		return strokes[:1], nil
+	}
+	if compose != nil {
+		if sequence, composeErr := keymap.ComposeKeystrokesLocked(compose, r, locked); composeErr == nil {
+			return sequence, nil
+		}
+	}
+	return nil, err
+}
+
+// UntypableError is returned by TypeString for text with characters no key
+// of the keymap produces
+type UntypableError struct {
+	Runes []rune // Each character once, in the order of the text
+}
+
+func (e *UntypableError) Error() string {
+	quoted := make([]string, len(e.Runes))
+	for i, r := range e.Runes {
+		quoted[i] = strconv.QuoteRune(r)
+	}
+	return "no key of the keymap types " + strings.Join(quoted, ", ")
+}
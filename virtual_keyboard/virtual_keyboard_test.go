@@ -2,15 +2,25 @@ package virtual_keyboard
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/bnema/libwldevices-go/fake_compositor"
+	"github.com/bnema/libwldevices-go/input_event_codes"
+	"github.com/bnema/libwldevices-go/session"
+	"github.com/bnema/libwldevices-go/xkb"
 )
 
 func TestNewVirtualKeyboardManager(t *testing.T) {
-	ctx := context.Background()
-	manager, err := NewVirtualKeyboardManager(ctx)
+	fc := fake_compositor.NewT(t)
+	manager, err := NewVirtualKeyboardManager(context.Background(), session.WithSocketPath(fc.Path()))
 	if err != nil {
-		t.Skipf("Skipping test - virtual keyboard manager not available: %v", err)
+		t.Fatalf("Failed to create virtual keyboard manager: %v", err)
 	}
 	if manager == nil {
 		t.Fatal("Manager should not be nil")
@@ -24,10 +34,10 @@ func TestNewVirtualKeyboardManager(t *testing.T) {
 }
 
 func TestVirtualKeyboardCreation(t *testing.T) {
-	ctx := context.Background()
-	manager, err := NewVirtualKeyboardManager(ctx)
+	fc := fake_compositor.NewT(t)
+	manager, err := NewVirtualKeyboardManager(context.Background(), session.WithSocketPath(fc.Path()))
 	if err != nil {
-		t.Skipf("Skipping test - virtual keyboard manager not available: %v", err)
+		t.Fatalf("Failed to create virtual keyboard manager: %v", err)
 	}
 	defer manager.Close()
 
@@ -46,10 +56,10 @@ func TestVirtualKeyboardCreation(t *testing.T) {
 
 
 func TestVirtualKeyboardKeys(t *testing.T) {
-	ctx := context.Background()
-	manager, err := NewVirtualKeyboardManager(ctx)
+	fc := fake_compositor.NewT(t)
+	manager, err := NewVirtualKeyboardManager(context.Background(), session.WithSocketPath(fc.Path()))
 	if err != nil {
-		t.Skipf("Skipping test - virtual keyboard manager not available: %v", err)
+		t.Fatalf("Failed to create virtual keyboard manager: %v", err)
 	}
 	defer manager.Close()
 
@@ -87,13 +97,21 @@ func TestVirtualKeyboardKeys(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to type key: %v", err)
 	}
+
+	keys := fc.ExpectRequests(t, 6, "zwp_virtual_keyboard_v1", "key")
+	want := []uint32{KEY_A, KEY_A, KEY_B, KEY_B, KEY_C, KEY_C}
+	for i, key := range keys {
+		if key.Uint(1) != want[i] || key.Uint(2) != uint32(1-i%2) {
+			t.Errorf("Key %d: %s", i, key.Format())
+		}
+	}
 }
 
 func TestVirtualKeyboardModifiers(t *testing.T) {
-	ctx := context.Background()
-	manager, err := NewVirtualKeyboardManager(ctx)
+	fc := fake_compositor.NewT(t)
+	manager, err := NewVirtualKeyboardManager(context.Background(), session.WithSocketPath(fc.Path()))
 	if err != nil {
-		t.Skipf("Skipping test - virtual keyboard manager not available: %v", err)
+		t.Fatalf("Failed to create virtual keyboard manager: %v", err)
 	}
 	defer manager.Close()
 
@@ -109,13 +127,67 @@ func TestVirtualKeyboardModifiers(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to set modifiers: %v", err)
 	}
+
+	modifiers := fc.ExpectRequests(t, 1, "zwp_virtual_keyboard_v1", "modifiers")[0]
+	if modifiers.Uint(0) != 1|4 {
+		t.Errorf("Unexpected modifiers: %s", modifiers.Format())
+	}
+}
+
+func TestVirtualKeyboardKeymap(t *testing.T) {
+	fc := fake_compositor.NewT(t)
+	manager, err := NewVirtualKeyboardManager(context.Background(), session.WithSocketPath(fc.Path()))
+	if err != nil {
+		t.Fatalf("Failed to create virtual keyboard manager: %v", err)
+	}
+	defer manager.Close()
+
+	if _, err := manager.CreateKeyboard(WithKeymapString("not a keymap")); err == nil {
+		t.Error("CreateKeyboard should fail with an invalid keymap")
+	}
+
+	german := strings.Replace(xkb.DefaultKeymap().String(), "pc+us+inet(evdev)", "pc+de+inet(evdev)", 1)
+	keyboard, err := manager.CreateKeyboard(WithKeymapString(german))
+	if err != nil {
+		t.Fatalf("Failed to create virtual keyboard: %v", err)
+	}
+	defer func() { _ = keyboard.Close() }()
+
+	// Keymaps are sent null terminated
+	sent := fc.ExpectRequests(t, 1, "zwp_virtual_keyboard_v1", "keymap")[0]
+	if string(sent.File(1)) != german+"\x00" || sent.Uint(0) != KEYMAP_FORMAT_XKB_V1 {
+		t.Errorf("Unexpected keymap: %s %q", sent.Format(), sent.File(1))
+	}
+
+	// Change it on the live keyboard
+	french := strings.Replace(german, "pc+de", "pc+fr", 1)
+	keymap, err := xkb.NewKeymapFromString(french)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := keyboard.SetKeymap(keymap); err != nil {
+		t.Fatalf("SetKeymap failed: %v", err)
+	}
+	sent = fc.ExpectRequests(t, 2, "zwp_virtual_keyboard_v1", "keymap")[1]
+	if string(sent.File(1)) != french+"\x00" {
+		t.Errorf("Unexpected keymap: %q", sent.File(1))
+	}
+	if keyboard.Keymap() != keymap {
+		t.Error("Keymap() should return the keymap last set")
+	}
+
+	// Keys still work with the new keymap
+	if err := keyboard.TypeKey(KEY_Q); err != nil {
+		t.Errorf("TypeKey failed: %v", err)
+	}
+	fc.ExpectRequests(t, 2, "zwp_virtual_keyboard_v1", "key")
 }
 
 func TestVirtualKeyboardClose(t *testing.T) {
-	ctx := context.Background()
-	manager, err := NewVirtualKeyboardManager(ctx)
+	fc := fake_compositor.NewT(t)
+	manager, err := NewVirtualKeyboardManager(context.Background(), session.WithSocketPath(fc.Path()))
 	if err != nil {
-		t.Skipf("Skipping test - virtual keyboard manager not available: %v", err)
+		t.Fatalf("Failed to create virtual keyboard manager: %v", err)
 	}
 	defer manager.Close()
 
@@ -129,13 +201,142 @@ func TestVirtualKeyboardClose(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to close keyboard: %v", err)
 	}
+
+	fc.ExpectRequests(t, 1, "zwp_virtual_keyboard_v1", "destroy")
+}
+
+func TestModifierState(t *testing.T) {
+	fc := fake_compositor.NewT(t)
+	manager, err := NewVirtualKeyboardManager(context.Background(), session.WithSocketPath(fc.Path()))
+	if err != nil {
+		t.Fatalf("Failed to create virtual keyboard manager: %v", err)
+	}
+	defer manager.Close()
+
+	keyboard, err := manager.CreateKeyboard(WithKeymapString(germanKeymap))
+	if err != nil {
+		t.Fatalf("Failed to create virtual keyboard: %v", err)
+	}
+	defer func() { _ = keyboard.Close() }()
+
+	// Shift and AltGr are held while typing, each change is sent
+	if err := keyboard.TypeString("\"@"); err != nil {
+		t.Fatalf("TypeString failed: %v", err)
+	}
+	if state := keyboard.ModifierState(); state != (xkb.ModifierState{}) {
+		t.Errorf("ModifierState() after TypeString = %+v", state)
+	}
+	if err := keyboard.PressKey(KEY_LEFTSHIFT); err != nil {
+		t.Fatalf("PressKey failed: %v", err)
+	}
+	if state := keyboard.ModifierState(); state != (xkb.ModifierState{Depressed: xkb.ModShift}) {
+		t.Errorf("ModifierState() with Shift held = %+v", state)
+	}
+	// Keys add to the modifiers set by hand
+	if err := keyboard.Modifiers(uint32(xkb.ModControl), 0, 0, 0); err != nil {
+		t.Fatalf("Modifiers failed: %v", err)
+	}
+	if err := keyboard.ReleaseKey(KEY_LEFTSHIFT); err != nil {
+		t.Fatalf("ReleaseKey failed: %v", err)
+	}
+
+	want := [][3]uint32{
+		{uint32(xkb.ModShift), 0, 0}, {0, 0, 0},
+		{uint32(xkb.Mod5), 0, 0}, {0, 0, 0},
+		{uint32(xkb.ModShift), 0, 0},
+		{uint32(xkb.ModControl), 0, 0},
+		{uint32(xkb.ModControl), 0, 0},
+	}
+	modifiers := fc.ExpectRequests(t, len(want), "zwp_virtual_keyboard_v1", "modifiers")
+	for i, w := range want {
+		if m := modifiers[i]; m.Uint(0) != w[0] || m.Uint(1) != w[1] || m.Uint(2) != w[2] || m.Uint(3) != 0 {
+			t.Errorf("Modifiers %d: %s, want %v", i, m.Format(), w)
+		}
+	}
+}
+
+func TestLockedModifiers(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		handling  LockHandling
+		keys      []uint32 // Pressed and released in order
+		modifiers [][3]uint32
+	}{
+		{
+			name:     "compensate",
+			handling: CompensateLocks,
+			keys:     []uint32{KEY_H, KEY_H, KEY_LEFTSHIFT, KEY_I, KEY_I, KEY_LEFTSHIFT},
+			modifiers: [][3]uint32{
+				{0, 0, uint32(xkb.ModLock)}, // SetCapsLock
+				{uint32(xkb.ModShift), 0, uint32(xkb.ModLock)}, {0, 0, uint32(xkb.ModLock)},
+				{0, 0, uint32(xkb.ModLock | xkb.Mod2)}, // SetNumLock
+				{0, 0, uint32(xkb.Mod2)},
+			},
+		},
+		{
+			name:     "neutralize",
+			handling: NeutralizeLocks,
+			keys:     []uint32{KEY_LEFTSHIFT, KEY_H, KEY_H, KEY_LEFTSHIFT, KEY_I, KEY_I},
+			modifiers: [][3]uint32{
+				{0, 0, uint32(xkb.ModLock)},
+				{0, 0, 0}, {uint32(xkb.ModShift), 0, 0}, {0, 0, 0},
+				{0, 0, uint32(xkb.ModLock)},
+				{0, 0, uint32(xkb.ModLock | xkb.Mod2)},
+				{0, 0, uint32(xkb.Mod2)},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			fc := fake_compositor.NewT(t)
+			manager, err := NewVirtualKeyboardManager(context.Background(), session.WithSocketPath(fc.Path()))
+			if err != nil {
+				t.Fatalf("Failed to create virtual keyboard manager: %v", err)
+			}
+			defer manager.Close()
+
+			keyboard, err := manager.CreateKeyboard(WithLockHandling(tt.handling), WithBurst())
+			if err != nil {
+				t.Fatalf("Failed to create virtual keyboard: %v", err)
+			}
+			defer func() { _ = keyboard.Close() }()
+
+			if err := keyboard.SetCapsLock(true); err != nil {
+				t.Fatalf("SetCapsLock failed: %v", err)
+			}
+			if err := keyboard.TypeString("Hi"); err != nil {
+				t.Fatalf("TypeString failed: %v", err)
+			}
+			if locked := keyboard.ModifierState().Locked; locked != xkb.ModLock {
+				t.Errorf("Locked modifiers after TypeString: %s, want Lock", locked)
+			}
+			if err := keyboard.SetNumLock(true); err != nil {
+				t.Fatalf("SetNumLock failed: %v", err)
+			}
+			if err := keyboard.SetCapsLock(false); err != nil {
+				t.Fatalf("SetCapsLock failed: %v", err)
+			}
+
+			keys := fc.ExpectRequests(t, len(tt.keys), "zwp_virtual_keyboard_v1", "key")
+			for i, key := range tt.keys {
+				if keys[i].Uint(1) != key {
+					t.Errorf("Key %d: %s, want key %d", i, keys[i].Format(), key)
+				}
+			}
+			modifiers := fc.ExpectRequests(t, len(tt.modifiers), "zwp_virtual_keyboard_v1", "modifiers")
+			for i, w := range tt.modifiers {
+				if m := modifiers[i]; m.Uint(0) != w[0] || m.Uint(1) != w[1] || m.Uint(2) != w[2] {
+					t.Errorf("Modifiers %d: %s, want %v", i, m.Format(), w)
+				}
+			}
+		})
+	}
 }
 
 func TestTypeString(t *testing.T) {
-	ctx := context.Background()
-	manager, err := NewVirtualKeyboardManager(ctx)
+	fc := fake_compositor.NewT(t)
+	manager, err := NewVirtualKeyboardManager(context.Background(), session.WithSocketPath(fc.Path()))
 	if err != nil {
-		t.Skipf("Skipping test - virtual keyboard manager not available: %v", err)
+		t.Fatalf("Failed to create virtual keyboard manager: %v", err)
 	}
 	defer manager.Close()
 
@@ -158,6 +359,616 @@ func TestTypeString(t *testing.T) {
 	}
 }
 
+func TestParseChords(t *testing.T) {
+	keymap := xkb.DefaultKeymap()
+	tests := []struct {
+		chords string
+		want   []Chord
+	}{
+		{"ctrl+shift+t", []Chord{{Modifiers: []uint32{KEY_LEFTCTRL, KEY_LEFTSHIFT}, Key: KEY_T}}},
+		{"super+Return", []Chord{{Modifiers: []uint32{KEY_LEFTMETA}, Key: KEY_ENTER}}},
+		{"Alt+f4", []Chord{{Modifiers: []uint32{KEY_LEFTALT}, Key: input_event_codes.KEY_F4}}},
+		{"ctrl+k ctrl+c", []Chord{
+			{Modifiers: []uint32{KEY_LEFTCTRL}, Key: KEY_K},
+			{Modifiers: []uint32{KEY_LEFTCTRL}, Key: KEY_C},
+		}},
+		{"ctrl+at", []Chord{{Modifiers: []uint32{KEY_LEFTCTRL, KEY_LEFTSHIFT}, Key: KEY_2}}},
+		{"ctrl++", []Chord{{Modifiers: []uint32{KEY_LEFTCTRL, KEY_LEFTSHIFT}, Key: KEY_EQUAL}}},
+		{"Mod1+<AE01>", []Chord{{Modifiers: []uint32{KEY_LEFTALT}, Key: KEY_1}}},
+		{"super", []Chord{{Key: KEY_LEFTMETA}}},
+		{"Escape", []Chord{{Key: KEY_ESC}}},
+		{"shift+KEY_VOLUMEUP", []Chord{{Modifiers: []uint32{KEY_LEFTSHIFT}, Key: input_event_codes.KEY_VOLUMEUP}}},
+	}
+	for _, tt := range tests {
+		got, err := ParseChords(keymap, tt.chords)
+		if err != nil {
+			t.Errorf("ParseChords(%q) failed: %v", tt.chords, err)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("ParseChords(%q) = %+v, want %+v", tt.chords, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i].Key != tt.want[i].Key || !slices.Equal(got[i].Modifiers, tt.want[i].Modifiers) {
+				t.Errorf("ParseChords(%q) = %+v, want %+v", tt.chords, got, tt.want)
+			}
+		}
+	}
+
+	for _, chords := range []string{"", "ctrl+", "t+ctrl", "ctrl+nosuchkey", "bogus+t", "ctrl+<NOPE>"} {
+		if _, err := ParseChords(keymap, chords); err == nil {
+			t.Errorf("ParseChords(%q) should fail", chords)
+		}
+	}
+}
+
+func TestTypeChord(t *testing.T) {
+	fc := fake_compositor.NewT(t)
+	manager, err := NewVirtualKeyboardManager(context.Background(), session.WithSocketPath(fc.Path()))
+	if err != nil {
+		t.Fatalf("Failed to create virtual keyboard manager: %v", err)
+	}
+	defer manager.Close()
+
+	keyboard, err := manager.CreateKeyboard()
+	if err != nil {
+		t.Fatalf("Failed to create virtual keyboard: %v", err)
+	}
+	defer func() { _ = keyboard.Close() }()
+
+	if err := keyboard.TypeChord("ctrl+shift+t bogus+x"); err == nil {
+		t.Fatal("TypeChord should fail on an unknown modifier")
+	}
+	if err := keyboard.TypeChord("ctrl+shift+t"); err != nil {
+		t.Fatalf("TypeChord failed: %v", err)
+	}
+
+	// Each modifier is followed by the new modifier state
+	want := []struct {
+		key   uint32
+		state KeyState
+		mods  xkb.ModMask
+	}{
+		{KEY_LEFTCTRL, KeyStatePressed, xkb.ModControl},
+		{KEY_LEFTSHIFT, KeyStatePressed, xkb.ModControl | xkb.ModShift},
+		{KEY_T, KeyStatePressed, 0}, {KEY_T, KeyStateReleased, 0},
+		{KEY_LEFTSHIFT, KeyStateReleased, xkb.ModControl},
+		{KEY_LEFTCTRL, KeyStateReleased, 0},
+	}
+	fc.ExpectRequests(t, len(want), "zwp_virtual_keyboard_v1", "key")
+	fc.ExpectRequests(t, 4, "zwp_virtual_keyboard_v1", "modifiers")
+	events := fc.Find(func(r fake_compositor.Request) bool {
+		return r.Interface == "zwp_virtual_keyboard_v1" && (r.Name == "key" || r.Name == "modifiers")
+	})
+	i := 0
+	for _, w := range want {
+		if i >= len(events) || events[i].Name != "key" || events[i].Uint(1) != w.key || KeyState(events[i].Uint(2)) != w.state {
+			t.Fatalf("Event %d: want key %d state %d, got %v", i, w.key, w.state, events[i:])
+		}
+		i++
+		if w.key == KEY_T {
+			continue
+		}
+		if i >= len(events) || events[i].Name != "modifiers" || xkb.ModMask(events[i].Uint(0)) != w.mods {
+			t.Fatalf("Event %d: want modifiers %s, got %v", i, w.mods, events[i:])
+		}
+		i++
+	}
+}
+
+func TestTypeStringBurst(t *testing.T) {
+	fc := fake_compositor.NewT(t)
+	manager, err := NewVirtualKeyboardManager(context.Background(), session.WithSocketPath(fc.Path()))
+	if err != nil {
+		t.Fatalf("Failed to create virtual keyboard manager: %v", err)
+	}
+	defer manager.Close()
+
+	keyboard, err := manager.CreateKeyboard(WithBurst())
+	if err != nil {
+		t.Fatalf("Failed to create virtual keyboard: %v", err)
+	}
+	defer func() { _ = keyboard.Close() }()
+
+	// A few seconds of typing at the default pace
+	text := strings.Repeat("Burst mode! ", 20)
+	start := time.Now()
+	if err := keyboard.TypeString(text); err != nil {
+		t.Fatalf("TypeString failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("TypeString took %v in burst mode", elapsed)
+	}
+	// The roundtrip at the end leaves the keys received by the compositor
+	keys := fc.Find(fake_compositor.Named("zwp_virtual_keyboard_v1", "key"))
+	if want := 2 * (len(text) + strings.Count(text, "B") + strings.Count(text, "!")); len(keys) != want {
+		t.Errorf("%d keys received after TypeString, want %d", len(keys), want)
+	}
+}
+
+func TestTypeStringContext(t *testing.T) {
+	fc := fake_compositor.NewT(t)
+	manager, err := NewVirtualKeyboardManager(context.Background(), session.WithSocketPath(fc.Path()))
+	if err != nil {
+		t.Fatalf("Failed to create virtual keyboard manager: %v", err)
+	}
+	defer manager.Close()
+
+	keyboard, err := manager.CreateKeyboard(WithModifierDelay(time.Hour), WithJitter(time.Millisecond))
+	if err != nil {
+		t.Fatalf("Failed to create virtual keyboard: %v", err)
+	}
+	defer func() { _ = keyboard.Close() }()
+
+	// Cancelled while Shift is held for A
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- keyboard.TypeStringContext(ctx, "aA") }()
+	fc.ExpectRequests(t, 3, "zwp_virtual_keyboard_v1", "key")
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("TypeStringContext returned %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("TypeStringContext didn't stop on cancel")
+	}
+
+	keys := fc.ExpectRequests(t, 4, "zwp_virtual_keyboard_v1", "key")
+	last := keys[len(keys)-1]
+	if len(keys) != 4 || last.Uint(1) != KEY_LEFTSHIFT || KeyState(last.Uint(2)) != KeyStateReleased {
+		t.Errorf("Shift wasn't released on cancel, keys sent: %v", keys)
+	}
+	if state := keyboard.ModifierState(); state != (xkb.ModifierState{}) {
+		t.Errorf("ModifierState() after cancel = %+v", state)
+	}
+}
+
+func TestReleaseAll(t *testing.T) {
+	fc := fake_compositor.NewT(t)
+	manager, err := NewVirtualKeyboardManager(context.Background(), session.WithSocketPath(fc.Path()))
+	if err != nil {
+		t.Fatalf("Failed to create virtual keyboard manager: %v", err)
+	}
+	defer manager.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	keyboard, err := manager.CreateKeyboard(WithReleaseContext(ctx), WithWatchdog(time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to create virtual keyboard: %v", err)
+	}
+	releases := func() []uint32 {
+		var keys []uint32
+		for _, req := range fc.Find(fake_compositor.Named("zwp_virtual_keyboard_v1", "key")) {
+			if KeyState(req.Uint(2)) == KeyStateReleased {
+				keys = append(keys, req.Uint(1))
+			}
+		}
+		return keys
+	}
+	waitForReleases := func(want ...uint32) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for len(releases()) < len(want) && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		if got := releases(); !slices.Equal(got, want) {
+			t.Fatalf("Keys released: %v, want %v", got, want)
+		}
+	}
+
+	// Released last pressed first, with the modifiers
+	_ = keyboard.PressKey(KEY_LEFTSHIFT)
+	_ = keyboard.PressKey(KEY_A)
+	if held := keyboard.HeldKeys(); !slices.Equal(held, []uint32{KEY_LEFTSHIFT, KEY_A}) {
+		t.Errorf("HeldKeys() = %v", held)
+	}
+	if err := keyboard.ReleaseAll(); err != nil {
+		t.Fatalf("ReleaseAll failed: %v", err)
+	}
+	waitForReleases(KEY_A, KEY_LEFTSHIFT)
+	if held := keyboard.HeldKeys(); len(held) != 0 {
+		t.Errorf("HeldKeys() after ReleaseAll = %v", held)
+	}
+	if state := keyboard.ModifierState(); state != (xkb.ModifierState{}) {
+		t.Errorf("ModifierState() after ReleaseAll = %+v", state)
+	}
+
+	// After a panic
+	func() {
+		defer func() { _ = recover() }()
+		defer keyboard.ReleaseOnPanic()
+		_ = keyboard.PressKey(KEY_LEFTCTRL)
+		panic("oops")
+	}()
+	waitForReleases(KEY_A, KEY_LEFTSHIFT, KEY_LEFTCTRL)
+
+	// When the context is done
+	_ = keyboard.PressKey(KEY_B)
+	cancel()
+	waitForReleases(KEY_A, KEY_LEFTSHIFT, KEY_LEFTCTRL, KEY_B)
+
+	// When the heartbeats stop
+	keyboard.held.StartWatchdog(20 * time.Millisecond)
+	_ = keyboard.PressKey(KEY_C)
+	waitForReleases(KEY_A, KEY_LEFTSHIFT, KEY_LEFTCTRL, KEY_B, KEY_C)
+
+	// On Close
+	keyboard.Heartbeat()
+	keyboard.held.StartWatchdog(0)
+	_ = keyboard.PressKey(KEY_D)
+	if err := keyboard.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	fc.ExpectRequests(t, 1, "zwp_virtual_keyboard_v1", "destroy")
+	waitForReleases(KEY_A, KEY_LEFTSHIFT, KEY_LEFTCTRL, KEY_B, KEY_C, KEY_D)
+}
+
+func TestHoldAndRepeatKey(t *testing.T) {
+	fc := fake_compositor.NewT(t)
+	manager, err := NewVirtualKeyboardManager(context.Background(), session.WithSocketPath(fc.Path()))
+	if err != nil {
+		t.Fatalf("Failed to create virtual keyboard manager: %v", err)
+	}
+	defer manager.Close()
+
+	keyboard, err := manager.CreateKeyboard()
+	if err != nil {
+		t.Fatalf("Failed to create virtual keyboard: %v", err)
+	}
+	defer func() { _ = keyboard.Close() }()
+
+	start := time.Now()
+	if err := keyboard.HoldKey(context.Background(), KEY_A, 50*time.Millisecond); err != nil {
+		t.Fatalf("HoldKey failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("HoldKey returned after %v", elapsed)
+	}
+
+	// Held until the context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if err := keyboard.HoldKey(ctx, KEY_B, 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("HoldKey returned %v, want context.DeadlineExceeded", err)
+	}
+	if held := keyboard.HeldKeys(); len(held) != 0 {
+		t.Errorf("HeldKeys() after HoldKey = %v", held)
+	}
+
+	// One tap, then 100 Hz after 50ms: taps at 0, 50, 60, 70, 80 and 90ms
+	if err := keyboard.RepeatKey(context.Background(), KEY_C, 100*time.Millisecond, 50*time.Millisecond, 100); err != nil {
+		t.Fatalf("RepeatKey failed: %v", err)
+	}
+	if err := keyboard.RepeatKey(context.Background(), KEY_C, time.Second, 0, 0); err == nil {
+		t.Error("RepeatKey should fail on a zero rate")
+	}
+	if err := keyboard.RepeatKey(context.Background(), KEY_C, time.Second, 0, math.Inf(1)); err == nil {
+		t.Error("RepeatKey should fail on an infinite rate")
+	}
+	if err := keyboard.RepeatKey(context.Background(), KEY_C, time.Second, -time.Millisecond, 10); err == nil {
+		t.Error("RepeatKey should fail on a negative delay")
+	}
+
+	keys := fc.ExpectRequests(t, 16, "zwp_virtual_keyboard_v1", "key")
+	var want []uint32
+	for _, key := range []uint32{KEY_A, KEY_B, KEY_C, KEY_C, KEY_C, KEY_C, KEY_C, KEY_C} {
+		want = append(want, key, key)
+	}
+	if len(keys) != len(want) {
+		t.Fatalf("%d keys sent, want %d", len(keys), len(want))
+	}
+	for i, key := range want {
+		if keys[i].Uint(1) != key || KeyState(keys[i].Uint(2)) != KeyState(1-i%2) {
+			t.Errorf("Key %d: %s, want key %d", i, keys[i].Format(), key)
+		}
+	}
+}
+
+// Taps stay shorter than the repeat delay and interval, whatever the press
+// duration of the keyboard
+func TestRepeatKeyShortTaps(t *testing.T) {
+	fc := fake_compositor.NewT(t)
+	manager, err := NewVirtualKeyboardManager(context.Background(), session.WithSocketPath(fc.Path()))
+	if err != nil {
+		t.Fatalf("Failed to create virtual keyboard manager: %v", err)
+	}
+	defer manager.Close()
+
+	keyboard, err := manager.CreateKeyboard(WithPressDuration(time.Second))
+	if err != nil {
+		t.Fatalf("Failed to create virtual keyboard: %v", err)
+	}
+	defer func() { _ = keyboard.Close() }()
+
+	// Taps at 0, 20, 30 and 40ms, each held at most 5ms
+	start := time.Now()
+	if err := keyboard.RepeatKey(context.Background(), KEY_C, 50*time.Millisecond, 20*time.Millisecond, 100); err != nil {
+		t.Fatalf("RepeatKey failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("RepeatKey took %v, taps weren't shortened", elapsed)
+	}
+	fc.ExpectRequests(t, 8, "zwp_virtual_keyboard_v1", "key")
+}
+
+// germanKeymap is a small German keymap: z and y are swapped, ü is on [ and
+// @ needs AltGr
+const germanKeymap = `xkb_keymap {
+	xkb_keycodes {
+		<AE02> = 11; <AD01> = 24; <AD06> = 29; <AD11> = 34;
+		<LFSH> = 50; <AB01> = 52; <SPCE> = 65; <RALT> = 108;
+	};
+	xkb_types {
+		virtual_modifiers LevelThree;
+		type "ONE_LEVEL" { modifiers = None; };
+		type "TWO_LEVEL" { modifiers = Shift; map[Shift] = Level2; };
+		type "FOUR_LEVEL" {
+			modifiers = Shift+LevelThree;
+			map[Shift] = Level2;
+			map[LevelThree] = Level3;
+			map[Shift+LevelThree] = Level4;
+		};
+	};
+	xkb_compat {
+		virtual_modifiers LevelThree;
+		interpret ISO_Level3_Shift+Any {
+			virtualModifier = LevelThree;
+			action = SetMods(modifiers=LevelThree);
+		};
+		interpret Any+AnyOf(all) { action = SetMods(modifiers=modMapMods); };
+	};
+	xkb_symbols {
+		key <AE02> { [ 2, quotedbl ] };
+		key <AD01> { type = "FOUR_LEVEL", symbols[Group1] = [ q, Q, at ] };
+		key <AD06> { [ z, Z ] };
+		key <AD11> { [ udiaeresis, Udiaeresis ] };
+		key <AB01> { [ y, Y ] };
+		key <SPCE> { [ space ] };
+		key <LFSH> { [ Shift_L ] };
+		key <RALT> { [ ISO_Level3_Shift ] };
+		modifier_map Shift { <LFSH> };
+		modifier_map Mod5 { <RALT> };
+	};
+};`
+
+func TestTypeStringKeymap(t *testing.T) {
+	fc := fake_compositor.NewT(t)
+	manager, err := NewVirtualKeyboardManager(context.Background(), session.WithSocketPath(fc.Path()))
+	if err != nil {
+		t.Fatalf("Failed to create virtual keyboard manager: %v", err)
+	}
+	defer manager.Close()
+
+	keyboard, err := manager.CreateKeyboard(WithKeymapString(germanKeymap))
+	if err != nil {
+		t.Fatalf("Failed to create virtual keyboard: %v", err)
+	}
+	defer func() { _ = keyboard.Close() }()
+
+	// Nothing is typed when a character has no key
+	err = keyboard.TypeString("zß@€ß")
+	var untypable *UntypableError
+	if !errors.As(err, &untypable) || string(untypable.Runes) != "ß€" {
+		t.Fatalf("TypeString returned %v, want an UntypableError for ß and €", err)
+	}
+
+	if err := keyboard.TypeString("zü @\"y"); err != nil {
+		t.Fatalf("TypeString failed: %v", err)
+	}
+	want := []struct {
+		key   uint32
+		state KeyState
+	}{
+		{KEY_Y, KeyStatePressed}, {KEY_Y, KeyStateReleased},
+		{KEY_LEFTBRACE, KeyStatePressed}, {KEY_LEFTBRACE, KeyStateReleased},
+		{KEY_SPACE, KeyStatePressed}, {KEY_SPACE, KeyStateReleased},
+		{100, KeyStatePressed}, // AltGr
+		{KEY_Q, KeyStatePressed}, {KEY_Q, KeyStateReleased},
+		{100, KeyStateReleased},
+		{KEY_LEFTSHIFT, KeyStatePressed},
+		{KEY_2, KeyStatePressed}, {KEY_2, KeyStateReleased},
+		{KEY_LEFTSHIFT, KeyStateReleased},
+		{KEY_Z, KeyStatePressed}, {KEY_Z, KeyStateReleased},
+	}
+	keys := fc.ExpectRequests(t, len(want), "zwp_virtual_keyboard_v1", "key")
+	for i, w := range want {
+		if keys[i].Uint(1) != w.key || KeyState(keys[i].Uint(2)) != w.state {
+			t.Errorf("Key %d: %s, want key %d state %d", i, keys[i].Format(), w.key, w.state)
+		}
+	}
+}
+
+func TestTypeStringCompose(t *testing.T) {
+	fc := fake_compositor.NewT(t)
+	manager, err := NewVirtualKeyboardManager(context.Background(), session.WithSocketPath(fc.Path()))
+	if err != nil {
+		t.Fatalf("Failed to create virtual keyboard manager: %v", err)
+	}
+	defer manager.Close()
+
+	table, err := xkb.NewComposeTableFromString(`<dead_diaeresis> <y> : "ÿ" ydiaeresis
+<dead_diaeresis> <e> : "ë" ediaeresis
+<Multi_key> <q> <q> : "ǫ"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// ¨ on AltGr+ü
+	keymap := strings.Replace(germanKeymap, "key <AD11> { [ udiaeresis, Udiaeresis ] };",
+		`key <AD11> { type = "FOUR_LEVEL", symbols[Group1] = [ udiaeresis, Udiaeresis, dead_diaeresis ] };`, 1)
+	keyboard, err := manager.CreateKeyboard(WithKeymapString(keymap), WithCompose(table))
+	if err != nil {
+		t.Fatalf("Failed to create virtual keyboard: %v", err)
+	}
+	defer func() { _ = keyboard.Close() }()
+
+	// No e key for ë, no Multi_key for ǫ
+	err = keyboard.TypeString("ÿëǫ")
+	var untypable *UntypableError
+	if !errors.As(err, &untypable) || string(untypable.Runes) != "ëǫ" {
+		t.Fatalf("TypeString returned %v, want an UntypableError for ë and ǫ", err)
+	}
+
+	if err := keyboard.TypeString("üÿ"); err != nil {
+		t.Fatalf("TypeString failed: %v", err)
+	}
+	want := []struct {
+		key   uint32
+		state KeyState
+	}{
+		{KEY_LEFTBRACE, KeyStatePressed}, {KEY_LEFTBRACE, KeyStateReleased},
+		{100, KeyStatePressed}, // AltGr
+		{KEY_LEFTBRACE, KeyStatePressed}, {KEY_LEFTBRACE, KeyStateReleased},
+		{100, KeyStateReleased},
+		{KEY_Z, KeyStatePressed}, {KEY_Z, KeyStateReleased},
+	}
+	keys := fc.ExpectRequests(t, len(want), "zwp_virtual_keyboard_v1", "key")
+	for i, w := range want {
+		if keys[i].Uint(1) != w.key || KeyState(keys[i].Uint(2)) != w.state {
+			t.Errorf("Key %d: %s, want key %d state %d", i, keys[i].Format(), w.key, w.state)
+		}
+	}
+}
+
+
+func TestTypeStringUnicode(t *testing.T) {
+	fc := fake_compositor.NewT(t)
+	manager, err := NewVirtualKeyboardManager(context.Background(), session.WithSocketPath(fc.Path()))
+	if err != nil {
+		t.Fatalf("Failed to create virtual keyboard manager: %v", err)
+	}
+	defer manager.Close()
+
+	// A keymap binding all keys but two
+	var keycodes, symbols strings.Builder
+	for code := 9; code <= 253; code++ {
+		fmt.Fprintf(&keycodes, "<K%d> = %d; ", code, code)
+		fmt.Fprintf(&symbols, "key <K%d> { [ a ] }; ", code)
+	}
+	full := fmt.Sprintf(`xkb_keymap {
+	xkb_keycodes { %s };
+	xkb_types { type "ONE_LEVEL" { modifiers = None; }; };
+	xkb_compat { };
+	xkb_symbols { %s };
+};`, keycodes.String(), symbols.String())
+
+	keyboard, err := manager.CreateKeyboard(WithKeymapString(full), WithUnicode())
+	if err != nil {
+		t.Fatalf("Failed to create virtual keyboard: %v", err)
+	}
+	defer func() { _ = keyboard.Close() }()
+
+	// Three characters to bind to two keys: two keymaps, then the original
+	if err := keyboard.TypeString("😀a€😀ü"); err != nil {
+		t.Fatalf("TypeString failed: %v", err)
+	}
+	keymaps := fc.ExpectRequests(t, 4, "zwp_virtual_keyboard_v1", "keymap")
+	for _, want := range []string{"U1F600", "EuroSign"} {
+		if !strings.Contains(string(keymaps[1].File(1)), "[ "+want+" ]") {
+			t.Errorf("First keymap doesn't bind %s:\n%s", want, keymaps[1].File(1))
+		}
+	}
+	if !strings.Contains(string(keymaps[2].File(1)), "[ udiaeresis ]") {
+		t.Errorf("Second keymap doesn't bind udiaeresis:\n%s", keymaps[2].File(1))
+	}
+	if string(keymaps[3].File(1)) != full+"\x00" {
+		t.Errorf("Keymap not restored: %q", keymaps[3].File(1))
+	}
+	if keyboard.Keymap().String() != full {
+		t.Error("Keymap() should still return the keyboard's keymap")
+	}
+
+	// Keys are sent between the keymaps: 246 and 247 are the spare keys
+	var typed []uint32
+	for _, req := range fc.Find(func(r fake_compositor.Request) bool { return r.Interface == "zwp_virtual_keyboard_v1" }) {
+		switch req.Name {
+		case "keymap":
+			typed = append(typed, 0)
+		case "key":
+			if KeyState(req.Uint(2)) == KeyStatePressed {
+				typed = append(typed, req.Uint(1))
+			}
+		}
+	}
+	want := []uint32{0, 0, 246, 1, 247, 246, 0, 246, 0}
+	if !slices.Equal(typed, want) {
+		t.Errorf("Typed %v, want %v (0 for keymaps)", typed, want)
+	}
+
+	// Control characters have no keysym to bind
+	var untypable *UntypableError
+	if err := keyboard.TypeString("a\x01"); !errors.As(err, &untypable) {
+		t.Errorf("TypeString returned %v, want an UntypableError", err)
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	fc := fake_compositor.NewT(t)
+	connected := make(chan error, 1)
+	manager, err := NewVirtualKeyboardManager(context.Background(), session.WithSocketPath(fc.Path()),
+		session.WithReconnect(session.Backoff{InitialDelay: 10 * time.Millisecond}),
+		session.WithStateHandler(func(state session.State, err error) {
+			if state == session.StateConnected {
+				connected <- err
+			}
+		}))
+	if err != nil {
+		t.Fatalf("Failed to create virtual keyboard manager: %v", err)
+	}
+	defer manager.Close()
+
+	german := strings.Replace(xkb.DefaultKeymap().String(), "pc+us+inet(evdev)", "pc+de+inet(evdev)", 1)
+	keyboard, err := manager.CreateKeyboard(WithKeymapString(german))
+	if err != nil {
+		t.Fatalf("Failed to create virtual keyboard: %v", err)
+	}
+	defer func() { _ = keyboard.Close() }()
+	if err := keyboard.PressKey(KEY_A); err != nil {
+		t.Fatalf("PressKey failed: %v", err)
+	}
+	fc.ExpectRequests(t, 1, "zwp_virtual_keyboard_v1", "key")
+
+	fc.DisconnectClients()
+	select {
+	case err := <-connected:
+		if err != nil {
+			t.Fatalf("Keyboard not restored: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Session didn't reconnect")
+	}
+
+	// A new keyboard on the new connection, with the same keymap
+	created := fc.ExpectRequests(t, 2, "zwp_virtual_keyboard_manager_v1", "create_virtual_keyboard")
+	if created[1].Client != 1 {
+		t.Errorf("Keyboard recreated on client %d, want 1", created[1].Client)
+	}
+	keymap := fc.ExpectRequests(t, 2, "zwp_virtual_keyboard_v1", "keymap")[1]
+	if keymap.Client != 1 || string(keymap.File(1)) != german+"\x00" {
+		t.Errorf("Unexpected keymap after reconnecting: %s %q", keymap.Format(), keymap.File(1))
+	}
+	object, _ := created[1].NewObject()
+	if keymap.Object != object.ID {
+		t.Errorf("Keymap sent to object %d, want the new keyboard %d", keymap.Object, object.ID)
+	}
+
+	// The key held on the old connection is forgotten, nothing is released
+	if held := keyboard.HeldKeys(); len(held) != 0 {
+		t.Errorf("HeldKeys() after reconnecting = %v", held)
+	}
+	if err := keyboard.ReleaseAll(); err != nil {
+		t.Fatalf("ReleaseAll failed: %v", err)
+	}
+	if err := keyboard.TypeKey(KEY_B); err != nil {
+		t.Fatalf("TypeKey failed: %v", err)
+	}
+	keys := fc.ExpectRequests(t, 3, "zwp_virtual_keyboard_v1", "key")
+	if keys[1].Client != 1 || keys[1].Object != object.ID || keys[1].Uint(1) != KEY_B || KeyState(keys[1].Uint(2)) != KeyStatePressed {
+		t.Errorf("Unexpected key after reconnecting: %s", keys[1].Format())
+	}
+}
 
 func TestKeyConstants(t *testing.T) {
 	// Test that key constants are defined and have reasonable values
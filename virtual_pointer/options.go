@@ -0,0 +1,78 @@
+package virtual_pointer
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bnema/libwldevices-go/output_management"
+)
+
+// PointerOption configures a virtual pointer created by CreatePointer
+type PointerOption func(*pointerOptions)
+
+// pointerOptions holds the settings collected from PointerOption values
+type pointerOptions struct {
+	seat   string
+	output string
+	err    error // From an invalid option, returned by CreatePointer
+
+	releaseCtx context.Context
+	watchdog   time.Duration
+}
+
+// WithSeat creates the pointer on the seat with the given name (see
+// session.Session.Seats) instead of the first seat announced by the compositor.
+func WithSeat(name string) PointerOption {
+	return func(o *pointerOptions) {
+		o.seat = name
+	}
+}
+
+// WithOutput binds the pointer to the output with the given name, such as
+// DP-1, so that the extents of MotionAbsolute cover that output instead of
+// the whole layout. It needs version 2 of the virtual pointer manager and a
+// compositor sending the names of its wl_output globals (version 4).
+func WithOutput(name string) PointerOption {
+	return func(o *pointerOptions) {
+		o.output = name
+	}
+}
+
+// WithOutputHead binds the pointer to the output of head, as WithOutput. A
+// nil head makes CreatePointer fail.
+func WithOutputHead(head *output_management.OutputHead) PointerOption {
+	if head == nil {
+		return func(o *pointerOptions) {
+			o.err = errors.New("WithOutputHead: head is nil")
+		}
+	}
+	return WithOutput(head.Name)
+}
+
+// WithReleaseContext releases the buttons held when ctx is done, so that a
+// cancelled drag doesn't leave a button down. The pointer stays usable.
+func WithReleaseContext(ctx context.Context) PointerOption {
+	return func(o *pointerOptions) {
+		o.releaseCtx = ctx
+	}
+}
+
+// WithWatchdog releases the buttons held whenever Heartbeat isn't called for
+// timeout, for callers that may hang between a press and its release
+func WithWatchdog(timeout time.Duration) PointerOption {
+	return func(o *pointerOptions) {
+		o.watchdog = timeout
+	}
+}
+
+// newPointerOptions applies opts on top of the defaults
+func newPointerOptions(opts []PointerOption) *pointerOptions {
+	o := &pointerOptions{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	return o
+}
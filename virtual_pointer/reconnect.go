@@ -0,0 +1,59 @@
+package virtual_pointer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// rebind is the session reconnect hook: it binds the manager on the new
+// connection and gives every open pointer a new proxy on the same seat and output
+func (m *VirtualPointerManager) rebind(ctx context.Context) error {
+	c := m.session.Client()
+	manager, name, err := bindManager(ctx, c)
+
+	m.mu.Lock()
+	if m.manager == nil {
+		// Closed while reconnecting
+		m.mu.Unlock()
+		if manager != nil {
+			_ = manager.Destroy()
+		}
+		return nil
+	}
+	m.client = c
+	if err != nil {
+		m.unavailable = true
+		handler := m.onUnavailable
+		m.mu.Unlock()
+
+		if handler != nil {
+			handler()
+		}
+		return err
+	}
+	m.manager = manager
+	m.unavailable = false
+	if m.unwatch != nil {
+		m.unwatch()
+	}
+	m.unwatch = c.WatchGlobal(name, m.handleGlobalRemoved)
+	pointers := make([]*VirtualPointer, 0, len(m.pointers))
+	for p := range m.pointers {
+		pointers = append(pointers, p)
+	}
+	m.mu.Unlock()
+
+	var errs []error
+	for _, p := range pointers {
+		pointer, output, err := createPointer(ctx, c, manager, p.seat, p.output)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to recreate virtual pointer: %w", err))
+			continue
+		}
+		p.pointer.Store(pointer)
+		p.bound.Store(output) // The previous output went with the old connection
+		p.held.Forget()       // The new pointer has no buttons down
+	}
+	return errors.Join(errs...)
+}
@@ -44,21 +44,28 @@ package virtual_pointer
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/bnema/libwldevices-go/input_event_codes"
 	"github.com/bnema/libwldevices-go/internal/client"
+	"github.com/bnema/libwldevices-go/internal/held"
 	"github.com/bnema/libwldevices-go/internal/protocols"
+	"github.com/bnema/libwldevices-go/session"
 	"github.com/bnema/wlturbo/wl"
 )
 
-// Button constants for mouse buttons
+// Button constants for mouse buttons. Package input_event_codes has all the
+// buttons, which Button and Click accept too.
 const (
-	BTN_LEFT   = 0x110
-	BTN_RIGHT  = 0x111
-	BTN_MIDDLE = 0x112
-	BTN_SIDE   = 0x113
-	BTN_EXTRA  = 0x114
+	BTN_LEFT   = input_event_codes.BTN_LEFT
+	BTN_RIGHT  = input_event_codes.BTN_RIGHT
+	BTN_MIDDLE = input_event_codes.BTN_MIDDLE
+	BTN_SIDE   = input_event_codes.BTN_SIDE
+	BTN_EXTRA  = input_event_codes.BTN_EXTRA
 )
 
 // Button state constants
@@ -110,59 +117,129 @@ const (
 	AxisSourceWheelTilt  AxisSource = 3 // Wheel tilt
 )
 
+// Protocol errors of zwlr_virtual_pointer_v1, for use with errors.Is
+var (
+	ErrInvalidAxis = &session.ProtocolError{
+		Interface: protocols.VirtualPointerInterface,
+		Code:      0,
+		Name:      "invalid_axis",
+	}
+	ErrInvalidAxisSource = &session.ProtocolError{
+		Interface: protocols.VirtualPointerInterface,
+		Code:      1,
+		Name:      "invalid_axis_source",
+	}
+)
+
 // VirtualPointerManager manages virtual pointer devices
 type VirtualPointerManager struct {
-	client  *client.Client
-	manager *protocols.VirtualPointerManager
+	session     *session.Session
+	client      *client.Client
+	manager     *protocols.VirtualPointerManager
+	ownsSession bool
+
+	mu            sync.Mutex
+	unavailable   bool
+	onUnavailable func()
+	unwatch       func()
+	removeHook    func()
+	pointers      map[*VirtualPointer]struct{} // Recreated after a reconnection
 }
 
 // VirtualPointer represents a virtual pointer device
 type VirtualPointer struct {
-	pointer *protocols.VirtualPointer
+	// Swapped for a new proxy when the session reconnects
+	pointer atomic.Pointer[protocols.VirtualPointer]
+	bound   atomic.Pointer[client.Output] // Output bound with WithOutput
+	manager *VirtualPointerManager
+	seat    string
+	output  string
+	held    *held.Tracker
 }
 
+// Pointer is the set of methods of a virtual pointer device. It's
+// implemented by VirtualPointer, and by fake_input.Pointer for applications
+// that test their own logic without a compositor.
+type Pointer interface {
+	Motion(timestamp time.Time, dx, dy float64) error
+	MotionAbsolute(timestamp time.Time, x, y uint32, xExtent, yExtent uint32) error
+	Button(timestamp time.Time, button uint32, state ButtonState) error
+	Axis(timestamp time.Time, axis Axis, value float64) error
+	Frame() error
+	AxisSource(source AxisSource) error
+	AxisStop(timestamp time.Time, axis Axis) error
+	AxisDiscrete(timestamp time.Time, axis Axis, value float64, discrete int32) error
+	MoveRelative(dx, dy float64) error
+	LeftClick() error
+	RightClick() error
+	MiddleClick() error
+	Click(button uint32) error
+	ScrollVertical(amount float64) error
+	ScrollHorizontal(amount float64) error
+	ReleaseAll() error
+	Close() error
+}
+
+var _ Pointer = (*VirtualPointer)(nil)
+
 // floatToFixed converts a float64 to wayland fixed point
 func floatToFixed(val float64) wl.Fixed {
 	return wl.Fixed(val * 256.0)
 }
 
-// NewVirtualPointerManager creates a new virtual pointer manager
-func NewVirtualPointerManager(ctx context.Context) (*VirtualPointerManager, error) {
-	// Check if context is already cancelled
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
+// NewVirtualPointerManager creates a new virtual pointer manager on its own Wayland connection.
+// The options select the compositor to connect to, see session.NewSession.
+func NewVirtualPointerManager(ctx context.Context, opts ...session.Option) (*VirtualPointerManager, error) {
+	s, err := session.NewSession(ctx, opts...)
+	if err != nil {
+		return nil, err
 	}
-	
-	// Create Wayland client with timeout
-	type clientResult struct {
-		client *client.Client
-		err    error
+
+	manager, err := newVirtualPointerManager(ctx, s, true)
+	if err != nil {
+		_ = s.Close()
+		return nil, err
 	}
-	
-	clientCh := make(chan clientResult, 1)
-	go func() {
-		c, err := client.NewClient()
-		clientCh <- clientResult{client: c, err: err}
-	}()
-	
-	// Wait for client creation or context cancellation
-	var c *client.Client
-	select {
-	case result := <-clientCh:
-		if result.err != nil {
-			return nil, fmt.Errorf("failed to create Wayland client: %w", result.err)
+	return manager, nil
+}
+
+// NewVirtualPointerManagerFromSession creates a virtual pointer manager on a shared session.
+// The manager is closed automatically when the session is closed.
+func NewVirtualPointerManagerFromSession(ctx context.Context, s *session.Session) (*VirtualPointerManager, error) {
+	return newVirtualPointerManager(ctx, s, false)
+}
+
+func newVirtualPointerManager(ctx context.Context, s *session.Session, ownsSession bool) (*VirtualPointerManager, error) {
+	c := s.Client()
+	manager, name, err := bindManager(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &VirtualPointerManager{
+		session:     s,
+		client:      c,
+		manager:     manager,
+		ownsSession: ownsSession,
+		pointers:    make(map[*VirtualPointer]struct{}),
+	}
+	if !ownsSession {
+		if err := s.Attach(m); err != nil {
+			_ = manager.Destroy()
+			return nil, err
 		}
-		c = result.client
-	case <-ctx.Done():
-		return nil, fmt.Errorf("context cancelled during client creation: %w", ctx.Err())
 	}
-	
+	m.unwatch = c.WatchGlobal(name, m.handleGlobalRemoved)
+	m.removeHook = s.AddReconnectHook(m.rebind)
+	return m, nil
+}
+
+// bindManager binds the virtual pointer manager global of c and returns it
+// with its registry name
+func bindManager(ctx context.Context, c *client.Client) (*protocols.VirtualPointerManager, uint32, error) {
 	// Check if virtual pointer protocol is available
 	if !c.HasVirtualPointer() {
-		_ = c.Close()
-		return nil, fmt.Errorf("zwlr_virtual_pointer_manager_v1 not available")
+		return nil, 0, fmt.Errorf("zwlr_virtual_pointer_manager_v1: %w", session.ErrProtocolUnavailable)
 	}
 	
 	// Create the manager proxy
@@ -171,124 +248,315 @@ func NewVirtualPointerManager(ctx context.Context) (*VirtualPointerManager, erro
 	// Check context before binding
 	select {
 	case <-ctx.Done():
-		_ = c.Close()
-		return nil, fmt.Errorf("context cancelled before binding: %w", ctx.Err())
+		return nil, 0, fmt.Errorf("context cancelled before binding: %w", ctx.Err())
 	default:
 	}
 	
 	// Bind to the global
 	name := c.GetPointerManagerName()
-	err := c.GetRegistry().Bind(name, protocols.VirtualPointerManagerInterface, 1, manager)
+	version := protocols.NegotiateVersion(c.GlobalVersion(name), protocols.VirtualPointerManagerVersion)
+	manager.SetVersion(version)
+	err := c.Bind(name, protocols.VirtualPointerManagerInterface, version, manager)
 	if err != nil {
-		_ = c.Close()
-		return nil, fmt.Errorf("failed to bind virtual pointer manager: %w", err)
+		return nil, 0, fmt.Errorf("failed to bind virtual pointer manager: %w", err)
 	}
 	
 	// Sync to ensure binding is complete with context support
-	sync, err := c.GetDisplay().Sync()
+	if err := c.RoundtripContext(ctx); err != nil {
+		_ = manager.Destroy()
+		return nil, 0, fmt.Errorf("failed to wait for sync: %w", err)
+	}
+	c.Logger().Debug("bound virtual pointer manager", "name", name, "version", version)
+	return manager, name, nil
+}
+
+// CreatePointer creates a new virtual pointer device on the default seat,
+// or on the seat selected with WithSeat. The pointer moves over the whole
+// layout unless WithOutput binds it to an output.
+func (m *VirtualPointerManager) CreatePointer(opts ...PointerOption) (*VirtualPointer, error) {
+	m.mu.Lock()
+	c, manager, unavailable := m.client, m.manager, m.unavailable
+	m.mu.Unlock()
+
+	if manager == nil {
+		return nil, fmt.Errorf("virtual pointer %w", session.ErrManagerClosed)
+	}
+	if unavailable {
+		return nil, fmt.Errorf("zwlr_virtual_pointer_manager_v1 was removed: %w", session.ErrProtocolUnavailable)
+	}
+	o := newPointerOptions(opts)
+	if o.err != nil {
+		return nil, o.err
+	}
+
+	// Create virtual pointer on the selected seat
+	pointer, output, err := createPointer(context.Background(), c, manager, o.seat, o.output)
 	if err != nil {
-		_ = c.Close()
-		return nil, fmt.Errorf("failed to sync: %w", err)
+		return nil, err
 	}
-	
-	// Wait for sync with context support
-	syncDone := make(chan error, 1)
-	go func() {
-		syncDone <- c.GetContext().RunTill(sync)
-	}()
-	
-	select {
-	case err = <-syncDone:
+
+	vp := &VirtualPointer{
+		manager: m,
+		seat:    o.seat,
+		output:  o.output,
+	}
+	vp.pointer.Store(pointer)
+	vp.bound.Store(output)
+	vp.held = held.NewTracker(vp.release)
+	if o.releaseCtx != nil {
+		vp.held.ReleaseWhenDone(o.releaseCtx)
+	}
+	if o.watchdog > 0 {
+		vp.held.StartWatchdog(o.watchdog)
+	}
+
+	m.mu.Lock()
+	m.pointers[vp] = struct{}{}
+	m.mu.Unlock()
+	return vp, nil
+}
+
+// createPointer creates a pointer proxy on the named seat, or the default seat if name is empty.
+// A pointer bound to an output is returned with the output, which is released with the pointer.
+func createPointer(ctx context.Context, c *client.Client, manager *protocols.VirtualPointerManager,
+	name, outputName string) (*protocols.VirtualPointer, *client.Output, error) {
+	seat := c.GetSeat()
+	if name != "" {
+		s, ok := c.FindSeat(name)
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: %q", session.ErrSeatNotFound, name)
+		}
+		seat = s.Proxy()
+	}
+
+	if outputName == "" {
+		pointer, err := manager.CreateVirtualPointer(seat)
 		if err != nil {
-			_ = c.Close()
-			return nil, fmt.Errorf("failed to wait for sync: %w", err)
+			return nil, nil, fmt.Errorf("failed to create virtual pointer: %w", err)
 		}
-	case <-ctx.Done():
-		_ = c.Close()
-		return nil, fmt.Errorf("context cancelled during sync: %w", ctx.Err())
+		return pointer, nil, nil
 	}
-	
-	return &VirtualPointerManager{
-		client:  c,
-		manager: manager,
-	}, nil
+
+	// Check the version before binding every output for nothing
+	if version := manager.Version(); version < 2 {
+		return nil, nil, fmt.Errorf("failed to create virtual pointer: %w", &protocols.VersionError{
+			Interface: protocols.VirtualPointerManagerInterface,
+			Feature:   "create_virtual_pointer_with_output",
+			Since:     2,
+			Version:   version,
+		})
+	}
+	output, err := findOutput(ctx, c, outputName)
+	if err != nil {
+		return nil, nil, err
+	}
+	pointer, err := manager.CreateVirtualPointerWithOutput(seat, output.Proxy())
+	if err != nil {
+		_ = output.Release()
+		return nil, nil, fmt.Errorf("failed to create virtual pointer: %w", err)
+	}
+	return pointer, output, nil
 }
 
-// CreatePointer creates a new virtual pointer device
-func (m *VirtualPointerManager) CreatePointer() (*VirtualPointer, error) {
-	// Create virtual pointer using the current seat
-	pointer, err := m.manager.CreateVirtualPointer(m.client.GetSeat())
+// findOutput binds the outputs of c and keeps the one called name
+func findOutput(ctx context.Context, c *client.Client, name string) (*client.Output, error) {
+	outputs, err := c.BindOutputs(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create virtual pointer: %w", err)
+		return nil, err
 	}
-	
-	return &VirtualPointer{
-		pointer: pointer,
-	}, nil
+	var found *client.Output
+	for _, output := range outputs {
+		if found == nil && output.Name() == name {
+			found = output
+			continue
+		}
+		_ = output.Release()
+	}
+	if found == nil {
+		return nil, fmt.Errorf("%w: %q", session.ErrOutputNotFound, name)
+	}
+	return found, nil
 }
 
 // Motion sends a relative motion event
 func (p *VirtualPointer) Motion(timestamp time.Time, dx, dy float64) error {
 	// Safe conversion: truncate to 32-bit milliseconds (about 49 days from epoch)
 	timeMs := uint32(timestamp.UnixMilli() & 0xFFFFFFFF)
-	return p.pointer.Motion(timeMs, floatToFixed(dx), floatToFixed(dy))
+	return p.pointer.Load().Motion(timeMs, floatToFixed(dx), floatToFixed(dy))
 }
 
 // MotionAbsolute sends an absolute motion event
 func (p *VirtualPointer) MotionAbsolute(timestamp time.Time, x, y uint32, xExtent, yExtent uint32) error {
 	// Safe conversion: truncate to 32-bit milliseconds (about 49 days from epoch)
 	timeMs := uint32(timestamp.UnixMilli() & 0xFFFFFFFF)
-	return p.pointer.MotionAbsolute(timeMs, x, y, xExtent, yExtent)
+	return p.pointer.Load().MotionAbsolute(timeMs, x, y, xExtent, yExtent)
 }
 
 // Button sends a button press/release event
 func (p *VirtualPointer) Button(timestamp time.Time, button uint32, state ButtonState) error {
 	// Safe conversion: truncate to 32-bit milliseconds (about 49 days from epoch)
 	timeMs := uint32(timestamp.UnixMilli() & 0xFFFFFFFF)
-	return p.pointer.Button(timeMs, button, uint32(state))
+	if err := p.pointer.Load().Button(timeMs, button, uint32(state)); err != nil {
+		return err
+	}
+	p.held.Update(button, state == ButtonStatePressed)
+	return nil
 }
 
 // Axis sends a scroll event
 func (p *VirtualPointer) Axis(timestamp time.Time, axis Axis, value float64) error {
 	timeMs := uint32(timestamp.UnixNano() / 1000000)
-	return p.pointer.Axis(timeMs, uint32(axis), floatToFixed(value))
+	return p.pointer.Load().Axis(timeMs, uint32(axis), floatToFixed(value))
 }
 
 // Frame indicates the end of a pointer event sequence
 func (p *VirtualPointer) Frame() error {
-	return p.pointer.Frame()
+	return p.pointer.Load().Frame()
 }
 
 // AxisSource sets the axis source for subsequent axis events
 func (p *VirtualPointer) AxisSource(source AxisSource) error {
-	return p.pointer.AxisSource(uint32(source))
+	return p.pointer.Load().AxisSource(uint32(source))
 }
 
 // AxisStop sends an axis stop event
 func (p *VirtualPointer) AxisStop(timestamp time.Time, axis Axis) error {
 	timeMs := uint32(timestamp.UnixNano() / 1000000)
-	return p.pointer.AxisStop(timeMs, uint32(axis))
+	return p.pointer.Load().AxisStop(timeMs, uint32(axis))
 }
 
 // AxisDiscrete sends a discrete axis event
 func (p *VirtualPointer) AxisDiscrete(timestamp time.Time, axis Axis, value float64, discrete int32) error {
 	timeMs := uint32(timestamp.UnixNano() / 1000000)
-	return p.pointer.AxisDiscrete(timeMs, uint32(axis), floatToFixed(value), discrete)
+	return p.pointer.Load().AxisDiscrete(timeMs, uint32(axis), floatToFixed(value), discrete)
 }
 
-// Close releases the virtual pointer device
+// Close releases the buttons held and the virtual pointer device
 func (p *VirtualPointer) Close() error {
-	return p.pointer.Destroy()
+	if p.manager != nil {
+		p.manager.mu.Lock()
+		delete(p.manager.pointers, p)
+		p.manager.mu.Unlock()
+	}
+	p.held.Stop()
+	releaseErr := p.held.ReleaseAll()
+	destroyErr := p.pointer.Load().Destroy()
+	var outputErr error
+	if output := p.bound.Swap(nil); output != nil {
+		outputErr = output.Release()
+	}
+	return errors.Join(releaseErr, destroyErr, outputErr)
+}
+
+// HeldButtons returns the buttons pressed and not released yet, in the
+// order they were pressed
+func (p *VirtualPointer) HeldButtons() []uint32 {
+	return p.held.Held()
 }
 
-// Close releases the virtual pointer manager
+// ReleaseAll releases the buttons held, last pressed first, so that a drag
+// doesn't go on in the compositor
+func (p *VirtualPointer) ReleaseAll() error {
+	return p.held.ReleaseAll()
+}
+
+// release sends the release of buttons, in one frame
+func (p *VirtualPointer) release(buttons []uint32) error {
+	now := time.Now()
+	var errs []error
+	for _, button := range buttons {
+		errs = append(errs, p.Button(now, button, ButtonStateReleased))
+	}
+	errs = append(errs, p.Frame())
+	return errors.Join(errs...)
+}
+
+// ReleaseOnPanic releases the buttons held when the calling function
+// panics, and lets the panic go on. Defer it right after pressing buttons:
+//
+//	defer pointer.ReleaseOnPanic()
+func (p *VirtualPointer) ReleaseOnPanic() {
+	if r := recover(); r != nil {
+		_ = p.held.ReleaseAll()
+		panic(r)
+	}
+}
+
+// Heartbeat tells the watchdog set WithWatchdog that the caller is alive
+func (p *VirtualPointer) Heartbeat() {
+	p.held.Heartbeat()
+}
+
+// Version returns the protocol version the manager was bound at: the lower of
+// the version advertised by the compositor and the one this package implements.
+// It returns 0 once the manager is closed.
+func (m *VirtualPointerManager) Version() uint32 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.manager == nil {
+		return 0
+	}
+	return m.manager.Version()
+}
+
+// Available reports whether the compositor still advertises zwlr_virtual_pointer_manager_v1.
+// Removal is only noticed while events are dispatched, see SetUnavailableHandler.
+func (m *VirtualPointerManager) Available() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return !m.unavailable
+}
+
+// SetUnavailableHandler sets a callback that is called once when the compositor
+// removes zwlr_virtual_pointer_manager_v1, for example when it reloads. Devices created
+// from the manager stop working and new ones can't be created; build a new
+// manager once the protocol is announced again. Events are dispatched by the
+// event loop of the session, which this call starts.
+func (m *VirtualPointerManager) SetUnavailableHandler(handler func()) {
+	m.mu.Lock()
+	m.onUnavailable = handler
+	c := m.client
+	m.mu.Unlock()
+	c.StartDispatch()
+}
+
+// handleGlobalRemoved marks the manager unavailable when its global goes away
+func (m *VirtualPointerManager) handleGlobalRemoved(client.Global) {
+	m.mu.Lock()
+	m.unavailable = true
+	handler := m.onUnavailable
+	m.client.Logger().Warn("compositor removed zwlr_virtual_pointer_manager_v1")
+	m.mu.Unlock()
+
+	if handler != nil {
+		handler()
+	}
+}
+
+// Close releases the virtual pointer manager. The underlying connection is only
+// closed if the manager was not created from a shared session.
 func (m *VirtualPointerManager) Close() error {
-	if m.manager != nil {
-		_ = m.manager.Destroy()
+	m.mu.Lock()
+	manager, unwatch, removeHook := m.manager, m.unwatch, m.removeHook
+	m.manager, m.unwatch, m.removeHook = nil, nil, nil
+	m.mu.Unlock()
+
+	if removeHook != nil {
+		removeHook()
+	}
+	if unwatch != nil {
+		unwatch()
 	}
-	if m.client != nil {
-		return m.client.Close()
+	if manager != nil {
+		_ = manager.Destroy()
 	}
+	if m.session == nil {
+		return nil
+	}
+	if m.ownsSession {
+		return m.session.Close()
+	}
+	m.session.Detach(m)
 	return nil
 }
 
@@ -304,35 +572,27 @@ func (p *VirtualPointer) MoveRelative(dx, dy float64) error {
 
 // LeftClick performs a left mouse button click
 func (p *VirtualPointer) LeftClick() error {
-	now := time.Now()
-	if err := p.Button(now, BTN_LEFT, ButtonStatePressed); err != nil {
-		return err
-	}
-	if err := p.Button(now, BTN_LEFT, ButtonStateReleased); err != nil {
-		return err
-	}
-	return p.Frame()
+	return p.Click(BTN_LEFT)
 }
 
 // RightClick performs a right mouse button click
 func (p *VirtualPointer) RightClick() error {
-	now := time.Now()
-	if err := p.Button(now, BTN_RIGHT, ButtonStatePressed); err != nil {
-		return err
-	}
-	if err := p.Button(now, BTN_RIGHT, ButtonStateReleased); err != nil {
-		return err
-	}
-	return p.Frame()
+	return p.Click(BTN_RIGHT)
 }
 
 // MiddleClick performs a middle mouse button click
 func (p *VirtualPointer) MiddleClick() error {
+	return p.Click(BTN_MIDDLE)
+}
+
+// Click presses and releases button, any BTN_* code of input_event_codes
+// such as BTN_SIDE or BTN_FORWARD
+func (p *VirtualPointer) Click(button uint32) error {
 	now := time.Now()
-	if err := p.Button(now, BTN_MIDDLE, ButtonStatePressed); err != nil {
+	if err := p.Button(now, button, ButtonStatePressed); err != nil {
 		return err
 	}
-	if err := p.Button(now, BTN_MIDDLE, ButtonStateReleased); err != nil {
+	if err := p.Button(now, button, ButtonStateReleased); err != nil {
 		return err
 	}
 	return p.Frame()
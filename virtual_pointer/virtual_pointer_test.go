@@ -2,13 +2,18 @@ package virtual_pointer
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
+
+	"github.com/bnema/libwldevices-go/fake_compositor"
+	"github.com/bnema/libwldevices-go/input_event_codes"
+	"github.com/bnema/libwldevices-go/session"
 )
 
 func TestNewVirtualPointerManager(t *testing.T) {
-	ctx := context.Background()
-	manager, err := NewVirtualPointerManager(ctx)
+	fc := fake_compositor.NewT(t)
+	manager, err := NewVirtualPointerManager(context.Background(), session.WithSocketPath(fc.Path()))
 	if err != nil {
 		t.Fatalf("Failed to create virtual pointer manager: %v", err)
 	}
@@ -24,8 +29,8 @@ func TestNewVirtualPointerManager(t *testing.T) {
 }
 
 func TestVirtualPointerCreation(t *testing.T) {
-	ctx := context.Background()
-	manager, err := NewVirtualPointerManager(ctx)
+	fc := fake_compositor.NewT(t)
+	manager, err := NewVirtualPointerManager(context.Background(), session.WithSocketPath(fc.Path()))
 	if err != nil {
 		t.Fatalf("Failed to create virtual pointer manager: %v", err)
 	}
@@ -45,8 +50,8 @@ func TestVirtualPointerCreation(t *testing.T) {
 }
 
 func TestVirtualPointerMotion(t *testing.T) {
-	ctx := context.Background()
-	manager, err := NewVirtualPointerManager(ctx)
+	fc := fake_compositor.NewT(t)
+	manager, err := NewVirtualPointerManager(context.Background(), session.WithSocketPath(fc.Path()))
 	if err != nil {
 		t.Fatalf("Failed to create virtual pointer manager: %v", err)
 	}
@@ -75,11 +80,20 @@ func TestVirtualPointerMotion(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to move relatively: %v", err)
 	}
+
+	motions := fc.ExpectRequests(t, 2, "zwlr_virtual_pointer_v1", "motion")
+	if motions[0].Fixed(1) != 10 || motions[0].Fixed(2) != 20 || motions[1].Fixed(1) != 5 {
+		t.Errorf("Unexpected motion: %s, %s", motions[0].Format(), motions[1].Format())
+	}
+	absolute := fc.ExpectRequests(t, 1, "zwlr_virtual_pointer_v1", "motion_absolute")[0]
+	if absolute.Uint(1) != 100 || absolute.Uint(2) != 200 || absolute.Uint(3) != 1920 || absolute.Uint(4) != 1080 {
+		t.Errorf("Unexpected absolute motion: %s", absolute.Format())
+	}
 }
 
 func TestVirtualPointerButtons(t *testing.T) {
-	ctx := context.Background()
-	manager, err := NewVirtualPointerManager(ctx)
+	fc := fake_compositor.NewT(t)
+	manager, err := NewVirtualPointerManager(context.Background(), session.WithSocketPath(fc.Path()))
 	if err != nil {
 		t.Fatalf("Failed to create virtual pointer manager: %v", err)
 	}
@@ -118,11 +132,76 @@ func TestVirtualPointerButtons(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to perform middle click: %v", err)
 	}
+
+	err = pointer.Click(input_event_codes.BTN_FORWARD)
+	if err != nil {
+		t.Fatalf("Failed to click the forward button: %v", err)
+	}
+
+	buttons := fc.ExpectRequests(t, 10, "zwlr_virtual_pointer_v1", "button")
+	want := []uint32{BTN_LEFT, BTN_LEFT, BTN_LEFT, BTN_LEFT, BTN_RIGHT, BTN_RIGHT, BTN_MIDDLE, BTN_MIDDLE,
+		input_event_codes.BTN_FORWARD, input_event_codes.BTN_FORWARD}
+	for i, button := range buttons {
+		if button.Uint(1) != want[i] || button.Uint(2) != uint32(1-i%2) {
+			t.Errorf("Button %d: %s", i, button.Format())
+		}
+	}
+}
+
+func TestVirtualPointerReleaseAll(t *testing.T) {
+	fc := fake_compositor.NewT(t)
+	manager, err := NewVirtualPointerManager(context.Background(), session.WithSocketPath(fc.Path()))
+	if err != nil {
+		t.Fatalf("Failed to create virtual pointer manager: %v", err)
+	}
+	defer func() { _ = manager.Close() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pointer, err := manager.CreatePointer(WithReleaseContext(ctx))
+	if err != nil {
+		t.Fatalf("Failed to create virtual pointer: %v", err)
+	}
+
+	_ = pointer.Button(time.Now(), BTN_LEFT, ButtonStatePressed)
+	_ = pointer.Button(time.Now(), BTN_SIDE, ButtonStatePressed)
+	if held := pointer.HeldButtons(); len(held) != 2 || held[0] != BTN_LEFT || held[1] != BTN_SIDE {
+		t.Errorf("HeldButtons() = %v", held)
+	}
+	if err := pointer.ReleaseAll(); err != nil {
+		t.Fatalf("ReleaseAll failed: %v", err)
+	}
+	if held := pointer.HeldButtons(); len(held) != 0 {
+		t.Errorf("HeldButtons() after ReleaseAll = %v", held)
+	}
+
+	// When the context is done, and on Close
+	_ = pointer.Button(time.Now(), BTN_RIGHT, ButtonStatePressed)
+	cancel()
+	fc.ExpectRequests(t, 6, "zwlr_virtual_pointer_v1", "button")
+	_ = pointer.Button(time.Now(), BTN_MIDDLE, ButtonStatePressed)
+	_ = pointer.Close()
+	fc.ExpectRequests(t, 1, "zwlr_virtual_pointer_v1", "destroy")
+
+	buttons := fc.ExpectRequests(t, 8, "zwlr_virtual_pointer_v1", "button")
+	want := []struct {
+		button uint32
+		state  ButtonState
+	}{
+		{BTN_LEFT, ButtonStatePressed}, {BTN_SIDE, ButtonStatePressed},
+		{BTN_SIDE, ButtonStateReleased}, {BTN_LEFT, ButtonStateReleased},
+		{BTN_RIGHT, ButtonStatePressed}, {BTN_RIGHT, ButtonStateReleased},
+		{BTN_MIDDLE, ButtonStatePressed}, {BTN_MIDDLE, ButtonStateReleased},
+	}
+	for i, w := range want {
+		if buttons[i].Uint(1) != w.button || ButtonState(buttons[i].Uint(2)) != w.state {
+			t.Errorf("Button %d: %s, want button %d state %d", i, buttons[i].Format(), w.button, w.state)
+		}
+	}
 }
 
 func TestVirtualPointerAxis(t *testing.T) {
-	ctx := context.Background()
-	manager, err := NewVirtualPointerManager(ctx)
+	fc := fake_compositor.NewT(t)
+	manager, err := NewVirtualPointerManager(context.Background(), session.WithSocketPath(fc.Path()))
 	if err != nil {
 		t.Fatalf("Failed to create virtual pointer manager: %v", err)
 	}
@@ -168,11 +247,20 @@ func TestVirtualPointerAxis(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to scroll horizontally: %v", err)
 	}
+
+	axes := fc.ExpectRequests(t, 3, "zwlr_virtual_pointer_v1", "axis")
+	if axes[0].Uint(1) != AXIS_VERTICAL_SCROLL || axes[0].Fixed(2) != 10 || axes[2].Uint(1) != AXIS_HORIZONTAL_SCROLL {
+		t.Errorf("Unexpected axis events: %s, %s", axes[0].Format(), axes[2].Format())
+	}
+	discrete := fc.ExpectRequests(t, 1, "zwlr_virtual_pointer_v1", "axis_discrete")[0]
+	if discrete.Fixed(2) != 10 || discrete.Int(3) != 1 {
+		t.Errorf("Unexpected discrete axis: %s", discrete.Format())
+	}
 }
 
 func TestVirtualPointerFrame(t *testing.T) {
-	ctx := context.Background()
-	manager, err := NewVirtualPointerManager(ctx)
+	fc := fake_compositor.NewT(t)
+	manager, err := NewVirtualPointerManager(context.Background(), session.WithSocketPath(fc.Path()))
 	if err != nil {
 		t.Fatalf("Failed to create virtual pointer manager: %v", err)
 	}
@@ -189,11 +277,13 @@ func TestVirtualPointerFrame(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to send frame: %v", err)
 	}
+
+	fc.ExpectRequests(t, 1, "zwlr_virtual_pointer_v1", "frame")
 }
 
 func TestVirtualPointerDestroy(t *testing.T) {
-	ctx := context.Background()
-	manager, err := NewVirtualPointerManager(ctx)
+	fc := fake_compositor.NewT(t)
+	manager, err := NewVirtualPointerManager(context.Background(), session.WithSocketPath(fc.Path()))
 	if err != nil {
 		t.Fatalf("Failed to create virtual pointer manager: %v", err)
 	}
@@ -210,10 +300,153 @@ func TestVirtualPointerDestroy(t *testing.T) {
 		t.Fatalf("Failed to close pointer: %v", err)
 	}
 
+	fc.ExpectRequests(t, 1, "zwlr_virtual_pointer_v1", "destroy")
+
 	// Note: The API doesn't guarantee errors after Close() is called
 	// so we don't test for that behavior
 }
 
+func TestVirtualPointerWithOutput(t *testing.T) {
+	second := fake_compositor.DefaultHead()
+	second.Name = "FAKE-2"
+	second.X = 1920
+	fc := fake_compositor.NewT(t, fake_compositor.WithHeads(fake_compositor.DefaultHead(), second))
+
+	manager, err := NewVirtualPointerManager(context.Background(), session.WithSocketPath(fc.Path()))
+	if err != nil {
+		t.Fatalf("Failed to create virtual pointer manager: %v", err)
+	}
+	defer func() { _ = manager.Close() }()
+
+	if _, err := manager.CreatePointer(WithOutput("HDMI-A-1")); !errors.Is(err, session.ErrOutputNotFound) {
+		t.Fatalf("CreatePointer with an unknown output: got %v, want ErrOutputNotFound", err)
+	}
+	fc.ExpectRequests(t, 2, "wl_output", "release")
+	fc.ClearRequests()
+
+	pointer, err := manager.CreatePointer(WithOutput("FAKE-2"))
+	if err != nil {
+		t.Fatalf("Failed to create virtual pointer: %v", err)
+	}
+	create := fc.ExpectRequests(t, 1, "zwlr_virtual_pointer_manager_v1", "create_virtual_pointer_with_output")[0]
+	released := fc.ExpectRequests(t, 1, "wl_output", "release")[0]
+	if create.Uint(1) == 0 || create.Uint(1) == released.Object {
+		t.Errorf("Pointer bound to output %d, want the output kept after releasing %d", create.Uint(1), released.Object)
+	}
+
+	if err := pointer.Close(); err != nil {
+		t.Fatalf("Failed to close pointer: %v", err)
+	}
+	fc.ExpectRequests(t, 2, "wl_output", "release")
+}
+
+func TestVirtualPointerWithOutputErrors(t *testing.T) {
+	fc := fake_compositor.NewT(t, fake_compositor.WithGlobal("zwlr_virtual_pointer_manager_v1", 1))
+
+	manager, err := NewVirtualPointerManager(context.Background(), session.WithSocketPath(fc.Path()))
+	if err != nil {
+		t.Fatalf("Failed to create virtual pointer manager: %v", err)
+	}
+	defer func() { _ = manager.Close() }()
+
+	if _, err := manager.CreatePointer(WithOutputHead(nil)); err == nil {
+		t.Error("CreatePointer should fail with a nil head")
+	}
+
+	// Version 1 can't bind pointers to outputs, nothing is bound to find out
+	var verr *session.VersionError
+	if _, err := manager.CreatePointer(WithOutput("FAKE-1")); !errors.As(err, &verr) || verr.Since != 2 {
+		t.Fatalf("CreatePointer on version 1: got %v, want a VersionError", err)
+	}
+	if binds := fc.Find(fake_compositor.Named("wl_registry", "bind")); len(binds) != 2 {
+		t.Errorf("Expected only the seat and manager to be bound, got %d binds", len(binds))
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	second := fake_compositor.DefaultHead()
+	second.Name = "FAKE-2"
+	second.X = 1920
+	fc := fake_compositor.NewT(t, fake_compositor.WithHeads(fake_compositor.DefaultHead(), second))
+	connected := make(chan error, 1)
+	manager, err := NewVirtualPointerManager(context.Background(), session.WithSocketPath(fc.Path()),
+		session.WithReconnect(session.Backoff{InitialDelay: 10 * time.Millisecond}),
+		session.WithStateHandler(func(state session.State, err error) {
+			if state == session.StateConnected {
+				connected <- err
+			}
+		}))
+	if err != nil {
+		t.Fatalf("Failed to create virtual pointer manager: %v", err)
+	}
+	defer func() { _ = manager.Close() }()
+
+	pointer, err := manager.CreatePointer(WithOutput("FAKE-2"))
+	if err != nil {
+		t.Fatalf("Failed to create virtual pointer: %v", err)
+	}
+	defer func() { _ = pointer.Close() }()
+	if err := pointer.Button(time.Now(), BTN_LEFT, ButtonStatePressed); err != nil {
+		t.Fatalf("Button failed: %v", err)
+	}
+	fc.ExpectRequests(t, 1, "zwlr_virtual_pointer_v1", "button")
+
+	// outputName returns the global a pointer was bound to
+	outputName := func(create fake_compositor.Request) uint32 {
+		t.Helper()
+		for _, bind := range fc.Find(fake_compositor.Named("wl_registry", "bind")) {
+			if object, _ := bind.NewObject(); object.Client == create.Client && object.ID == create.Uint(1) {
+				return bind.Uint(0)
+			}
+		}
+		t.Fatalf("Pointer bound to unknown output %d", create.Uint(1))
+		return 0
+	}
+	before := outputName(fc.ExpectRequests(t, 1, "zwlr_virtual_pointer_manager_v1", "create_virtual_pointer_with_output")[0])
+
+	fc.DisconnectClients()
+	select {
+	case err := <-connected:
+		if err != nil {
+			t.Fatalf("Pointer not restored: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Session didn't reconnect")
+	}
+
+	// A new pointer on the new connection, bound to the same output
+	create := fc.ExpectRequests(t, 2, "zwlr_virtual_pointer_manager_v1", "create_virtual_pointer_with_output")[1]
+	if create.Client != 1 {
+		t.Errorf("Pointer recreated on client %d, want 1", create.Client)
+	}
+	if after := outputName(create); after != before {
+		t.Errorf("Pointer rebound to output global %d, want %d", after, before)
+	}
+	live := false
+	for _, output := range fc.Objects("wl_output") {
+		live = live || output == fake_compositor.Object{Client: 1, ID: create.Uint(1)}
+	}
+	if !live {
+		t.Error("The output of the new pointer should stay bound")
+	}
+
+	// The button held on the old connection is forgotten, nothing is released
+	if held := pointer.HeldButtons(); len(held) != 0 {
+		t.Errorf("HeldButtons() after reconnecting = %v", held)
+	}
+	if err := pointer.ReleaseAll(); err != nil {
+		t.Fatalf("ReleaseAll failed: %v", err)
+	}
+	if err := pointer.Click(BTN_RIGHT); err != nil {
+		t.Fatalf("Click failed: %v", err)
+	}
+	object, _ := create.NewObject()
+	buttons := fc.ExpectRequests(t, 3, "zwlr_virtual_pointer_v1", "button")
+	if buttons[1].Client != 1 || buttons[1].Object != object.ID || buttons[1].Uint(1) != BTN_RIGHT {
+		t.Errorf("Unexpected button after reconnecting: %s", buttons[1].Format())
+	}
+}
+
 func TestButtonConstants(t *testing.T) {
 	// Test that button constants are defined
 	buttons := []uint32{BTN_LEFT, BTN_RIGHT, BTN_MIDDLE, BTN_SIDE, BTN_EXTRA}
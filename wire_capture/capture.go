@@ -0,0 +1,195 @@
+// Package wire_capture records the Wayland messages exchanged with a
+// compositor and replays them later as test fixtures, to test the managers
+// against the behaviour of real compositors without running one.
+//
+// Record a session with session.WithWireCapture:
+//
+//	f, _ := os.Create("testdata/hyprland_outputs.wire")
+//	defer f.Close()
+//	manager, err := output_management.NewOutputManager(ctx, session.WithWireCapture(f))
+//
+// Captures are text files, one message per line with decoded arguments, so
+// they can be reviewed and edited by hand, for example to reorder events:
+//
+//	# Two monitors on Hyprland 0.45
+//	-> wl_display@1.get_registry(new id wl_registry@2)
+//	<- wl_registry@2.global(1, "zwlr_output_manager_v1", 4)
+//	<- zwlr_output_head_v1@9.adaptive_sync(1)
+//
+// Then replay them in a unit test:
+//
+//	capture, err := wire_capture.Load("testdata/hyprland_outputs.wire")
+//	replay, err := wire_capture.NewReplay(capture)
+//	defer replay.Close()
+//	manager, err := output_management.NewOutputManager(ctx, session.WithSocketPath(replay.Path()))
+//
+// The replay answers the requests of the client with the events that followed
+// them in the capture. The client must make the same requests in the same
+// order, which it does as long as the code under test behaves the same.
+package wire_capture
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Capture is a recorded exchange between a client and a compositor
+type Capture struct {
+	Messages []Message
+}
+
+// Parse reads a capture written by a Recorder or by Write. Blank lines and
+// lines starting with # are ignored.
+func Parse(r io.Reader) (*Capture, error) {
+	capture := &Capture{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		m, err := parseMessage(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		capture.Messages = append(capture.Messages, m)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return capture, nil
+}
+
+// Load reads the capture file at path
+func Load(path string) (*Capture, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	capture, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return capture, nil
+}
+
+// Write writes the capture in the format read by Parse
+func (c *Capture) Write(w io.Writer) error {
+	r := NewRecorder(w)
+	for _, m := range c.Messages {
+		if err := r.write(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Requests returns the requests of the capture
+func (c *Capture) Requests() []Message {
+	return c.filter(Request)
+}
+
+// Events returns the events of the capture
+func (c *Capture) Events() []Message {
+	return c.filter(Event)
+}
+
+func (c *Capture) filter(d Direction) []Message {
+	var messages []Message
+	for _, m := range c.Messages {
+		if m.Direction == d {
+			messages = append(messages, m)
+		}
+	}
+	return messages
+}
+
+// Recorder writes messages to a capture as they are exchanged. It follows
+// the objects created and destroyed on the connection to name the interface
+// of each message, so it must see every message from the start of the
+// connection.
+type Recorder struct {
+	mu      sync.Mutex
+	w       io.Writer
+	objects map[uint32]string
+	err     error
+}
+
+// NewRecorder returns a recorder writing to w
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{
+		w:       w,
+		objects: map[uint32]string{1: "wl_display"},
+	}
+}
+
+// Record writes a message given by its object, opcode and arguments. Once
+// writing has failed, every call returns the error.
+func (r *Recorder) Record(d Direction, object uint32, opcode uint16, body []byte) error {
+	return r.write(Message{Direction: d, Object: object, Opcode: opcode, Body: body})
+}
+
+// write writes m, naming its interface from the known objects unless set
+func (r *Recorder) write(m Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+
+	if m.Interface == "" {
+		m.Interface = r.interfaceOf(m.Object)
+	}
+	line := m.format(r.interfaceOf)
+	r.follow(m)
+
+	_, r.err = io.WriteString(r.w, line+"\n")
+	return r.err
+}
+
+// interfaceOf returns the interface of a known object
+func (r *Recorder) interfaceOf(id uint32) string {
+	if iface, ok := r.objects[id]; ok {
+		return iface
+	}
+	return "unknown"
+}
+
+// follow updates the known objects with the ones m creates or destroys
+func (r *Recorder) follow(m Message) {
+	// wl_display.delete_id releases an object ID for reuse
+	if m.Direction == Event && m.Object == 1 && m.Opcode == 1 && len(m.Body) >= 4 {
+		delete(r.objects, binary.LittleEndian.Uint32(m.Body))
+		return
+	}
+
+	spec, ok := m.spec()
+	if !ok {
+		return
+	}
+	args, err := decodeArgs(spec.Signature, m.Body)
+	if err != nil {
+		return
+	}
+	var lastString string
+	for _, a := range args {
+		switch a.kind {
+		case 's':
+			lastString = a.str
+		case 'n':
+			iface := spec.NewInterface
+			if iface == "" {
+				iface = lastString
+			}
+			r.objects[a.value] = iface
+		}
+	}
+}
@@ -0,0 +1,376 @@
+package wire_capture
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/bnema/libwldevices-go/internal/protocols"
+)
+
+// Direction tells who sent a message
+type Direction int
+
+// Message directions
+const (
+	Request Direction = iota // Sent by the client
+	Event                    // Sent by the compositor
+)
+
+// String returns the arrow used for the direction in capture files
+func (d Direction) String() string {
+	if d == Request {
+		return "->"
+	}
+	return "<-"
+}
+
+// Message is a request or an event as it was on the wire
+type Message struct {
+	Direction Direction
+	Object    uint32
+	Interface string // "unknown" for objects the recorder couldn't follow
+	Opcode    uint16
+	Body      []byte // The arguments, without the header
+}
+
+// Bytes returns the message as sent on the wire, header included. File
+// descriptors are passed out of band and aren't part of it.
+func (m Message) Bytes() []byte {
+	data := make([]byte, 8, 8+len(m.Body))
+	binary.LittleEndian.PutUint32(data[0:4], m.Object)
+	binary.LittleEndian.PutUint32(data[4:8], uint32(8+len(m.Body))<<16|uint32(m.Opcode))
+	return append(data, m.Body...)
+}
+
+// spec returns the description of the message, if the interface is known
+func (m Message) spec() (protocols.Message, bool) {
+	messages, ok := protocols.LookupMessages(m.Interface)
+	if !ok {
+		return protocols.Message{}, false
+	}
+	list := messages.Events
+	if m.Direction == Request {
+		list = messages.Requests
+	}
+	if int(m.Opcode) >= len(list) {
+		return protocols.Message{}, false
+	}
+	return list[m.Opcode], true
+}
+
+// Name returns the name of the request or event, or "opcode_N" if the
+// interface is unknown
+func (m Message) Name() string {
+	if spec, ok := m.spec(); ok {
+		return spec.Name
+	}
+	return fmt.Sprintf("opcode_%d", m.Opcode)
+}
+
+// FDs returns the number of file descriptors passed with the message
+func (m Message) FDs() int {
+	spec, _ := m.spec()
+	return strings.Count(spec.Signature, "h")
+}
+
+// String formats the message as a line of a capture file, see Parse
+func (m Message) String() string {
+	return m.format(nil)
+}
+
+// arg is a decoded argument
+type arg struct {
+	kind  byte
+	value uint32 // i, u, f, o, n
+	str   string // s
+	null  bool   // s
+	data  []byte // a
+}
+
+// decodeArgs decodes the body of a message following signature
+func decodeArgs(signature string, body []byte) ([]arg, error) {
+	args := make([]arg, 0, len(signature))
+	for i := 0; i < len(signature); i++ {
+		a := arg{kind: signature[i]}
+		if a.kind == 'h' {
+			// Passed out of band
+			args = append(args, a)
+			continue
+		}
+		if len(body) < 4 {
+			return nil, fmt.Errorf("argument %d: message too short", len(args))
+		}
+		a.value = binary.LittleEndian.Uint32(body)
+		body = body[4:]
+
+		switch a.kind {
+		case 's', 'a':
+			size := int(a.value)
+			padded := (size + 3) &^ 3
+			if padded > len(body) {
+				return nil, fmt.Errorf("argument %d: %d bytes past the end of the message", len(args), padded-len(body))
+			}
+			if a.kind == 'a' {
+				a.data = body[:size]
+			} else if size == 0 {
+				a.null = true
+			} else {
+				a.str = string(body[:size-1])
+			}
+			body = body[padded:]
+		}
+		args = append(args, a)
+	}
+	if len(body) > 0 {
+		return nil, fmt.Errorf("%d bytes left after the arguments", len(body))
+	}
+	return args, nil
+}
+
+// format writes the message, naming the objects it refers to with
+// interfaceOf when known
+func (m Message) format(interfaceOf func(uint32) string) string {
+	var line strings.Builder
+	fmt.Fprintf(&line, "%s %s@%d.", m.Direction, m.Interface, m.Object)
+
+	spec, ok := m.spec()
+	var args []arg
+	var err error
+	if ok {
+		args, err = decodeArgs(spec.Signature, m.Body)
+	}
+	if !ok || err != nil {
+		// Keep the raw body so the line still reads back to the same bytes
+		fmt.Fprintf(&line, "opcode_%d[%s]", m.Opcode, hex.EncodeToString(m.Body))
+		return line.String()
+	}
+
+	fmt.Fprintf(&line, "%s(", spec.Name)
+	var lastString string
+	for i, a := range args {
+		if i > 0 {
+			line.WriteString(", ")
+		}
+		switch a.kind {
+		case 'i':
+			line.WriteString(strconv.Itoa(int(int32(a.value))))
+		case 'u':
+			line.WriteString(strconv.FormatUint(uint64(a.value), 10))
+		case 'f':
+			line.WriteString(strconv.FormatFloat(float64(int32(a.value))/256, 'f', -1, 64))
+		case 's':
+			lastString = a.str
+			if a.null {
+				line.WriteString("nil")
+			} else {
+				line.WriteString(strconv.Quote(a.str))
+			}
+		case 'o':
+			if a.value == 0 {
+				line.WriteString("nil")
+				break
+			}
+			iface := "unknown"
+			if interfaceOf != nil {
+				iface = interfaceOf(a.value)
+			}
+			fmt.Fprintf(&line, "%s@%d", iface, a.value)
+		case 'n':
+			iface := spec.NewInterface
+			if iface == "" {
+				iface = lastString
+			}
+			fmt.Fprintf(&line, "new id %s@%d", iface, a.value)
+		case 'a':
+			fmt.Fprintf(&line, "array[%s]", hex.EncodeToString(a.data))
+		case 'h':
+			line.WriteString("fd")
+		}
+	}
+	line.WriteString(")")
+	return line.String()
+}
+
+// parseMessage reads a line written by Message.String
+func parseMessage(line string) (Message, error) {
+	var m Message
+	switch {
+	case strings.HasPrefix(line, "-> "):
+		m.Direction = Request
+	case strings.HasPrefix(line, "<- "):
+		m.Direction = Event
+	default:
+		return m, fmt.Errorf("expected -> or <-")
+	}
+	line = line[3:]
+
+	at := strings.IndexByte(line, '@')
+	dot := strings.IndexByte(line, '.')
+	if at <= 0 || dot < at {
+		return m, fmt.Errorf("expected interface@id.name")
+	}
+	m.Interface = line[:at]
+	object, err := strconv.ParseUint(line[at+1:dot], 10, 32)
+	if err != nil {
+		return m, fmt.Errorf("invalid object ID: %w", err)
+	}
+	m.Object = uint32(object)
+	line = line[dot+1:]
+
+	// Raw message of an interface the recorder didn't know
+	if open := strings.IndexByte(line, '['); strings.HasPrefix(line, "opcode_") && open > 0 && strings.HasSuffix(line, "]") {
+		opcode, err := strconv.ParseUint(line[len("opcode_"):open], 10, 16)
+		if err != nil {
+			return m, fmt.Errorf("invalid opcode: %w", err)
+		}
+		m.Opcode = uint16(opcode)
+		if m.Body, err = hex.DecodeString(line[open+1 : len(line)-1]); err != nil {
+			return m, fmt.Errorf("invalid body: %w", err)
+		}
+		return m, nil
+	}
+
+	open := strings.IndexByte(line, '(')
+	if open <= 0 || !strings.HasSuffix(line, ")") {
+		return m, fmt.Errorf("expected name(arguments)")
+	}
+	name := line[:open]
+	messages, ok := protocols.LookupMessages(m.Interface)
+	if !ok {
+		return m, fmt.Errorf("unknown interface %s, write its messages as opcode_N[hex]", m.Interface)
+	}
+	list := messages.Events
+	if m.Direction == Request {
+		list = messages.Requests
+	}
+	index := -1
+	for i, spec := range list {
+		if spec.Name == name {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		kind := "event"
+		if m.Direction == Request {
+			kind = "request"
+		}
+		return m, fmt.Errorf("%s has no %s named %s", m.Interface, kind, name)
+	}
+	m.Opcode = uint16(index)
+
+	tokens, err := splitArgs(line[open+1 : len(line)-1])
+	if err != nil {
+		return m, err
+	}
+	signature := list[index].Signature
+	if len(tokens) != len(signature) {
+		return m, fmt.Errorf("%s takes %d arguments, got %d", name, len(signature), len(tokens))
+	}
+	for i, token := range tokens {
+		if m.Body, err = appendArg(m.Body, signature[i], token); err != nil {
+			return m, fmt.Errorf("argument %d: %w", i, err)
+		}
+	}
+	return m, nil
+}
+
+// splitArgs splits an argument list on the commas outside of strings
+func splitArgs(list string) ([]string, error) {
+	var tokens []string
+	for list = strings.TrimSpace(list); list != ""; {
+		var token string
+		if list[0] == '"' {
+			quoted, err := strconv.QuotedPrefix(list)
+			if err != nil {
+				return nil, fmt.Errorf("invalid string %s", list)
+			}
+			token = quoted
+		} else if comma := strings.IndexByte(list, ','); comma >= 0 {
+			token = list[:comma]
+		} else {
+			token = list
+		}
+		tokens = append(tokens, strings.TrimSpace(token))
+
+		list = strings.TrimSpace(list[len(token):])
+		if list == "" {
+			break
+		}
+		if list[0] != ',' {
+			return nil, fmt.Errorf("expected a comma before %s", list)
+		}
+		list = strings.TrimSpace(list[1:])
+	}
+	return tokens, nil
+}
+
+// appendArg encodes one argument written as by Message.String
+func appendArg(body []byte, kind byte, token string) ([]byte, error) {
+	switch kind {
+	case 'i':
+		v, err := strconv.ParseInt(token, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		return binary.LittleEndian.AppendUint32(body, uint32(int32(v))), nil
+	case 'u':
+		v, err := strconv.ParseUint(token, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		return binary.LittleEndian.AppendUint32(body, uint32(v)), nil
+	case 'f':
+		v, err := strconv.ParseFloat(token, 64)
+		if err != nil {
+			return nil, err
+		}
+		return binary.LittleEndian.AppendUint32(body, uint32(int32(math.Round(v*256)))), nil
+	case 's':
+		if token == "nil" {
+			return binary.LittleEndian.AppendUint32(body, 0), nil
+		}
+		s, err := strconv.Unquote(token)
+		if err != nil {
+			return nil, fmt.Errorf("invalid string %s", token)
+		}
+		return appendPadded(body, append([]byte(s), 0)), nil
+	case 'o', 'n':
+		token = strings.TrimPrefix(token, "new id ")
+		if token == "nil" {
+			return binary.LittleEndian.AppendUint32(body, 0), nil
+		}
+		at := strings.LastIndexByte(token, '@')
+		id, err := strconv.ParseUint(token[at+1:], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid object %s", token)
+		}
+		return binary.LittleEndian.AppendUint32(body, uint32(id)), nil
+	case 'a':
+		if !strings.HasPrefix(token, "array[") || !strings.HasSuffix(token, "]") {
+			return nil, fmt.Errorf("expected array[hex], got %s", token)
+		}
+		data, err := hex.DecodeString(token[len("array[") : len(token)-1])
+		if err != nil {
+			return nil, err
+		}
+		return appendPadded(body, data), nil
+	case 'h':
+		if token != "fd" {
+			return nil, fmt.Errorf("expected fd, got %s", token)
+		}
+		return body, nil
+	}
+	return nil, fmt.Errorf("unknown argument type %q", kind)
+}
+
+// appendPadded appends the size of data, then data padded to 32 bits
+func appendPadded(body, data []byte) []byte {
+	body = binary.LittleEndian.AppendUint32(body, uint32(len(data)))
+	body = append(body, data...)
+	return append(body, make([]byte, (4-len(data)%4)%4)...)
+}
@@ -0,0 +1,281 @@
+package wire_capture
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+)
+
+// ErrReplayClosed is returned by Wait when the replay is closed before the
+// capture was played to the end
+var ErrReplayClosed = errors.New("replay closed")
+
+// Replay plays the compositor side of a capture to the first client that
+// connects to its socket. Every event of the capture is sent once the client
+// has made all the requests recorded before it. Requests are matched on their
+// object and opcode only, so arguments such as timestamps may differ from the
+// capture; any other difference stops the replay and disconnects the client.
+//
+// Once the capture has been played, the replay keeps the connection open and
+// answers wl_display.sync, so the client can keep making roundtrips and close
+// cleanly. File descriptors sent with events are replaced by /dev/null.
+type Replay struct {
+	capture  *Capture
+	dir      string
+	path     string
+	listener *net.UnixListener
+	wg       sync.WaitGroup
+
+	mu       sync.Mutex
+	conns    []*net.UnixConn
+	played   bool
+	err      error
+	finished chan struct{}
+	closed   bool
+}
+
+// NewReplay listens on a socket in a new temporary directory and plays
+// capture to the first client. Close it when done.
+func NewReplay(capture *Capture) (*Replay, error) {
+	dir, err := os.MkdirTemp("", "wire-replay-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	r := &Replay{
+		capture:  capture,
+		dir:      dir,
+		path:     filepath.Join(dir, "wayland-0"),
+		finished: make(chan struct{}),
+	}
+
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: r.path, Net: "unix"})
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to listen on %s: %w", r.path, err)
+	}
+	r.listener = listener
+
+	r.wg.Add(1)
+	go r.accept()
+	return r, nil
+}
+
+// Path returns the socket path, for session.WithSocketPath
+func (r *Replay) Path() string {
+	return r.path
+}
+
+// Wait waits until the whole capture has been played, or the replay failed.
+// It returns the first mismatch between the client and the capture.
+func (r *Replay) Wait(ctx context.Context) error {
+	select {
+	case <-r.finished:
+		return r.Err()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Err returns the first mismatch between the client and the capture, if any
+func (r *Replay) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Close stops the replay and disconnects the client
+func (r *Replay) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	conns := r.conns
+	r.mu.Unlock()
+
+	err := r.listener.Close()
+	for _, conn := range conns {
+		_ = conn.Close()
+	}
+	r.wg.Wait()
+	r.finish(ErrReplayClosed)
+	if rmErr := os.RemoveAll(r.dir); err == nil {
+		err = rmErr
+	}
+	return err
+}
+
+// finish records the outcome of the replay, the first one wins
+func (r *Replay) finish(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.played {
+		return
+	}
+	r.played = true
+	r.err = err
+	close(r.finished)
+}
+
+func (r *Replay) accept() {
+	defer r.wg.Done()
+	first := true
+	for {
+		conn, err := r.listener.AcceptUnix()
+		if err != nil {
+			return
+		}
+		r.mu.Lock()
+		if r.closed {
+			r.mu.Unlock()
+			_ = conn.Close()
+			return
+		}
+		r.conns = append(r.conns, conn)
+		r.mu.Unlock()
+
+		if !first {
+			// The capture holds a single connection
+			_ = conn.Close()
+			continue
+		}
+		first = false
+		r.wg.Add(1)
+		go r.play(conn)
+	}
+}
+
+// play runs the capture on conn
+func (r *Replay) play(conn *net.UnixConn) {
+	defer r.wg.Done()
+	defer func() { _ = conn.Close() }()
+
+	// Follows the objects of the capture to name them in errors
+	objects := NewRecorder(io.Discard)
+	reader := &requestReader{conn: conn}
+
+	for i, want := range r.capture.Messages {
+		if want.Direction == Event {
+			if err := sendEvent(conn, want); err != nil {
+				r.finish(fmt.Errorf("message %d: failed to send %s: %w", i, want, err))
+				return
+			}
+			_ = objects.write(want)
+			continue
+		}
+
+		object, opcode, body, err := reader.next()
+		if err != nil {
+			r.finish(fmt.Errorf("message %d: client disconnected, want %s", i, want))
+			return
+		}
+		if object != want.Object || opcode != want.Opcode {
+			got := Message{Direction: Request, Object: object, Interface: objects.interfaceOf(object), Opcode: opcode, Body: body}
+			r.finish(fmt.Errorf("message %d: got %s, want %s", i, got, want))
+			return
+		}
+		_ = objects.write(want)
+	}
+	r.finish(nil)
+
+	for {
+		object, opcode, body, err := reader.next()
+		if err != nil {
+			return
+		}
+		// wl_display.sync: callback.done, then wl_display.delete_id
+		if object == 1 && opcode == 0 && len(body) >= 4 {
+			callback := binary.LittleEndian.Uint32(body)
+			done := Message{Direction: Event, Object: callback, Body: binary.LittleEndian.AppendUint32(nil, 0)}
+			deleteID := Message{Direction: Event, Object: 1, Opcode: 1, Body: binary.LittleEndian.AppendUint32(nil, callback)}
+			if sendEvent(conn, done) != nil || sendEvent(conn, deleteID) != nil {
+				return
+			}
+		}
+	}
+}
+
+// sendEvent writes m to conn, with /dev/null for its file descriptors
+func sendEvent(conn *net.UnixConn, m Message) error {
+	var oob []byte
+	if n := m.FDs(); n > 0 {
+		fds := make([]int, 0, n)
+		defer func() {
+			for _, fd := range fds {
+				_ = syscall.Close(fd)
+			}
+		}()
+		for range n {
+			fd, err := syscall.Open(os.DevNull, syscall.O_RDONLY|syscall.O_CLOEXEC, 0)
+			if err != nil {
+				return err
+			}
+			fds = append(fds, fd)
+		}
+		oob = syscall.UnixRights(fds...)
+	}
+	_, _, err := conn.WriteMsgUnix(m.Bytes(), oob, nil)
+	return err
+}
+
+// requestReader splits the stream of a client into messages
+type requestReader struct {
+	conn *net.UnixConn
+	buf  []byte
+}
+
+// next returns the next request. Descriptors sent by the client are closed.
+func (r *requestReader) next() (object uint32, opcode uint16, body []byte, err error) {
+	chunk := make([]byte, 4096)
+	oob := make([]byte, syscall.CmsgSpace(28*4))
+	for {
+		if len(r.buf) >= 8 {
+			size := int(binary.LittleEndian.Uint32(r.buf[4:8]) >> 16)
+			if size < 8 {
+				return 0, 0, nil, fmt.Errorf("invalid message size %d", size)
+			}
+			if len(r.buf) >= size {
+				object = binary.LittleEndian.Uint32(r.buf[0:4])
+				opcode = uint16(binary.LittleEndian.Uint32(r.buf[4:8]))
+				body = append([]byte(nil), r.buf[8:size]...)
+				r.buf = r.buf[size:]
+				return object, opcode, body, nil
+			}
+		}
+
+		n, oobn, _, _, err := r.conn.ReadMsgUnix(chunk, oob)
+		if oobn > 0 {
+			closeFDs(oob[:oobn])
+		}
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		if n <= 0 {
+			return 0, 0, nil, io.EOF
+		}
+		r.buf = append(r.buf, chunk[:n]...)
+	}
+}
+
+// closeFDs closes the descriptors passed in a control message
+func closeFDs(oob []byte) {
+	messages, err := syscall.ParseSocketControlMessage(oob)
+	if err != nil {
+		return
+	}
+	for _, m := range messages {
+		if fds, err := syscall.ParseUnixRights(&m); err == nil {
+			for _, fd := range fds {
+				_ = syscall.Close(fd)
+			}
+		}
+	}
+}
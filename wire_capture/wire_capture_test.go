@@ -0,0 +1,205 @@
+package wire_capture
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sample = `# A comment
+-> wl_display@1.get_registry(new id wl_registry@2)
+<- wl_registry@2.global(1, "zwlr_output_manager_v1", 4)
+-> wl_registry@2.bind(1, "zwlr_output_manager_v1", 4, new id zwlr_output_manager_v1@3)
+
+<- zwlr_output_manager_v1@3.head(new id zwlr_output_head_v1@4278190080)
+<- zwlr_output_head_v1@4278190080.name("DP-1, \"main\"")
+<- zwlr_output_head_v1@4278190080.position(-1920, 0)
+<- zwlr_output_head_v1@4278190080.scale(1.25)
+<- zwlr_output_head_v1@4278190080.current_mode(zwlr_output_mode_v1@4278190081)
+<- zwlr_output_head_v1@4278190080.description(nil)
+<- wl_seat@5.opcode_7[0a0b0c0d]
+`
+
+func TestParseAndWrite(t *testing.T) {
+	capture, err := Parse(strings.NewReader(sample))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(capture.Messages) != 10 {
+		t.Fatalf("Parsed %d messages, want 10", len(capture.Messages))
+	}
+	if len(capture.Requests()) != 2 || len(capture.Events()) != 8 {
+		t.Errorf("Got %d requests and %d events", len(capture.Requests()), len(capture.Events()))
+	}
+
+	bind := capture.Messages[2]
+	if bind.Direction != Request || bind.Object != 2 || bind.Opcode != 0 || bind.Name() != "bind" {
+		t.Errorf("Unexpected bind: %+v", bind)
+	}
+	// name, the interface padded to 32 bits, version and new ID
+	want := []byte{
+		1, 0, 0, 0,
+		23, 0, 0, 0, 'z', 'w', 'l', 'r', '_', 'o', 'u', 't', 'p', 'u', 't', '_', 'm', 'a', 'n', 'a', 'g', 'e', 'r', '_', 'v', '1', 0, 0,
+		4, 0, 0, 0,
+		3, 0, 0, 0,
+	}
+	if !bytes.Equal(bind.Body, want) {
+		t.Errorf("bind body = %v, want %v", bind.Body, want)
+	}
+	if size := binary.LittleEndian.Uint32(bind.Bytes()[4:8]) >> 16; int(size) != 8+len(want) {
+		t.Errorf("Header size %d, want %d", size, 8+len(want))
+	}
+
+	scale := capture.Messages[6]
+	if v := int32(binary.LittleEndian.Uint32(scale.Body)); v != 320 {
+		t.Errorf("scale 1.25 encoded as %d, want 320", v)
+	}
+	if raw := capture.Messages[9]; raw.Opcode != 7 || !bytes.Equal(raw.Body, []byte{10, 11, 12, 13}) {
+		t.Errorf("Unexpected raw message: %+v", raw)
+	}
+
+	// Writing follows the objects, naming the mode before it was announced
+	// is the only difference
+	var out bytes.Buffer
+	if err := capture.Write(&out); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	expected := strings.ReplaceAll(sample, "# A comment\n", "")
+	expected = strings.ReplaceAll(expected, "\n\n", "\n")
+	expected = strings.ReplaceAll(expected, "zwlr_output_mode_v1@4278190081", "unknown@4278190081")
+	if out.String() != expected {
+		t.Errorf("Write produced:\n%s\nwant:\n%s", out.String(), expected)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, line := range []string{
+		"wl_display@1.sync(new id wl_callback@3)",
+		"-> wl_display@1.frobnicate()",
+		"-> wl_display@1.sync()",
+		"<- zwlr_output_head_v1@4.position(1.5, 0)",
+		`<- zwlr_output_head_v1@4.name("unterminated)`,
+		"<- wl_compositor@4.enter()",
+	} {
+		if _, err := Parse(strings.NewReader(line)); err == nil {
+			t.Errorf("Parse(%q) should fail", line)
+		}
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	var out bytes.Buffer
+	r := NewRecorder(&out)
+	body := func(words ...uint32) []byte {
+		var b []byte
+		for _, w := range words {
+			b = binary.LittleEndian.AppendUint32(b, w)
+		}
+		return b
+	}
+	_ = r.Record(Request, 1, 0, body(3)) // sync
+	_ = r.Record(Event, 3, 0, body(42))  // done
+	_ = r.Record(Event, 1, 1, body(3))   // delete_id
+	_ = r.Record(Event, 3, 0, body(43))  // no longer known
+
+	want := "-> wl_display@1.sync(new id wl_callback@3)\n" +
+		"<- wl_callback@3.done(42)\n" +
+		"<- wl_display@1.delete_id(3)\n" +
+		"<- unknown@3.opcode_0[2b000000]\n"
+	if out.String() != want {
+		t.Errorf("Recorded:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+// dial connects to a replay and returns a function reading its next message
+func dial(t *testing.T, r *Replay) (*net.UnixConn, func() Message) {
+	t.Helper()
+	conn, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: r.Path(), Net: "unix"})
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	reader := &requestReader{conn: conn}
+	return conn, func() Message {
+		t.Helper()
+		object, opcode, body, err := reader.next()
+		if err != nil {
+			t.Fatalf("Failed to read an event: %v", err)
+		}
+		return Message{Direction: Event, Object: object, Opcode: opcode, Body: body}
+	}
+}
+
+func TestReplay(t *testing.T) {
+	capture, err := Parse(strings.NewReader(`
+-> wl_display@1.sync(new id wl_callback@2)
+<- wl_callback@2.done(7)
+<- wl_display@1.delete_id(2)
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewReplay(capture)
+	if err != nil {
+		t.Fatalf("NewReplay failed: %v", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	conn, next := dial(t, r)
+	sync := Message{Object: 1, Body: binary.LittleEndian.AppendUint32(nil, 2)}
+	if _, err := conn.Write(sync.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if done := next(); done.Object != 2 || binary.LittleEndian.Uint32(done.Body) != 7 {
+		t.Errorf("Unexpected event: %+v", done)
+	}
+	if deleteID := next(); deleteID.Object != 1 || deleteID.Opcode != 1 {
+		t.Errorf("Unexpected event: %+v", deleteID)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := r.Wait(ctx); err != nil {
+		t.Errorf("Wait returned %v", err)
+	}
+
+	// Past the end of the capture, syncs are still answered
+	sync.Body = binary.LittleEndian.AppendUint32(nil, 3)
+	if _, err := conn.Write(sync.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if done := next(); done.Object != 3 {
+		t.Errorf("Unexpected event: %+v", done)
+	}
+}
+
+func TestReplayMismatch(t *testing.T) {
+	capture, err := Parse(strings.NewReader("-> wl_display@1.sync(new id wl_callback@2)\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewReplay(capture)
+	if err != nil {
+		t.Fatalf("NewReplay failed: %v", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	conn, _ := dial(t, r)
+	getRegistry := Message{Object: 1, Opcode: 1, Body: binary.LittleEndian.AppendUint32(nil, 2)}
+	if _, err := conn.Write(getRegistry.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = r.Wait(ctx)
+	if err == nil || !strings.Contains(err.Error(), "got -> wl_display@1.get_registry(new id wl_registry@2)") {
+		t.Errorf("Wait returned %v, want a mismatch", err)
+	}
+}
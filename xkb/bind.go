@@ -0,0 +1,134 @@
+package xkb
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// lastX11Keycode is the last XKB keycode X11 clients see: spare keys past
+// it would type nothing in Xwayland
+const lastX11Keycode = 255
+
+// SpareKeys returns the keys BindKeysyms binds keysyms to, as input event
+// codes: the keys X11 clients see that the keymap binds nothing to, keys
+// without a name in the keycodes section first.
+func (k *Keymap) SpareKeys() ([]uint32, error) {
+	km, err := k.compile()
+	if err != nil {
+		return nil, err
+	}
+	keys := km.spareKeycodes()
+	for i := range keys {
+		keys[i] -= evdevOffset
+	}
+	return keys, nil
+}
+
+func (km *compiled) spareKeycodes() []uint32 {
+	named := make(map[uint32]bool)
+	for _, code := range km.keyNames {
+		named[code] = true
+	}
+	var unnamed, unbound []uint32
+	for code := uint32(evdevOffset + 1); code <= lastX11Keycode; code++ {
+		switch {
+		case km.keys[code] != nil:
+		case named[code]:
+			unbound = append(unbound, code)
+		default:
+			unnamed = append(unnamed, code)
+		}
+	}
+	return append(unnamed, unbound...)
+}
+
+// keyName returns a name of the keycode, the first in alphabetical order
+func (km *compiled) keyName(code uint32) (string, bool) {
+	var names []string
+	for name, c := range km.keyNames {
+		if c == code {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "", false
+	}
+	sort.Strings(names)
+	return names[0], true
+}
+
+// BindKeysyms returns a copy of the keymap with syms bound to spare keys,
+// one level each, and the keystrokes typing them in the order of syms. The
+// other keys are left as they are. It fails when there are more keysyms
+// than spare keys, see SpareKeys.
+//
+// This is how characters missing from a keymap are typed: send the new
+// keymap, type, and send the original one again.
+func (k *Keymap) BindKeysyms(syms []Keysym) (*Keymap, []Keystroke, error) {
+	km, err := k.compile()
+	if err != nil {
+		return nil, nil, err
+	}
+	spare := km.spareKeycodes()
+	if len(syms) > len(spare) {
+		return nil, nil, fmt.Errorf("can't bind %d keysyms to %d spare keys", len(syms), len(spare))
+	}
+
+	var keycodes, symbols strings.Builder
+	strokes := make([]Keystroke, len(syms))
+	for i, sym := range syms {
+		if sym == NoSymbol {
+			return nil, nil, errors.New("can't bind NoSymbol")
+		}
+		code := spare[i]
+		name, ok := km.keyName(code)
+		if !ok {
+			name = fmt.Sprintf("S%03d", code)
+			for n := 1; km.keyNames[name] != 0; n++ {
+				name = fmt.Sprintf("S%03d%d", code, n)
+			}
+			fmt.Fprintf(&keycodes, "\t<%s> = %d;\n", name, code)
+		}
+		fmt.Fprintf(&symbols, "\tkey <%s> { [ %s ] };\n", name, sym.Name())
+		strokes[i] = Keystroke{Key: code - evdevOffset, Keysym: sym}
+	}
+
+	bound := &Keymap{}
+	if bound.text, err = addStatements(k.text, keycodes.String(), symbols.String()); err != nil {
+		return nil, nil, err
+	}
+	if k.fallback != "" {
+		if bound.fallback, err = addStatements(k.fallback, keycodes.String(), symbols.String()); err != nil {
+			return nil, nil, err
+		}
+	}
+	return bound, strokes, nil
+}
+
+// addStatements adds statements at the end of the keycodes and symbols
+// sections of a keymap, where they override the included ones
+func addStatements(text, keycodes, symbols string) (string, error) {
+	ends, err := componentEnds(text)
+	if err != nil {
+		return "", err
+	}
+	keycodesEnd, ok := ends[sectionKeycodes]
+	symbolsEnd, ok2 := ends[sectionSymbols]
+	if !ok || !ok2 {
+		return "", errors.New("keymap has no keycodes or symbols section")
+	}
+	insert := []struct {
+		at   int
+		text string
+	}{{keycodesEnd, keycodes}, {symbolsEnd, symbols}}
+	if keycodesEnd > symbolsEnd {
+		insert[0], insert[1] = insert[1], insert[0]
+	}
+	// From the end, so that the first offset stays valid
+	for i := len(insert) - 1; i >= 0; i-- {
+		text = text[:insert[i].at] + "\n" + insert[i].text + text[insert[i].at:]
+	}
+	return text, nil
+}
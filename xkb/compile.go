@@ -0,0 +1,1331 @@
+package xkb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// This file compiles keymaps the way xkbcommon does, as far as needed to know
+// what each key types: it resolves includes, merges the definitions, applies
+// the symbol interpretations and maps virtual modifiers to real ones.
+
+// Real modifiers, in the order of their index
+var realModNames = []string{"Shift", "Lock", "Control", "Mod1", "Mod2", "Mod3", "Mod4", "Mod5"}
+
+// maxIncludeDepth stops include loops
+const maxIncludeDepth = 15
+
+// modSet holds the real and virtual modifiers of a keymap. Masks use the
+// index of the modifiers: real ones first, then virtual ones in the order
+// they were declared, as in xkbcommon.
+type modSet struct {
+	names    []string
+	explicit []uint32 // Mapping given in virtual_modifiers, if any
+}
+
+func newModSet() *modSet {
+	m := &modSet{}
+	for _, name := range realModNames {
+		m.names = append(m.names, name)
+		m.explicit = append(m.explicit, 0)
+	}
+	return m
+}
+
+// index returns the index of the modifier called name
+func (m *modSet) index(name string) (int, bool) {
+	for i, n := range m.names {
+		if strings.EqualFold(n, name) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// declare adds a virtual modifier, unless it exists
+func (m *modSet) declare(name string) (int, error) {
+	if i, ok := m.index(name); ok {
+		return i, nil
+	}
+	if len(m.names) >= 32 {
+		return 0, fmt.Errorf("too many modifiers, can't declare %s", name)
+	}
+	m.names = append(m.names, name)
+	m.explicit = append(m.explicit, 0)
+	return len(m.names) - 1, nil
+}
+
+// actionKind is the type of a key action, as far as it matters to typing
+type actionKind int
+
+const (
+	actionNone actionKind = iota
+	actionSetMods
+	actionLatchMods
+	actionLockMods
+	actionSetGroup
+	actionLatchGroup
+	actionLockGroup
+	actionOther
+)
+
+// action is what a key does when pressed, besides producing its keysyms
+type action struct {
+	kind      actionKind
+	mods      uint32 // Modifier indexes, resolved to real modifiers at the end
+	useModMap bool   // modifiers=modMapMods
+	group     int32
+	absolute  bool // group=2 rather than group=+1
+}
+
+// Info gathered from the sections, before the keymap is put together
+
+type keycodesInfo struct {
+	codes   map[string]uint32
+	aliases map[string]string
+}
+
+type typeEntry struct {
+	mods  uint32
+	level int
+}
+
+type typeInfo struct {
+	mods    uint32
+	entries []typeEntry
+	levels  int
+}
+
+type typesInfo struct {
+	types map[string]*typeInfo
+}
+
+// matchOp is the predicate of an interpret statement
+type matchOp int
+
+const (
+	matchNone matchOp = iota
+	matchAnyOrNone
+	matchAny
+	matchAll
+	matchExactly
+)
+
+type interpretInfo struct {
+	sym      Keysym // NoSymbol for Any
+	match    matchOp
+	mods     uint32
+	action   action
+	vmod     int // -1 when not set
+	levelOne bool
+}
+
+type compatInfo struct {
+	interprets []*interpretInfo
+}
+
+type levelInfo struct {
+	syms      []Keysym // nil when not defined
+	action    action
+	hasAction bool
+}
+
+type groupInfo struct {
+	typeName string
+	levels   []levelInfo
+}
+
+type keyInfo struct {
+	groups      []groupInfo
+	defaultType string
+	vmods       uint32
+	hasVmods    bool
+}
+
+type modMapEntry struct {
+	mod     int
+	key     string // Key name, or "" for a keysym
+	sym     Keysym
+	augment bool
+}
+
+type symbolsInfo struct {
+	keys       map[string]*keyInfo
+	modMap     []modMapEntry
+	groupNames map[int]string
+}
+
+// compiler builds a keymap from its text
+type compiler struct {
+	mods     *modSet
+	files    map[string][]*section // Parsed include files
+	depth    int
+	keycodes *keycodesInfo
+	types    *typesInfo
+	compat   *compatInfo
+	symbols  *symbolsInfo
+}
+
+// compileKeymap compiles the text of a keymap
+func compileKeymap(text string) (*compiled, error) {
+	sections, err := parseFile(text)
+	if err != nil {
+		return nil, err
+	}
+	if len(sections) != 1 || sections[0].kind != sectionKeymap {
+		return nil, errors.New("expected a single xkb_keymap section")
+	}
+
+	c := &compiler{
+		mods:     newModSet(),
+		files:    make(map[string][]*section),
+		keycodes: &keycodesInfo{codes: map[string]uint32{}, aliases: map[string]string{}},
+		types:    &typesInfo{types: map[string]*typeInfo{}},
+		compat:   &compatInfo{},
+		symbols:  newSymbolsInfo(),
+	}
+	// Components are compiled in this order whatever the order of the keymap
+	found := make(map[sectionKind]bool)
+	for _, kind := range []sectionKind{sectionKeycodes, sectionTypes, sectionCompat, sectionSymbols} {
+		for _, s := range sections[0].sections {
+			if s.kind != kind {
+				continue
+			}
+			if found[kind] {
+				return nil, fmt.Errorf("more than one %s section", sectionDirs[kind])
+			}
+			found[kind] = true
+			if err := c.section(s, c.infoOf(kind), 0); err != nil {
+				return nil, fmt.Errorf("%s: %w", sectionDirs[kind], err)
+			}
+		}
+	}
+	for _, kind := range []sectionKind{sectionKeycodes, sectionTypes, sectionCompat, sectionSymbols} {
+		if !found[kind] {
+			return nil, fmt.Errorf("no %s section", sectionDirs[kind])
+		}
+	}
+	return c.build()
+}
+
+func newSymbolsInfo() *symbolsInfo {
+	return &symbolsInfo{keys: map[string]*keyInfo{}, groupNames: map[int]string{}}
+}
+
+// infoOf returns the info the top level section of kind is gathered in
+func (c *compiler) infoOf(kind sectionKind) any {
+	switch kind {
+	case sectionKeycodes:
+		return c.keycodes
+	case sectionTypes:
+		return c.types
+	case sectionCompat:
+		return c.compat
+	}
+	return c.symbols
+}
+
+// newInfo returns an empty info for a section of kind
+func newInfo(kind sectionKind) any {
+	switch kind {
+	case sectionKeycodes:
+		return &keycodesInfo{codes: map[string]uint32{}, aliases: map[string]string{}}
+	case sectionTypes:
+		return &typesInfo{types: map[string]*typeInfo{}}
+	case sectionCompat:
+		return &compatInfo{}
+	}
+	return newSymbolsInfo()
+}
+
+// sectionState holds the defaults set by statements such as
+// key.type = "FOUR_LEVEL" for the rest of a section
+type sectionState struct {
+	group        int // Group the first group of keys goes to, from include "us:2"
+	defaultKey   keyInfo
+	interpLevel1 bool
+}
+
+// section gathers the statements of s into info, following its includes.
+// Keys of symbols sections go to group and the groups after it.
+func (c *compiler) section(s *section, info any, group int) error {
+	state := &sectionState{group: group}
+	for _, st := range s.stmts {
+		var err error
+		switch st := st.(type) {
+		case *includeStmt:
+			var included any
+			included, err = c.include(s.kind, st.spec, group)
+			if err == nil {
+				mergeInfo(info, included, st.merge)
+			}
+		case *vmodStmt:
+			err = c.vmods(st)
+		default:
+			switch s.kind {
+			case sectionKeycodes:
+				c.keycodesStmt(info.(*keycodesInfo), st)
+			case sectionTypes:
+				err = c.typesStmt(info.(*typesInfo), st)
+			case sectionCompat:
+				err = c.compatStmt(info.(*compatInfo), state, st)
+			case sectionSymbols:
+				err = c.symbolsStmt(info.(*symbolsInfo), state, st)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// includePart is a file of an include statement such as "pc+de(basic):2"
+type includePart struct {
+	merge   mergeMode
+	file    string
+	section string
+	group   int // From the :N suffix, 0 when absent
+}
+
+func parseIncludeSpec(spec string) ([]includePart, error) {
+	var parts []includePart
+	merge := mergeOverride
+	for spec != "" {
+		switch spec[0] {
+		case '+':
+			merge, spec = mergeOverride, spec[1:]
+			continue
+		case '|':
+			merge, spec = mergeAugment, spec[1:]
+			continue
+		}
+		end := strings.IndexAny(spec, "+|")
+		if end < 0 {
+			end = len(spec)
+		}
+		part := includePart{merge: merge, file: spec[:end]}
+		spec = spec[end:]
+
+		if colon := strings.IndexByte(part.file, ':'); colon >= 0 {
+			g, err := strconv.Atoi(part.file[colon+1:])
+			if err != nil || g < 1 || g > 4 {
+				return nil, fmt.Errorf("invalid group in %q", part.file)
+			}
+			part.file, part.group = part.file[:colon], g
+		}
+		if open := strings.IndexByte(part.file, '('); open >= 0 {
+			if !strings.HasSuffix(part.file, ")") {
+				return nil, fmt.Errorf("invalid include %q", part.file)
+			}
+			part.file, part.section = part.file[:open], part.file[open+1:len(part.file)-1]
+		}
+		if part.file == "" {
+			return nil, fmt.Errorf("empty file name in include")
+		}
+		parts = append(parts, part)
+	}
+	return parts, nil
+}
+
+// include gathers the files named by an include statement
+func (c *compiler) include(kind sectionKind, spec string, group int) (any, error) {
+	if c.depth >= maxIncludeDepth {
+		return nil, fmt.Errorf("include %q: too many nested includes", spec)
+	}
+	parts, err := parseIncludeSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	result := newInfo(kind)
+	for _, part := range parts {
+		s, err := c.loadSection(kind, part.file, part.section)
+		if err != nil {
+			return nil, err
+		}
+		g := group
+		if part.group > 0 {
+			g = part.group - 1
+		}
+		next := newInfo(kind)
+		c.depth++
+		err = c.section(s, next, g)
+		c.depth--
+		if err != nil {
+			return nil, fmt.Errorf("%s(%s): %w", part.file, s.name, err)
+		}
+		mergeInfo(result, next, part.merge)
+	}
+	return result, nil
+}
+
+// loadSection finds the section called name, or the default one, in the
+// file of the include path
+func (c *compiler) loadSection(kind sectionKind, file, name string) (*section, error) {
+	path, err := findFile(sectionDirs[kind], file)
+	if err != nil {
+		return nil, err
+	}
+	sections, ok := c.files[path]
+	if !ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if sections, err = parseFile(string(data)); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		c.files[path] = sections
+	}
+
+	var candidates []*section
+	for _, s := range sections {
+		if s.kind == kind {
+			candidates = append(candidates, s)
+		}
+	}
+	for _, s := range candidates {
+		if name != "" && s.name == name || name == "" && s.isDefault {
+			return s, nil
+		}
+	}
+	if name == "" && len(candidates) > 0 {
+		return candidates[0], nil
+	}
+	return nil, fmt.Errorf("no section %q in %s", name, filepath.Join(sectionDirs[kind], file))
+}
+
+// vmods declares virtual modifiers, with their mapping when given
+func (c *compiler) vmods(st *vmodStmt) error {
+	for i, name := range st.names {
+		index, err := c.mods.declare(name)
+		if err != nil {
+			return err
+		}
+		if st.maps[i] != nil {
+			mask, err := c.resolveMods(st.maps[i])
+			if err != nil {
+				return err
+			}
+			c.mods.explicit[index] = mask
+		}
+	}
+	return nil
+}
+
+// mergeInfo merges the info gathered from an include into info
+func mergeInfo(into, from any, merge mergeMode) {
+	clobber := merge != mergeAugment
+	switch into := into.(type) {
+	case *keycodesInfo:
+		from := from.(*keycodesInfo)
+		for name, code := range from.codes {
+			if _, ok := into.codes[name]; !ok || clobber {
+				into.codes[name] = code
+			}
+		}
+		for alias, real := range from.aliases {
+			if _, ok := into.aliases[alias]; !ok || clobber {
+				into.aliases[alias] = real
+			}
+		}
+	case *typesInfo:
+		for name, t := range from.(*typesInfo).types {
+			if _, ok := into.types[name]; !ok || clobber {
+				into.types[name] = t
+			}
+		}
+	case *compatInfo:
+		for _, in := range from.(*compatInfo).interprets {
+			into.add(in, clobber)
+		}
+	case *symbolsInfo:
+		from := from.(*symbolsInfo)
+		for name, k := range from.keys {
+			into.addKey(name, k, merge)
+		}
+		for _, entry := range from.modMap {
+			entry.augment = entry.augment || !clobber
+			into.modMap = append(into.modMap, entry)
+		}
+		for g, name := range from.groupNames {
+			if _, ok := into.groupNames[g]; !ok || clobber {
+				into.groupNames[g] = name
+			}
+		}
+	}
+}
+
+// Keycodes
+
+func (c *compiler) keycodesStmt(info *keycodesInfo, st stmt) {
+	switch st := st.(type) {
+	case *keycodeStmt:
+		if _, ok := info.codes[st.name]; !ok || st.merge != mergeAugment {
+			info.codes[st.name] = uint32(st.code)
+		}
+	case *aliasStmt:
+		if _, ok := info.aliases[st.alias]; !ok || st.merge != mergeAugment {
+			info.aliases[st.alias] = st.real
+		}
+	}
+	// minimum, maximum and indicators don't matter here
+}
+
+// Types
+
+func (c *compiler) typesStmt(info *typesInfo, st stmt) error {
+	ts, ok := st.(*typeStmt)
+	if !ok {
+		return nil
+	}
+	t := &typeInfo{levels: 1}
+	for _, v := range ts.body {
+		switch strings.ToLower(v.lhs.field) {
+		case "modifiers":
+			mods, err := c.resolveMods(v.value)
+			if err != nil {
+				return fmt.Errorf("type %s: %w", ts.name, err)
+			}
+			t.mods = mods
+		case "map":
+			if v.lhs.index == nil {
+				return fmt.Errorf("type %s: map without modifiers", ts.name)
+			}
+			mods, err := c.resolveMods(v.lhs.index)
+			if err != nil {
+				return fmt.Errorf("type %s: %w", ts.name, err)
+			}
+			level, err := resolveLevel(v.value)
+			if err != nil {
+				return fmt.Errorf("type %s: %w", ts.name, err)
+			}
+			// A later entry for the same modifiers wins
+			replaced := false
+			for i := range t.entries {
+				if t.entries[i].mods == mods {
+					t.entries[i].level, replaced = level, true
+				}
+			}
+			if !replaced {
+				t.entries = append(t.entries, typeEntry{mods: mods, level: level})
+			}
+			t.levels = max(t.levels, level+1)
+		case "level_name", "levelname":
+			if v.lhs.index != nil {
+				if level, err := resolveLevel(v.lhs.index); err == nil {
+					t.levels = max(t.levels, level+1)
+				}
+			}
+		}
+		// preserve only changes the modifiers consumed by the level
+	}
+	if _, exists := info.types[ts.name]; !exists || ts.merge != mergeAugment {
+		info.types[ts.name] = t
+	}
+	return nil
+}
+
+// Compat
+
+// add adds an interpretation, replacing the one with the same keysym and
+// predicate if clobber
+func (info *compatInfo) add(in *interpretInfo, clobber bool) {
+	for i, old := range info.interprets {
+		if old.sym == in.sym && old.match == in.match && old.mods == in.mods {
+			if clobber {
+				info.interprets[i] = in
+			}
+			return
+		}
+	}
+	info.interprets = append(info.interprets, in)
+}
+
+func (c *compiler) compatStmt(info *compatInfo, state *sectionState, st stmt) error {
+	switch st := st.(type) {
+	case *varStmt:
+		// interpret.useModMapMods = level1; sets the default of the section
+		if strings.EqualFold(st.lhs.elem, "interpret") && strings.EqualFold(st.lhs.field, "useModMapMods") {
+			levelOne, err := resolveLevelOne(st.value)
+			if err != nil {
+				return err
+			}
+			state.interpLevel1 = levelOne
+		}
+		return nil
+	case *interpretStmt:
+		in := &interpretInfo{vmod: -1, levelOne: state.interpLevel1}
+		if !strings.EqualFold(st.sym, "Any") {
+			sym, err := resolveKeysym(&expr{kind: exprIdent, text: st.sym})
+			if err != nil {
+				return err
+			}
+			if sym == NoSymbol {
+				// Unknown keysyms never match a key
+				return nil
+			}
+			in.sym = sym
+		}
+		if err := c.resolvePredicate(st.pred, in); err != nil {
+			return fmt.Errorf("interpret %s: %w", st.sym, err)
+		}
+		for _, v := range st.body {
+			var err error
+			switch strings.ToLower(v.lhs.field) {
+			case "action":
+				in.action, err = c.resolveAction(v.value)
+			case "virtualmodifier", "virtualmod":
+				if v.value.kind != exprIdent {
+					return fmt.Errorf("interpret %s: invalid virtual modifier", st.sym)
+				}
+				in.vmod, err = c.mods.declare(v.value.text)
+			case "usemodmapmods", "usemodmap":
+				in.levelOne, err = resolveLevelOne(v.value)
+			}
+			if err != nil {
+				return fmt.Errorf("interpret %s: %w", st.sym, err)
+			}
+		}
+		info.add(in, st.merge != mergeAugment)
+	}
+	return nil
+}
+
+// resolvePredicate reads the part of interpret Sym+Pred(mods) after the +
+func (c *compiler) resolvePredicate(pred *expr, in *interpretInfo) error {
+	if pred == nil {
+		in.match, in.mods = matchAnyOrNone, 0xff
+		return nil
+	}
+	in.match = matchExactly
+	if pred.kind == exprAction {
+		ops := map[string]matchOp{
+			"noneof":      matchNone,
+			"anyofornone": matchAnyOrNone,
+			"anyof":       matchAny,
+			"allof":       matchAll,
+			"exactly":     matchExactly,
+		}
+		op, ok := ops[strings.ToLower(pred.text)]
+		if !ok || len(pred.args) != 1 {
+			return fmt.Errorf("invalid predicate %s", pred.text)
+		}
+		in.match = op
+		pred = pred.args[0]
+	} else if pred.kind == exprIdent && strings.EqualFold(pred.text, "any") {
+		in.match, in.mods = matchAny, 0xff
+		return nil
+	}
+	mods, err := c.resolveMods(pred)
+	if err != nil {
+		return err
+	}
+	in.mods = mods & 0xff
+	return nil
+}
+
+func resolveLevelOne(e *expr) (bool, error) {
+	if e.kind == exprIdent {
+		switch strings.ToLower(e.text) {
+		case "level1", "levelone", "true", "yes", "on":
+			return true, nil
+		case "anylevel", "any", "false", "no", "off":
+			return false, nil
+		}
+	}
+	return false, errors.New("invalid useModMapMods")
+}
+
+// Symbols
+
+// addKey merges a key definition into the keys
+func (info *symbolsInfo) addKey(name string, k *keyInfo, merge mergeMode) {
+	old, ok := info.keys[name]
+	if !ok || merge == mergeReplace {
+		info.keys[name] = k
+		return
+	}
+	clobber := merge != mergeAugment
+	for g := range k.groups {
+		for len(old.groups) <= g {
+			old.groups = append(old.groups, groupInfo{})
+		}
+		from, into := &k.groups[g], &old.groups[g]
+		if from.typeName != "" && (clobber || into.typeName == "") {
+			into.typeName = from.typeName
+		}
+		for l, level := range from.levels {
+			for len(into.levels) <= l {
+				into.levels = append(into.levels, levelInfo{})
+			}
+			if level.isDefined() && (clobber || !into.levels[l].isDefined()) {
+				into.levels[l].syms = level.syms
+			}
+			if level.hasAction && (clobber || !into.levels[l].hasAction) {
+				into.levels[l].action, into.levels[l].hasAction = level.action, true
+			}
+		}
+	}
+	if k.defaultType != "" && (clobber || old.defaultType == "") {
+		old.defaultType = k.defaultType
+	}
+	if k.hasVmods && (clobber || !old.hasVmods) {
+		old.vmods, old.hasVmods = k.vmods, true
+	}
+}
+
+// isDefined reports whether the level has keysyms: NoSymbol doesn't
+// override what was defined before
+func (l levelInfo) isDefined() bool {
+	for _, sym := range l.syms {
+		if sym != NoSymbol {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *compiler) symbolsStmt(info *symbolsInfo, state *sectionState, st stmt) error {
+	switch st := st.(type) {
+	case *keyStmt:
+		k, err := c.keyDef(st, state)
+		if err != nil {
+			return fmt.Errorf("key <%s>: %w", st.name, err)
+		}
+		info.addKey(st.name, k, st.merge)
+
+	case *modMapStmt:
+		mod, ok := c.mods.index(st.mod)
+		if !ok || mod >= len(realModNames) {
+			return fmt.Errorf("modifier_map: %s is not a real modifier", st.mod)
+		}
+		for _, e := range st.keys {
+			entry := modMapEntry{mod: mod, augment: st.merge == mergeAugment}
+			if e.kind == exprKeyName {
+				entry.key = e.text
+			} else {
+				sym, err := resolveKeysym(e)
+				if err != nil {
+					return fmt.Errorf("modifier_map: %w", err)
+				}
+				if sym == NoSymbol {
+					continue
+				}
+				entry.sym = sym
+			}
+			info.modMap = append(info.modMap, entry)
+		}
+
+	case *varStmt:
+		switch {
+		case strings.EqualFold(st.lhs.elem, "key"):
+			// key.type[Group1] = "FOUR_LEVEL"; applies to the next keys
+			if strings.EqualFold(st.lhs.field, "type") {
+				if err := c.keyType(&state.defaultKey, st, state.group); err != nil {
+					return err
+				}
+			}
+		case st.lhs.elem == "" && (strings.EqualFold(st.lhs.field, "name") || strings.EqualFold(st.lhs.field, "groupname")):
+			if st.lhs.index == nil || st.value.kind != exprString {
+				return errors.New("invalid group name")
+			}
+			g, err := resolveGroup(st.lhs.index)
+			if err != nil {
+				return err
+			}
+			if g == 0 && state.group > 0 {
+				g = state.group
+			}
+			if _, ok := info.groupNames[g]; !ok || st.merge != mergeAugment {
+				info.groupNames[g] = st.value.text
+			}
+		}
+	}
+	return nil
+}
+
+// keyDef reads the body of a key statement
+func (c *compiler) keyDef(st *keyStmt, state *sectionState) (*keyInfo, error) {
+	k := &keyInfo{defaultType: state.defaultKey.defaultType}
+	for _, g := range state.defaultKey.groups {
+		k.groups = append(k.groups, groupInfo{typeName: g.typeName})
+	}
+	group := func(g int) *groupInfo {
+		for len(k.groups) <= g {
+			k.groups = append(k.groups, groupInfo{})
+		}
+		return &k.groups[g]
+	}
+
+	nextGroup := 0
+	for _, v := range st.body {
+		field := strings.ToLower(v.lhs.field)
+		switch {
+		case v.lhs.field == "" || field == "symbols":
+			g := nextGroup
+			if v.lhs.index != nil {
+				var err error
+				if g, err = resolveGroup(v.lhs.index); err != nil {
+					return nil, err
+				}
+			}
+			nextGroup = g + 1
+			levels, err := resolveSymbols(v.value)
+			if err != nil {
+				return nil, err
+			}
+			gi := group(g)
+			for len(gi.levels) < len(levels) {
+				gi.levels = append(gi.levels, levelInfo{})
+			}
+			for l, syms := range levels {
+				gi.levels[l].syms = syms
+			}
+		case field == "actions":
+			g := 0
+			if v.lhs.index != nil {
+				var err error
+				if g, err = resolveGroup(v.lhs.index); err != nil {
+					return nil, err
+				}
+			}
+			if v.value.kind != exprList {
+				return nil, errors.New("actions must be a list")
+			}
+			gi := group(g)
+			for l, a := range v.value.args {
+				act, err := c.resolveAction(a)
+				if err != nil {
+					return nil, err
+				}
+				for len(gi.levels) <= l {
+					gi.levels = append(gi.levels, levelInfo{})
+				}
+				gi.levels[l].action, gi.levels[l].hasAction = act, true
+			}
+		case field == "type":
+			if err := c.keyType(k, v, 0); err != nil {
+				return nil, err
+			}
+		case field == "vmods" || field == "virtualmods" || field == "virtualmodifiers":
+			mods, err := c.resolveMods(v.value)
+			if err != nil {
+				return nil, err
+			}
+			k.vmods, k.hasVmods = mods, true
+		}
+		// repeat, locks, overlays and group behaviour don't change the keysyms
+	}
+
+	// Keys of an include such as "de:2" go to that group
+	if state.group > 0 && len(k.groups) > 0 {
+		moved := make([]groupInfo, state.group+1)
+		moved[state.group] = k.groups[0]
+		k.groups = moved
+	}
+	return k, nil
+}
+
+// keyType reads type = "NAME" or type[GroupN] = "NAME"
+func (c *compiler) keyType(k *keyInfo, v *varStmt, group int) error {
+	if v.value.kind != exprString {
+		return errors.New("type must be a string")
+	}
+	if v.lhs.index == nil {
+		k.defaultType = v.value.text
+		return nil
+	}
+	g, err := resolveGroup(v.lhs.index)
+	if err != nil {
+		return err
+	}
+	if g == 0 && group > 0 {
+		g = group
+	}
+	for len(k.groups) <= g {
+		k.groups = append(k.groups, groupInfo{})
+	}
+	k.groups[g].typeName = v.value.text
+	return nil
+}
+
+// Expressions
+
+// resolveMods evaluates a modifier mask such as Shift+LevelThree
+func (c *compiler) resolveMods(e *expr) (uint32, error) {
+	switch e.kind {
+	case exprIdent:
+		switch strings.ToLower(e.text) {
+		case "none":
+			return 0, nil
+		case "all":
+			return 0xff, nil
+		}
+		index, err := c.mods.declare(e.text)
+		if err != nil {
+			return 0, err
+		}
+		return 1 << index, nil
+	case exprNumber:
+		return uint32(e.num), nil
+	case exprAdd, exprSub:
+		a, err := c.resolveMods(e.args[0])
+		if err != nil {
+			return 0, err
+		}
+		b, err := c.resolveMods(e.args[1])
+		if err != nil {
+			return 0, err
+		}
+		if e.kind == exprSub {
+			return a &^ b, nil
+		}
+		return a | b, nil
+	}
+	return 0, errors.New("invalid modifiers")
+}
+
+// resolveLevel evaluates Level1 to Level8, or 1 to 8, to an index from 0
+func resolveLevel(e *expr) (int, error) {
+	return resolveIndex(e, "level")
+}
+
+// resolveGroup evaluates Group1 to Group4, or 1 to 4, to an index from 0
+func resolveGroup(e *expr) (int, error) {
+	return resolveIndex(e, "group")
+}
+
+func resolveIndex(e *expr, prefix string) (int, error) {
+	var n int64
+	switch e.kind {
+	case exprNumber:
+		n = e.num
+	case exprIdent:
+		if len(e.text) > len(prefix) && strings.EqualFold(e.text[:len(prefix)], prefix) {
+			v, err := strconv.ParseInt(e.text[len(prefix):], 10, 32)
+			if err == nil {
+				n = v
+			}
+		}
+	}
+	if n < 1 || n > 8 {
+		return 0, fmt.Errorf("invalid %s", prefix)
+	}
+	return int(n - 1), nil
+}
+
+// resolveKeysym evaluates a keysym of a list. Unknown names give NoSymbol,
+// as in xkbcommon.
+func resolveKeysym(e *expr) (Keysym, error) {
+	switch e.kind {
+	case exprIdent:
+		switch e.text {
+		case "NoSymbol", "any":
+			return NoSymbol, nil
+		}
+		sym, _ := KeysymFromName(e.text)
+		return sym, nil
+	case exprNumber:
+		// Digits are the keysyms of digits, other numbers keysym values
+		if e.num >= 0 && e.num < 10 {
+			return Keysym('0' + e.num), nil
+		}
+		return Keysym(e.num), nil
+	}
+	return NoSymbol, errors.New("invalid keysym")
+}
+
+// resolveSymbols evaluates the levels of [ a, A, { b, c } ]
+func resolveSymbols(e *expr) ([][]Keysym, error) {
+	if e.kind != exprList {
+		return nil, errors.New("symbols must be a list")
+	}
+	levels := make([][]Keysym, 0, len(e.args))
+	for _, item := range e.args {
+		items := []*expr{item}
+		if item.kind == exprGroup {
+			items = item.args
+		}
+		syms := make([]Keysym, 0, len(items))
+		for _, i := range items {
+			sym, err := resolveKeysym(i)
+			if err != nil {
+				return nil, err
+			}
+			syms = append(syms, sym)
+		}
+		levels = append(levels, syms)
+	}
+	return levels, nil
+}
+
+// resolveAction evaluates an action such as SetMods(modifiers=Shift)
+func (c *compiler) resolveAction(e *expr) (action, error) {
+	if e.kind != exprAction {
+		return action{}, errors.New("invalid action")
+	}
+	var a action
+	switch strings.ToLower(e.text) {
+	case "noaction":
+		return a, nil
+	case "setmods":
+		a.kind = actionSetMods
+	case "latchmods":
+		a.kind = actionLatchMods
+	case "lockmods":
+		a.kind = actionLockMods
+	case "setgroup":
+		a.kind = actionSetGroup
+	case "latchgroup":
+		a.kind = actionLatchGroup
+	case "lockgroup":
+		a.kind = actionLockGroup
+	default:
+		return action{kind: actionOther}, nil
+	}
+
+	for _, arg := range e.args {
+		if arg.kind != exprAssign {
+			continue // Flags such as clearLocks
+		}
+		value := arg.args[0]
+		switch strings.ToLower(arg.lhs.field) {
+		case "modifiers", "mods":
+			if value.kind == exprIdent && strings.EqualFold(value.text, "modMapMods") {
+				a.useModMap = true
+				continue
+			}
+			mods, err := c.resolveMods(value)
+			if err != nil {
+				return a, err
+			}
+			a.mods = mods
+		case "group":
+			switch value.kind {
+			case exprPlus, exprNeg:
+				g := value.args[0]
+				if g.kind != exprNumber {
+					return a, errors.New("invalid group")
+				}
+				a.group = int32(g.num)
+				if value.kind == exprNeg {
+					a.group = -a.group
+				}
+			default:
+				g, err := resolveGroup(value)
+				if err != nil {
+					return a, err
+				}
+				a.group, a.absolute = int32(g), true
+			}
+		}
+	}
+	return a, nil
+}
+
+// Putting the keymap together
+
+// build resolves the gathered info into the compiled keymap
+func (c *compiler) build() (*compiled, error) {
+	km := &compiled{
+		mods:       make([]modInfo, len(c.mods.names)),
+		keys:       make(map[uint32]*key),
+		keyNames:   make(map[string]uint32),
+		groupNames: c.symbols.groupNames,
+	}
+	for name := range c.keycodes.codes {
+		km.keyNames[name], _ = c.keycode(name)
+	}
+	for alias := range c.keycodes.aliases {
+		if code, ok := c.keycode(alias); ok {
+			km.keyNames[alias] = code
+		}
+	}
+	for i, name := range c.mods.names {
+		km.mods[i] = modInfo{name: name, mapping: c.mods.explicit[i]}
+		if i < len(realModNames) {
+			km.mods[i].mapping = 1 << i
+		}
+	}
+
+	for name, k := range c.symbols.keys {
+		code, ok := c.keycode(name)
+		if !ok {
+			continue // Keys without a keycode are ignored, as in xkbcommon
+		}
+		key := &key{code: code, name: name, vmodmap: k.vmods, explicitVmods: k.hasVmods}
+		for _, g := range k.groups {
+			key.groups = append(key.groups, c.buildGroup(k, g, key))
+		}
+		// Trailing empty groups don't count
+		for len(key.groups) > 0 && len(key.groups[len(key.groups)-1].levels) == 0 {
+			key.groups = key.groups[:len(key.groups)-1]
+		}
+		km.keys[code] = key
+	}
+	for code := range km.keys {
+		km.codes = append(km.codes, code)
+	}
+	sort.Slice(km.codes, func(i, j int) bool { return km.codes[i] < km.codes[j] })
+
+	c.applyModMap(km)
+	c.applyInterprets(km)
+
+	// Virtual modifiers map to the real modifiers of the keys that set them
+	for _, code := range km.codes {
+		k := km.keys[code]
+		for i := len(realModNames); i < len(km.mods); i++ {
+			if k.vmodmap&(1<<i) != 0 {
+				km.mods[i].mapping |= k.modmap
+			}
+		}
+	}
+	for _, code := range km.codes {
+		for g := range km.keys[code].groups {
+			group := &km.keys[code].groups[g]
+			group.typ = km.resolveType(group.typ)
+			for l := range group.levels {
+				a := &group.levels[l].action
+				if a.useModMap {
+					a.mods = km.keys[code].modmap
+				} else {
+					a.mods = km.effective(a.mods)
+				}
+			}
+		}
+	}
+	return km, nil
+}
+
+// keycode returns the keycode of a key name, following aliases
+func (c *compiler) keycode(name string) (uint32, bool) {
+	if code, ok := c.keycodes.codes[name]; ok {
+		return code, true
+	}
+	if real, ok := c.keycodes.aliases[name]; ok {
+		code, ok := c.keycodes.codes[real]
+		return code, ok
+	}
+	return 0, false
+}
+
+// buildGroup picks the type of a group and fits its levels to it
+func (c *compiler) buildGroup(k *keyInfo, g groupInfo, key *key) group {
+	name := g.typeName
+	if name == "" {
+		name = k.defaultType
+	}
+	t, ok := c.types.types[name]
+	if !ok {
+		name = automaticType(g.levels)
+		if t, ok = c.types.types[name]; !ok {
+			name, t = "ONE_LEVEL", &typeInfo{levels: 1}
+		}
+	}
+
+	out := group{typ: &keyType{name: name, mods: t.mods, levels: t.levels}}
+	for _, e := range t.entries {
+		out.typ.entries = append(out.typ.entries, keyTypeEntry{mods: e.mods, level: e.level})
+	}
+	hasLevels := false
+	for l := 0; l < t.levels; l++ {
+		var level level
+		if l < len(g.levels) {
+			for _, sym := range g.levels[l].syms {
+				if sym != NoSymbol {
+					level.syms = append(level.syms, sym)
+				}
+			}
+			if g.levels[l].hasAction {
+				level.action = g.levels[l].action
+				key.explicitActions = true
+			}
+		}
+		hasLevels = hasLevels || len(level.syms) > 0 || level.action.kind != actionNone
+		out.levels = append(out.levels, level)
+	}
+	if !hasLevels {
+		out.levels = nil
+	}
+	return out
+}
+
+// automaticType picks the type of keys without an explicit one from their
+// keysyms, as xkbcommon does
+func automaticType(levels []levelInfo) string {
+	sym := func(l int) Keysym {
+		if l < len(levels) && len(levels[l].syms) > 0 {
+			return levels[l].syms[0]
+		}
+		return NoSymbol
+	}
+	width := len(levels)
+	switch {
+	case width <= 1:
+		return "ONE_LEVEL"
+	case width == 2:
+		if sym(0).isLower() && sym(1).isUpper() {
+			return "ALPHABETIC"
+		}
+		if sym(0).IsKeypad() || sym(1).IsKeypad() {
+			return "KEYPAD"
+		}
+		return "TWO_LEVEL"
+	case width <= 4:
+		if sym(0).isLower() && sym(1).isUpper() {
+			if sym(2).isLower() && sym(3).isUpper() {
+				return "FOUR_LEVEL_ALPHABETIC"
+			}
+			return "FOUR_LEVEL_SEMIALPHABETIC"
+		}
+		if sym(0).IsKeypad() || sym(1).IsKeypad() {
+			return "FOUR_LEVEL_KEYPAD"
+		}
+		return "FOUR_LEVEL"
+	default:
+		if sym(0).isLower() && sym(1).isUpper() {
+			if sym(2).isLower() && sym(3).isUpper() {
+				return "EIGHT_LEVEL_ALPHABETIC"
+			}
+			return "EIGHT_LEVEL_SEMIALPHABETIC"
+		}
+		return "EIGHT_LEVEL"
+	}
+}
+
+// applyModMap gives keys the real modifiers of modifier_map statements
+func (c *compiler) applyModMap(km *compiled) {
+	assigned := make(map[uint32]bool)
+	for _, entry := range c.symbols.modMap {
+		var k *key
+		if entry.key != "" {
+			code, ok := c.keycode(entry.key)
+			if !ok {
+				continue
+			}
+			k = km.keys[code]
+			if k == nil {
+				// Keys without symbols can still hold a modifier
+				k = &key{code: code, name: entry.key}
+				km.keys[code] = k
+				km.codes = append(km.codes, code)
+				sort.Slice(km.codes, func(i, j int) bool { return km.codes[i] < km.codes[j] })
+			}
+		} else if k = km.findKeyForSymbol(entry.sym); k == nil {
+			continue
+		}
+		if assigned[k.code] && entry.augment {
+			continue
+		}
+		// A key maps to a single real modifier, the last one given
+		k.modmap = 1 << entry.mod
+		assigned[k.code] = true
+	}
+}
+
+// findKeyForSymbol returns the key with keysym alone on its lowest group and
+// level, with the lowest keycode
+func (km *compiled) findKeyForSymbol(sym Keysym) *key {
+	for g := 0; ; g++ {
+		anyGroup := false
+		for l := 0; ; l++ {
+			anyLevel := false
+			for _, code := range km.codes {
+				k := km.keys[code]
+				if g >= len(k.groups) || l >= len(k.groups[g].levels) {
+					continue
+				}
+				anyGroup, anyLevel = true, true
+				if syms := k.groups[g].levels[l].syms; len(syms) == 1 && syms[0] == sym {
+					return k
+				}
+			}
+			if !anyLevel {
+				break
+			}
+		}
+		if !anyGroup {
+			return nil
+		}
+	}
+}
+
+// applyInterprets gives the keys without explicit actions the actions and
+// virtual modifiers of the interpretations matching their keysyms
+func (c *compiler) applyInterprets(km *compiled) {
+	// Most specific first: with a keysym before Any, then by predicate
+	var sorted []*interpretInfo
+	for _, withSym := range []bool{true, false} {
+		for _, match := range []matchOp{matchExactly, matchAll, matchNone, matchAny, matchAnyOrNone} {
+			for _, in := range c.compat.interprets {
+				if (in.sym != NoSymbol) == withSym && in.match == match {
+					sorted = append(sorted, in)
+				}
+			}
+		}
+	}
+
+	for _, code := range km.codes {
+		k := km.keys[code]
+		if k.explicitActions {
+			continue
+		}
+		var vmodmap uint32
+		for g := range k.groups {
+			for l := range k.groups[g].levels {
+				in := findInterpret(sorted, k, l, k.groups[g].levels[l].syms)
+				if in == nil {
+					continue
+				}
+				if in.vmod >= 0 && (g == 0 && l == 0 || !in.levelOne) {
+					vmodmap |= 1 << in.vmod
+				}
+				if in.action.kind != actionNone {
+					k.groups[g].levels[l].action = in.action
+				}
+			}
+		}
+		if !k.explicitVmods {
+			k.vmodmap = vmodmap
+		}
+	}
+}
+
+// findInterpret returns the first interpretation matching a level of k
+func findInterpret(sorted []*interpretInfo, k *key, level int, syms []Keysym) *interpretInfo {
+	if len(syms) == 0 {
+		return nil
+	}
+	for _, in := range sorted {
+		if in.sym != NoSymbol && (len(syms) > 1 || in.sym != syms[0]) {
+			continue
+		}
+		mods := k.modmap
+		if in.levelOne && level != 0 {
+			mods = 0
+		}
+		var found bool
+		switch in.match {
+		case matchNone:
+			found = in.mods&mods == 0
+		case matchAnyOrNone:
+			found = mods == 0 || in.mods&mods != 0
+		case matchAny:
+			found = in.mods&mods != 0
+		case matchAll:
+			found = in.mods&mods == in.mods
+		case matchExactly:
+			found = in.mods == mods
+		}
+		if found {
+			return in
+		}
+	}
+	return nil
+}
@@ -0,0 +1,423 @@
+package xkb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ComposeTable holds Compose sequences, in the format of the Compose files of
+// libX11 (see Compose(5)): keysyms typed one after the other, starting with
+// a dead key or Multi_key, and the text they produce.
+//
+//	<dead_acute> <e>        : "é" eacute
+//	<Multi_key> <o> <slash> : "ø" oslash
+//
+// Applications compose the sequences themselves, with the table of their
+// locale: a table only helps typing when it matches theirs.
+type ComposeTable struct {
+	sequences map[rune][][]Keysym // Shortest first
+}
+
+// maxComposeIncludes limits nested includes, which may loop
+const maxComposeIncludes = 10
+
+// NewComposeTableFromString parses the Compose sequences in text. Includes
+// are read from the file system, with %H, %L and %S expanded as libX11 does.
+// Sequences with modifiers or unknown keysyms are left out.
+func NewComposeTableFromString(text string) (*ComposeTable, error) {
+	p := newComposeParser(localeFromEnv())
+	if err := p.parse("compose", text, 0); err != nil {
+		return nil, err
+	}
+	return p.table(), nil
+}
+
+// NewComposeTableFromFile reads the Compose file at path, see
+// NewComposeTableFromString
+func NewComposeTableFromFile(path string) (*ComposeTable, error) {
+	p := newComposeParser(localeFromEnv())
+	if err := p.parseFile(path, 0); err != nil {
+		return nil, err
+	}
+	return p.table(), nil
+}
+
+// NewComposeTableFromLocale reads the Compose file applications use for
+// locale, such as de_DE.UTF-8, or for the locale of the environment
+// ($LC_ALL, $LC_CTYPE, $LANG) when locale is empty. As with libxkbcommon,
+// the first file found of $XCOMPOSEFILE, $XDG_CONFIG_HOME/XCompose,
+// ~/.XCompose and the file of the locale in $XLOCALEDIR (default
+// /usr/share/X11/locale) is used.
+func NewComposeTableFromLocale(locale string) (*ComposeTable, error) {
+	if locale == "" {
+		locale = localeFromEnv()
+	}
+	var candidates []string
+	if file := os.Getenv("XCOMPOSEFILE"); file != "" {
+		candidates = append(candidates, file)
+	}
+	home, _ := os.UserHomeDir()
+	if config := os.Getenv("XDG_CONFIG_HOME"); config != "" {
+		candidates = append(candidates, filepath.Join(config, "XCompose"))
+	} else if home != "" {
+		candidates = append(candidates, filepath.Join(home, ".config", "XCompose"))
+	}
+	if home != "" {
+		candidates = append(candidates, filepath.Join(home, ".XCompose"))
+	}
+
+	path := ""
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			path = candidate
+			break
+		}
+	}
+	if path == "" {
+		var err error
+		if path, err = localeComposeFile(locale); err != nil {
+			return nil, err
+		}
+	}
+	p := newComposeParser(locale)
+	if err := p.parseFile(path, 0); err != nil {
+		return nil, err
+	}
+	return p.table(), nil
+}
+
+// Sequences returns the sequences producing r, shortest first
+func (t *ComposeTable) Sequences(r rune) [][]Keysym {
+	return slices.Clone(t.sequences[r])
+}
+
+// ComposeKeystrokes returns the keystrokes typing r with a sequence of
+// table, such as dead_acute then e for é, for characters no key of the
+// keymap types. The first sequence with keys for all its keysyms is used,
+// so dead keys come before longer Multi_key sequences.
+func (k *Keymap) ComposeKeystrokes(table *ComposeTable, r rune) ([]Keystroke, error) {
+	return k.ComposeKeystrokesLocked(table, r, 0)
+}
+
+// ComposeKeystrokesLocked returns the keystrokes typing r with a sequence of
+// table while the locked modifiers are active, see KeystrokesLocked
+func (k *Keymap) ComposeKeystrokesLocked(table *ComposeTable, r rune, locked ModMask) ([]Keystroke, error) {
+	if _, err := k.compile(); err != nil {
+		return nil, err
+	}
+	for _, seq := range table.sequences[r] {
+		strokes := make([]Keystroke, 0, len(seq))
+		for _, sym := range seq {
+			found, err := k.KeystrokesForKeysymLocked(sym, locked)
+			if err != nil {
+				break
+			}
+			strokes = append(strokes, found[0])
+		}
+		if len(strokes) == len(seq) {
+			return strokes, nil
+		}
+	}
+	return nil, fmt.Errorf("no compose sequence of the keymap types %q", r)
+}
+
+// localeFromEnv returns the locale of character types, as setlocale does
+func localeFromEnv() string {
+	for _, env := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if locale := os.Getenv(env); locale != "" {
+			return locale
+		}
+	}
+	return "C"
+}
+
+// localeDir returns the directory of the libX11 locale files
+func localeDir() string {
+	if dir := os.Getenv("XLOCALEDIR"); dir != "" {
+		return dir
+	}
+	return "/usr/share/X11/locale"
+}
+
+// localeComposeFile returns the Compose file of locale listed in
+// compose.dir, trying the name locale.alias gives it when it isn't listed
+func localeComposeFile(locale string) (string, error) {
+	dir := localeDir()
+	names := []string{locale}
+	if alias, err := lookupLocaleFile(filepath.Join(dir, "locale.alias"), locale, false); err == nil && alias != "" {
+		names = append(names, alias)
+	}
+	for _, name := range names {
+		file, err := lookupLocaleFile(filepath.Join(dir, "compose.dir"), name, true)
+		if err != nil {
+			return "", err
+		}
+		if file != "" {
+			return filepath.Join(dir, file), nil
+		}
+	}
+	return "", fmt.Errorf("no Compose file for locale %s in %s", locale, dir)
+}
+
+// lookupLocaleFile returns the first field of the first line of path whose
+// second field is locale, or the second field of the first line whose
+// first field is locale when reverse is false. Fields may end with a colon.
+func lookupLocaleFile(path, locale string, reverse bool) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	for line := range strings.Lines(string(data)) {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		key, value := strings.TrimSuffix(fields[0], ":"), fields[1]
+		if reverse {
+			key, value = value, key
+		}
+		if key == locale {
+			return value, nil
+		}
+	}
+	return "", nil
+}
+
+// composeSequence is a sequence of a Compose file
+type composeSequence struct {
+	keysyms []Keysym
+	char    rune // 0 when the text isn't a single character
+	order   int  // Sequences defined again keep their new place
+}
+
+type composeParser struct {
+	locale    string
+	sequences map[string]composeSequence // By seqKey
+	n         int
+}
+
+func newComposeParser(locale string) *composeParser {
+	return &composeParser{locale: locale, sequences: make(map[string]composeSequence)}
+}
+
+// seqKey returns a map key for keysyms
+func seqKey(keysyms []Keysym) string {
+	b := make([]byte, 0, 4*len(keysyms))
+	for _, sym := range keysyms {
+		b = binary.BigEndian.AppendUint32(b, uint32(sym))
+	}
+	return string(b)
+}
+
+func (p *composeParser) parseFile(path string, depth int) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return p.parse(path, string(data), depth)
+}
+
+func (p *composeParser) parse(path, text string, depth int) error {
+	n := 0
+	for line := range strings.Lines(text) {
+		n++
+		if err := p.parseLine(line, depth); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+	}
+	return nil
+}
+
+func (p *composeParser) parseLine(line string, depth int) error {
+	line = strings.TrimSpace(line)
+	if line == "" || line[0] == '#' {
+		return nil
+	}
+	if rest, ok := strings.CutPrefix(line, "include"); ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t' || rest[0] == '"') {
+		file, _, err := readComposeString(strings.TrimSpace(rest))
+		if err != nil {
+			return fmt.Errorf("include: %w", err)
+		}
+		if depth >= maxComposeIncludes {
+			return fmt.Errorf("include %q: too many nested includes", file)
+		}
+		path, err := p.expand(file)
+		if err != nil {
+			return err
+		}
+		return p.parseFile(path, depth+1)
+	}
+
+	var keysyms []Keysym
+	rest := line
+	for {
+		rest = strings.TrimLeft(rest, " \t")
+		if rest == "" {
+			return errors.New("missing : after the keysyms")
+		}
+		if rest[0] == ':' {
+			rest = rest[1:]
+			break
+		}
+		if rest[0] == '!' || rest[0] == '~' || unicode.IsLetter(rune(rest[0])) {
+			// Modifiers such as ~Shift or Ctrl, which typed sequences
+			// can't match
+			return nil
+		}
+		if rest[0] != '<' {
+			return fmt.Errorf("unexpected %q before :", rest[0])
+		}
+		end := strings.IndexByte(rest, '>')
+		if end < 0 {
+			return errors.New("missing >")
+		}
+		sym, ok := KeysymFromName(rest[1:end])
+		if !ok {
+			return nil
+		}
+		keysyms = append(keysyms, sym)
+		rest = rest[end+1:]
+	}
+	if len(keysyms) == 0 {
+		return errors.New("no keysyms before :")
+	}
+
+	rest = strings.TrimSpace(rest)
+	text, hasText := "", strings.HasPrefix(rest, "\"")
+	if hasText {
+		var err error
+		if text, rest, err = readComposeString(rest); err != nil {
+			return err
+		}
+	}
+	name, _, _ := strings.Cut(rest, "#")
+	name = strings.TrimSpace(name)
+	if !hasText && name == "" {
+		return errors.New("no result after :")
+	}
+
+	var char rune
+	if r, size := utf8.DecodeRuneInString(text); hasText && size == len(text) && r != utf8.RuneError {
+		char = r
+	} else if !hasText {
+		sym, ok := KeysymFromName(name)
+		if !ok {
+			return nil
+		}
+		char = sym.Rune()
+	}
+	p.n++
+	p.sequences[seqKey(keysyms)] = composeSequence{keysyms: keysyms, char: char, order: p.n}
+	return nil
+}
+
+// expand replaces %H with the home directory, %L with the Compose file of
+// the locale, %S with the directory of the locale files and %% with %
+func (p *composeParser) expand(file string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(file); i++ {
+		if file[i] != '%' || i+1 == len(file) {
+			b.WriteByte(file[i])
+			continue
+		}
+		i++
+		switch file[i] {
+		case '%':
+			b.WriteByte('%')
+		case 'H':
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(home)
+		case 'L':
+			path, err := localeComposeFile(p.locale)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(path)
+		case 'S':
+			b.WriteString(localeDir())
+		default:
+			return "", fmt.Errorf("include %q: unknown %%%c", file, file[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// readComposeString reads the quoted string s starts with, and returns it
+// unquoted with the text after it. Escapes are \\, \", octal (\351) and
+// hexadecimal (\xe9) bytes.
+func readComposeString(s string) (string, string, error) {
+	if !strings.HasPrefix(s, "\"") {
+		return "", "", errors.New("missing quoted string")
+	}
+	var b []byte
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"':
+			return string(b), s[i+1:], nil
+		case c != '\\' || i+1 == len(s):
+			b = append(b, c)
+		case s[i+1] == 'x' || s[i+1] == 'X':
+			j := i + 2
+			for j < len(s) && j < i+4 && strings.IndexByte("0123456789abcdefABCDEF", s[j]) >= 0 {
+				j++
+			}
+			if j == i+2 {
+				return "", "", errors.New("missing hexadecimal digits after \\x")
+			}
+			v, _ := strconv.ParseUint(s[i+2:j], 16, 8)
+			b = append(b, byte(v))
+			i = j - 1
+		case s[i+1] >= '0' && s[i+1] <= '7':
+			j := i + 1
+			for j < len(s) && j < i+4 && s[j] >= '0' && s[j] <= '7' {
+				j++
+			}
+			v, _ := strconv.ParseUint(s[i+1:j], 8, 16)
+			b = append(b, byte(v))
+			i = j - 1
+		default:
+			b = append(b, s[i+1])
+			i++
+		}
+	}
+	return "", "", errors.New("unterminated string")
+}
+
+// table indexes the sequences by character, leaving out those a shorter
+// sequence completes before they end
+func (p *composeParser) table() *ComposeTable {
+	var seqs []composeSequence
+	for _, seq := range p.sequences {
+		reachable := seq.char != 0
+		for i := 1; reachable && i < len(seq.keysyms); i++ {
+			_, ok := p.sequences[seqKey(seq.keysyms[:i])]
+			reachable = !ok
+		}
+		if reachable {
+			seqs = append(seqs, seq)
+		}
+	}
+	slices.SortFunc(seqs, func(a, b composeSequence) int {
+		if n := len(a.keysyms) - len(b.keysyms); n != 0 {
+			return n
+		}
+		return a.order - b.order
+	})
+
+	t := &ComposeTable{sequences: make(map[rune][][]Keysym)}
+	for _, seq := range seqs {
+		t.sequences[seq.char] = append(t.sequences[seq.char], seq.keysyms)
+	}
+	return t
+}
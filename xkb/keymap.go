@@ -0,0 +1,123 @@
+// Package xkb builds the XKB keymaps sent to the compositor by virtual
+// keyboards. It is written in Go and doesn't need libxkbcommon: keymaps
+// built from names are resolved with the XKB rules files installed on the
+// system, and compiled from the same files to know which keys type which
+// characters.
+//
+// A keymap can come from the text of a full keymap, from a file, or from the
+// names used by setxkbmap and compositor configurations:
+//
+//	keymap, err := xkb.NewKeymapFromNames(xkb.RuleNames{Layout: "de", Variant: "nodeadkeys"})
+//	keyboard, err := manager.CreateKeyboard(virtual_keyboard.WithKeymap(keymap))
+//
+// Keystrokes returns the key and modifiers typing a character with a keymap:
+//
+//	strokes, err := keymap.Keystrokes('@') // AltGr+Q with a German keymap
+package xkb
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/bnema/libwldevices-go/internal/protocols"
+)
+
+// Keymap is an XKB keymap in the text format (XKB_KEYMAP_FORMAT_TEXT_V1)
+// understood by compositors. Keymaps are immutable and safe for concurrent
+// use.
+type Keymap struct {
+	text     string
+	names    *RuleNames // The names it was built from, nil otherwise
+	fallback string     // Compiled instead when the XKB data is missing
+
+	once     sync.Once
+	compiled *compiled
+	err      error
+}
+
+// usKeymap is the default keymap with its components expanded, for systems
+// without XKB data
+//
+//go:embed us.xkb
+var usKeymap string
+
+var defaultKeymap = sync.OnceValue(func() *Keymap {
+	return &Keymap{
+		text:     protocols.DefaultKeymap,
+		names:    &RuleNames{Rules: DefaultRules, Model: DefaultModel, Layout: DefaultLayout},
+		fallback: usKeymap,
+	}
+})
+
+// DefaultKeymap returns the keymap virtual keyboards use unless told
+// otherwise: a US layout on a pc105 keyboard. It can be compiled even where
+// the XKB data isn't installed.
+func DefaultKeymap() *Keymap {
+	return defaultKeymap()
+}
+
+// NewKeymapFromString uses text as the keymap, as written by xkbcomp or
+// xkbcli compile-keymap. Only basic checks are made: the compositor compiles
+// it and may still reject it.
+func NewKeymapFromString(text string) (*Keymap, error) {
+	if strings.IndexByte(text, 0) >= 0 {
+		return nil, errors.New("keymap contains a null byte")
+	}
+	if !strings.Contains(text, "xkb_keymap") {
+		return nil, errors.New("not an XKB keymap: no xkb_keymap section")
+	}
+	return &Keymap{text: text}, nil
+}
+
+// NewKeymapFromFile reads the keymap at path, see NewKeymapFromString
+func NewKeymapFromFile(path string) (*Keymap, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	keymap, err := NewKeymapFromString(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return keymap, nil
+}
+
+// NewKeymapFromNames builds the keymap selected by names. The rules are
+// resolved here, so unknown rules fail now, but unknown layouts and variants
+// are only reported by the compositor when it compiles the keymap.
+func NewKeymapFromNames(names RuleNames) (*Keymap, error) {
+	names = names.withDefaults()
+	c, err := ResolveNames(names)
+	if err != nil {
+		return nil, err
+	}
+	return &Keymap{text: c.keymap(), names: &names}, nil
+}
+
+// keymap writes a keymap including the components
+func (c Components) keymap() string {
+	return fmt.Sprintf(`xkb_keymap {
+	xkb_keycodes  { include %q	};
+	xkb_types     { include %q	};
+	xkb_compat    { include %q	};
+	xkb_symbols   { include %q	};
+};`, c.Keycodes, c.Types, c.Compat, c.Symbols)
+}
+
+// String returns the text of the keymap, as sent to the compositor
+func (k *Keymap) String() string {
+	return k.text
+}
+
+// Names returns the names the keymap was built from, with the defaults
+// filled in. It returns false for keymaps built from text.
+func (k *Keymap) Names() (RuleNames, bool) {
+	if k.names == nil {
+		return RuleNames{}, false
+	}
+	return *k.names, true
+}
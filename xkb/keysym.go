@@ -0,0 +1,185 @@
+package xkb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+//go:generate go run ../tools/keysyms -include=/usr/include/X11 -output=keysym_table.go
+
+// Keysym is an X keysym: the symbol a key produces at a given level, such as
+// a, Adiaeresis, Return or dead_acute
+type Keysym uint32
+
+// NoSymbol is the keysym of levels that produce nothing
+const NoSymbol Keysym = 0
+
+var (
+	keysymsOnce   sync.Once
+	keysymsByName map[string]Keysym
+	namesByKeysym map[Keysym]string
+	keysymsByChar map[rune]Keysym
+)
+
+// loadKeysyms indexes the generated table
+func loadKeysyms() {
+	keysymsByName = make(map[string]Keysym, len(keysymNames))
+	namesByKeysym = make(map[Keysym]string, len(keysymNames))
+	keysymsByChar = make(map[rune]Keysym, len(keysymChars))
+	for _, k := range keysymNames {
+		keysymsByName[k.name] = k.sym
+		if _, ok := namesByKeysym[k.sym]; !ok {
+			namesByKeysym[k.sym] = k.name
+		}
+		if r, ok := keysymChars[k.sym]; ok {
+			if _, ok := keysymsByChar[r]; !ok {
+				keysymsByChar[r] = k.sym
+			}
+		}
+	}
+}
+
+// KeysymFromName returns the keysym called name, as written in keymaps:
+// a name from keysymdef.h or XF86keysym.h, U followed by the hexadecimal
+// code point of a character (U20AC), or a number (0x1001e9e).
+func KeysymFromName(name string) (Keysym, bool) {
+	keysymsOnce.Do(loadKeysyms)
+	if sym, ok := keysymsByName[name]; ok {
+		return sym, true
+	}
+	if len(name) > 1 && name[0] == 'U' {
+		cp, err := strconv.ParseUint(name[1:], 16, 32)
+		if err != nil || cp < 0x20 || (cp > 0x7e && cp < 0xa0) || cp > 0x10ffff {
+			return NoSymbol, false
+		}
+		if cp < 0x100 {
+			return Keysym(cp), true
+		}
+		return Keysym(cp) | 0x01000000, true
+	}
+	if strings.HasPrefix(name, "0x") {
+		v, err := strconv.ParseUint(name[2:], 16, 32)
+		if err != nil {
+			return NoSymbol, false
+		}
+		return Keysym(v), true
+	}
+	// Older keymaps separate XF86 from the rest of the name
+	if strings.HasPrefix(name, "XF86_") {
+		return KeysymFromName("XF86" + name[len("XF86_"):])
+	}
+	return NoSymbol, false
+}
+
+// KeysymFromNameFold is KeysymFromName ignoring case, as written by hand:
+// return, f4 or xf86audiomute. Exact matches come first; when names only
+// differ in case, such as a and A, the lowercase keysym is returned.
+func KeysymFromNameFold(name string) (Keysym, bool) {
+	if sym, ok := KeysymFromName(name); ok {
+		return sym, true
+	}
+	found, ok := NoSymbol, false
+	for _, k := range keysymNames {
+		if !strings.EqualFold(k.name, name) {
+			continue
+		}
+		if !ok || k.sym.isLower() && !found.isLower() {
+			found, ok = k.sym, true
+		}
+	}
+	return found, ok
+}
+
+// KeysymFromRune returns the keysym producing r: its Latin-1 keysym, its
+// legacy keysym if it has one, or its Unicode keysym
+func KeysymFromRune(r rune) Keysym {
+	switch {
+	case r >= 0x20 && r <= 0x7e, r >= 0xa0 && r <= 0xff:
+		return Keysym(r)
+	case r == '\b', r == '\t', r == '\n', r == 0x0b, r == 0x1b:
+		return Keysym(0xff00 | r)
+	case r == '\r':
+		return 0xff0d // Return
+	case r == 0x7f:
+		return 0xffff // Delete
+	case r < 0x20, r > 0x10ffff:
+		return NoSymbol
+	}
+	keysymsOnce.Do(loadKeysyms)
+	if sym, ok := keysymsByChar[r]; ok {
+		return sym
+	}
+	return Keysym(r) | 0x01000000
+}
+
+// Name returns the name of the keysym, as accepted by KeysymFromName
+func (s Keysym) Name() string {
+	keysymsOnce.Do(loadKeysyms)
+	if name, ok := namesByKeysym[s]; ok {
+		return name
+	}
+	if s >= 0x01000100 && s <= 0x0110ffff {
+		return fmt.Sprintf("U%04X", uint32(s)&0xffffff)
+	}
+	return fmt.Sprintf("0x%08x", uint32(s))
+}
+
+// String returns the name of the keysym
+func (s Keysym) String() string {
+	return s.Name()
+}
+
+// Rune returns the character the keysym types, or 0 for keysyms that don't
+// type any, such as modifiers and dead keys. Return and Tab type \r and \t,
+// and the keypad keysyms the character on the key.
+func (s Keysym) Rune() rune {
+	switch {
+	case s >= 0x20 && s <= 0x7e, s >= 0xa0 && s <= 0xff:
+		return rune(s)
+	case s >= 0x01000100 && s <= 0x0110ffff:
+		return rune(s & 0xffffff)
+	case s == 0xff08, s == 0xff09, s == 0xff0a, s == 0xff0b, s == 0xff0d, s == 0xff1b:
+		// BackSpace, Tab, Linefeed, Clear, Return, Escape
+		return rune(s & 0xff)
+	case s == 0xffff:
+		return 0x7f // Delete
+	case s == 0xff80:
+		return ' ' // KP_Space
+	case s == 0xff89:
+		return '\t' // KP_Tab
+	case s == 0xff8d:
+		return '\r' // KP_Enter
+	case s >= 0xffaa && s <= 0xffb9, s == 0xffbd:
+		// KP_Multiply to KP_9, and KP_Equal
+		return rune(s - 0xff80)
+	}
+	return keysymChars[s]
+}
+
+// IsKeypad reports whether the keysym is on the keypad, such as KP_1
+func (s Keysym) IsKeypad() bool {
+	return s >= 0xff80 && s <= 0xffbd
+}
+
+// IsModifier reports whether the keysym is a modifier, such as Shift_L,
+// ISO_Level3_Shift or Num_Lock
+func (s Keysym) IsModifier() bool {
+	return (s >= 0xffe1 && s <= 0xffee) || // Shift_L to Hyper_R
+		(s >= 0xfe01 && s <= 0xfe13) || // ISO_Lock to ISO_Level5_Lock
+		s == 0xff7e || s == 0xff7f // Mode_switch, Num_Lock
+}
+
+// isLower and isUpper tell the case of letters, to pick the type of keys
+// without an explicit one
+func (s Keysym) isLower() bool {
+	r := s.Rune()
+	return r != 0 && unicode.IsLower(r) && unicode.ToUpper(r) != r
+}
+
+func (s Keysym) isUpper() bool {
+	r := s.Rune()
+	return r != 0 && unicode.IsUpper(r) && unicode.ToLower(r) != r
+}
@@ -0,0 +1,393 @@
+package xkb
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ModMask is a set of real modifiers, as sent in wl_keyboard.modifiers
+type ModMask uint32
+
+// Real modifiers. Keymaps map the virtual ones (Alt, Super, LevelThree...)
+// to these; pc keymaps use Mod1 for Alt, Mod4 for Super and Mod5 for AltGr.
+const (
+	ModShift ModMask = 1 << iota
+	ModLock
+	ModControl
+	Mod1
+	Mod2
+	Mod3
+	Mod4
+	Mod5
+)
+
+// String returns the modifiers joined by +, such as Shift+Mod5
+func (m ModMask) String() string {
+	if m == 0 {
+		return "none"
+	}
+	var names []string
+	for i, name := range realModNames {
+		if m&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	if rest := m &^ 0xff; rest != 0 {
+		names = append(names, fmt.Sprintf("0x%x", uint32(rest)))
+	}
+	return strings.Join(names, "+")
+}
+
+// Keystroke is a way to produce a keysym: pressing Key while Modifiers are
+// held selects Level of Group on the key. Keys are Linux input event codes,
+// as sent to the compositor, not XKB keycodes.
+type Keystroke struct {
+	Key       uint32
+	Group     uint32
+	Level     uint32
+	Modifiers ModMask
+	Keysym    Keysym
+}
+
+// evdevOffset is the difference between XKB keycodes and input event codes
+const evdevOffset = 8
+
+// maxPCKey is the last input event code of pc105 keyboards (KEY_COMPOSE).
+// Keys past it, such as KEY_KPLEFTPAREN, are missing on most keyboards and
+// may be ignored by applications; X11 clients don't even see the keys past
+// 247.
+const maxPCKey = 127
+
+// Fake keys of the pc symbols, pressed by nothing on a real keyboard
+var fakeKeys = map[string]bool{
+	"LVL3": true, "MDSW": true, "ALT": true, "META": true,
+	"SUPR": true, "HYPR": true, "LVL5": true,
+}
+
+// compiled is a keymap compiled by compileKeymap
+type compiled struct {
+	mods       []modInfo
+	keys       map[uint32]*key   // By XKB keycode
+	codes      []uint32          // Keycodes of keys, sorted
+	keyNames   map[string]uint32 // Keycodes by name and alias
+	groupNames map[int]string
+}
+
+type modInfo struct {
+	name    string
+	mapping uint32 // Real modifiers
+}
+
+type key struct {
+	code            uint32
+	name            string
+	groups          []group
+	modmap          uint32 // Real modifier of modifier_map
+	vmodmap         uint32
+	explicitVmods   bool
+	explicitActions bool
+}
+
+type group struct {
+	typ    *keyType
+	levels []level
+}
+
+type level struct {
+	syms   []Keysym
+	action action
+}
+
+type keyType struct {
+	name    string
+	mods    uint32
+	levels  int
+	entries []keyTypeEntry
+}
+
+type keyTypeEntry struct {
+	mods   uint32
+	level  int
+	active bool
+}
+
+// effective maps virtual modifiers to real ones
+func (km *compiled) effective(mods uint32) uint32 {
+	real := mods & 0xff
+	for i := len(realModNames); i < len(km.mods); i++ {
+		if mods&(1<<i) != 0 {
+			real |= km.mods[i].mapping
+		}
+	}
+	return real
+}
+
+// resolveType returns t with effective masks. Entries using virtual
+// modifiers mapped to nothing are disabled, as in xkbcommon.
+func (km *compiled) resolveType(t *keyType) *keyType {
+	resolved := &keyType{name: t.name, mods: km.effective(t.mods), levels: t.levels}
+	for _, e := range t.entries {
+		mods := km.effective(e.mods)
+		resolved.entries = append(resolved.entries, keyTypeEntry{
+			mods:   mods,
+			level:  e.level,
+			active: e.mods == 0 || mods != 0,
+		})
+	}
+	return resolved
+}
+
+// level returns the level mods select
+func (t *keyType) level(mods uint32) int {
+	mods &= t.mods
+	for _, e := range t.entries {
+		if e.active && e.mods == mods {
+			return e.level
+		}
+	}
+	return 0
+}
+
+// Compile compiles the keymap, which gives the keys producing characters.
+// Keymaps including components are compiled from the XKB data installed on
+// the system. It is done once, later calls return the same result.
+func (k *Keymap) Compile() error {
+	_, err := k.compile()
+	return err
+}
+
+func (k *Keymap) compile() (*compiled, error) {
+	k.once.Do(func() {
+		k.compiled, k.err = compileKeymap(k.text)
+		if k.err != nil && k.fallback != "" {
+			// The XKB data isn't installed, use the built-in copy
+			k.compiled, k.err = compileKeymap(k.fallback)
+		}
+		if k.err != nil {
+			k.err = fmt.Errorf("compile keymap: %w", k.err)
+		}
+	})
+	return k.compiled, k.err
+}
+
+// Keystrokes returns the ways the keymap types r, best first: keys outside
+// the keypad, keys of pc105 keyboards, with fewer modifiers, at lower levels and with lower keycodes
+// come first. Only the first group is used, and only modifiers that keys
+// set while held, so Caps Lock is never needed. A newline is typed with
+// Return.
+func (k *Keymap) Keystrokes(r rune) ([]Keystroke, error) {
+	return k.KeystrokesLocked(r, 0)
+}
+
+// KeystrokesLocked returns the ways the keymap types r while the locked
+// modifiers are active, as Keystrokes does. The modifiers of the keystrokes
+// are held on top of the locked ones: with Caps Lock, A is typed without
+// Shift and a with it. Keysyms of keys whose type ignores Lock are made
+// uppercase, as applications do.
+func (k *Keymap) KeystrokesLocked(r rune, locked ModMask) ([]Keystroke, error) {
+	km, err := k.compile()
+	if err != nil {
+		return nil, err
+	}
+	strokes := km.keystrokes(func(sym Keysym) bool {
+		return sym.Rune() == r || r == '\n' && sym == 0xff0d // Return
+	}, uint32(locked))
+	if len(strokes) == 0 {
+		return nil, fmt.Errorf("no key types %q", r)
+	}
+	return strokes, nil
+}
+
+// KeystrokesForKeysym returns the ways the keymap produces sym, best first,
+// as Keystrokes does
+func (k *Keymap) KeystrokesForKeysym(sym Keysym) ([]Keystroke, error) {
+	return k.KeystrokesForKeysymLocked(sym, 0)
+}
+
+// KeystrokesForKeysymLocked returns the ways the keymap produces sym while
+// the locked modifiers are active, as KeystrokesLocked does
+func (k *Keymap) KeystrokesForKeysymLocked(sym Keysym, locked ModMask) ([]Keystroke, error) {
+	km, err := k.compile()
+	if err != nil {
+		return nil, err
+	}
+	strokes := km.keystrokes(func(s Keysym) bool { return s == sym }, uint32(locked))
+	if len(strokes) == 0 {
+		return nil, fmt.Errorf("no key produces %s", sym)
+	}
+	return strokes, nil
+}
+
+// ModifierKeys returns the keys to hold for mods, as input event codes. It
+// fails when a modifier isn't set by any key.
+func (k *Keymap) ModifierKeys(mods ModMask) ([]uint32, error) {
+	km, err := k.compile()
+	if err != nil {
+		return nil, err
+	}
+	keys, ok := km.modifierKeys(uint32(mods))
+	if !ok {
+		return nil, fmt.Errorf("no keys set modifiers %s", mods)
+	}
+	for i := range keys {
+		keys[i] -= evdevOffset
+	}
+	return keys, nil
+}
+
+// ModifierMask returns the real modifiers a modifier of the keymap maps to,
+// by name: a real modifier (Shift, Lock, Control, Mod1...) or a virtual one
+// (Alt, Super, NumLock, LevelThree...), in any case. It fails when the
+// modifier is missing or mapped to nothing.
+func (k *Keymap) ModifierMask(name string) (ModMask, error) {
+	km, err := k.compile()
+	if err != nil {
+		return 0, err
+	}
+	for _, mod := range km.mods {
+		if !strings.EqualFold(mod.name, name) {
+			continue
+		}
+		if mod.mapping == 0 {
+			return 0, fmt.Errorf("modifier %s is mapped to no real modifier", mod.name)
+		}
+		return ModMask(mod.mapping), nil
+	}
+	return 0, fmt.Errorf("no modifier %s in the keymap", name)
+}
+
+// KeyFromName returns the key called name in the keycodes section, such as
+// AE01 or RTRN, as an input event code. Aliases are names too.
+func (k *Keymap) KeyFromName(name string) (uint32, error) {
+	km, err := k.compile()
+	if err != nil {
+		return 0, err
+	}
+	code, ok := km.keyNames[name]
+	if !ok || code < evdevOffset {
+		return 0, fmt.Errorf("no key <%s> in the keymap", name)
+	}
+	return code - evdevOffset, nil
+}
+
+// keystrokes returns the keystrokes of the levels with a keysym match
+// accepts while locked modifiers are active, best first
+func (km *compiled) keystrokes(match func(Keysym) bool, locked uint32) []Keystroke {
+	var strokes []Keystroke
+	for _, code := range km.codes {
+		k := km.keys[code]
+		if code < evdevOffset || len(k.groups) == 0 {
+			continue
+		}
+		g := k.groups[0]
+		for l, lvl := range g.levels {
+			if len(lvl.syms) != 1 {
+				continue
+			}
+			sym := lvl.syms[0]
+			if locked&uint32(ModLock) != 0 && g.typ.mods&uint32(ModLock) == 0 && sym.isLower() {
+				// Caps Lock the type doesn't consume
+				sym = KeysymFromRune(unicode.ToUpper(sym.Rune()))
+			}
+			if !match(sym) {
+				continue
+			}
+			mods, ok := km.levelMods(g.typ, l, locked)
+			if !ok {
+				continue
+			}
+			strokes = append(strokes, Keystroke{
+				Key:       code - evdevOffset,
+				Level:     uint32(l),
+				Modifiers: ModMask(mods),
+				Keysym:    sym,
+			})
+		}
+	}
+	sort.SliceStable(strokes, func(i, j int) bool {
+		a, b := strokes[i], strokes[j]
+		if a.Keysym.IsKeypad() != b.Keysym.IsKeypad() {
+			return b.Keysym.IsKeypad()
+		}
+		if a.Key > maxPCKey != (b.Key > maxPCKey) {
+			return b.Key > maxPCKey
+		}
+		if n, m := bits.OnesCount32(uint32(a.Modifiers)), bits.OnesCount32(uint32(b.Modifiers)); n != m {
+			return n < m
+		}
+		if a.Level != b.Level {
+			return a.Level < b.Level
+		}
+		return a.Key < b.Key
+	})
+	return strokes
+}
+
+// levelMods returns the fewest modifiers selecting level on a key of type
+// t that keys can set, held on top of the locked modifiers
+func (km *compiled) levelMods(t *keyType, level int, locked uint32) (uint32, bool) {
+	var candidates []uint32
+	if level == 0 {
+		candidates = append(candidates, 0)
+	}
+	for _, e := range t.entries {
+		if e.active && e.level == level {
+			candidates = append(candidates, e.mods&^locked)
+		}
+	}
+	if t.mods&locked != 0 {
+		// Locked modifiers may select another level, which more modifiers
+		// undo: Shift with Caps Lock, or with Num Lock on the keypad
+		free := t.mods &^ locked
+		for mods := free; mods != 0; mods = (mods - 1) & free {
+			candidates = append(candidates, mods)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return bits.OnesCount32(candidates[i]) < bits.OnesCount32(candidates[j])
+	})
+	for _, mods := range candidates {
+		if t.level(mods|locked) != level {
+			continue
+		}
+		if _, ok := km.modifierKeys(mods); ok {
+			return mods, true
+		}
+	}
+	return 0, false
+}
+
+// modifierKeys returns the XKB keycodes of the keys to hold for mods: keys
+// setting some of them and no others while held, real keys first
+func (km *compiled) modifierKeys(mods uint32) ([]uint32, bool) {
+	var keys []uint32
+	var covered uint32
+	for bit := uint32(1); bit <= 0x80; bit <<= 1 {
+		if mods&bit == 0 || covered&bit != 0 {
+			continue
+		}
+		best, found := uint32(0), false
+		for _, code := range km.codes {
+			k := km.keys[code]
+			if code < evdevOffset || len(k.groups) == 0 || len(k.groups[0].levels) == 0 {
+				continue
+			}
+			a := k.groups[0].levels[0].action
+			if a.kind != actionSetMods || a.mods&bit == 0 || a.mods&^mods != 0 {
+				continue
+			}
+			if !found || fakeKeys[km.keys[best].name] && !fakeKeys[k.name] {
+				best, found = code, true
+			}
+		}
+		if !found {
+			return nil, false
+		}
+		keys = append(keys, best)
+		covered |= km.keys[best].groups[0].levels[0].action.mods
+	}
+	return keys, true
+}
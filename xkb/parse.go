@@ -0,0 +1,899 @@
+package xkb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// This file parses the XKB text format into statements. It accepts the
+// subset of the language found in keymaps and in the files of
+// xkeyboard-config; geometry sections are skipped.
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokFloat
+	tokKeyName
+	tokPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string // Identifier, string contents, key name without <>, punctuation
+	num  int64
+	line int
+	pos  int // Offset in the source of punctuation
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of file"
+	case tokString:
+		return strconv.Quote(t.text)
+	case tokKeyName:
+		return "<" + t.text + ">"
+	}
+	return t.text
+}
+
+// lex splits src into tokens
+func lex(src string) ([]token, error) {
+	var tokens []token
+	line := 1
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			i++
+		case c == '#' || (c == '/' && i+1 < len(src) && src[i+1] == '/'):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated comment", line)
+			}
+			line += strings.Count(src[i:i+2+end], "\n")
+			i += end + 4
+		case c == '"':
+			var s strings.Builder
+			j := i + 1
+			for ; j < len(src) && src[j] != '"'; j++ {
+				if src[j] == '\n' {
+					line++
+				}
+				if src[j] != '\\' || j+1 >= len(src) {
+					s.WriteByte(src[j])
+					continue
+				}
+				j++
+				switch e := src[j]; e {
+				case 'n':
+					s.WriteByte('\n')
+				case 't':
+					s.WriteByte('\t')
+				case 'r':
+					s.WriteByte('\r')
+				case 'b':
+					s.WriteByte('\b')
+				case 'f':
+					s.WriteByte('\f')
+				case 'v':
+					s.WriteByte('\v')
+				case 'e':
+					s.WriteByte(0x1b)
+				default:
+					if e >= '0' && e <= '7' {
+						k := j
+						for k < len(src) && k < j+3 && src[k] >= '0' && src[k] <= '7' {
+							k++
+						}
+						v, _ := strconv.ParseUint(src[j:k], 8, 8)
+						s.WriteByte(byte(v))
+						j = k - 1
+					} else {
+						s.WriteByte(e)
+					}
+				}
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("line %d: unterminated string", line)
+			}
+			tokens = append(tokens, token{kind: tokString, text: s.String(), line: line})
+			i = j + 1
+		case c == '<':
+			end := strings.IndexAny(src[i+1:], ">\n")
+			if end < 0 || src[i+1+end] != '>' {
+				return nil, fmt.Errorf("line %d: unterminated key name", line)
+			}
+			tokens = append(tokens, token{kind: tokKeyName, text: src[i+1 : i+1+end], line: line})
+			i += end + 2
+		case c >= '0' && c <= '9':
+			j := i
+			isFloat := false
+			if c == '0' && i+1 < len(src) && (src[i+1] == 'x' || src[i+1] == 'X') {
+				j += 2
+				for j < len(src) && isHexDigit(src[j]) {
+					j++
+				}
+			} else {
+				for j < len(src) && (src[j] >= '0' && src[j] <= '9' || src[j] == '.') {
+					isFloat = isFloat || src[j] == '.'
+					j++
+				}
+			}
+			t := token{kind: tokNumber, text: src[i:j], line: line}
+			if isFloat {
+				t.kind = tokFloat
+			} else {
+				v, err := strconv.ParseInt(t.text, 0, 64)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid number %s", line, t.text)
+				}
+				t.num = v
+			}
+			tokens = append(tokens, t)
+			i = j
+		case isIdentStart(c):
+			j := i + 1
+			for j < len(src) && (isIdentStart(src[j]) || src[j] >= '0' && src[j] <= '9') {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: src[i:j], line: line})
+			i = j
+		case strings.IndexByte("{}[]();,=+-*/!~.", c) >= 0:
+			tokens = append(tokens, token{kind: tokPunct, text: string(c), line: line, pos: i})
+			i++
+		default:
+			return nil, fmt.Errorf("line %d: unexpected character %q", line, c)
+		}
+	}
+	return append(tokens, token{kind: tokEOF, line: line}), nil
+}
+
+// componentEnds returns the offsets of the closing braces of the sections
+// of the keymap in src, where statements can be added to them
+func componentEnds(src string) (map[sectionKind]int, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	ends := make(map[sectionKind]int)
+	depth := 0
+	kind, inSection := sectionKind(0), false
+	for _, t := range tokens {
+		switch {
+		case t.kind == tokPunct && t.text == "{":
+			depth++
+		case t.kind == tokPunct && t.text == "}":
+			depth--
+			if depth == 1 && inSection {
+				ends[kind], inSection = t.pos, false
+			}
+		case t.kind == tokIdent && depth == 1 && !inSection:
+			if k, ok := sectionKinds[t.text]; ok {
+				kind, inSection = k, true
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced braces")
+	}
+	return ends, nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isHexDigit(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+// mergeMode tells how a statement combines with what was defined before
+type mergeMode int
+
+const (
+	mergeDefault mergeMode = iota // Override, unless the enclosing include says otherwise
+	mergeAugment
+	mergeOverride
+	mergeReplace
+)
+
+// sectionKind is the kind of a keymap section
+type sectionKind int
+
+const (
+	sectionKeycodes sectionKind = iota
+	sectionTypes
+	sectionCompat
+	sectionSymbols
+	sectionGeometry
+	sectionKeymap
+)
+
+// sectionDirs names the directory of the include path holding each kind
+var sectionDirs = []string{"keycodes", "types", "compat", "symbols", "geometry", "keymap"}
+
+// section is an xkb_keycodes, xkb_symbols, ... block
+type section struct {
+	kind      sectionKind
+	name      string
+	isDefault bool
+	stmts     []stmt
+	sections  []*section // Of an xkb_keymap
+}
+
+// stmt is a statement of a section, one of the *Stmt types
+type stmt interface{}
+
+// includeStmt is include "pc+de(nodeadkeys):2", or augment, override, replace
+type includeStmt struct {
+	merge mergeMode
+	spec  string
+	line  int
+}
+
+// varStmt is an assignment, lhs = value, or lhs; and !lhs; for booleans
+type varStmt struct {
+	merge mergeMode
+	lhs   lvalue
+	value *expr // nil for a list without name in a key body
+}
+
+// lvalue is elem.field[index], with elem and index optional
+type lvalue struct {
+	elem  string
+	field string
+	index *expr
+}
+
+type keycodeStmt struct {
+	merge mergeMode
+	name  string
+	code  int64
+}
+
+type aliasStmt struct {
+	merge       mergeMode
+	alias, real string
+}
+
+type vmodStmt struct {
+	merge mergeMode
+	names []string
+	maps  []*expr // nil when no mapping is given
+}
+
+type typeStmt struct {
+	merge mergeMode
+	name  string
+	body  []*varStmt
+}
+
+type interpretStmt struct {
+	merge mergeMode
+	sym   string
+	pred  *expr // nil without predicate
+	body  []*varStmt
+}
+
+type keyStmt struct {
+	merge mergeMode
+	name  string
+	body  []*varStmt
+}
+
+type modMapStmt struct {
+	merge mergeMode
+	mod   string
+	keys  []*expr // Key names or keysyms
+}
+
+type exprKind int
+
+const (
+	exprIdent exprKind = iota
+	exprString
+	exprNumber
+	exprFloat
+	exprKeyName
+	exprAdd
+	exprSub
+	exprMul
+	exprDiv
+	exprNeg
+	exprPlus
+	exprNot
+	exprInvert
+	exprAssign // name=value in action arguments
+	exprAction // name(args)
+	exprList   // [ a, { b, c } ]
+	exprGroup  // { b, c } in a list
+	exprField  // elem.field and lists with an index
+)
+
+type expr struct {
+	kind exprKind
+	text string // Identifier, string, key name, action name
+	num  int64
+	lhs  lvalue  // exprField and exprAssign
+	args []*expr // Operands, action arguments or list items
+}
+
+// parser reads statements from tokens
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// parseFile parses the sections of a file or keymap
+func parseFile(src string) ([]*section, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	var sections []*section
+	for p.peek().kind != tokEOF {
+		s, err := p.section()
+		if err != nil {
+			return nil, err
+		}
+		if s != nil {
+			sections = append(sections, s)
+		}
+	}
+	return sections, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// is reports whether the next token is the given punctuation
+func (p *parser) is(punct string) bool {
+	t := p.peek()
+	return t.kind == tokPunct && t.text == punct
+}
+
+// accept consumes the punctuation if it comes next
+func (p *parser) accept(punct string) bool {
+	if p.is(punct) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("line %d: %s", p.peek().line, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) expect(punct string) error {
+	if !p.accept(punct) {
+		return p.errorf("expected %s, got %s", punct, p.peek())
+	}
+	return nil
+}
+
+// isKeyword compares identifiers the way XKB does, ignoring case
+func isKeyword(t token, words ...string) bool {
+	if t.kind != tokIdent {
+		return false
+	}
+	for _, w := range words {
+		if strings.EqualFold(t.text, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// sectionKinds maps the section keywords to their kind
+var sectionKinds = map[string]sectionKind{
+	"xkb_keycodes":          sectionKeycodes,
+	"xkb_types":             sectionTypes,
+	"xkb_compatibility":     sectionCompat,
+	"xkb_compatibility_map": sectionCompat,
+	"xkb_compat":            sectionCompat,
+	"xkb_compat_map":        sectionCompat,
+	"xkb_symbols":           sectionSymbols,
+	"xkb_geometry":          sectionGeometry,
+	"xkb_keymap":            sectionKeymap,
+	"xkb_semantics":         sectionKeymap,
+	"xkb_layout":            sectionKeymap,
+}
+
+// section parses flags xkb_kind "name" { ... };
+func (p *parser) section() (*section, error) {
+	s := &section{}
+	for {
+		t := p.next()
+		if t.kind != tokIdent {
+			return nil, fmt.Errorf("line %d: expected a section, got %s", t.line, t)
+		}
+		if kind, ok := sectionKinds[strings.ToLower(t.text)]; ok {
+			s.kind = kind
+			break
+		}
+		switch strings.ToLower(t.text) {
+		case "default":
+			s.isDefault = true
+		case "partial", "hidden", "alphanumeric_keys", "modifier_keys", "keypad_keys",
+			"function_keys", "alternate_group":
+		default:
+			return nil, fmt.Errorf("line %d: unknown section flag %s", t.line, t)
+		}
+	}
+	if p.peek().kind == tokString {
+		s.name = p.next().text
+	}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	switch s.kind {
+	case sectionGeometry:
+		if err := p.skipBlock(); err != nil {
+			return nil, err
+		}
+	case sectionKeymap:
+		for !p.accept("}") {
+			if p.peek().kind == tokEOF {
+				return nil, p.errorf("unterminated xkb_keymap")
+			}
+			sub, err := p.section()
+			if err != nil {
+				return nil, err
+			}
+			s.sections = append(s.sections, sub)
+		}
+	default:
+		for !p.accept("}") {
+			if p.peek().kind == tokEOF {
+				return nil, p.errorf("unterminated section")
+			}
+			st, err := p.statement()
+			if err != nil {
+				return nil, err
+			}
+			if st != nil {
+				s.stmts = append(s.stmts, st)
+			}
+		}
+	}
+	p.accept(";")
+	return s, nil
+}
+
+// skipBlock skips to the brace closing an opened block
+func (p *parser) skipBlock() error {
+	for depth := 1; depth > 0; {
+		t := p.next()
+		switch {
+		case t.kind == tokEOF:
+			return p.errorf("unterminated block")
+		case t.kind == tokPunct && t.text == "{":
+			depth++
+		case t.kind == tokPunct && t.text == "}":
+			depth--
+		}
+	}
+	return nil
+}
+
+// statement parses a statement of a section. It returns nil for the
+// statements that are skipped, such as indicators.
+func (p *parser) statement() (stmt, error) {
+	if p.accept(";") {
+		return nil, nil
+	}
+	merge := mergeDefault
+	t := p.peek()
+	switch {
+	case isKeyword(t, "include"):
+		merge = mergeDefault
+	case isKeyword(t, "augment"):
+		merge = mergeAugment
+	case isKeyword(t, "override"):
+		merge = mergeOverride
+	case isKeyword(t, "replace"):
+		merge = mergeReplace
+	case isKeyword(t, "alternate"):
+		merge = mergeAugment
+	}
+	if merge != mergeDefault || isKeyword(t, "include") {
+		p.next()
+		if p.peek().kind == tokString {
+			spec := p.next()
+			p.accept(";")
+			return &includeStmt{merge: merge, spec: spec.text, line: spec.line}, nil
+		}
+		t = p.peek()
+	}
+
+	switch {
+	case t.kind == tokKeyName:
+		// <AE01> = 10;
+		p.next()
+		if err := p.expect("="); err != nil {
+			return nil, err
+		}
+		code := p.next()
+		if code.kind != tokNumber {
+			return nil, fmt.Errorf("line %d: expected a key code, got %s", code.line, code)
+		}
+		return &keycodeStmt{merge: merge, name: t.text, code: code.num}, p.expect(";")
+
+	case isKeyword(t, "alias"):
+		p.next()
+		alias := p.next()
+		if err := p.expect("="); err != nil {
+			return nil, err
+		}
+		real := p.next()
+		if alias.kind != tokKeyName || real.kind != tokKeyName {
+			return nil, fmt.Errorf("line %d: expected alias <alias> = <key>", t.line)
+		}
+		return &aliasStmt{merge: merge, alias: alias.text, real: real.text}, p.expect(";")
+
+	case isKeyword(t, "virtual_modifiers"):
+		p.next()
+		v := &vmodStmt{merge: merge}
+		for {
+			name := p.next()
+			if name.kind != tokIdent {
+				return nil, fmt.Errorf("line %d: expected a modifier name, got %s", name.line, name)
+			}
+			var mapping *expr
+			if p.accept("=") {
+				var err error
+				if mapping, err = p.expr(); err != nil {
+					return nil, err
+				}
+			}
+			v.names = append(v.names, name.text)
+			v.maps = append(v.maps, mapping)
+			if !p.accept(",") {
+				break
+			}
+		}
+		return v, p.expect(";")
+
+	case isKeyword(t, "type") && p.tokens[p.pos+1].kind == tokString:
+		p.next()
+		name := p.next().text
+		body, err := p.block()
+		if err != nil {
+			return nil, err
+		}
+		return &typeStmt{merge: merge, name: name, body: body}, nil
+
+	case isKeyword(t, "interpret") && !(p.tokens[p.pos+1].kind == tokPunct && p.tokens[p.pos+1].text == "."):
+		p.next()
+		sym := p.next()
+		if sym.kind != tokIdent && sym.kind != tokNumber {
+			return nil, fmt.Errorf("line %d: expected a keysym, got %s", sym.line, sym)
+		}
+		in := &interpretStmt{merge: merge, sym: sym.text}
+		if p.accept("+") {
+			var err error
+			if in.pred, err = p.expr(); err != nil {
+				return nil, err
+			}
+		}
+		var err error
+		if in.body, err = p.block(); err != nil {
+			return nil, err
+		}
+		return in, nil
+
+	case isKeyword(t, "key") && p.tokens[p.pos+1].kind == tokKeyName:
+		p.next()
+		name := p.next().text
+		if err := p.expect("{"); err != nil {
+			return nil, err
+		}
+		k := &keyStmt{merge: merge, name: name}
+		for !p.accept("}") {
+			if p.is("[") {
+				list, err := p.expr()
+				if err != nil {
+					return nil, err
+				}
+				k.body = append(k.body, &varStmt{value: list})
+			} else {
+				v, err := p.varStmt(merge)
+				if err != nil {
+					return nil, err
+				}
+				k.body = append(k.body, v)
+			}
+			if !p.accept(",") && !p.is("}") {
+				return nil, p.errorf("expected , or } in key <%s>, got %s", name, p.peek())
+			}
+		}
+		return k, p.expect(";")
+
+	case isKeyword(t, "modifier_map", "mod_map", "modmap"):
+		p.next()
+		mod := p.next()
+		if mod.kind != tokIdent {
+			return nil, fmt.Errorf("line %d: expected a modifier, got %s", mod.line, mod)
+		}
+		if err := p.expect("{"); err != nil {
+			return nil, err
+		}
+		m := &modMapStmt{merge: merge, mod: mod.text}
+		for !p.accept("}") {
+			e, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			m.keys = append(m.keys, e)
+			if !p.accept(",") && !p.is("}") {
+				return nil, p.errorf("expected , or } in modifier_map, got %s", p.peek())
+			}
+		}
+		return m, p.expect(";")
+
+	case isKeyword(t, "indicator", "virtual") && !(p.tokens[p.pos+1].kind == tokPunct && p.tokens[p.pos+1].text == "."),
+		isKeyword(t, "group") && p.tokens[p.pos+1].kind == tokNumber:
+		// Indicators and group compatibility maps don't change what keys type
+		return nil, p.skipStatement()
+	}
+	v, err := p.varStmt(merge)
+	if err != nil {
+		return nil, err
+	}
+	return v, p.expect(";")
+}
+
+// skipStatement skips to the end of the statement, over blocks
+func (p *parser) skipStatement() error {
+	for {
+		t := p.next()
+		switch {
+		case t.kind == tokEOF:
+			return p.errorf("unterminated statement")
+		case t.kind == tokPunct && t.text == ";":
+			return nil
+		case t.kind == tokPunct && t.text == "{":
+			if err := p.skipBlock(); err != nil {
+				return err
+			}
+			p.accept(";")
+			return nil
+		}
+	}
+}
+
+// block parses { var; var; };
+func (p *parser) block() ([]*varStmt, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var body []*varStmt
+	for !p.accept("}") {
+		if p.accept(";") {
+			continue
+		}
+		v, err := p.varStmt(mergeDefault)
+		if err != nil {
+			return nil, err
+		}
+		body = append(body, v)
+		if !p.accept(";") && !p.is("}") {
+			return nil, p.errorf("expected ;, got %s", p.peek())
+		}
+	}
+	return body, p.expect(";")
+}
+
+// varStmt parses lhs = expr, lhs or !lhs, leaving the terminator to the
+// caller
+func (p *parser) varStmt(merge mergeMode) (*varStmt, error) {
+	negate := p.accept("!")
+	lhs, err := p.lvalue()
+	if err != nil {
+		return nil, err
+	}
+	v := &varStmt{merge: merge, lhs: lhs}
+	switch {
+	case negate:
+		v.value = &expr{kind: exprIdent, text: "false"}
+	case p.accept("="):
+		if v.value, err = p.expr(); err != nil {
+			return nil, err
+		}
+	default:
+		v.value = &expr{kind: exprIdent, text: "true"}
+	}
+	return v, nil
+}
+
+// lvalue parses name, elem.field, name[index] or elem.field[index]
+func (p *parser) lvalue() (lvalue, error) {
+	t := p.next()
+	if t.kind != tokIdent {
+		return lvalue{}, fmt.Errorf("line %d: expected a name, got %s", t.line, t)
+	}
+	lv := lvalue{field: t.text}
+	if p.accept(".") {
+		f := p.next()
+		if f.kind != tokIdent {
+			return lvalue{}, fmt.Errorf("line %d: expected a field, got %s", f.line, f)
+		}
+		lv.elem, lv.field = t.text, f.text
+	}
+	if p.accept("[") {
+		index, err := p.expr()
+		if err != nil {
+			return lvalue{}, err
+		}
+		lv.index = index
+		if err := p.expect("]"); err != nil {
+			return lvalue{}, err
+		}
+	}
+	return lv, nil
+}
+
+// expr parses an expression, or an assignment in action arguments such as
+// modifiers=Shift+Lock
+func (p *parser) expr() (*expr, error) {
+	left, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	if !p.accept("=") {
+		return left, nil
+	}
+	if left.kind != exprIdent && left.kind != exprField {
+		return nil, p.errorf("invalid assignment")
+	}
+	lhs := left.lhs
+	if left.kind == exprIdent {
+		lhs = lvalue{field: left.text}
+	}
+	right, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	return &expr{kind: exprAssign, lhs: lhs, args: []*expr{right}}, nil
+}
+
+// sum parses additions and subtractions of products
+func (p *parser) sum() (*expr, error) {
+	left, err := p.product()
+	if err != nil {
+		return nil, err
+	}
+	for p.is("+") || p.is("-") {
+		kind := exprAdd
+		if p.next().text == "-" {
+			kind = exprSub
+		}
+		right, err := p.product()
+		if err != nil {
+			return nil, err
+		}
+		left = &expr{kind: kind, args: []*expr{left, right}}
+	}
+	return left, nil
+}
+
+func (p *parser) product() (*expr, error) {
+	left, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for p.is("*") || p.is("/") {
+		kind := exprMul
+		if p.next().text == "/" {
+			kind = exprDiv
+		}
+		right, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		left = &expr{kind: kind, args: []*expr{left, right}}
+	}
+	return left, nil
+}
+
+func (p *parser) term() (*expr, error) {
+	t := p.next()
+	switch t.kind {
+	case tokString:
+		return &expr{kind: exprString, text: t.text}, nil
+	case tokNumber:
+		return &expr{kind: exprNumber, num: t.num, text: t.text}, nil
+	case tokFloat:
+		return &expr{kind: exprFloat, text: t.text}, nil
+	case tokKeyName:
+		return &expr{kind: exprKeyName, text: t.text}, nil
+	case tokIdent:
+		p.pos--
+		lv, err := p.lvalue()
+		if err != nil {
+			return nil, err
+		}
+		if p.accept("(") {
+			// Action: SetMods(modifiers=Shift, clearLocks)
+			if lv.elem != "" || lv.index != nil {
+				return nil, p.errorf("invalid action name")
+			}
+			a := &expr{kind: exprAction, text: lv.field}
+			for !p.accept(")") {
+				arg, err := p.expr()
+				if err != nil {
+					return nil, err
+				}
+				a.args = append(a.args, arg)
+				if !p.accept(",") && !p.is(")") {
+					return nil, p.errorf("expected , or ) in %s(), got %s", lv.field, p.peek())
+				}
+			}
+			return a, nil
+		}
+		if lv.elem == "" && lv.index == nil {
+			return &expr{kind: exprIdent, text: lv.field}, nil
+		}
+		return &expr{kind: exprField, lhs: lv}, nil
+	case tokPunct:
+		switch t.text {
+		case "-", "+", "!", "~":
+			operand, err := p.term()
+			if err != nil {
+				return nil, err
+			}
+			kind := map[string]exprKind{"-": exprNeg, "+": exprPlus, "!": exprNot, "~": exprInvert}[t.text]
+			return &expr{kind: kind, args: []*expr{operand}}, nil
+		case "(":
+			e, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			return e, p.expect(")")
+		case "[", "{":
+			closing := "]"
+			kind := exprList
+			if t.text == "{" {
+				closing, kind = "}", exprGroup
+			}
+			list := &expr{kind: kind}
+			for !p.accept(closing) {
+				item, err := p.expr()
+				if err != nil {
+					return nil, err
+				}
+				list.args = append(list.args, item)
+				if !p.accept(",") && !p.is(closing) {
+					return nil, p.errorf("expected , or %s, got %s", closing, p.peek())
+				}
+			}
+			return list, nil
+		}
+	}
+	return nil, fmt.Errorf("line %d: unexpected %s", t.line, t)
+}
@@ -0,0 +1,528 @@
+package xkb
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Defaults used for the names left empty and not set in the environment,
+// the same as xkbcommon
+const (
+	DefaultRules  = "evdev"
+	DefaultModel  = "pc105"
+	DefaultLayout = "us"
+)
+
+// RuleNames selects a keymap the way setxkbmap and compositor configurations
+// do, by rules, model, layout, variant and options (RMLVO). Layouts, variants
+// and options are comma separated lists, for example Layout "us,de" with
+// Variant ",nodeadkeys".
+//
+// Empty fields are taken from the XKB_DEFAULT_RULES, XKB_DEFAULT_MODEL,
+// XKB_DEFAULT_LAYOUT, XKB_DEFAULT_VARIANT and XKB_DEFAULT_OPTIONS environment
+// variables, then from the defaults above.
+type RuleNames struct {
+	Rules   string
+	Model   string
+	Layout  string
+	Variant string
+	Options string
+}
+
+// withDefaults fills the empty names as xkbcommon does
+func (n RuleNames) withDefaults() RuleNames {
+	fill := func(v *string, env, def string) {
+		if *v == "" {
+			*v = os.Getenv(env)
+		}
+		if *v == "" {
+			*v = def
+		}
+	}
+	fill(&n.Rules, "XKB_DEFAULT_RULES", DefaultRules)
+	fill(&n.Model, "XKB_DEFAULT_MODEL", DefaultModel)
+	// The default variant only goes with the default layout
+	if n.Layout == "" {
+		n.Layout = os.Getenv("XKB_DEFAULT_LAYOUT")
+		if n.Variant == "" {
+			n.Variant = os.Getenv("XKB_DEFAULT_VARIANT")
+		}
+		if n.Layout == "" {
+			n.Layout, n.Variant = DefaultLayout, ""
+		}
+	}
+	if n.Options == "" {
+		n.Options = os.Getenv("XKB_DEFAULT_OPTIONS")
+	}
+	return n
+}
+
+// Components are the keymap sections the rules resolve names to
+type Components struct {
+	Keycodes string
+	Types    string
+	Compat   string
+	Symbols  string
+}
+
+// IncludePath returns the directories searched for rules and keymap
+// components, in the order xkbcommon searches them: $XDG_CONFIG_HOME/xkb,
+// ~/.xkb, $XKB_CONFIG_EXTRA_PATH (default /etc/xkb) and $XKB_CONFIG_ROOT
+// (default /usr/share/X11/xkb). Directories that don't exist are left out.
+func IncludePath() []string {
+	var candidates []string
+	home, _ := os.UserHomeDir()
+	if config := os.Getenv("XDG_CONFIG_HOME"); config != "" {
+		candidates = append(candidates, filepath.Join(config, "xkb"))
+	} else if home != "" {
+		candidates = append(candidates, filepath.Join(home, ".config", "xkb"))
+	}
+	if home != "" {
+		candidates = append(candidates, filepath.Join(home, ".xkb"))
+	}
+	extra := os.Getenv("XKB_CONFIG_EXTRA_PATH")
+	if extra == "" {
+		extra = "/etc/xkb"
+	}
+	root := os.Getenv("XKB_CONFIG_ROOT")
+	if root == "" {
+		root = "/usr/share/X11/xkb"
+	}
+	candidates = append(candidates, extra, root)
+
+	var dirs []string
+	for _, dir := range candidates {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// findFile looks for dir/name in the include path
+func findFile(dir, name string) (string, error) {
+	for _, root := range IncludePath() {
+		path := filepath.Join(root, dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("%s/%s not found in %s", dir, name, strings.Join(IncludePath(), ":"))
+}
+
+// ResolveNames applies the rules file named by names.Rules to the other
+// names, as xkbcommon does before compiling a keymap
+func ResolveNames(names RuleNames) (Components, error) {
+	names = names.withDefaults()
+	path := names.Rules
+	if !filepath.IsAbs(path) {
+		var err error
+		if path, err = findFile("rules", names.Rules); err != nil {
+			return Components{}, err
+		}
+	}
+
+	m := newMatcher(names)
+	if err := m.parseFile(path, 0); err != nil {
+		return Components{}, err
+	}
+	c := Components{
+		Keycodes: m.result[kcKeycodes],
+		Types:    m.result[kcTypes],
+		Compat:   m.result[kcCompat],
+		Symbols:  m.result[kcSymbols],
+	}
+	var missing []string
+	for i, v := range []string{c.Keycodes, c.Types, c.Compat, c.Symbols} {
+		if v == "" {
+			missing = append(missing, kccgstNames[i])
+		}
+	}
+	if len(missing) > 0 {
+		return c, fmt.Errorf("rules %s give no %s for %s", names.Rules, strings.Join(missing, ", "), names.describe())
+	}
+	return c, nil
+}
+
+// describe formats the names for errors
+func (n RuleNames) describe() string {
+	return fmt.Sprintf("model %q, layout %q, variant %q, options %q", n.Model, n.Layout, n.Variant, n.Options)
+}
+
+// Components of a keymap, in the order of kccgstNames
+const (
+	kcKeycodes = iota
+	kcTypes
+	kcCompat
+	kcSymbols
+	kcGeometry
+)
+
+var kccgstNames = []string{"keycodes", "types", "compat", "symbols", "geometry"}
+
+// Names that can be matched by a rule
+const (
+	mlvoModel = iota
+	mlvoLayout
+	mlvoVariant
+	mlvoOption
+)
+
+// mlvoField is a column of a rule set header, such as layout[2]
+type mlvoField struct {
+	kind  int
+	index int // 0 when not indexed, otherwise the layout number from 1
+}
+
+// matcher parses a rules file and collects the components of the rules
+// matching its names, following the semantics of xkbcommon's rules.c
+type matcher struct {
+	model    string
+	layouts  []string
+	variants []string
+	options  []string
+	groups   map[string][]string
+
+	// Current rule set
+	fields  []mlvoField
+	target  int  // Component, -1 when the header was invalid
+	matched bool // A rule without option matched, skip the rest of the set
+	skip    bool // The set doesn't apply to the names
+
+	result [5]string
+}
+
+func newMatcher(names RuleNames) *matcher {
+	split := func(s string) []string {
+		parts := strings.Split(s, ",")
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		return parts
+	}
+	m := &matcher{
+		model:   names.Model,
+		layouts: split(names.Layout),
+		groups:  make(map[string][]string),
+		target:  -1,
+	}
+	m.variants = split(names.Variant)
+	for len(m.variants) < len(m.layouts) {
+		m.variants = append(m.variants, "")
+	}
+	for _, o := range split(names.Options) {
+		if o != "" {
+			m.options = append(m.options, o)
+		}
+	}
+	return m
+}
+
+// parseFile reads the rules at path, following includes up to a small depth
+func (m *matcher) parseFile(path string, depth int) error {
+	if depth > 15 {
+		return fmt.Errorf("%s: too many nested includes", path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	var line strings.Builder
+	for n := 1; scanner.Scan(); n++ {
+		text := scanner.Text()
+		if i := strings.Index(text, "//"); i >= 0 {
+			text = text[:i]
+		}
+		if strings.HasSuffix(text, "\\") {
+			line.WriteString(strings.TrimSuffix(text, "\\"))
+			line.WriteByte(' ')
+			continue
+		}
+		line.WriteString(text)
+		err := m.parseLine(path, strings.TrimSpace(line.String()), depth)
+		line.Reset()
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+	}
+	return scanner.Err()
+}
+
+func (m *matcher) parseLine(path, line string, depth int) error {
+	if line == "" {
+		return nil
+	}
+	if !strings.HasPrefix(line, "!") {
+		return m.matchRule(strings.Fields(line))
+	}
+
+	tokens := strings.Fields(strings.TrimPrefix(line, "!"))
+	if len(tokens) == 0 {
+		return errors.New("empty header")
+	}
+	if tokens[0] == "include" {
+		if len(tokens) != 2 {
+			return errors.New("include takes a file")
+		}
+		return m.parseFile(m.expandInclude(path, tokens[1]), depth+1)
+	}
+	if strings.HasPrefix(tokens[0], "$") {
+		if len(tokens) < 2 || tokens[1] != "=" {
+			return fmt.Errorf("expected = after %s", tokens[0])
+		}
+		m.groups[tokens[0]] = tokens[2:]
+		return nil
+	}
+	return m.startRuleSet(tokens)
+}
+
+// expandInclude resolves %H (home), %S (system rules) and %E (extra rules)
+// in an include path
+func (m *matcher) expandInclude(current, file string) string {
+	home, _ := os.UserHomeDir()
+	root := os.Getenv("XKB_CONFIG_ROOT")
+	if root == "" {
+		root = "/usr/share/X11/xkb"
+	}
+	extra := os.Getenv("XKB_CONFIG_EXTRA_PATH")
+	if extra == "" {
+		extra = "/etc/xkb"
+	}
+	file = strings.NewReplacer(
+		"%H", home,
+		"%S", filepath.Join(root, "rules"),
+		"%E", filepath.Join(extra, "rules"),
+		"%%", "%",
+	).Replace(file)
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(filepath.Dir(current), file)
+	}
+	return file
+}
+
+// startRuleSet reads a header such as "! model layout[1] = symbols"
+func (m *matcher) startRuleSet(tokens []string) error {
+	m.fields, m.target, m.matched, m.skip = nil, -1, false, false
+	if len(tokens) < 3 || tokens[len(tokens)-2] != "=" {
+		return errors.New("expected names = component")
+	}
+	for i, name := range kccgstNames {
+		if tokens[len(tokens)-1] == name {
+			m.target = i
+		}
+	}
+	if m.target < 0 {
+		return fmt.Errorf("unknown component %s", tokens[len(tokens)-1])
+	}
+
+	for _, token := range tokens[:len(tokens)-2] {
+		name, index := token, 0
+		if open := strings.IndexByte(token, '['); open > 0 && strings.HasSuffix(token, "]") {
+			v, err := strconv.Atoi(token[open+1 : len(token)-1])
+			if err != nil || v < 1 {
+				return fmt.Errorf("invalid index in %s", token)
+			}
+			name, index = token[:open], v
+		}
+		var f mlvoField
+		switch name {
+		case "model":
+			f.kind = mlvoModel
+		case "layout":
+			f.kind = mlvoLayout
+		case "variant":
+			f.kind = mlvoVariant
+		case "option":
+			f.kind = mlvoOption
+		default:
+			return fmt.Errorf("unknown name %s", name)
+		}
+		if index > 0 && f.kind != mlvoLayout && f.kind != mlvoVariant {
+			return fmt.Errorf("%s can't be indexed", name)
+		}
+		f.index = index
+
+		// Unindexed layouts only apply to a single layout, indexed ones
+		// only to several
+		if f.kind == mlvoLayout || f.kind == mlvoVariant {
+			if index == 0 && len(m.layouts) > 1 {
+				m.skip = true
+			}
+			if index > 0 && (len(m.layouts) == 1 || index > len(m.layouts)) {
+				m.skip = true
+			}
+		}
+		m.fields = append(m.fields, f)
+	}
+	return nil
+}
+
+// matchRule applies a rule of the current set if it matches the names
+func (m *matcher) matchRule(tokens []string) error {
+	if m.target < 0 {
+		return errors.New("rule outside of a rule set")
+	}
+	if len(tokens) != len(m.fields)+2 || tokens[len(tokens)-2] != "=" {
+		return fmt.Errorf("expected %d names = value", len(m.fields))
+	}
+	if m.skip || m.matched {
+		return nil
+	}
+
+	hasOption := false
+	for i, f := range m.fields {
+		pattern := tokens[i]
+		var ok bool
+		switch f.kind {
+		case mlvoModel:
+			ok = m.matchValue(pattern, m.model)
+		case mlvoLayout:
+			ok = m.matchValue(pattern, m.layoutValue(m.layouts, f.index))
+		case mlvoVariant:
+			ok = m.matchValue(pattern, m.layoutValue(m.variants, f.index))
+		case mlvoOption:
+			hasOption = true
+			for _, o := range m.options {
+				if m.matchValue(pattern, o) {
+					ok = true
+					break
+				}
+			}
+		}
+		if !ok {
+			return nil
+		}
+	}
+
+	value, err := m.expand(tokens[len(tokens)-1], m.fields)
+	if err != nil {
+		return err
+	}
+	m.result[m.target] = appendValue(m.result[m.target], value)
+	if !hasOption {
+		m.matched = true
+	}
+	return nil
+}
+
+// layoutValue returns the layout or variant selected by index
+func (m *matcher) layoutValue(values []string, index int) string {
+	if index == 0 {
+		return values[0]
+	}
+	return values[index-1]
+}
+
+// matchValue matches a value against a rule pattern: *, a $group or a literal
+func (m *matcher) matchValue(pattern, value string) bool {
+	if pattern == "*" {
+		return true
+	}
+	if strings.HasPrefix(pattern, "$") {
+		for _, v := range m.groups[pattern] {
+			if v == value {
+				return true
+			}
+		}
+		return false
+	}
+	return pattern == value
+}
+
+// expand replaces %m, %l, %v and their variants (%l[2], %(v), %_v, ...) in
+// the value of a rule
+func (m *matcher) expand(value string, fields []mlvoField) (string, error) {
+	var out strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '%' {
+			out.WriteByte(value[i])
+			continue
+		}
+		i++
+		if i >= len(value) {
+			return "", fmt.Errorf("%s: trailing %%", value)
+		}
+		var prefix, suffix string
+		switch value[i] {
+		case '+', '|', '_', '-':
+			prefix = string(value[i])
+			i++
+		case '(':
+			prefix, suffix = "(", ")"
+			i++
+		}
+		if i >= len(value) {
+			return "", fmt.Errorf("%s: incomplete expansion", value)
+		}
+		kind := value[i]
+		index := 0
+		if i+1 < len(value) && value[i+1] == '[' {
+			end := strings.IndexByte(value[i+1:], ']')
+			if end < 0 {
+				return "", fmt.Errorf("%s: unterminated index", value)
+			}
+			v, err := strconv.Atoi(value[i+2 : i+1+end])
+			if err != nil || v < 1 {
+				return "", fmt.Errorf("%s: invalid index", value)
+			}
+			index = v
+			i += end + 1
+		}
+		if suffix != "" {
+			if i+1 >= len(value) || value[i+1] != ')' {
+				return "", fmt.Errorf("%s: expected )", value)
+			}
+			i++
+		}
+
+		var expanded string
+		switch kind {
+		case 'm':
+			expanded = m.model
+		case 'l', 'v':
+			values := m.layouts
+			if kind == 'v' {
+				values = m.variants
+			}
+			if index == 0 && len(m.layouts) == 1 {
+				expanded = values[0]
+			} else if index > 0 && len(m.layouts) > 1 && index <= len(m.layouts) {
+				expanded = values[index-1]
+			}
+		default:
+			return "", fmt.Errorf("%s: unknown expansion %%%c", value, kind)
+		}
+		if expanded != "" {
+			out.WriteString(prefix + expanded + suffix)
+		}
+	}
+	return out.String(), nil
+}
+
+// appendValue merges the value of a matching rule into a component:
+// values starting with + or | are appended, others only start a component
+// or are put in front of one made of such values
+func appendValue(component, value string) string {
+	if value == "" {
+		return component
+	}
+	isMerge := func(s string) bool {
+		return s != "" && (s[0] == '+' || s[0] == '|')
+	}
+	switch {
+	case isMerge(value) || component == "":
+		return component + value
+	case isMerge(component):
+		return value + component
+	default:
+		return component
+	}
+}
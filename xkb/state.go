@@ -0,0 +1,197 @@
+package xkb
+
+// ModifierState is the state sent in modifiers events: real modifiers held
+// down, latched until the next key, and locked, and the active group
+type ModifierState struct {
+	Depressed ModMask
+	Latched   ModMask
+	Locked    ModMask
+	Group     uint32
+}
+
+// Effective returns the modifiers in effect
+func (m ModifierState) Effective() ModMask {
+	return m.Depressed | m.Latched | m.Locked
+}
+
+// State follows the modifiers and group of a keyboard from its key presses
+// and releases, as xkb_state does in libxkbcommon: keys with SetMods,
+// LatchMods and LockMods actions change the modifiers, keys with SetGroup,
+// LatchGroup and LockGroup actions the group. State isn't safe for
+// concurrent use.
+type State struct {
+	km   *compiled
+	held map[uint32]*heldKey // By XKB keycode
+
+	latched     ModMask
+	locked      ModMask
+	lockedGroup int32
+	latchGroup  int32 // Latched group, added to the group until the next key
+}
+
+// heldKey is a pressed key and what its action does until it is released
+type heldKey struct {
+	action action
+	unlock bool // LockMods on modifiers locked before the press
+	latch  bool // LatchMods and LatchGroup until another key is pressed
+}
+
+// NewState returns the state of a keyboard with keymap and no keys down
+func NewState(keymap *Keymap) (*State, error) {
+	km, err := keymap.compile()
+	if err != nil {
+		return nil, err
+	}
+	return &State{km: km, held: make(map[uint32]*heldKey)}, nil
+}
+
+// Modifiers returns the current modifiers and group
+func (s *State) Modifiers() ModifierState {
+	var depressed ModMask
+	group := s.lockedGroup + s.latchGroup
+	for _, h := range s.held {
+		switch h.action.kind {
+		case actionSetMods, actionLatchMods, actionLockMods:
+			depressed |= ModMask(h.action.mods)
+		case actionSetGroup, actionLatchGroup:
+			group += h.action.group
+		}
+	}
+	return ModifierState{
+		Depressed: depressed,
+		Latched:   s.latched,
+		Locked:    s.locked,
+		Group:     s.wrapGroup(group),
+	}
+}
+
+// wrapGroup brings group within the groups of the keymap
+func (s *State) wrapGroup(group int32) uint32 {
+	n := int32(s.numGroups())
+	group %= n
+	if group < 0 {
+		group += n
+	}
+	return uint32(group)
+}
+
+func (s *State) numGroups() int {
+	n := 1
+	for _, k := range s.km.keys {
+		n = max(n, len(k.groups))
+	}
+	return n
+}
+
+// UpdateKey applies a press or release of key, an input event code, and
+// reports whether the modifiers or group changed
+func (s *State) UpdateKey(key uint32, pressed bool) bool {
+	before := s.Modifiers()
+	code := key + evdevOffset
+	if pressed {
+		s.press(code)
+	} else {
+		s.release(code)
+	}
+	return s.Modifiers() != before
+}
+
+func (s *State) press(code uint32) {
+	if _, ok := s.held[code]; ok {
+		return // Repeated press
+	}
+	a := s.keyAction(code)
+
+	// Pressing any key turns pending latches into plain presses, and uses up
+	// the latches in effect
+	for _, h := range s.held {
+		h.latch = false
+	}
+	if a.kind != actionLatchMods && a.kind != actionLatchGroup {
+		s.latched = 0
+		s.latchGroup = 0
+	}
+
+	h := &heldKey{action: a}
+	switch a.kind {
+	case actionLockMods:
+		if s.locked&ModMask(a.mods) == ModMask(a.mods) {
+			h.unlock = true
+		} else {
+			s.locked |= ModMask(a.mods)
+		}
+	case actionLockGroup:
+		if a.absolute {
+			s.lockedGroup = a.group
+		} else {
+			s.lockedGroup += a.group
+		}
+		h.action = action{} // Nothing happens on release
+	case actionLatchMods, actionLatchGroup:
+		h.latch = true
+	case actionSetGroup:
+		if a.absolute {
+			// Held absolute groups replace the locked group while held
+			h.action.group = a.group - s.lockedGroup
+		}
+	}
+	s.held[code] = h
+}
+
+func (s *State) release(code uint32) {
+	h, ok := s.held[code]
+	if !ok {
+		return
+	}
+	delete(s.held, code)
+	switch h.action.kind {
+	case actionLockMods:
+		if h.unlock {
+			s.locked &^= ModMask(h.action.mods)
+		}
+	case actionLatchMods:
+		if h.latch {
+			s.latched |= ModMask(h.action.mods)
+		}
+	case actionLatchGroup:
+		if h.latch {
+			s.latchGroup += h.action.group
+		}
+	}
+}
+
+// keyAction returns the action of the level of key code selected by the
+// current state
+func (s *State) keyAction(code uint32) action {
+	k := s.km.keys[code]
+	if k == nil || len(k.groups) == 0 {
+		return action{}
+	}
+	state := s.Modifiers()
+	group := k.groups[int(state.Group)%len(k.groups)]
+	if len(group.levels) == 0 {
+		return action{}
+	}
+	level := group.typ.level(uint32(state.Effective()))
+	if level >= len(group.levels) {
+		return action{}
+	}
+	return group.levels[level].action
+}
+
+// SetModifiers replaces the modifiers and group, as modifiers events from
+// the compositor do. Keys held keep their effect: their modifiers add to
+// depressed.
+func (s *State) SetModifiers(m ModifierState) {
+	s.latched = m.Latched
+	s.locked = m.Locked
+	s.lockedGroup = int32(m.Group)
+	s.latchGroup = 0
+	held := s.Modifiers().Depressed
+	if extra := m.Depressed &^ held; extra != 0 {
+		// Depressed without a key: held by a key the state doesn't know
+		s.held[0] = &heldKey{action: action{kind: actionSetMods, mods: uint32(extra)}}
+	} else {
+		delete(s.held, 0)
+	}
+}
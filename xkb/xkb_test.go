@@ -0,0 +1,654 @@
+package xkb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// useTestdata makes the rules and environment of the test independent of the
+// machine running it
+func useTestdata(t *testing.T) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("XKB_CONFIG_EXTRA_PATH", home)
+	t.Setenv("XKB_CONFIG_ROOT", "testdata")
+	for _, name := range []string{"RULES", "MODEL", "LAYOUT", "VARIANT", "OPTIONS"} {
+		t.Setenv("XKB_DEFAULT_"+name, "")
+	}
+}
+
+func TestResolveNames(t *testing.T) {
+	useTestdata(t)
+
+	tests := []struct {
+		names RuleNames
+		want  Components
+	}{
+		{
+			RuleNames{},
+			Components{"evdev+aliases(qwerty)", "complete", "complete", "pc+us+inet(evdev)"},
+		},
+		{
+			RuleNames{Layout: "de", Variant: "nodeadkeys"},
+			Components{"evdev+aliases(qwertz)", "complete", "complete", "pc+de(nodeadkeys)+inet(evdev)"},
+		},
+		{
+			RuleNames{Layout: "dvorak", Variant: "fr"},
+			Components{"evdev+aliases(qwerty)", "complete", "complete", "pc+fr(dvorak)+inet(evdev)"},
+		},
+		{
+			RuleNames{Model: "macbook79", Layout: "us,de", Variant: ",nodeadkeys", Options: "ctrl:nocaps,grp:alt_shift_toggle"},
+			Components{
+				"evdev+aliases(qwerty)", "complete+numpad(mac)", "complete",
+				"pc+us+de(nodeadkeys):2+inet(evdev)+ctrl(nocaps)+group(alt_shift_toggle)",
+			},
+		},
+	}
+	for _, test := range tests {
+		got, err := ResolveNames(test.names)
+		if err != nil {
+			t.Errorf("ResolveNames(%+v) failed: %v", test.names, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("ResolveNames(%+v) = %+v, want %+v", test.names, got, test.want)
+		}
+	}
+}
+
+func TestResolveNamesEnvironment(t *testing.T) {
+	useTestdata(t)
+	t.Setenv("XKB_DEFAULT_LAYOUT", "de")
+	t.Setenv("XKB_DEFAULT_VARIANT", "nodeadkeys")
+
+	got, err := ResolveNames(RuleNames{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Symbols != "pc+de(nodeadkeys)+inet(evdev)" {
+		t.Errorf("Symbols = %s, want the layout of the environment", got.Symbols)
+	}
+
+	// The variant of the environment doesn't apply to another layout
+	if got, _ := ResolveNames(RuleNames{Layout: "fr"}); got.Symbols != "pc+fr+inet(evdev)" {
+		t.Errorf("Symbols = %s, want pc+fr+inet(evdev)", got.Symbols)
+	}
+}
+
+func TestResolveNamesErrors(t *testing.T) {
+	useTestdata(t)
+	if _, err := ResolveNames(RuleNames{Rules: "missing"}); err == nil {
+		t.Error("Unknown rules should fail")
+	}
+}
+
+func TestKeymapFromNames(t *testing.T) {
+	useTestdata(t)
+
+	keymap, err := NewKeymapFromNames(RuleNames{Layout: "de"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(keymap.String(), `xkb_symbols   { include "pc+de+inet(evdev)"	};`) {
+		t.Errorf("Unexpected keymap:\n%s", keymap)
+	}
+	names, ok := keymap.Names()
+	if !ok || names != (RuleNames{Rules: "evdev", Model: "pc105", Layout: "de"}) {
+		t.Errorf("Names() = %+v, %v", names, ok)
+	}
+}
+
+func TestKeymapFromFile(t *testing.T) {
+	keymap, err := NewKeymapFromFile("testdata/de.xkb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(keymap.String(), "pc+de+inet(evdev)") {
+		t.Errorf("Unexpected keymap:\n%s", keymap)
+	}
+	if _, ok := keymap.Names(); ok {
+		t.Error("A keymap read from a file has no names")
+	}
+
+	if _, err := NewKeymapFromString("xkb_symbols { };"); err == nil {
+		t.Error("A keymap without xkb_keymap should be rejected")
+	}
+	if _, err := NewKeymapFromFile("testdata/missing.xkb"); err == nil {
+		t.Error("A missing file should fail")
+	}
+}
+
+func TestDefaultKeymap(t *testing.T) {
+	useTestdata(t)
+
+	// The default keymap is the one the default names resolve to
+	want, err := ResolveNames(RuleNames{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := DefaultKeymap().String()
+	for _, component := range []string{want.Keycodes, want.Types, want.Compat, want.Symbols} {
+		if !strings.Contains(text, `"`+component+`"`) {
+			t.Errorf("Default keymap doesn't include %s:\n%s", component, text)
+		}
+	}
+}
+
+func TestKeysyms(t *testing.T) {
+	tests := []struct {
+		name string
+		sym  Keysym
+		char rune
+	}{
+		{"a", 0x61, 'a'},
+		{"udiaeresis", 0xfc, 'ü'},
+		{"EuroSign", 0x20ac, '€'},
+		{"Cyrillic_ya", 0x6d1, 'я'},
+		{"U1F600", 0x0101f600, '😀'},
+		{"Return", 0xff0d, '\r'},
+		{"KP_7", 0xffb7, '7'},
+		{"dead_acute", 0xfe51, 0},
+		{"XF86AudioMute", 0x1008ff12, 0},
+	}
+	for _, test := range tests {
+		sym, ok := KeysymFromName(test.name)
+		if !ok || sym != test.sym {
+			t.Errorf("KeysymFromName(%q) = %#x, %v, want %#x", test.name, uint32(sym), ok, uint32(test.sym))
+		}
+		if sym.Name() != test.name {
+			t.Errorf("Name of %#x = %q, want %q", uint32(sym), sym.Name(), test.name)
+		}
+		if sym.Rune() != test.char {
+			t.Errorf("%s types %q, want %q", test.name, sym.Rune(), test.char)
+		}
+		if test.char > ' ' && KeysymFromRune(test.char) != sym && !sym.IsKeypad() {
+			t.Errorf("KeysymFromRune(%q) = %s, want %s", test.char, KeysymFromRune(test.char), sym)
+		}
+	}
+	if _, ok := KeysymFromName("NotAKeysym"); ok {
+		t.Error("KeysymFromName should fail for unknown names")
+	}
+}
+
+func TestKeystrokes(t *testing.T) {
+	useTestdata(t)
+	keymap, err := NewKeymapFromFile("testdata/de.xkb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := keymap.Compile(); err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	tests := []struct {
+		char      rune
+		key       uint32
+		level     uint32
+		modifiers ModMask
+	}{
+		{'q', 16, 0, 0},
+		{'Q', 16, 1, ModShift},
+		{'@', 16, 2, Mod5},
+		{'"', 3, 1, ModShift},
+		{'z', 21, 0, 0},    // Swapped with y on German keyboards
+		{'y', 44, 0, 0},    // Key type from key.type
+		{'ü', 26, 0, 0},    // Overrides [ of the latin symbols
+		{'€', 18, 2, Mod5}, // Augmented by latin(type4)
+		{'»', 44, 2, Mod5}, // On two keys, the lowest keycode first
+		{'›', 44, 3, ModShift | Mod5},
+		{'\n', 28, 0, 0},
+		{'1', 2, 0, 0}, // Before KP_1 of the keypad
+	}
+	for _, test := range tests {
+		strokes, err := keymap.Keystrokes(test.char)
+		if err != nil {
+			t.Errorf("Keystrokes(%q) failed: %v", test.char, err)
+			continue
+		}
+		got := strokes[0]
+		if got.Key != test.key || got.Level != test.level || got.Modifiers != test.modifiers {
+			t.Errorf("Keystrokes(%q) = %+v, want key %d level %d with %s", test.char, got, test.key, test.level, test.modifiers)
+		}
+	}
+
+	// Level 5 needs LevelFive, which no key sets, and dead keys type nothing
+	for _, char := range []rune{'“', '«', '¨', 'ñ'} {
+		if strokes, err := keymap.Keystrokes(char); err == nil {
+			t.Errorf("Keystrokes(%q) = %+v, want an error", char, strokes)
+		}
+	}
+	if strokes, err := keymap.KeystrokesForKeysym(0xfe57); err != nil || strokes[0].Key != 26 || strokes[0].Level != 2 {
+		t.Errorf("KeystrokesForKeysym(dead_diaeresis) = %+v, %v", strokes, err)
+	}
+
+	// AltGr sets LevelThree on German keyboards; <LVL3> only does on others
+	keys, err := keymap.ModifierKeys(ModShift | Mod5)
+	if err != nil || len(keys) != 2 || keys[0] != 42 || keys[1] != 100 {
+		t.Errorf("ModifierKeys(Shift+Mod5) = %v, %v, want [42 100]", keys, err)
+	}
+	if _, err := keymap.ModifierKeys(Mod3); err == nil {
+		t.Error("ModifierKeys(Mod3) should fail, no key sets it")
+	}
+}
+
+func TestKeystrokesGroups(t *testing.T) {
+	useTestdata(t)
+	keymap, err := NewKeymapFromNames(RuleNames{Layout: "us,de"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := keymap.Compile(); err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	if names := keymap.compiled.groupNames; names[0] != "English (US)" || names[1] != "German" {
+		t.Errorf("Unexpected group names: %v", names)
+	}
+	// Only the first group is typed with
+	if strokes, err := keymap.Keystrokes('y'); err != nil || strokes[0].Key != 21 {
+		t.Errorf("Keystrokes('y') = %+v, %v", strokes, err)
+	}
+	if _, err := keymap.Keystrokes('ü'); err == nil {
+		t.Error("Keystrokes('ü') should fail, it is on the second group")
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	useTestdata(t)
+	for _, text := range []string{
+		`xkb_keymap { xkb_keycodes { include "evdev" }; };`,
+		`xkb_keymap { xkb_keycodes { include "missing" }; xkb_types { include "complete" };
+			xkb_compat { include "complete" }; xkb_symbols { include "pc" }; };`,
+		`xkb_keymap { xkb_keycodes { include "evdev" }; xkb_types { include "complete(missing)" };
+			xkb_compat { include "complete" }; xkb_symbols { include "pc" }; };`,
+		`xkb_keymap { xkb_keycodes { include "evdev" }; xkb_types { include "complete" };
+			xkb_compat { include "complete" }; xkb_symbols { key <AE01> { [ 1, } }; };`,
+	} {
+		keymap, err := NewKeymapFromString(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := keymap.Compile(); err == nil {
+			t.Errorf("Compile should fail for %s", text)
+		}
+	}
+}
+
+func TestDefaultKeymapFallback(t *testing.T) {
+	useTestdata(t)
+	t.Setenv("XKB_CONFIG_ROOT", t.TempDir())
+
+	keymap := &Keymap{text: DefaultKeymap().String(), fallback: usKeymap}
+	if err := keymap.Compile(); err != nil {
+		t.Fatalf("Compile failed without XKB data: %v", err)
+	}
+	for r := rune(' '); r < 0x7f; r++ {
+		if _, err := keymap.Keystrokes(r); err != nil {
+			t.Errorf("The built-in keymap can't type %q: %v", r, err)
+		}
+	}
+}
+
+// The built-in keymap types like the one it was expanded from
+func TestDefaultKeymapSystem(t *testing.T) {
+	if _, err := os.Stat("/usr/share/X11/xkb/symbols/us"); err != nil {
+		t.Skip("XKB data isn't installed")
+	}
+	t.Setenv("XKB_CONFIG_ROOT", "/usr/share/X11/xkb")
+	t.Setenv("XKB_CONFIG_EXTRA_PATH", t.TempDir())
+	system := &Keymap{text: DefaultKeymap().String()}
+	builtin := &Keymap{text: usKeymap}
+	for _, keymap := range []*Keymap{system, builtin} {
+		if err := keymap.Compile(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for r := rune(' '); r < 0x7f; r++ {
+		want, err := system.Keystrokes(r)
+		if err != nil {
+			t.Errorf("Keystrokes(%q) failed: %v", r, err)
+			continue
+		}
+		if got, err := builtin.Keystrokes(r); err != nil || got[0] != want[0] {
+			t.Errorf("Built-in keymap types %q with %+v, %v, want %+v", r, got, err, want[0])
+		}
+	}
+	for _, mods := range []ModMask{ModShift, ModControl, Mod1, Mod4, Mod5} {
+		want, _ := system.ModifierKeys(mods)
+		if got, err := builtin.ModifierKeys(mods); err != nil || !slices.Equal(got, want) {
+			t.Errorf("Built-in keymap holds %v, %v for %s, want %v", got, err, mods, want)
+		}
+	}
+}
+
+func TestBindKeysyms(t *testing.T) {
+	useTestdata(t)
+	keymap, err := NewKeymapFromFile("testdata/de.xkb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	spare, err := keymap.SpareKeys()
+	if err != nil {
+		t.Fatalf("SpareKeys failed: %v", err)
+	}
+	// ESC, 1, 2 and 3 are bound, 4 isn't
+	if len(spare) == 0 || spare[0] != 5 || slices.Contains(spare, 16) {
+		t.Errorf("Unexpected spare keys: %v", spare)
+	}
+
+	grinning, _ := KeysymFromName("U1F600")
+	bound, strokes, err := keymap.BindKeysyms([]Keysym{grinning, 0x6d1})
+	if err != nil {
+		t.Fatalf("BindKeysyms failed: %v", err)
+	}
+	if len(strokes) != 2 || strokes[0].Key != spare[0] || strokes[1].Key != spare[1] || strokes[1].Keysym != 0x6d1 {
+		t.Errorf("Unexpected keystrokes: %+v", strokes)
+	}
+	for _, test := range []struct {
+		char rune
+		key  uint32
+	}{{'😀', spare[0]}, {'я', spare[1]}, {'ü', 26}} {
+		if got, err := bound.Keystrokes(test.char); err != nil || got[0].Key != test.key || got[0].Modifiers != 0 {
+			t.Errorf("Bound keymap types %q with %+v, %v, want key %d", test.char, got, err, test.key)
+		}
+	}
+	if _, err := keymap.Keystrokes('😀'); err == nil {
+		t.Error("BindKeysyms changed the original keymap")
+	}
+
+	if _, _, err := keymap.BindKeysyms(make([]Keysym, len(spare)+1)); err == nil {
+		t.Error("BindKeysyms should fail with more keysyms than spare keys")
+	}
+}
+
+func TestState(t *testing.T) {
+	useTestdata(t)
+	keymap, err := NewKeymapFromFile("testdata/de.xkb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewState(keymap)
+	if err != nil {
+		t.Fatalf("NewState failed: %v", err)
+	}
+
+	steps := []struct {
+		key     uint32
+		pressed bool
+		changed bool
+		want    ModifierState
+	}{
+		{42, true, true, ModifierState{Depressed: ModShift}},         // Shift
+		{100, true, true, ModifierState{Depressed: ModShift | Mod5}}, // AltGr
+		{30, true, false, ModifierState{Depressed: ModShift | Mod5}}, // A
+		{30, false, false, ModifierState{Depressed: ModShift | Mod5}},
+		{100, false, true, ModifierState{Depressed: ModShift}},
+		{42, false, true, ModifierState{}},
+		{58, true, true, ModifierState{Depressed: ModLock, Locked: ModLock}}, // Caps Lock
+		{58, false, true, ModifierState{Locked: ModLock}},
+		{58, true, true, ModifierState{Depressed: ModLock, Locked: ModLock}},
+		{58, false, true, ModifierState{}},
+		{999, true, false, ModifierState{}}, // Not in the keymap
+	}
+	for i, step := range steps {
+		if changed := s.UpdateKey(step.key, step.pressed); changed != step.changed {
+			t.Errorf("Step %d: UpdateKey(%d, %v) = %v", i, step.key, step.pressed, changed)
+		}
+		if got := s.Modifiers(); got != step.want {
+			t.Errorf("Step %d: Modifiers() = %+v, want %+v", i, got, step.want)
+		}
+	}
+
+	// Modifiers set without keys add to the keys held
+	s.UpdateKey(42, true)
+	s.SetModifiers(ModifierState{Depressed: ModControl, Locked: Mod2})
+	if got := s.Modifiers(); got != (ModifierState{Depressed: ModShift | ModControl, Locked: Mod2}) {
+		t.Errorf("Modifiers() after SetModifiers = %+v", got)
+	}
+}
+
+func TestStateLatchesAndGroups(t *testing.T) {
+	keymap, err := NewKeymapFromString(`xkb_keymap {
+	xkb_keycodes { <LFSH> = 50; <AC01> = 38; <LWIN> = 133; <MENU> = 135; };
+	xkb_types { type "ONE_LEVEL" { modifiers = None; }; };
+	xkb_compat { };
+	xkb_symbols {
+		key <LFSH> { [ ISO_Level2_Latch ], actions[Group1] = [ LatchMods(modifiers=Shift) ] };
+		key <LWIN> { [ ISO_Next_Group ], actions[Group1] = [ LockGroup(group=+1) ] };
+		key <MENU> { [ ISO_Group_Shift ], actions[Group1] = [ SetGroup(group=3) ] };
+		key <AC01> { [ a ], [ b ], [ c ] };
+	};
+};`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewState(keymap)
+	if err != nil {
+		t.Fatalf("NewState failed: %v", err)
+	}
+
+	// A latch lasts until the next key
+	s.UpdateKey(42, true)
+	s.UpdateKey(42, false)
+	if got := s.Modifiers(); got != (ModifierState{Latched: ModShift}) {
+		t.Errorf("Modifiers() after the latch = %+v", got)
+	}
+	s.UpdateKey(30, true)
+	s.UpdateKey(30, false)
+	if got := s.Modifiers(); got != (ModifierState{}) {
+		t.Errorf("Modifiers() after the next key = %+v", got)
+	}
+	// Held while another key is pressed, it doesn't latch
+	s.UpdateKey(42, true)
+	s.UpdateKey(30, true)
+	s.UpdateKey(30, false)
+	s.UpdateKey(42, false)
+	if got := s.Modifiers(); got != (ModifierState{}) {
+		t.Errorf("Modifiers() after Shift+a = %+v", got)
+	}
+
+	// Groups wrap around the 3 groups of the keymap
+	for _, want := range []uint32{1, 2, 0} {
+		s.UpdateKey(125, true)
+		s.UpdateKey(125, false)
+		if got := s.Modifiers().Group; got != want {
+			t.Errorf("Group after LockGroup = %d, want %d", got, want)
+		}
+	}
+	s.UpdateKey(125, true)
+	s.UpdateKey(125, false)
+	s.UpdateKey(127, true)
+	if got := s.Modifiers().Group; got != 2 {
+		t.Errorf("Group while SetGroup(group=3) is held = %d, want 2", got)
+	}
+	s.UpdateKey(127, false)
+	if got := s.Modifiers().Group; got != 1 {
+		t.Errorf("Group after SetGroup(group=3) = %d, want 1", got)
+	}
+}
+
+func TestNamesFold(t *testing.T) {
+	for name, want := range map[string]Keysym{"return": 0xff0d, "F4": 0xffc1, "a": 'a', "A": 'A', "ODIAERESIS": 0xf6} {
+		if sym, ok := KeysymFromNameFold(name); !ok || sym != want {
+			t.Errorf("KeysymFromNameFold(%q) = %v, %v, want %v", name, sym, ok, want)
+		}
+	}
+	if _, ok := KeysymFromNameFold("nosuchkeysym"); ok {
+		t.Error("KeysymFromNameFold should fail on unknown names")
+	}
+
+	useTestdata(t)
+	keymap, err := NewKeymapFromFile("testdata/de.xkb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]ModMask{"shift": ModShift, "Alt": Mod1, "LevelThree": Mod5, "mod4": Mod4} {
+		if mods, err := keymap.ModifierMask(name); err != nil || mods != want {
+			t.Errorf("ModifierMask(%q) = %s, %v, want %s", name, mods, err, want)
+		}
+	}
+	if _, err := keymap.ModifierMask("Bogus"); err == nil {
+		t.Error("ModifierMask should fail on unknown modifiers")
+	}
+	if key, err := keymap.KeyFromName("AE01"); err != nil || key != 2 {
+		t.Errorf("KeyFromName(AE01) = %d, %v", key, err)
+	}
+	if _, err := keymap.KeyFromName("NOPE"); err == nil {
+		t.Error("KeyFromName should fail on unknown keys")
+	}
+}
+
+func TestComposeTable(t *testing.T) {
+	table, err := NewComposeTableFromString(`# Comment
+<dead_acute> <e>		: "é"	eacute # LATIN SMALL LETTER E WITH ACUTE
+<dead_acute> <E>		: "\303\211"
+<Multi_key> <e> <equal>	: EuroSign
+<Multi_key> <apostrophe> <e>	: "\xc3\xa9"
+~Ctrl <dead_grave> <a>	: "à"
+<dead_grave> <nosuchkeysym>	: "?"
+<Multi_key> <c>		: "ç"
+<Multi_key> <c> <o>	: "©"
+<dead_acute> <e>		: "è" # Defined again
+<Multi_key> <q> <u>	: "\"qu\""
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sequences := map[rune]string{
+		'é': "[[Multi_key apostrophe e]]",
+		'è': "[[dead_acute e]]",
+		'É': "[[dead_acute E]]",
+		'€': "[[Multi_key e equal]]",
+		'ç': "[[Multi_key c]]",
+		'©': "[]", // Multi_key c is complete before o
+		'à': "[]", // Modifiers
+		'?': "[]", // Unknown keysym
+	}
+	for r, want := range sequences {
+		var names [][]string
+		for _, seq := range table.Sequences(r) {
+			var seqNames []string
+			for _, sym := range seq {
+				seqNames = append(seqNames, sym.Name())
+			}
+			names = append(names, seqNames)
+		}
+		if got := fmt.Sprint(names); got != want && !(want == "[]" && names == nil) {
+			t.Errorf("Sequences(%q) = %s, want %s", r, got, want)
+		}
+	}
+
+	for _, text := range []string{`<a> "a"`, `<a : "a"`, `: "a"`, `<a> :`, `<a> : "a`, `include "%Q"`} {
+		if _, err := NewComposeTableFromString(text); err == nil {
+			t.Errorf("NewComposeTableFromString(%q) should fail", text)
+		}
+	}
+}
+
+func TestComposeTableFromLocale(t *testing.T) {
+	useTestdata(t)
+	t.Setenv("XLOCALEDIR", "testdata/locale")
+	t.Setenv("XCOMPOSEFILE", "")
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_CTYPE", "")
+	t.Setenv("LANG", "en_US.utf8")
+
+	table, err := NewComposeTableFromLocale("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seqs := table.Sequences('ë'); len(seqs) != 2 || len(seqs[0]) != 2 {
+		t.Errorf("Sequences('ë') = %v, want the dead key first", seqs)
+	}
+	if _, err := NewComposeTableFromLocale("xx_XX.UTF-8"); err == nil {
+		t.Error("NewComposeTableFromLocale should fail for locales without a Compose file")
+	}
+
+	// A user file including the one of the locale
+	user := filepath.Join(t.TempDir(), "XCompose")
+	if err := os.WriteFile(user, []byte("include \"%L\"\n<Multi_key> <e> <e> : \"ə\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("XCOMPOSEFILE", user)
+	table, err = NewComposeTableFromLocale("en_US.UTF-8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(table.Sequences('ə')) != 1 || len(table.Sequences('ß')) != 1 {
+		t.Error("The user Compose file should include the sequences of the locale")
+	}
+
+	keymap, err := NewKeymapFromFile("testdata/de.xkb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for r, want := range map[rune][]Keystroke{
+		'ë': {{Key: 26, Modifiers: Mod5}, {Key: 18}},            // AltGr+ü, e
+		'å': {{Key: 26, Modifiers: ModShift | Mod5}, {Key: 30}}, // Shift+AltGr+ü, a
+	} {
+		strokes, err := keymap.ComposeKeystrokes(table, r)
+		if err != nil || len(strokes) != len(want) {
+			t.Fatalf("ComposeKeystrokes(%q) = %v, %v, want %v", r, strokes, err, want)
+		}
+		for i := range want {
+			if strokes[i].Key != want[i].Key || strokes[i].Modifiers != want[i].Modifiers {
+				t.Errorf("ComposeKeystrokes(%q) = %v, want %v", r, strokes, want)
+			}
+		}
+	}
+	if _, err := keymap.ComposeKeystrokes(table, 'œ'); err == nil {
+		t.Error("ComposeKeystrokes should fail without a Multi_key key")
+	}
+}
+
+func TestKeystrokesLocked(t *testing.T) {
+	useTestdata(t)
+	keymap, err := NewKeymapFromFile("testdata/de.xkb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	numLock, err := keymap.ModifierMask("NumLock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		r      rune
+		locked ModMask
+		key    uint32
+		mods   ModMask
+	}{
+		{'Q', ModLock, 16, 0},
+		{'q', ModLock, 16, ModShift},
+		{'@', ModLock, 16, Mod5},
+		{'Ω', ModLock, 16, ModShift | Mod5},
+		{'2', ModLock, 3, 0},
+		{'"', ModLock, 3, ModShift},
+		{'X', ModLock, 45, 0}, // Made uppercase, the type ignores Lock
+		{'q', numLock, 16, 0},
+	}
+	for _, tt := range tests {
+		strokes, err := keymap.KeystrokesLocked(tt.r, tt.locked)
+		if err != nil {
+			t.Errorf("KeystrokesLocked(%q, %s) failed: %v", tt.r, tt.locked, err)
+			continue
+		}
+		if strokes[0].Key != tt.key || strokes[0].Modifiers != tt.mods {
+			t.Errorf("KeystrokesLocked(%q, %s) = key %d with %s, want key %d with %s",
+				tt.r, tt.locked, strokes[0].Key, strokes[0].Modifiers, tt.key, tt.mods)
+		}
+	}
+	if strokes, err := keymap.KeystrokesLocked('x', ModLock); err == nil {
+		t.Errorf("KeystrokesLocked('x', Lock) = %v, want an error", strokes)
+	}
+
+	// Shift undoes Num Lock on the keypad
+	strokes, err := keymap.KeystrokesForKeysymLocked(0xff9c, numLock) // KP_End
+	if err != nil || strokes[0].Key != 79 || strokes[0].Modifiers != ModShift {
+		t.Errorf("KeystrokesForKeysymLocked(KP_End, NumLock) = %v, %v, want KEY_KP1 with Shift", strokes, err)
+	}
+}